@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/signalmice/signalmice/internal/logger"
+)
+
+// aliveReporter emits a periodic INFO pulse with the check count and uptime, so quiet
+// fleets can tell signalmice is still running without enabling DEBUG
+type aliveReporter struct {
+	interval time.Duration
+	logger   *logger.Logger
+	now      func() time.Time
+
+	start  time.Time
+	last   time.Time
+	checks atomic.Int64
+}
+
+// newAliveReporter creates a reporter whose first pulse is due one interval from now
+func newAliveReporter(interval time.Duration, appLogger *logger.Logger) *aliveReporter {
+	a := &aliveReporter{interval: interval, logger: appLogger, now: time.Now}
+	a.start = a.now()
+	a.last = a.start
+	return a
+}
+
+// countChecks wraps check so every run is counted by the reporter
+func (a *aliveReporter) countChecks(check func(context.Context)) func(context.Context) {
+	return func(ctx context.Context) {
+		a.checks.Add(1)
+		check(ctx)
+	}
+}
+
+// run emits the pulse at the configured cadence until ctx is cancelled
+func (a *aliveReporter) run(ctx context.Context) {
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			a.maybeLog(ctx)
+		}
+	}
+}
+
+// maybeLog emits the pulse if an interval has elapsed since the previous one and reports whether it did
+func (a *aliveReporter) maybeLog(ctx context.Context) bool {
+	now := a.now()
+	if now.Sub(a.last) < a.interval {
+		return false
+	}
+	a.last = now
+
+	a.logger.InfoWithExtra(ctx, "signalmice alive", map[string]any{
+		"checks": a.checks.Load(),
+		"uptime": now.Sub(a.start).Round(time.Second).String(),
+	})
+	return true
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAliveReporter_FiresAtCadence(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	a := newAliveReporter(time.Minute, createMockLogger())
+	a.now = func() time.Time { return now }
+	a.start, a.last = now, now
+
+	check := a.countChecks(func(context.Context) {})
+	ctx := context.Background()
+
+	var fired int
+	// Advance the clock in 15s steps for 3 minutes, checking every step
+	for i := 0; i < 12; i++ {
+		now = now.Add(15 * time.Second)
+		check(ctx)
+		if a.maybeLog(ctx) {
+			fired++
+		}
+	}
+
+	if fired != 3 {
+		t.Errorf("expected 3 alive logs over 3 minutes at a 1m cadence, got %d", fired)
+	}
+	if got := strings.Count(buf.String(), "[INFO] signalmice alive"); got != 3 {
+		t.Errorf("expected 3 alive lines on stdout, got %d: %s", got, buf.String())
+	}
+	if got := a.checks.Load(); got != 12 {
+		t.Errorf("expected 12 counted checks, got %d", got)
+	}
+}
+
+func TestAliveReporter_NotBeforeInterval(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	a := newAliveReporter(time.Minute, createMockLogger())
+	a.now = func() time.Time { return now }
+	a.start, a.last = now, now
+
+	now = now.Add(59 * time.Second)
+	if a.maybeLog(context.Background()) {
+		t.Error("expected no alive log before the interval elapsed")
+	}
+}
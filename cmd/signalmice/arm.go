@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// armState requires a signal key to stay present across consecutive checks for a delay
+// before it is acted on, so a key that is set and quickly deleted again never fires
+type armState struct {
+	delay time.Duration
+	now   func() time.Time
+
+	mu    sync.Mutex
+	key   string    // Key being armed; empty while none is
+	since time.Time // When key was first seen present
+}
+
+// newArmState creates an arm state requiring keys to stay present for delay
+func newArmState(delay time.Duration) *armState {
+	return &armState{delay: delay, now: time.Now}
+}
+
+// arm records that key is present and reports whether it has been for the whole delay,
+// clearing the state once it has. Otherwise it reports whether arming just started and how
+// long remains. A nil state arms every key immediately.
+func (a *armState) arm(key string) (armed, started bool, remaining time.Duration) {
+	if a == nil {
+		return true, false, 0
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	now := a.now()
+	if a.key != key {
+		a.key = key
+		a.since = now
+		started = true
+	}
+	if elapsed := now.Sub(a.since); elapsed < a.delay {
+		return false, started, a.delay - elapsed
+	}
+	a.key = ""
+	return true, started, 0
+}
+
+// disarm clears the state once no key is present, returning the key that was being armed
+// and for how long, if any
+func (a *armState) disarm() (string, time.Duration) {
+	if a == nil {
+		return "", 0
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	key := a.key
+	if key == "" {
+		return "", 0
+	}
+	a.key = ""
+	return key, a.now().Sub(a.since)
+}
+
+type armStateKey struct{}
+
+// withArmState returns a context whose checks act only on keys that stayed present for the
+// state's delay
+func withArmState(ctx context.Context, a *armState) context.Context {
+	return context.WithValue(ctx, armStateKey{}, a)
+}
+
+// armStateFrom returns the arm state stored in ctx, or nil to act on keys immediately
+func armStateFrom(ctx context.Context) *armState {
+	a, _ := ctx.Value(armStateKey{}).(*armState)
+	return a
+}
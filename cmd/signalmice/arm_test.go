@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/signalmice/signalmice/internal/config"
+)
+
+func TestArmState(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	arming := newArmState(10 * time.Second)
+	arming.now = func() time.Time { return now }
+
+	if armed, started, remaining := arming.arm("signalmice:main"); armed || !started || remaining != 10*time.Second {
+		t.Fatalf("expected arming to start with 10s remaining, got %v, %v, %v", armed, started, remaining)
+	}
+	now = now.Add(4 * time.Second)
+	if armed, started, remaining := arming.arm("signalmice:main"); armed || started || remaining != 6*time.Second {
+		t.Errorf("expected arming to continue with 6s remaining, got %v, %v, %v", armed, started, remaining)
+	}
+
+	// Another key restarts the delay
+	if armed, started, _ := arming.arm("signalmice:reboot"); armed || !started {
+		t.Errorf("expected a different key to restart arming, got %v, %v", armed, started)
+	}
+	now = now.Add(10 * time.Second)
+	if armed, _, _ := arming.arm("signalmice:reboot"); !armed {
+		t.Error("expected the key to be armed once the delay elapsed")
+	}
+	if key, _ := arming.disarm(); key != "" {
+		t.Errorf("expected an armed key to clear the state, got %q", key)
+	}
+
+	var nilArming *armState
+	if armed, _, _ := nilArming.arm("signalmice:main"); !armed {
+		t.Error("expected a nil state to arm immediately")
+	}
+	if key, _ := nilArming.disarm(); key != "" {
+		t.Error("expected a nil state to have nothing to disarm")
+	}
+}
+
+func TestCheckAndShutdown_ArmDelay_PersistentKeyActs(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	arming := newArmState(10 * time.Second)
+	arming.now = func() time.Time { return now }
+	ctx := withArmState(context.Background(), arming)
+
+	cfg := &config.Config{RedisKey: "signalmice:main", ArmDelay: 10 * time.Second}
+	source := &fakeSource{keys: []string{"signalmice:main"}, values: map[string]string{"signalmice:main": "shutdown"}}
+	manager := &fakeShutdowner{}
+	appLogger := createMockLogger()
+
+	// Checks within the arm window leave the key in place without acting
+	for i := 0; i < 3; i++ {
+		checkAndShutdown(ctx, cfg, source, manager, appLogger)
+		now = now.Add(3 * time.Second)
+	}
+	if manager.calls != 0 {
+		t.Fatalf("expected no shutdown while arming, got %d", manager.calls)
+	}
+	if _, ok := source.values["signalmice:main"]; !ok {
+		t.Fatal("expected the key to stay in place while arming")
+	}
+
+	// 10s after the key was first seen, it is armed
+	now = now.Add(time.Second)
+	checkAndShutdown(ctx, cfg, source, manager, appLogger)
+	if manager.calls != 1 {
+		t.Fatalf("expected a shutdown once armed, got %d", manager.calls)
+	}
+	if _, ok := source.values["signalmice:main"]; ok {
+		t.Error("expected the armed key to be consumed")
+	}
+}
+
+func TestCheckAndShutdown_ArmDelay_VanishingKeyDoesNotAct(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	arming := newArmState(10 * time.Second)
+	arming.now = func() time.Time { return now }
+	ctx := withArmState(context.Background(), arming)
+
+	cfg := &config.Config{RedisKey: "signalmice:main", ArmDelay: 10 * time.Second}
+	source := &fakeSource{keys: []string{"signalmice:main"}, values: map[string]string{"signalmice:main": "shutdown"}}
+	manager := &fakeShutdowner{}
+	appLogger := createMockLogger()
+
+	checkAndShutdown(ctx, cfg, source, manager, appLogger)
+	now = now.Add(5 * time.Second)
+
+	// The controller deletes the key mid-arm
+	delete(source.values, "signalmice:main")
+	checkAndShutdown(ctx, cfg, source, manager, appLogger)
+	if !strings.Contains(buf.String(), "Signal key vanished before the arm delay elapsed") {
+		t.Errorf("expected the vanished key to be logged, got:\n%s", buf.String())
+	}
+
+	// Set again, it must stay present for a full delay from now on
+	source.values["signalmice:main"] = "shutdown"
+	now = now.Add(5 * time.Second)
+	checkAndShutdown(ctx, cfg, source, manager, appLogger)
+	now = now.Add(5 * time.Second)
+	checkAndShutdown(ctx, cfg, source, manager, appLogger)
+	if manager.calls != 0 {
+		t.Errorf("expected no shutdown for a key that flickered, got %d", manager.calls)
+	}
+}
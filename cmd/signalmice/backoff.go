@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/signalmice/signalmice/internal/logger"
+	"github.com/signalmice/signalmice/internal/redis"
+)
+
+// fixedInterval returns an interval func that always yields d
+func fixedInterval(d time.Duration) func() time.Duration {
+	return func() time.Duration { return d }
+}
+
+// checkBackoff spaces checks out exponentially while Redis is unreachable, doubling the
+// interval on every consecutive connection error up to a ceiling. A successful check resets it.
+type checkBackoff struct {
+	base   time.Duration
+	max    time.Duration
+	logger *logger.Logger
+
+	mu       sync.Mutex
+	failures int
+}
+
+// newCheckBackoff creates a backoff starting at base and never exceeding max
+func newCheckBackoff(base, max time.Duration, appLogger *logger.Logger) *checkBackoff {
+	if max < base {
+		max = base
+	}
+	return &checkBackoff{base: base, max: max, logger: appLogger}
+}
+
+// interval returns the delay until the next check
+func (b *checkBackoff) interval() time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.intervalLocked()
+}
+
+func (b *checkBackoff) intervalLocked() time.Duration {
+	d := b.base
+	for i := 0; i < b.failures; i++ {
+		d *= 2
+		if d <= 0 || d >= b.max {
+			return b.max
+		}
+	}
+	return d
+}
+
+// record updates the backoff with the outcome of a Redis operation.
+// Only connection errors back off; server errors mean Redis is reachable.
+func (b *checkBackoff) record(ctx context.Context, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err == nil {
+		if b.failures > 0 {
+			b.failures = 0
+			b.logger.InfoWithExtra(ctx, "Redis reachable again, restoring check interval", map[string]string{"interval": b.base.String()})
+		}
+		return
+	}
+	if !errors.Is(err, redis.ErrConnection) {
+		return
+	}
+
+	before := b.intervalLocked()
+	b.failures++
+	if after := b.intervalLocked(); after != before {
+		b.logger.WarnWithExtra(ctx, "Redis unreachable, backing off checks", map[string]string{
+			"interval":     after.String(),
+			"max_interval": b.max.String(),
+		})
+	}
+}
+
+// backoffSource reports the outcome of every signal lookup to a checkBackoff
+type backoffSource struct {
+	signalSource
+	backoff *checkBackoff
+}
+
+func (s *backoffSource) CheckAndDeleteSignal(ctx context.Context) (redis.Signal, bool, error) {
+	sig, found, err := s.signalSource.CheckAndDeleteSignal(ctx)
+	s.backoff.record(ctx, err)
+	return sig, found, err
+}
+
+func (s *backoffSource) PeekSignal(ctx context.Context) (redis.Signal, bool, error) {
+	sig, found, err := s.signalSource.PeekSignal(ctx)
+	s.backoff.record(ctx, err)
+	return sig, found, err
+}
+
+func (s *backoffSource) KeyExists(ctx context.Context) (bool, error) {
+	exists, err := s.signalSource.KeyExists(ctx)
+	s.backoff.record(ctx, err)
+	return exists, err
+}
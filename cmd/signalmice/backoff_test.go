@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/signalmice/signalmice/internal/redis"
+)
+
+func TestCheckBackoff_NeverExceedsMaxInterval(t *testing.T) {
+	b := newCheckBackoff(time.Second, 10*time.Second, createMockLogger())
+	connErr := fmt.Errorf("%w: dial tcp: connection refused", redis.ErrConnection)
+
+	want := []time.Duration{2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second}
+	for i := 0; i < 100; i++ {
+		b.record(context.Background(), connErr)
+		got := b.interval()
+		if got > 10*time.Second {
+			t.Fatalf("interval %v exceeds the 10s cap after %d failures", got, i+1)
+		}
+		if i < len(want) && got != want[i] {
+			t.Errorf("after %d failures expected %v, got %v", i+1, want[i], got)
+		}
+	}
+}
+
+func TestCheckBackoff_ResetsOnSuccess(t *testing.T) {
+	b := newCheckBackoff(time.Second, time.Minute, createMockLogger())
+	ctx := context.Background()
+
+	b.record(ctx, fmt.Errorf("%w: timeout", redis.ErrConnection))
+	b.record(ctx, fmt.Errorf("%w: timeout", redis.ErrConnection))
+	if got := b.interval(); got != 4*time.Second {
+		t.Fatalf("expected 4s after two failures, got %v", got)
+	}
+
+	b.record(ctx, nil)
+	if got := b.interval(); got != time.Second {
+		t.Errorf("expected the base interval after a success, got %v", got)
+	}
+}
+
+func TestCheckBackoff_IgnoresServerErrors(t *testing.T) {
+	b := newCheckBackoff(time.Second, time.Minute, createMockLogger())
+
+	b.record(context.Background(), fmt.Errorf("%w: NOPERM", redis.ErrServer))
+	b.record(context.Background(), errors.New("unexpected"))
+
+	if got := b.interval(); got != time.Second {
+		t.Errorf("expected no backoff for non-connection errors, got %v", got)
+	}
+}
+
+func TestCheckBackoff_MaxBelowBase(t *testing.T) {
+	b := newCheckBackoff(time.Minute, time.Second, createMockLogger())
+	b.record(context.Background(), redis.ErrConnection)
+
+	if got := b.interval(); got != time.Minute {
+		t.Errorf("expected a cap below the base to fall back to the base, got %v", got)
+	}
+}
+
+func TestBackoffSource_RecordsLookups(t *testing.T) {
+	b := newCheckBackoff(time.Second, time.Minute, createMockLogger())
+	source := &backoffSource{
+		signalSource: &fakeSource{errs: []error{redis.ErrConnection}},
+		backoff:      b,
+	}
+
+	if _, _, err := source.CheckAndDeleteSignal(context.Background()); err == nil {
+		t.Fatal("expected the source error to be returned")
+	}
+	if got := b.interval(); got != 2*time.Second {
+		t.Errorf("expected the failed lookup to back off, got %v", got)
+	}
+
+	if _, _, err := source.CheckAndDeleteSignal(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := b.interval(); got != time.Second {
+		t.Errorf("expected the successful lookup to reset the backoff, got %v", got)
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/signalmice/signalmice/internal/logger"
+	"github.com/signalmice/signalmice/internal/redis"
+)
+
+// channelReconnectDelay is the pause before re-subscribing after the channel subscription dropped
+var channelReconnectDelay = 5 * time.Second
+
+// maxPendingChannelSignals bounds the published signals waiting for a check; extra ones are dropped
+const maxPendingChannelSignals = 16
+
+// channelSubscriber is the part of the Redis client used to follow a pub/sub channel
+type channelSubscriber interface {
+	ChannelMessages(ctx context.Context, channel string) (<-chan string, error)
+}
+
+// channelSource turns messages published on a Redis channel into signals, keyed by the channel
+// name with the payload as value. Published signals take precedence over the wrapped key source.
+type channelSource struct {
+	signalSource
+	channel    string
+	subscriber channelSubscriber
+	logger     *logger.Logger
+	events     chan struct{} // Nudges the monitor to check as soon as a message arrives
+
+	mu      sync.Mutex
+	pending []string
+}
+
+// newChannelSource creates a source following channel on top of source
+func newChannelSource(source signalSource, channel string, subscriber channelSubscriber, appLogger *logger.Logger) *channelSource {
+	return &channelSource{
+		signalSource: source,
+		channel:      channel,
+		subscriber:   subscriber,
+		logger:       appLogger,
+		events:       make(chan struct{}, 1),
+	}
+}
+
+// Events emits once per received message, coalescing while a check is pending
+func (s *channelSource) Events() <-chan struct{} {
+	return s.events
+}
+
+// run subscribes to the channel until ctx is cancelled, re-subscribing whenever the subscription drops
+func (s *channelSource) run(ctx context.Context) {
+	for {
+		payloads, err := s.subscriber.ChannelMessages(ctx, s.channel)
+		if err != nil {
+			s.logger.WarnWithExtra(ctx, "Failed to subscribe to signal channel, retrying", map[string]string{
+				"channel":    s.channel,
+				"error":      err.Error(),
+				"error_type": redis.ErrorType(err),
+			})
+		} else {
+			s.logger.InfoWithExtra(ctx, "Subscribed to signal channel", map[string]string{"channel": s.channel})
+			for payload := range payloads {
+				s.push(ctx, payload)
+			}
+			if ctx.Err() == nil {
+				s.logger.WarnWithExtra(ctx, "Signal channel subscription dropped, reconnecting", map[string]string{"channel": s.channel})
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(channelReconnectDelay):
+		}
+	}
+}
+
+// push queues a published signal and nudges the monitor
+func (s *channelSource) push(ctx context.Context, payload string) {
+	s.mu.Lock()
+	if len(s.pending) >= maxPendingChannelSignals {
+		s.mu.Unlock()
+		s.logger.WarnWithExtra(ctx, "Too many pending channel signals, dropping message", map[string]string{"channel": s.channel})
+		return
+	}
+	s.pending = append(s.pending, payload)
+	s.mu.Unlock()
+
+	select {
+	case s.events <- struct{}{}:
+	default:
+	}
+}
+
+// CheckAndDeleteSignal consumes the oldest published signal, falling back to the key source
+func (s *channelSource) CheckAndDeleteSignal(ctx context.Context) (redis.Signal, bool, error) {
+	s.mu.Lock()
+	if len(s.pending) > 0 {
+		payload := s.pending[0]
+		s.pending = s.pending[1:]
+		s.mu.Unlock()
+		return redis.Signal{Key: s.channel, Value: payload}, true, nil
+	}
+	s.mu.Unlock()
+	return s.signalSource.CheckAndDeleteSignal(ctx)
+}
+
+// PeekSignal returns the oldest published signal without consuming it, falling back to the key source
+func (s *channelSource) PeekSignal(ctx context.Context) (redis.Signal, bool, error) {
+	s.mu.Lock()
+	if len(s.pending) > 0 {
+		payload := s.pending[0]
+		s.mu.Unlock()
+		return redis.Signal{Key: s.channel, Value: payload}, true, nil
+	}
+	s.mu.Unlock()
+	return s.signalSource.PeekSignal(ctx)
+}
+
+// DeleteSignal consumes a published signal returned by PeekSignal, or deletes a signal key
+func (s *channelSource) DeleteSignal(ctx context.Context, key string) error {
+	if key != s.channel {
+		return s.signalSource.DeleteSignal(ctx, key)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.pending) > 0 {
+		s.pending = s.pending[1:]
+	}
+	return nil
+}
+
+// ConfirmDeleted reports published signals as never reappearing; keys are checked in Redis
+func (s *channelSource) ConfirmDeleted(ctx context.Context, key string) (bool, error) {
+	if key == s.channel {
+		return false, nil
+	}
+	return s.signalSource.ConfirmDeleted(ctx, key)
+}
+
+// mergeEvents forwards values from both channels until ctx is cancelled; a nil channel is ignored
+func mergeEvents(ctx context.Context, a, b <-chan struct{}) <-chan struct{} {
+	if a == nil {
+		return b
+	}
+	if b == nil {
+		return a
+	}
+
+	out := make(chan struct{}, 1)
+	go func() {
+		for a != nil || b != nil {
+			select {
+			case <-ctx.Done():
+				return
+			case _, ok := <-a:
+				if !ok {
+					a = nil
+					continue
+				}
+			case _, ok := <-b:
+				if !ok {
+					b = nil
+					continue
+				}
+			}
+			select {
+			case out <- struct{}{}:
+			default:
+			}
+		}
+	}()
+	return out
+}
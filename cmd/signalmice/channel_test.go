@@ -0,0 +1,142 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func init() {
+	channelReconnectDelay = time.Millisecond
+}
+
+// fakeSubscriber hands out one prepared payload channel per subscription, failing when none is left
+type fakeSubscriber struct {
+	subscriptions chan chan string
+	calls         atomic.Int32
+}
+
+func (f *fakeSubscriber) ChannelMessages(ctx context.Context, channel string) (<-chan string, error) {
+	f.calls.Add(1)
+	select {
+	case payloads := <-f.subscriptions:
+		return payloads, nil
+	default:
+		return nil, errors.New("connection refused")
+	}
+}
+
+func TestChannelSource_PublishedMessageIsFound(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	payloads := make(chan string, 1)
+	subscriber := &fakeSubscriber{subscriptions: make(chan chan string, 1)}
+	subscriber.subscriptions <- payloads
+
+	source := newChannelSource(&fakeSource{}, "signalmice:shutdown", subscriber, createMockLogger())
+	go source.run(ctx)
+
+	payloads <- "reboot"
+	select {
+	case <-source.Events():
+	case <-time.After(time.Second):
+		t.Fatal("expected a published message to nudge the monitor")
+	}
+
+	sig, found, err := source.CheckAndDeleteSignal(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !found || sig.Key != "signalmice:shutdown" || sig.Value != "reboot" {
+		t.Errorf("expected the published signal, got found=%v sig=%+v", found, sig)
+	}
+
+	if _, found, _ := source.CheckAndDeleteSignal(ctx); found {
+		t.Error("expected the published signal to be consumed")
+	}
+}
+
+func TestChannelSource_ReconnectsAfterDrop(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	first := make(chan string)
+	second := make(chan string, 1)
+	subscriber := &fakeSubscriber{subscriptions: make(chan chan string, 2)}
+	subscriber.subscriptions <- first
+
+	source := newChannelSource(&fakeSource{}, "signalmice:shutdown", subscriber, createMockLogger())
+	go source.run(ctx)
+
+	// Drop the first subscription; failed attempts are retried until a new one is available
+	close(first)
+	time.Sleep(10 * time.Millisecond)
+	subscriber.subscriptions <- second
+	second <- "poweroff"
+
+	select {
+	case <-source.Events():
+	case <-time.After(time.Second):
+		t.Fatal("expected a message after reconnecting")
+	}
+	if calls := subscriber.calls.Load(); calls < 2 {
+		t.Errorf("expected the subscription to be re-established, got %d subscribe calls", calls)
+	}
+	if sig, found, _ := source.CheckAndDeleteSignal(ctx); !found || sig.Value != "poweroff" {
+		t.Errorf("expected the message from the new subscription, got found=%v sig=%+v", found, sig)
+	}
+}
+
+func TestChannelSource_FallsBackToKeys(t *testing.T) {
+	keys := &fakeSource{keys: []string{"signalmice"}, values: map[string]string{"signalmice": "1"}}
+	source := newChannelSource(keys, "signalmice:shutdown", &fakeSubscriber{}, createMockLogger())
+
+	sig, found, err := source.CheckAndDeleteSignal(context.Background())
+	if err != nil || !found || sig.Key != "signalmice" {
+		t.Errorf("expected the key signal without published messages, got found=%v sig=%+v err=%v", found, sig, err)
+	}
+}
+
+func TestChannelSource_PeekAndDelete(t *testing.T) {
+	source := newChannelSource(&fakeSource{}, "signalmice:shutdown", &fakeSubscriber{}, createMockLogger())
+	source.push(context.Background(), "reboot")
+
+	ctx := context.Background()
+	if sig, found, _ := source.PeekSignal(ctx); !found || sig.Value != "reboot" {
+		t.Fatalf("expected peek to return the published signal, got found=%v sig=%+v", found, sig)
+	}
+	if _, found, _ := source.PeekSignal(ctx); !found {
+		t.Fatal("expected peek to leave the published signal in place")
+	}
+	if err := source.DeleteSignal(ctx, "signalmice:shutdown"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, found, _ := source.PeekSignal(ctx); found {
+		t.Error("expected delete to consume the published signal")
+	}
+}
+
+func TestMergeEvents(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	a := make(chan struct{}, 1)
+	b := make(chan struct{}, 1)
+	merged := mergeEvents(ctx, a, b)
+
+	for _, in := range []chan struct{}{a, b} {
+		in <- struct{}{}
+		select {
+		case <-merged:
+		case <-time.After(time.Second):
+			t.Fatal("expected event to be forwarded")
+		}
+	}
+
+	if got := mergeEvents(ctx, nil, b); got != (<-chan struct{})(b) {
+		t.Error("expected a nil channel to be ignored")
+	}
+}
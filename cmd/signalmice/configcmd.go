@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"time"
+
+	"github.com/signalmice/signalmice/internal/config"
+	"github.com/signalmice/signalmice/internal/logger"
+	"github.com/signalmice/signalmice/internal/redis"
+	"github.com/signalmice/signalmice/internal/shutdown"
+)
+
+// configCommand is the subcommand that prints the effective configuration and exits
+const configCommand = "config"
+
+// configValidation is a startup check of the configuration, reported with message on failure
+type configValidation struct {
+	message  string
+	validate func(cfg *config.Config) error
+}
+
+// configValidations are the configuration checks that need no connection, in the order run
+var configValidations = []configValidation{
+	{"Invalid hostname", func(cfg *config.Config) error { return cfg.ValidateHostname() }},
+	{"Invalid SIGNALMICE_REQUIRED", validateRequired},
+	{"Invalid REDIS_TLS_MIN_VERSION", func(cfg *config.Config) error {
+		_, err := config.ParseTLSVersion(cfg.RedisTLSMinVersion)
+		return err
+	}},
+	{"Invalid LOG_BACKEND", func(cfg *config.Config) error { return logger.ValidateBackend(cfg.LogBackend) }},
+	{"Invalid SIGNALMICE_LOG_SCHEMA", func(cfg *config.Config) error { return logger.ValidateSchema(cfg.LogSchema) }},
+	{"Invalid SIGNALMICE_LOG_FORMAT", func(cfg *config.Config) error { return logger.ValidateFormat(cfg.LogFormat) }},
+	{"Invalid OPENSEARCH_REFRESH", func(cfg *config.Config) error { return logger.ValidateRefresh(cfg.OpensearchRefresh) }},
+	{"Invalid OPENSEARCH_ROUTING", func(cfg *config.Config) error { return logger.ValidateRouting(cfg.OpensearchRouting) }},
+	{"Invalid SIGNALMICE_CHECK_COMMAND", func(cfg *config.Config) error {
+		if err := redis.ValidateCheckCommand(cfg.CheckCommand); err != nil {
+			return err
+		}
+		if cfg.CheckCommand == redis.CheckCommandExists && cfg.SigningKey != "" {
+			return errors.New("exists never reads signal values, so signed signals cannot be verified")
+		}
+		if cfg.CheckCommand == redis.CheckCommandExists && cfg.SignalJSON {
+			return errors.New("exists never reads signal values, so JSON signal payloads cannot be parsed")
+		}
+		return nil
+	}},
+	{"Invalid SIGNALMICE_SIGNAL_REQUIRE_EXPIRY", func(cfg *config.Config) error {
+		if cfg.SignalRequireExpiry && !cfg.SignalJSON {
+			return errors.New("signal expiry is read from JSON signals, so SIGNALMICE_SIGNAL_JSON must be enabled")
+		}
+		return nil
+	}},
+	{"Invalid SIGNALMICE_KEY_ACTIONS", validateKeyActions},
+	{"Invalid SIGNALMICE_ARM_DELAY", func(cfg *config.Config) error {
+		if cfg.ArmDelay > 0 && cfg.Mode != config.ModeSignal {
+			return fmt.Errorf("the arm delay only applies to %s mode", config.ModeSignal)
+		}
+		return nil
+	}},
+	{"Invalid SIGNALMICE_IGNORE_EXISTING_AT_START", func(cfg *config.Config) error {
+		// In the other modes the key is a heartbeat or a fleet-wide budget, not a stale signal
+		if cfg.IgnoreExisting && cfg.Mode != config.ModeSignal {
+			return fmt.Errorf("ignoring existing signals only applies to %s mode", config.ModeSignal)
+		}
+		return nil
+	}},
+	{"Invalid SIGNALMICE_CONSUL_WATCH", func(cfg *config.Config) error {
+		if cfg.ConsulWatch && cfg.ConsulKey == "" {
+			return errors.New("watching Consul needs SIGNALMICE_CONSUL_KEY")
+		}
+		return nil
+	}},
+	{"Invalid SIGNALMICE_ETCD_ENDPOINTS", func(cfg *config.Config) error {
+		if cfg.EtcdKey != "" && len(cfg.EtcdEndpoints) == 0 {
+			return errors.New("the etcd source needs at least one endpoint")
+		}
+		return nil
+	}},
+	{"Invalid SIGNALMICE_ETCD_USERNAME", func(cfg *config.Config) error {
+		if cfg.EtcdPassword != "" && cfg.EtcdUsername == "" {
+			return errors.New("SIGNALMICE_ETCD_PASSWORD is set without a user to authenticate as")
+		}
+		return nil
+	}},
+	{"Invalid SIGNALMICE_ETCD_CERT_FILE", func(cfg *config.Config) error {
+		if (cfg.EtcdCertFile == "") != (cfg.EtcdKeyFile == "") {
+			return errors.New("both SIGNALMICE_ETCD_CERT_FILE and SIGNALMICE_ETCD_KEY_FILE are required for mutual TLS")
+		}
+		return nil
+	}},
+	{"Invalid SIGNALMICE_NO_HOST_ACCESS", func(cfg *config.Config) error { return shutdown.ValidateNoHostAccess(cfg.NoHostAccess) }},
+	{"Invalid SIGNALMICE_HOSTNAME_FILE_INTERVAL", func(cfg *config.Config) error {
+		if cfg.HostnameFileInterval > 0 && cfg.HostnameFile == "" {
+			return errors.New("re-reading the hostname needs SIGNALMICE_HOSTNAME_FILE")
+		}
+		return nil
+	}},
+	{"Invalid SIGNALMICE_KEY_PATTERN", func(cfg *config.Config) error {
+		if cfg.ScanCount < 0 || cfg.MaxKeysPerCycle < 0 {
+			return errors.New("SIGNALMICE_SCAN_COUNT and SIGNALMICE_MAX_KEYS_PER_CYCLE cannot be negative")
+		}
+		if cfg.KeyPattern != "" && cfg.Mode != config.ModeSignal {
+			return fmt.Errorf("pattern matching only applies to %s mode", config.ModeSignal)
+		}
+		return nil
+	}},
+	{"Invalid SIGNALMICE_MODE", func(cfg *config.Config) error {
+		switch cfg.Mode {
+		case config.ModeSignal, config.ModeDeadmans:
+			return nil
+		case config.ModeSemaphore:
+			if cfg.SigningKey != "" || cfg.SignalJSON {
+				return errors.New("semaphore mode decrements an integer budget, so it cannot be signed or a JSON payload")
+			}
+			return nil
+		default:
+			return fmt.Errorf("unknown mode %q (expected %s, %s or %s)", cfg.Mode, config.ModeSignal, config.ModeDeadmans, config.ModeSemaphore)
+		}
+	}},
+}
+
+// validateConfig runs the configuration checks, returning the message and error of the first
+// that fails
+func validateConfig(cfg *config.Config) (string, error) {
+	for _, v := range configValidations {
+		if err := v.validate(cfg); err != nil {
+			return v.message, err
+		}
+	}
+	return "", nil
+}
+
+// runConfig validates the configuration and prints it redacted to out as JSON, or the first
+// validation failure to errOut. It returns the process exit code.
+func runConfig(cfg *config.Config, out, errOut io.Writer) int {
+	if message, err := validateConfig(cfg); err != nil {
+		fmt.Fprintf(errOut, "%s: %v\n", message, err)
+		return 1
+	}
+
+	data, err := configJSON(cfg.Redacted())
+	if err != nil {
+		fmt.Fprintf(errOut, "Failed to encode configuration: %v\n", err)
+		return 1
+	}
+	out.Write(append(data, '\n'))
+	return 0
+}
+
+// configJSON encodes every exported configuration field under its Go name, in declaration
+// order, with durations written as Go duration strings
+func configJSON(cfg *config.Config) ([]byte, error) {
+	v := reflect.ValueOf(*cfg)
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i := 0; i < v.NumField(); i++ {
+		if !v.Type().Field(i).IsExported() {
+			continue
+		}
+		value := v.Field(i).Interface()
+		if d, ok := value.(time.Duration); ok {
+			value = d.String()
+		}
+		data, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s: %w", v.Type().Field(i).Name, err)
+		}
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		fmt.Fprintf(&buf, "%q:%s", v.Type().Field(i).Name, data)
+	}
+	buf.WriteByte('}')
+
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, buf.Bytes(), "", "  "); err != nil {
+		return nil, err
+	}
+	return indented.Bytes(), nil
+}
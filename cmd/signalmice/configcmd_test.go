@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/signalmice/signalmice/internal/config"
+)
+
+// validConfig returns the default configuration, which passes every configuration check
+func validConfig() *config.Config {
+	cfg := config.Load()
+	cfg.Hostname = "node-1"
+	return cfg
+}
+
+func TestRunConfig_PrintsRedactedConfig(t *testing.T) {
+	cfg := validConfig()
+	cfg.CheckInterval = 5 * time.Second
+	cfg.RedisPassword = "redis-secret"
+	cfg.SigningKey = "signing-secret"
+	cfg.EtcdPassword = "etcd-secret"
+	cfg.EtcdUsername = "signalmice"
+	cfg.WebhookHeaders = map[string]string{"Authorization": "Bearer webhook-secret"}
+
+	var out, errOut bytes.Buffer
+	if code := runConfig(cfg, &out, &errOut); code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, errOut.String())
+	}
+
+	for _, secret := range []string{"redis-secret", "signing-secret", "etcd-secret", "webhook-secret"} {
+		if strings.Contains(out.String(), secret) {
+			t.Errorf("expected %s to be redacted, got %s", secret, out.String())
+		}
+	}
+
+	var printed map[string]any
+	if err := json.Unmarshal(out.Bytes(), &printed); err != nil {
+		t.Fatalf("expected JSON output, got %v: %s", err, out.String())
+	}
+	if printed["RedisPassword"] != config.RedactedValue {
+		t.Errorf("expected RedisPassword to be %s, got %v", config.RedactedValue, printed["RedisPassword"])
+	}
+	if printed["Hostname"] != "node-1" {
+		t.Errorf("expected Hostname node-1, got %v", printed["Hostname"])
+	}
+	if printed["CheckInterval"] != "5s" {
+		t.Errorf("expected durations as Go duration strings, got %v", printed["CheckInterval"])
+	}
+}
+
+func TestRunConfig_AllFieldsPresent(t *testing.T) {
+	var out, errOut bytes.Buffer
+	if code := runConfig(validConfig(), &out, &errOut); code != 0 {
+		t.Fatalf("expected exit code 0, got %d: %s", code, errOut.String())
+	}
+
+	var printed map[string]any
+	if err := json.Unmarshal(out.Bytes(), &printed); err != nil {
+		t.Fatalf("expected JSON output, got %v", err)
+	}
+
+	typ := reflect.TypeOf(config.Config{})
+	exported := 0
+	for i := 0; i < typ.NumField(); i++ {
+		if !typ.Field(i).IsExported() {
+			continue
+		}
+		exported++
+		if _, ok := printed[typ.Field(i).Name]; !ok {
+			t.Errorf("expected field %s in the printed configuration", typ.Field(i).Name)
+		}
+	}
+	if len(printed) != exported {
+		t.Errorf("expected %d fields, got %d", exported, len(printed))
+	}
+}
+
+func TestRunConfig_InvalidConfig(t *testing.T) {
+	cfg := validConfig()
+	cfg.Mode = "bogus"
+
+	var out, errOut bytes.Buffer
+	if code := runConfig(cfg, &out, &errOut); code != 1 {
+		t.Fatalf("expected exit code 1, got %d", code)
+	}
+	if !strings.Contains(errOut.String(), "Invalid SIGNALMICE_MODE") {
+		t.Errorf("expected the validation failure to be printed, got %s", errOut.String())
+	}
+	if out.Len() != 0 {
+		t.Errorf("expected no configuration for an invalid one, got %s", out.String())
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(cfg *config.Config)
+		message string
+	}{
+		{name: "valid", modify: func(cfg *config.Config) {}},
+		{name: "unknown dependency", modify: func(cfg *config.Config) { cfg.Required = []string{"postgres"} }, message: "Invalid SIGNALMICE_REQUIRED"},
+		{name: "semaphore mode", modify: func(cfg *config.Config) { cfg.Mode = config.ModeSemaphore }},
+		{name: "signed semaphore mode", modify: func(cfg *config.Config) {
+			cfg.Mode = config.ModeSemaphore
+			cfg.SigningKey = "key"
+		}, message: "Invalid SIGNALMICE_MODE"},
+		{name: "expiry without JSON signals", modify: func(cfg *config.Config) { cfg.SignalRequireExpiry = true }, message: "Invalid SIGNALMICE_SIGNAL_REQUIRE_EXPIRY"},
+		{name: "key pattern", modify: func(cfg *config.Config) { cfg.KeyPattern = "signalmice:host:*" }},
+		{name: "key pattern in deadmans mode", modify: func(cfg *config.Config) {
+			cfg.Mode = config.ModeDeadmans
+			cfg.KeyPattern = "signalmice:host:*"
+		}, message: "Invalid SIGNALMICE_KEY_PATTERN"},
+		{name: "negative max keys per cycle", modify: func(cfg *config.Config) { cfg.MaxKeysPerCycle = -1 }, message: "Invalid SIGNALMICE_KEY_PATTERN"},
+		{name: "hostname interval without file", modify: func(cfg *config.Config) { cfg.HostnameFileInterval = time.Minute }, message: "Invalid SIGNALMICE_HOSTNAME_FILE_INTERVAL"},
+		{name: "ignore existing in semaphore mode", modify: func(cfg *config.Config) { cfg.IgnoreExisting = true; cfg.Mode = config.ModeSemaphore }, message: "Invalid SIGNALMICE_IGNORE_EXISTING_AT_START"},
+		{name: "ignore existing in deadmans mode", modify: func(cfg *config.Config) { cfg.IgnoreExisting = true; cfg.Mode = config.ModeDeadmans }, message: "Invalid SIGNALMICE_IGNORE_EXISTING_AT_START"},
+		{name: "consul watch without key", modify: func(cfg *config.Config) { cfg.ConsulWatch = true }, message: "Invalid SIGNALMICE_CONSUL_WATCH"},
+		{name: "etcd key without endpoints", modify: func(cfg *config.Config) { cfg.EtcdKey = "signalmice/shutdown"; cfg.EtcdEndpoints = nil }, message: "Invalid SIGNALMICE_ETCD_ENDPOINTS"},
+		{name: "etcd password without user", modify: func(cfg *config.Config) { cfg.EtcdPassword = "secret" }, message: "Invalid SIGNALMICE_ETCD_USERNAME"},
+		{name: "etcd certificate without key", modify: func(cfg *config.Config) { cfg.EtcdCertFile = "/etc/etcd/client.pem" }, message: "Invalid SIGNALMICE_ETCD_CERT_FILE"},
+		{name: "unknown no host access behavior", modify: func(cfg *config.Config) { cfg.NoHostAccess = "ignore" }, message: "Invalid SIGNALMICE_NO_HOST_ACCESS"},
+		{name: "unknown log format", modify: func(cfg *config.Config) { cfg.LogFormat = "json" }, message: "Invalid SIGNALMICE_LOG_FORMAT"},
+		{name: "unknown mode", modify: func(cfg *config.Config) { cfg.Mode = "bogus" }, message: "Invalid SIGNALMICE_MODE"},
+		{name: "exists with signing key", modify: func(cfg *config.Config) {
+			cfg.CheckCommand = "exists"
+			cfg.SigningKey = "key"
+		}, message: "Invalid SIGNALMICE_CHECK_COMMAND"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			tt.modify(cfg)
+
+			message, err := validateConfig(cfg)
+			if tt.message == "" {
+				if err != nil {
+					t.Fatalf("unexpected error: %s: %v", message, err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("expected %s, got no error", tt.message)
+			}
+			if message != tt.message {
+				t.Errorf("expected %s, got %s", tt.message, message)
+			}
+		})
+	}
+}
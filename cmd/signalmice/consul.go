@@ -0,0 +1,273 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/signalmice/signalmice/internal/logger"
+	"github.com/signalmice/signalmice/internal/redis"
+)
+
+// consulWatchWait is how long a blocking query waits for the key to change before returning
+const consulWatchWait = 5 * time.Minute
+
+// consulWatchRetry is the pause before another blocking query after one failed
+var consulWatchRetry = 5 * time.Second
+
+// maxConsulResponseBytes bounds the KV response read from Consul
+const maxConsulResponseBytes = 64 << 10
+
+// consulEntry is the part of a Consul KV entry signalmice reads
+type consulEntry struct {
+	Value       string // Base64-encoded
+	ModifyIndex uint64
+}
+
+// consulSource reads the signal from a Consul KV key: a set key is the signal, its value the
+// payload. Consuming it deletes the key with check-and-set, so a value rewritten since it was
+// read is left for the next check instead of being lost.
+type consulSource struct {
+	addr   string
+	key    string
+	token  string
+	delete bool // Delete the key once it was read as a signal
+	client *http.Client
+	logger *logger.Logger
+	events chan struct{} // Nudges the monitor to check as soon as the key changes
+}
+
+// newConsulSource creates a Consul KV source for key on the agent at addr. An address without
+// a scheme uses http.
+func newConsulSource(addr, key, token string, deleteKey bool, timeout time.Duration, appLogger *logger.Logger) *consulSource {
+	if !strings.Contains(addr, "://") {
+		addr = "http://" + addr
+	}
+	return &consulSource{
+		addr:   strings.TrimSuffix(addr, "/"),
+		key:    strings.TrimPrefix(key, "/"),
+		token:  token,
+		delete: deleteKey,
+		client: &http.Client{Timeout: timeout},
+		logger: appLogger,
+		events: make(chan struct{}, 1),
+	}
+}
+
+// Events emits once per change of the key seen by run, coalescing while a check is pending
+func (c *consulSource) Events() <-chan struct{} {
+	return c.events
+}
+
+// run watches the key with blocking queries until ctx is cancelled, nudging the monitor each
+// time its index moves
+func (c *consulSource) run(ctx context.Context) {
+	// Blocking queries outlive the request timeout of regular checks
+	watcher := &http.Client{Transport: c.client.Transport}
+	var index uint64
+	for {
+		next, err := c.waitChange(ctx, watcher, index)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			c.logger.WarnWithExtra(ctx, "Consul KV watch failed, retrying", map[string]string{
+				"key":   c.key,
+				"error": err.Error(),
+			})
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(consulWatchRetry):
+			}
+			continue
+		}
+
+		if next != index {
+			select {
+			case c.events <- struct{}{}:
+			default:
+			}
+		}
+		// An index going backwards means the KV store was reset; start over
+		if next < index {
+			next = 0
+		}
+		index = next
+	}
+}
+
+// waitChange runs a blocking query returning once the key's index passes index, or after
+// consulWatchWait, and returns the current index
+func (c *consulSource) waitChange(ctx context.Context, client *http.Client, index uint64) (uint64, error) {
+	query := url.Values{"index": {strconv.FormatUint(index, 10)}, "wait": {consulWatchWait.String()}}
+	res, err := c.do(ctx, client, http.MethodGet, query)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(res.Body, maxConsulResponseBytes))
+
+	if res.StatusCode != http.StatusOK && res.StatusCode != http.StatusNotFound {
+		return 0, fmt.Errorf("Consul returned status %d", res.StatusCode)
+	}
+	next, err := strconv.ParseUint(res.Header.Get("X-Consul-Index"), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Consul returned no valid X-Consul-Index: %w", err)
+	}
+	return next, nil
+}
+
+// get reads the key, returning its entry and whether it is set
+func (c *consulSource) get(ctx context.Context) (consulEntry, bool, error) {
+	res, err := c.do(ctx, c.client, http.MethodGet, nil)
+	if err != nil {
+		return consulEntry{}, false, err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return consulEntry{}, false, nil
+	default:
+		return consulEntry{}, false, fmt.Errorf("Consul returned status %d", res.StatusCode)
+	}
+
+	var entries []consulEntry
+	if err := json.NewDecoder(io.LimitReader(res.Body, maxConsulResponseBytes)).Decode(&entries); err != nil {
+		return consulEntry{}, false, fmt.Errorf("failed to decode Consul KV response: %w", err)
+	}
+	if len(entries) == 0 {
+		return consulEntry{}, false, nil
+	}
+	return entries[0], true, nil
+}
+
+// value decodes the payload of entry
+func (e consulEntry) value() (string, error) {
+	data, err := base64.StdEncoding.DecodeString(e.Value)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode Consul KV value: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// consume deletes the key if it is still at modifyIndex, reporting whether it was deleted.
+// A modifyIndex of 0 deletes it unconditionally.
+func (c *consulSource) consume(ctx context.Context, modifyIndex uint64) (bool, error) {
+	var query url.Values
+	if modifyIndex > 0 {
+		query = url.Values{"cas": {strconv.FormatUint(modifyIndex, 10)}}
+	}
+	res, err := c.do(ctx, c.client, http.MethodDelete, query)
+	if err != nil {
+		return false, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return false, fmt.Errorf("Consul returned status %d on delete", res.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxConsulResponseBytes))
+	if err != nil {
+		return false, fmt.Errorf("failed to read Consul delete response: %w", err)
+	}
+	return strings.TrimSpace(string(body)) == "true", nil
+}
+
+// do sends a request for the key with the ACL token, if any
+func (c *consulSource) do(ctx context.Context, client *http.Client, method string, query url.Values) (*http.Response, error) {
+	target := c.addr + "/v1/kv/" + c.key
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	if c.token != "" {
+		req.Header.Set("X-Consul-Token", c.token)
+	}
+	res, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", redis.ErrConnection, err)
+	}
+	return res, nil
+}
+
+func (c *consulSource) CheckAndDeleteSignal(ctx context.Context) (redis.Signal, bool, error) {
+	entry, found, err := c.get(ctx)
+	if err != nil || !found {
+		return redis.Signal{}, false, err
+	}
+	value, err := entry.value()
+	if err != nil {
+		return redis.Signal{}, false, err
+	}
+	if c.delete {
+		deleted, err := c.consume(ctx, entry.ModifyIndex)
+		if err != nil {
+			return redis.Signal{}, false, err
+		}
+		// Rewritten since it was read; the next check acts on the new value
+		if !deleted {
+			return redis.Signal{}, false, nil
+		}
+	}
+	return redis.Signal{Key: c.key, Value: value}, true, nil
+}
+
+func (c *consulSource) PeekSignal(ctx context.Context) (redis.Signal, bool, error) {
+	entry, found, err := c.get(ctx)
+	if err != nil || !found {
+		return redis.Signal{}, false, err
+	}
+	value, err := entry.value()
+	if err != nil {
+		return redis.Signal{}, false, err
+	}
+	return redis.Signal{Key: c.key, Value: value}, true, nil
+}
+
+func (c *consulSource) DeleteSignal(ctx context.Context, key string) error {
+	if key != c.key {
+		return nil
+	}
+	_, err := c.consume(ctx, 0)
+	return err
+}
+
+func (c *consulSource) ClearSignals(ctx context.Context) ([]string, error) {
+	_, found, err := c.get(ctx)
+	if err != nil || !found {
+		return nil, err
+	}
+	if _, err := c.consume(ctx, 0); err != nil {
+		return nil, err
+	}
+	return []string{c.key}, nil
+}
+
+func (c *consulSource) KeyExists(ctx context.Context) (bool, error) {
+	_, found, err := c.get(ctx)
+	return found, err
+}
+
+func (c *consulSource) ConfirmDeleted(ctx context.Context, key string) (bool, error) {
+	if key != c.key {
+		return false, nil
+	}
+	_, found, err := c.get(ctx)
+	if err != nil || !found {
+		return false, err
+	}
+	_, err = c.consume(ctx, 0)
+	return true, err
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeConsul serves the KV endpoints of a single key like a Consul agent: GET with blocking
+// queries and DELETE with check-and-set
+type fakeConsul struct {
+	t       *testing.T
+	key     string
+	mu      sync.Mutex
+	value   string
+	set     bool
+	index   uint64 // Raft index, bumped on every write
+	changed chan struct{}
+	deletes int
+	tokens  []string
+}
+
+func newFakeConsul(t *testing.T, key string) (*fakeConsul, *httptest.Server) {
+	t.Helper()
+	f := &fakeConsul{t: t, key: key, index: 1, changed: make(chan struct{})}
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+	return f, server
+}
+
+// put sets the key, as a writer would
+func (f *fakeConsul) put(value string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.value, f.set = value, true
+	f.bump()
+}
+
+// bump advances the index and wakes blocking queries; callers hold mu
+func (f *fakeConsul) bump() {
+	f.index++
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+func (f *fakeConsul) present() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.set
+}
+
+func (f *fakeConsul) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/v1/kv/"+f.key {
+		http.NotFound(w, r)
+		return
+	}
+	f.mu.Lock()
+	f.tokens = append(f.tokens, r.Header.Get("X-Consul-Token"))
+
+	switch r.Method {
+	case http.MethodGet:
+		// A blocking query waits while the index has not moved past the one given
+		if wait, _ := strconv.ParseUint(r.URL.Query().Get("index"), 10, 64); wait >= f.index {
+			changed := f.changed
+			f.mu.Unlock()
+			select {
+			case <-changed:
+			case <-r.Context().Done():
+				return
+			}
+			f.mu.Lock()
+		}
+		defer f.mu.Unlock()
+		w.Header().Set("X-Consul-Index", strconv.FormatUint(f.index, 10))
+		if !f.set {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, `[{"Key":%q,"Value":%q,"ModifyIndex":%d}]`, f.key, base64.StdEncoding.EncodeToString([]byte(f.value)), f.index)
+	case http.MethodDelete:
+		defer f.mu.Unlock()
+		f.deletes++
+		if cas := r.URL.Query().Get("cas"); cas != "" && cas != strconv.FormatUint(f.index, 10) {
+			fmt.Fprint(w, "false")
+			return
+		}
+		f.set = false
+		f.bump()
+		fmt.Fprint(w, "true")
+	default:
+		f.mu.Unlock()
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func TestConsulSource_CheckAndDelete(t *testing.T) {
+	consul, server := newFakeConsul(t, "signalmice/shutdown")
+	source := newConsulSource(server.URL, "signalmice/shutdown", "acl-token", true, time.Second, createMockLogger())
+	ctx := context.Background()
+
+	if _, found, err := source.CheckAndDeleteSignal(ctx); err != nil || found {
+		t.Fatalf("expected no signal while the key is unset, got %v, %v", found, err)
+	}
+
+	consul.put("maintenance window\n")
+	sig, found, err := source.CheckAndDeleteSignal(ctx)
+	if err != nil || !found {
+		t.Fatalf("expected the signal to be found, got %v, %v", found, err)
+	}
+	if sig.Key != "signalmice/shutdown" || sig.Value != "maintenance window" {
+		t.Errorf("unexpected signal: %+v", sig)
+	}
+	if consul.present() {
+		t.Error("expected the key to be deleted")
+	}
+	if consul.tokens[0] != "acl-token" {
+		t.Errorf("expected the ACL token to be sent, got %q", consul.tokens[0])
+	}
+}
+
+func TestConsulSource_KeepsKeyWithoutDelete(t *testing.T) {
+	consul, server := newFakeConsul(t, "signalmice/shutdown")
+	source := newConsulSource(strings.TrimPrefix(server.URL, "http://"), "signalmice/shutdown", "", false, time.Second, createMockLogger())
+	consul.put("reboot")
+
+	if _, found, err := source.CheckAndDeleteSignal(context.Background()); err != nil || !found {
+		t.Fatalf("expected the signal to be found, got %v, %v", found, err)
+	}
+	if !consul.present() || consul.deletes != 0 {
+		t.Errorf("expected the key to be left in place, got %d deletes", consul.deletes)
+	}
+}
+
+func TestConsulSource_RewrittenKeyIsNotConsumed(t *testing.T) {
+	consul, server := newFakeConsul(t, "signalmice/shutdown")
+	source := newConsulSource(server.URL, "signalmice/shutdown", "", true, time.Second, createMockLogger())
+	consul.put("first")
+
+	entry, found, err := source.get(context.Background())
+	if err != nil || !found {
+		t.Fatalf("expected the key to be read, got %v, %v", found, err)
+	}
+	consul.put("second")
+
+	if deleted, err := source.consume(context.Background(), entry.ModifyIndex); err != nil || deleted {
+		t.Errorf("expected a stale check-and-set delete to be refused, got %v, %v", deleted, err)
+	}
+	if !consul.present() {
+		t.Error("expected the rewritten key to survive")
+	}
+}
+
+func TestConsulSource_WatchNudgesOnChange(t *testing.T) {
+	consul, server := newFakeConsul(t, "signalmice/shutdown")
+	source := newConsulSource(server.URL, "signalmice/shutdown", "", true, time.Second, createMockLogger())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go source.run(ctx)
+
+	// The first query returns at once
+	select {
+	case <-source.Events():
+	case <-time.After(time.Second):
+		t.Fatal("expected the initial query to nudge a check")
+	}
+
+	consul.put("now")
+	select {
+	case <-source.Events():
+	case <-time.After(time.Second):
+		t.Fatal("expected a put to nudge a check")
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/signalmice/signalmice/internal/config"
+	"github.com/signalmice/signalmice/internal/logger"
+	"github.com/signalmice/signalmice/internal/redis"
+	"github.com/signalmice/signalmice/internal/shutdown"
+)
+
+// deadmansSwitch fires when the signal key is missing once the startup grace period is over
+type deadmansSwitch struct {
+	armAt time.Time
+}
+
+// newDeadmansSwitch creates a switch that arms after the given grace period
+func newDeadmansSwitch(grace time.Duration) *deadmansSwitch {
+	return &deadmansSwitch{armAt: time.Now().Add(grace)}
+}
+
+// shouldFire reports whether a check at now, with the key present or not, triggers shutdown
+func (d *deadmansSwitch) shouldFire(present bool, now time.Time) bool {
+	return !present && !now.Before(d.armAt)
+}
+
+// checkDeadmans shuts down the host when the controller stops keeping the signal key alive
+func checkDeadmans(ctx context.Context, cfg *config.Config, source signalSource, dms *deadmansSwitch, shutdownManager shutdowner, appLogger *logger.Logger) {
+	var present bool
+	err := retryTransient(ctx, cfg.CheckRetries, appLogger, func(ctx context.Context) error {
+		var err error
+		present, err = source.KeyExists(ctx)
+		return err
+	})
+	if err != nil {
+		appLogger.ErrorWithExtra(ctx, "Error checking Redis key", map[string]string{
+			"error":      err.Error(),
+			"error_type": redis.ErrorType(err),
+		})
+		return
+	}
+
+	if present {
+		appLogger.Debug(ctx, "Dead man's switch key present, continuing to monitor...")
+		return
+	}
+
+	if !dms.shouldFire(present, time.Now()) {
+		appLogger.WarnWithExtra(ctx, "Dead man's switch key missing during startup grace period", map[string]string{
+			"armed_at": dms.armAt.UTC().Format(time.RFC3339),
+		})
+		return
+	}
+
+	ctx = logger.WithCorrelationID(ctx, logger.NewCorrelationID())
+	appLogger.InfoWithExtra(ctx, "Dead man's switch key missing! Initiating shutdown.", map[string]string{"key": cfg.SignalKey()})
+
+	initiateShutdown(shutdown.WithReason(ctx, cfg.ShutdownReason), shutdown.ActionPoweroff, shutdownManager, appLogger)
+}
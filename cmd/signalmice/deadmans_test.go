@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/signalmice/signalmice/internal/config"
+)
+
+func TestDeadmansSwitch_ShouldFire(t *testing.T) {
+	now := time.Now()
+	dms := &deadmansSwitch{armAt: now}
+
+	if dms.shouldFire(true, now.Add(time.Hour)) {
+		t.Error("expected no fire while the key is present")
+	}
+	if dms.shouldFire(false, now.Add(-time.Second)) {
+		t.Error("expected no fire during the grace period")
+	}
+	if !dms.shouldFire(false, now) {
+		t.Error("expected fire when the key is missing after the grace period")
+	}
+}
+
+func TestCheckDeadmans_KeyPresent(t *testing.T) {
+	cfg := &config.Config{RedisKey: "signalmice:alive", Mode: config.ModeDeadmans}
+	source := &fakeSource{
+		keys:   []string{"signalmice:alive"},
+		values: map[string]string{"signalmice:alive": "1"},
+	}
+	manager := &fakeShutdowner{}
+	dms := &deadmansSwitch{armAt: time.Now().Add(-time.Minute)}
+
+	checkDeadmans(context.Background(), cfg, source, dms, manager, createMockLogger())
+
+	if manager.calls != 0 {
+		t.Errorf("expected no shutdown while the key is present, got %d", manager.calls)
+	}
+	if _, ok := source.values["signalmice:alive"]; !ok {
+		t.Error("expected the key not to be deleted in deadmans mode")
+	}
+}
+
+func TestCheckDeadmans_KeyAbsent(t *testing.T) {
+	cfg := &config.Config{RedisKey: "signalmice:alive", Mode: config.ModeDeadmans}
+	source := &fakeSource{keys: []string{"signalmice:alive"}, values: map[string]string{}}
+	manager := &fakeShutdowner{}
+	ctx := context.Background()
+
+	// Within the grace period, a missing key is tolerated
+	dms := newDeadmansSwitch(time.Hour)
+	checkDeadmans(ctx, cfg, source, dms, manager, createMockLogger())
+	if manager.calls != 0 {
+		t.Errorf("expected no shutdown during grace period, got %d", manager.calls)
+	}
+
+	// After the grace period, a missing key triggers shutdown
+	dms.armAt = time.Now().Add(-time.Second)
+	checkDeadmans(ctx, cfg, source, dms, manager, createMockLogger())
+	if manager.calls != 1 {
+		t.Errorf("expected 1 shutdown after grace period, got %d", manager.calls)
+	}
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// debounce coalesces bursts of events: it emits once after in has been quiet for window.
+// The returned channel is closed when in is closed or ctx is cancelled.
+func debounce(ctx context.Context, in <-chan struct{}, window time.Duration) <-chan struct{} {
+	out := make(chan struct{}, 1)
+	go func() {
+		defer close(out)
+
+		timer := time.NewTimer(window)
+		timer.Stop()
+		pending := false
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case _, ok := <-in:
+				if !ok {
+					return
+				}
+				if pending && !timer.Stop() {
+					<-timer.C
+				}
+				timer.Reset(window)
+				pending = true
+			case <-timer.C:
+				pending = false
+				select {
+				case out <- struct{}{}:
+				default:
+				}
+			}
+		}
+	}()
+	return out
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestDebounce_CoalescesBurst(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	in := make(chan struct{})
+	out := debounce(ctx, in, 50*time.Millisecond)
+
+	// A burst of rapid events within the window
+	for i := 0; i < 10; i++ {
+		in <- struct{}{}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	select {
+	case <-out:
+	case <-time.After(time.Second):
+		t.Fatal("expected one evaluation after the burst went quiet")
+	}
+
+	select {
+	case <-out:
+		t.Error("expected the burst to be coalesced into a single evaluation")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestRunMonitor_DebouncedEvents(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	in := make(chan struct{})
+	sigChan := make(chan os.Signal, 1)
+	var checks int32
+
+	done := make(chan os.Signal)
+	go func() {
+		done <- runMonitor(ctx, fixedInterval(time.Hour), true, sigChan, debounce(ctx, in, 30*time.Millisecond), nil, func(context.Context) {
+			atomic.AddInt32(&checks, 1)
+		})
+	}()
+
+	for i := 0; i < 5; i++ {
+		in <- struct{}{}
+	}
+	time.Sleep(150 * time.Millisecond)
+	sigChan <- syscall.SIGTERM
+	<-done
+
+	if got := atomic.LoadInt32(&checks); got != 1 {
+		t.Errorf("expected a single evaluation for rapid events, got %d", got)
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/signalmice/signalmice/internal/config"
+	"github.com/signalmice/signalmice/internal/logger"
+)
+
+// knownDependencies are the names accepted in SIGNALMICE_REQUIRED
+var knownDependencies = map[string]bool{
+	config.DependencyRedis:      true,
+	config.DependencyOpensearch: true,
+}
+
+// validateRequired ensures SIGNALMICE_REQUIRED only names known dependencies
+func validateRequired(cfg *config.Config) error {
+	for _, dependency := range cfg.Required {
+		if !knownDependencies[dependency] {
+			return fmt.Errorf("unknown dependency %q (expected %s or %s)", dependency, config.DependencyRedis, config.DependencyOpensearch)
+		}
+	}
+	return nil
+}
+
+// handleDependencyFailure decides whether startup can continue after dependency failed with err.
+// A required dependency returns an error; an optional one is logged as degraded and returns nil.
+func handleDependencyFailure(ctx context.Context, cfg *config.Config, dependency string, err error, appLogger *logger.Logger) error {
+	if cfg.Requires(dependency) {
+		return fmt.Errorf("required dependency %s unavailable: %w", dependency, err)
+	}
+
+	appLogger.WarnWithExtra(ctx, fmt.Sprintf("Optional dependency %s unavailable, continuing degraded", dependency), map[string]string{
+		"dependency": dependency,
+		"error":      err.Error(),
+	})
+	return nil
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/signalmice/signalmice/internal/config"
+)
+
+func TestHandleDependencyFailure(t *testing.T) {
+	connErr := errors.New("connection refused")
+
+	tests := []struct {
+		name       string
+		required   []string
+		dependency string
+		wantErr    bool
+	}{
+		{name: "required redis fails startup", required: []string{"redis"}, dependency: config.DependencyRedis, wantErr: true},
+		{name: "optional redis degrades", required: []string{"opensearch"}, dependency: config.DependencyRedis},
+		{name: "required opensearch fails startup", required: []string{"redis", "opensearch"}, dependency: config.DependencyOpensearch, wantErr: true},
+		{name: "optional opensearch degrades", required: []string{"redis"}, dependency: config.DependencyOpensearch},
+		{name: "nothing required", dependency: config.DependencyRedis},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			log.SetOutput(&buf)
+			defer log.SetOutput(os.Stderr)
+
+			cfg := &config.Config{Required: tt.required}
+			err := handleDependencyFailure(context.Background(), cfg, tt.dependency, connErr, createMockLogger())
+
+			if tt.wantErr {
+				if !errors.Is(err, connErr) {
+					t.Errorf("expected error wrapping the failure, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Errorf("expected optional dependency to degrade, got %v", err)
+			}
+			if !strings.Contains(buf.String(), "[WARN] Optional dependency "+tt.dependency+" unavailable") {
+				t.Errorf("expected degradation warning, got: %s", buf.String())
+			}
+		})
+	}
+}
+
+func TestValidateRequired(t *testing.T) {
+	if err := validateRequired(&config.Config{Required: []string{"redis", "opensearch"}}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := validateRequired(&config.Config{Required: []string{"postgres"}}); err == nil {
+		t.Error("expected error for unknown dependency")
+	}
+}
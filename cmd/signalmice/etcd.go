@@ -0,0 +1,552 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/signalmice/signalmice/internal/config"
+	"github.com/signalmice/signalmice/internal/logger"
+	"github.com/signalmice/signalmice/internal/redis"
+)
+
+// etcdReconnectDelay is the pause before watching again after the watch stream dropped
+var etcdReconnectDelay = 5 * time.Second
+
+// maxPendingEtcdPuts bounds the puts waiting for a check; extra ones are dropped
+const maxPendingEtcdPuts = 16
+
+// maxEtcdResponseBytes bounds a single etcd response or watch message
+const maxEtcdResponseBytes = 1 << 20
+
+// errEtcdUnauthorized is returned when etcd rejects the auth token, e.g. once it expired
+var errEtcdUnauthorized = errors.New("etcd rejected the auth token")
+
+// etcdWatchEvent is a change to the watched key. Every event carries the revision the watch
+// reached, so a dropped watch resumes right after it.
+type etcdWatchEvent struct {
+	revision  int64
+	put       bool   // The key was put; otherwise the event only advances the revision
+	value     string // Value put to the key
+	compacted bool   // The history from the start revision was compacted away
+}
+
+// etcdKV is the part of etcd used by the etcd source
+type etcdKV interface {
+	// get reads key, returning its value and mod revision
+	get(ctx context.Context, key string) (value string, revision int64, found bool, err error)
+	// consume deletes key if it is still at revision, or unconditionally with revision 0,
+	// reporting whether it was deleted
+	consume(ctx context.Context, key string, revision int64) (bool, error)
+	// watch streams the changes to key from startRevision, or from now with 0, until ctx is
+	// cancelled or the stream drops, closing the channel either way
+	watch(ctx context.Context, key string, startRevision int64) (<-chan etcdWatchEvent, error)
+}
+
+// etcdSource reads the signal from an etcd key, treating a put as the signal and its value as
+// the payload. With deletion, every check reads the key and consumes it, the watch only
+// nudging the monitor; without, each put seen by the watch is one signal and the key is left
+// in place.
+type etcdSource struct {
+	kv     etcdKV
+	key    string
+	delete bool
+	logger *logger.Logger
+	events chan struct{} // Nudges the monitor to check as soon as a put arrives
+
+	revision int64 // Last revision the watch reached; only used by run
+
+	mu      sync.Mutex
+	pending []string // Values put while deletion is off, oldest first
+}
+
+// newEtcdSource creates an etcd source for key
+func newEtcdSource(kv etcdKV, key string, deleteKey bool, appLogger *logger.Logger) *etcdSource {
+	return &etcdSource{
+		kv:     kv,
+		key:    key,
+		delete: deleteKey,
+		logger: appLogger,
+		events: make(chan struct{}, 1),
+	}
+}
+
+// Events emits once per put, coalescing while a check is pending
+func (s *etcdSource) Events() <-chan struct{} {
+	return s.events
+}
+
+// run watches the key until ctx is cancelled, watching again whenever the stream drops. A new
+// watch resumes after the last revision seen, so puts made while disconnected are not lost.
+func (s *etcdSource) run(ctx context.Context) {
+	for {
+		var start int64
+		if s.revision > 0 {
+			start = s.revision + 1
+		}
+		events, err := s.kv.watch(ctx, s.key, start)
+		if err != nil {
+			s.logger.WarnWithExtra(ctx, "Failed to watch etcd key, retrying", map[string]string{
+				"key":   s.key,
+				"error": err.Error(),
+			})
+		} else {
+			s.logger.InfoWithExtra(ctx, "Watching etcd key", map[string]string{"key": s.key})
+			for event := range events {
+				if event.compacted {
+					s.logger.WarnWithExtra(ctx, "etcd history was compacted while reconnecting, puts may have been missed", map[string]string{
+						"key":      s.key,
+						"revision": strconv.FormatInt(s.revision, 10),
+					})
+				}
+				s.revision = event.revision
+				if event.put {
+					s.push(ctx, event.value)
+				}
+			}
+			if ctx.Err() == nil {
+				s.logger.WarnWithExtra(ctx, "etcd watch dropped, reconnecting", map[string]string{"key": s.key})
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(etcdReconnectDelay):
+		}
+	}
+}
+
+// push records a put and nudges the monitor. With deletion, the check reads the key itself.
+func (s *etcdSource) push(ctx context.Context, value string) {
+	if !s.delete {
+		s.mu.Lock()
+		if len(s.pending) < maxPendingEtcdPuts {
+			s.pending = append(s.pending, value)
+		} else {
+			s.logger.WarnWithExtra(ctx, "Too many pending etcd puts, dropping one", map[string]string{"key": s.key})
+		}
+		s.mu.Unlock()
+	}
+
+	select {
+	case s.events <- struct{}{}:
+	default:
+	}
+}
+
+// next returns the oldest pending put, removing it when pop is set
+func (s *etcdSource) next(pop bool) (redis.Signal, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.pending) == 0 {
+		return redis.Signal{}, false
+	}
+	value := s.pending[0]
+	if pop {
+		s.pending = s.pending[1:]
+	}
+	return redis.Signal{Key: s.key, Value: value}, true
+}
+
+func (s *etcdSource) CheckAndDeleteSignal(ctx context.Context) (redis.Signal, bool, error) {
+	if !s.delete {
+		sig, found := s.next(true)
+		return sig, found, nil
+	}
+
+	value, revision, found, err := s.kv.get(ctx, s.key)
+	if err != nil || !found {
+		return redis.Signal{}, false, err
+	}
+	deleted, err := s.kv.consume(ctx, s.key, revision)
+	if err != nil {
+		return redis.Signal{}, false, err
+	}
+	// Put again since it was read; the next check acts on the new value
+	if !deleted {
+		return redis.Signal{}, false, nil
+	}
+	return redis.Signal{Key: s.key, Value: value}, true, nil
+}
+
+func (s *etcdSource) PeekSignal(ctx context.Context) (redis.Signal, bool, error) {
+	if !s.delete {
+		sig, found := s.next(false)
+		return sig, found, nil
+	}
+	value, _, found, err := s.kv.get(ctx, s.key)
+	if err != nil || !found {
+		return redis.Signal{}, false, err
+	}
+	return redis.Signal{Key: s.key, Value: value}, true, nil
+}
+
+func (s *etcdSource) DeleteSignal(ctx context.Context, key string) error {
+	if key != s.key {
+		return nil
+	}
+	if !s.delete {
+		s.next(true)
+		return nil
+	}
+	_, err := s.kv.consume(ctx, s.key, 0)
+	return err
+}
+
+func (s *etcdSource) ClearSignals(ctx context.Context) ([]string, error) {
+	if !s.delete {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		if len(s.pending) == 0 {
+			return nil, nil
+		}
+		s.pending = nil
+		return []string{s.key}, nil
+	}
+
+	_, _, found, err := s.kv.get(ctx, s.key)
+	if err != nil || !found {
+		return nil, err
+	}
+	if _, err := s.kv.consume(ctx, s.key, 0); err != nil {
+		return nil, err
+	}
+	return []string{s.key}, nil
+}
+
+func (s *etcdSource) KeyExists(ctx context.Context) (bool, error) {
+	_, _, found, err := s.kv.get(ctx, s.key)
+	return found, err
+}
+
+func (s *etcdSource) ConfirmDeleted(ctx context.Context, key string) (bool, error) {
+	if key != s.key || !s.delete {
+		return false, nil
+	}
+	_, _, found, err := s.kv.get(ctx, s.key)
+	if err != nil || !found {
+		return false, err
+	}
+	_, err = s.kv.consume(ctx, s.key, 0)
+	return true, err
+}
+
+// etcdGateway talks to etcd through its v3 JSON gateway, trying the endpoints in order
+type etcdGateway struct {
+	endpoints []string
+	client    *http.Client
+	streams   *http.Client // Without a timeout, for watch streams
+	username  string
+	password  string
+
+	mu    sync.Mutex
+	token string // Auth token, fetched on first use and again once rejected
+}
+
+// newEtcdGateway creates a gateway client for the configured endpoints. An endpoint without a
+// scheme uses http.
+func newEtcdGateway(cfg *config.Config) (*etcdGateway, error) {
+	tlsConfig, err := etcdTLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	g := &etcdGateway{
+		client:   &http.Client{Timeout: cfg.EtcdTimeout, Transport: transport},
+		streams:  &http.Client{Transport: transport},
+		username: cfg.EtcdUsername,
+		password: cfg.EtcdPassword,
+	}
+	for _, endpoint := range cfg.EtcdEndpoints {
+		if !strings.Contains(endpoint, "://") {
+			endpoint = "http://" + endpoint
+		}
+		g.endpoints = append(g.endpoints, strings.TrimSuffix(endpoint, "/"))
+	}
+	return g, nil
+}
+
+// etcdTLSConfig builds the TLS configuration for https endpoints. Without a CA file, server
+// certificates are verified against the system roots.
+func etcdTLSConfig(cfg *config.Config) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if cfg.EtcdCAFile != "" {
+		caPEM, err := os.ReadFile(cfg.EtcdCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read etcd CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no valid certificates found in etcd CA file: %s", cfg.EtcdCAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.EtcdCertFile != "" || cfg.EtcdKeyFile != "" {
+		if cfg.EtcdCertFile == "" || cfg.EtcdKeyFile == "" {
+			return nil, fmt.Errorf("both SIGNALMICE_ETCD_CERT_FILE and SIGNALMICE_ETCD_KEY_FILE are required for mutual TLS")
+		}
+		cert, err := tls.LoadX509KeyPair(cfg.EtcdCertFile, cfg.EtcdKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load etcd client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// etcdKeyValue is a key-value pair in gateway responses; bytes are base64 and int64s strings
+type etcdKeyValue struct {
+	Value       string `json:"value"`
+	ModRevision string `json:"mod_revision"`
+}
+
+func (g *etcdGateway) get(ctx context.Context, key string) (string, int64, bool, error) {
+	var res struct {
+		Kvs []etcdKeyValue `json:"kvs"`
+	}
+	if err := g.call(ctx, "/v3/kv/range", map[string]any{"key": encodeEtcd(key)}, &res); err != nil {
+		return "", 0, false, err
+	}
+	if len(res.Kvs) == 0 {
+		return "", 0, false, nil
+	}
+	value, err := decodeEtcd(res.Kvs[0].Value)
+	if err != nil {
+		return "", 0, false, err
+	}
+	revision, _ := strconv.ParseInt(res.Kvs[0].ModRevision, 10, 64)
+	return strings.TrimSpace(value), revision, true, nil
+}
+
+func (g *etcdGateway) consume(ctx context.Context, key string, revision int64) (bool, error) {
+	deleteRange := map[string]any{"key": encodeEtcd(key)}
+	if revision == 0 {
+		return true, g.call(ctx, "/v3/kv/deleterange", deleteRange, nil)
+	}
+
+	txn := map[string]any{
+		"compare": []map[string]any{{
+			"key":          encodeEtcd(key),
+			"target":       "MOD",
+			"result":       "EQUAL",
+			"mod_revision": strconv.FormatInt(revision, 10),
+		}},
+		"success": []map[string]any{{"request_delete_range": deleteRange}},
+	}
+	var res struct {
+		Succeeded bool `json:"succeeded"`
+	}
+	if err := g.call(ctx, "/v3/kv/txn", txn, &res); err != nil {
+		return false, err
+	}
+	return res.Succeeded, nil
+}
+
+func (g *etcdGateway) watch(ctx context.Context, key string, startRevision int64) (<-chan etcdWatchEvent, error) {
+	create := map[string]any{"key": encodeEtcd(key)}
+	if startRevision > 0 {
+		create["start_revision"] = strconv.FormatInt(startRevision, 10)
+	}
+	res, err := g.post(ctx, g.streams, "/v3/watch", map[string]any{"create_request": create})
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan etcdWatchEvent, maxPendingEtcdPuts)
+	send := func(event etcdWatchEvent) bool {
+		select {
+		case events <- event:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+	go func() {
+		defer close(events)
+		defer res.Body.Close()
+
+		scanner := bufio.NewScanner(res.Body)
+		scanner.Buffer(make([]byte, 0, 64<<10), maxEtcdResponseBytes)
+		for scanner.Scan() {
+			var msg struct {
+				Result struct {
+					Header struct {
+						Revision string `json:"revision"`
+					} `json:"header"`
+					Created         bool   `json:"created"`
+					CompactRevision string `json:"compact_revision"`
+					Events          []struct {
+						Type string       `json:"type"` // Omitted for puts
+						Kv   etcdKeyValue `json:"kv"`
+					} `json:"events"`
+				} `json:"result"`
+			}
+			if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+				return
+			}
+			// etcd cancels a watch whose start revision was compacted; resume from the oldest kept
+			if compact, _ := strconv.ParseInt(msg.Result.CompactRevision, 10, 64); compact > 0 {
+				send(etcdWatchEvent{revision: compact - 1, compacted: true})
+				return
+			}
+			// A watch from now starts after the revision it was created at
+			if msg.Result.Created {
+				revision, _ := strconv.ParseInt(msg.Result.Header.Revision, 10, 64)
+				if startRevision == 0 && revision > 0 && !send(etcdWatchEvent{revision: revision}) {
+					return
+				}
+				continue
+			}
+			for _, change := range msg.Result.Events {
+				event := etcdWatchEvent{}
+				event.revision, _ = strconv.ParseInt(change.Kv.ModRevision, 10, 64)
+				if change.Type == "" || change.Type == "PUT" {
+					if value, err := decodeEtcd(change.Kv.Value); err == nil {
+						event.put, event.value = true, strings.TrimSpace(value)
+					}
+				}
+				if !send(event) {
+					return
+				}
+			}
+		}
+	}()
+	return events, nil
+}
+
+// call posts body to path and decodes the response into out, if not nil
+func (g *etcdGateway) call(ctx context.Context, path string, body, out any) error {
+	res, err := g.post(ctx, g.client, path, body)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(io.LimitReader(res.Body, maxEtcdResponseBytes)).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode etcd response: %w", err)
+	}
+	return nil
+}
+
+// post sends body to path on the first endpoint that answers with 200, authenticating first
+// when a user is configured and once more when the token was rejected
+func (g *etcdGateway) post(ctx context.Context, client *http.Client, path string, body any) (*http.Response, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := g.authToken(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	res, err := g.send(ctx, client, path, data, token)
+	if errors.Is(err, errEtcdUnauthorized) && g.username != "" {
+		if token, err = g.authToken(ctx, true); err != nil {
+			return nil, err
+		}
+		res, err = g.send(ctx, client, path, data, token)
+	}
+	return res, err
+}
+
+// authToken returns the token to send with requests, authenticating when there is none yet or
+// refresh is set. Without a configured user, requests go unauthenticated.
+func (g *etcdGateway) authToken(ctx context.Context, refresh bool) (string, error) {
+	if g.username == "" {
+		return "", nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.token != "" && !refresh {
+		return g.token, nil
+	}
+
+	data, err := json.Marshal(map[string]string{"name": g.username, "password": g.password})
+	if err != nil {
+		return "", err
+	}
+	res, err := g.send(ctx, g.client, "/v3/auth/authenticate", data, "")
+	if err != nil {
+		return "", fmt.Errorf("failed to authenticate to etcd: %w", err)
+	}
+	defer res.Body.Close()
+	var auth struct {
+		Token string `json:"token"`
+	}
+	if err := json.NewDecoder(io.LimitReader(res.Body, maxEtcdResponseBytes)).Decode(&auth); err != nil {
+		return "", fmt.Errorf("failed to decode etcd auth response: %w", err)
+	}
+	if auth.Token == "" {
+		return "", errors.New("etcd returned no auth token; is authentication enabled?")
+	}
+	g.token = auth.Token
+	return g.token, nil
+}
+
+// send posts data to path on each endpoint in turn, returning the first 200 response
+func (g *etcdGateway) send(ctx context.Context, client *http.Client, path string, data []byte, token string) (*http.Response, error) {
+	var errs []error
+	for _, endpoint := range g.endpoints {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+path, bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", token)
+		}
+		res, err := client.Do(req)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%w: %w", redis.ErrConnection, err))
+			continue
+		}
+		if res.StatusCode == http.StatusUnauthorized {
+			res.Body.Close()
+			errs = append(errs, fmt.Errorf("etcd at %s: %w", endpoint, errEtcdUnauthorized))
+			continue
+		}
+		if res.StatusCode != http.StatusOK {
+			res.Body.Close()
+			errs = append(errs, fmt.Errorf("etcd at %s returned status %d", endpoint, res.StatusCode))
+			continue
+		}
+		return res, nil
+	}
+	return nil, errors.Join(errs...)
+}
+
+// encodeEtcd encodes a key for the gateway
+func encodeEtcd(s string) string {
+	return base64.StdEncoding.EncodeToString([]byte(s))
+}
+
+// decodeEtcd decodes a value from the gateway
+func decodeEtcd(s string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode etcd value: %w", err)
+	}
+	return string(data), nil
+}
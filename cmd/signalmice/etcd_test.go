@@ -0,0 +1,524 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/signalmice/signalmice/internal/config"
+)
+
+// fakeEtcd is an in-memory etcdKV holding a single key, with a watch fed by its changes
+type fakeEtcd struct {
+	mu        sync.Mutex
+	value     string
+	set       bool
+	revision  int64
+	deletes   int
+	history   []etcdWatchEvent // Every change, replayed to watches from a start revision
+	compacted int64            // Revisions before this one were compacted away
+	watchers  []chan etcdWatchEvent
+}
+
+// put sets the key, as a writer would, and sends it to the watchers
+func (f *fakeEtcd) put(value string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.value, f.set = value, true
+	f.revision++
+	f.record(etcdWatchEvent{revision: f.revision, put: true, value: value})
+}
+
+// record adds a change to the history and sends it to the watchers
+func (f *fakeEtcd) record(event etcdWatchEvent) {
+	f.history = append(f.history, event)
+	for _, watcher := range f.watchers {
+		watcher <- event
+	}
+}
+
+// drop ends every watch, as a lost connection would
+func (f *fakeEtcd) drop() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, watcher := range f.watchers {
+		close(watcher)
+	}
+	f.watchers = nil
+}
+
+func (f *fakeEtcd) present() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.set
+}
+
+func (f *fakeEtcd) get(ctx context.Context, key string) (string, int64, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.value, f.revision, f.set, nil
+}
+
+func (f *fakeEtcd) consume(ctx context.Context, key string, revision int64) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.deletes++
+	if revision != 0 && revision != f.revision {
+		return false, nil
+	}
+	f.set = false
+	f.revision++
+	f.record(etcdWatchEvent{revision: f.revision})
+	return true, nil
+}
+
+func (f *fakeEtcd) watch(ctx context.Context, key string, startRevision int64) (<-chan etcdWatchEvent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	watcher := make(chan etcdWatchEvent, maxPendingEtcdPuts)
+	if startRevision > 0 && startRevision < f.compacted {
+		watcher <- etcdWatchEvent{revision: f.compacted - 1, compacted: true}
+		close(watcher)
+		return watcher, nil
+	}
+	for _, event := range f.history {
+		if startRevision > 0 && event.revision >= startRevision {
+			watcher <- event
+		}
+	}
+	f.watchers = append(f.watchers, watcher)
+	return watcher, nil
+}
+
+// watching waits until run started watching
+func (f *fakeEtcd) watching(t *testing.T) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		f.mu.Lock()
+		n := len(f.watchers)
+		f.mu.Unlock()
+		if n > 0 {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("expected the source to watch the key")
+}
+
+// runEtcdSource starts watching with source until the test ends
+func runEtcdSource(t *testing.T, source *etcdSource) {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	go source.run(ctx)
+}
+
+func waitEtcdEvent(t *testing.T, source *etcdSource) {
+	t.Helper()
+	select {
+	case <-source.Events():
+	case <-time.After(time.Second):
+		t.Fatal("expected a put to nudge a check")
+	}
+}
+
+func TestEtcdSource_PutTriggersFound(t *testing.T) {
+	etcd := &fakeEtcd{}
+	source := newEtcdSource(etcd, "signalmice/shutdown", false, createMockLogger())
+	runEtcdSource(t, source)
+	etcd.watching(t)
+
+	if _, found, err := source.CheckAndDeleteSignal(context.Background()); err != nil || found {
+		t.Fatalf("expected no signal before a put, got %v, %v", found, err)
+	}
+
+	etcd.put("maintenance window")
+	waitEtcdEvent(t, source)
+
+	sig, found, err := source.CheckAndDeleteSignal(context.Background())
+	if err != nil || !found {
+		t.Fatalf("expected the put to be found, got %v, %v", found, err)
+	}
+	if sig.Key != "signalmice/shutdown" || sig.Value != "maintenance window" {
+		t.Errorf("unexpected signal: %+v", sig)
+	}
+	if !etcd.present() || etcd.deletes != 0 {
+		t.Errorf("expected the key to be left in place, got %d deletes", etcd.deletes)
+	}
+	if _, found, _ := source.CheckAndDeleteSignal(context.Background()); found {
+		t.Error("expected a put to be acted on once")
+	}
+}
+
+func TestEtcdSource_DeletesAfterPut(t *testing.T) {
+	etcd := &fakeEtcd{}
+	source := newEtcdSource(etcd, "signalmice/shutdown", true, createMockLogger())
+	runEtcdSource(t, source)
+	etcd.watching(t)
+
+	etcd.put("reboot")
+	waitEtcdEvent(t, source)
+
+	sig, found, err := source.CheckAndDeleteSignal(context.Background())
+	if err != nil || !found || sig.Value != "reboot" {
+		t.Fatalf("expected the put to be found, got %+v, %v, %v", sig, found, err)
+	}
+	if etcd.present() {
+		t.Error("expected the key to be deleted")
+	}
+}
+
+func TestEtcdSource_ResumesAfterDroppedWatch(t *testing.T) {
+	defer func(delay time.Duration) { etcdReconnectDelay = delay }(etcdReconnectDelay)
+	etcdReconnectDelay = 10 * time.Millisecond
+
+	etcd := &fakeEtcd{}
+	source := newEtcdSource(etcd, "signalmice/shutdown", false, createMockLogger())
+	runEtcdSource(t, source)
+	etcd.watching(t)
+
+	etcd.put("first")
+	waitEtcdEvent(t, source)
+	if sig, found, _ := source.CheckAndDeleteSignal(context.Background()); !found || sig.Value != "first" {
+		t.Fatalf("expected the first put, got %+v, %v", sig, found)
+	}
+
+	// Put while the watch is down; the new watch must replay it
+	etcd.drop()
+	etcd.put("second")
+	waitEtcdEvent(t, source)
+
+	sig, found, err := source.CheckAndDeleteSignal(context.Background())
+	if err != nil || !found || sig.Value != "second" {
+		t.Fatalf("expected the put made while disconnected, got %+v, %v, %v", sig, found, err)
+	}
+	if _, found, _ := source.CheckAndDeleteSignal(context.Background()); found {
+		t.Error("expected the first put not to be replayed")
+	}
+}
+
+func TestEtcdSource_PutBeforeWatchIsFoundWithDelete(t *testing.T) {
+	etcd := &fakeEtcd{}
+	etcd.put("reboot")
+	source := newEtcdSource(etcd, "signalmice/shutdown", true, createMockLogger())
+
+	if _, found, err := source.CheckAndDeleteSignal(context.Background()); err != nil || !found {
+		t.Fatalf("expected the key to be read without a watch, got %v, %v", found, err)
+	}
+}
+
+// fakeEtcdGateway serves the v3 JSON gateway endpoints of a single key
+func fakeEtcdGateway(t *testing.T, etcd *fakeEtcd) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(fakeEtcdHandler(t, etcd))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// fakeEtcdHandler handles the v3 JSON gateway requests for etcd
+func fakeEtcdHandler(t *testing.T, etcd *fakeEtcd) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Key     string `json:"key"`
+			Compare []struct {
+				ModRevision string `json:"mod_revision"`
+			} `json:"compare"`
+			CreateRequest struct {
+				Key           string `json:"key"`
+				StartRevision string `json:"start_revision"`
+			} `json:"create_request"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("invalid request body: %v", err)
+		}
+		encode := func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) }
+
+		switch r.URL.Path {
+		case "/v3/kv/range":
+			value, revision, found, _ := etcd.get(r.Context(), "")
+			if !found {
+				fmt.Fprint(w, `{"header":{}}`)
+				return
+			}
+			fmt.Fprintf(w, `{"kvs":[{"key":%q,"value":%q,"mod_revision":"%d"}],"count":"1"}`, req.Key, encode(value), revision)
+		case "/v3/kv/txn":
+			revision, _ := strconv.ParseInt(req.Compare[0].ModRevision, 10, 64)
+			deleted, _ := etcd.consume(r.Context(), "", revision)
+			fmt.Fprintf(w, `{"succeeded":%t}`, deleted)
+		case "/v3/watch":
+			start, _ := strconv.ParseInt(req.CreateRequest.StartRevision, 10, 64)
+			_, revision, _, _ := etcd.get(r.Context(), "")
+			events, _ := etcd.watch(r.Context(), "", start)
+			fmt.Fprintf(w, `{"result":{"header":{"revision":"%d"},"created":true}}`+"\n", revision)
+			w.(http.Flusher).Flush()
+			for {
+				select {
+				case event, ok := <-events:
+					switch {
+					case !ok:
+						return
+					case event.compacted:
+						fmt.Fprintf(w, `{"result":{"canceled":true,"compact_revision":"%d"}}`+"\n", event.revision+1)
+					case event.put:
+						fmt.Fprintf(w, `{"result":{"events":[{"kv":{"key":%q,"value":%q,"mod_revision":"%d"}}]}}`+"\n",
+							req.CreateRequest.Key, encode(event.value), event.revision)
+					default:
+						fmt.Fprintf(w, `{"result":{"events":[{"type":"DELETE","kv":{"key":%q,"mod_revision":"%d"}}]}}`+"\n",
+							req.CreateRequest.Key, event.revision)
+					}
+					w.(http.Flusher).Flush()
+				case <-r.Context().Done():
+					return
+				}
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	})
+}
+
+// newTestEtcdGateway creates a gateway for endpoints with a short timeout
+func newTestEtcdGateway(t *testing.T, endpoints ...string) *etcdGateway {
+	t.Helper()
+	gateway, err := newEtcdGateway(&config.Config{EtcdEndpoints: endpoints, EtcdTimeout: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return gateway
+}
+
+func TestEtcdGateway_CheckAndDelete(t *testing.T) {
+	etcd := &fakeEtcd{}
+	server := fakeEtcdGateway(t, etcd)
+	// The first endpoint is down, so the second one answers
+	gateway := newTestEtcdGateway(t, "127.0.0.1:1", server.URL)
+	source := newEtcdSource(gateway, "signalmice/shutdown", true, createMockLogger())
+
+	if _, found, err := source.CheckAndDeleteSignal(context.Background()); err != nil || found {
+		t.Fatalf("expected no signal while the key is unset, got %v, %v", found, err)
+	}
+
+	etcd.put("maintenance window\n")
+	sig, found, err := source.CheckAndDeleteSignal(context.Background())
+	if err != nil || !found || sig.Value != "maintenance window" {
+		t.Fatalf("expected the signal to be found, got %+v, %v, %v", sig, found, err)
+	}
+	if etcd.present() {
+		t.Error("expected the key to be deleted")
+	}
+}
+
+func TestEtcdGateway_StaleRevisionIsNotConsumed(t *testing.T) {
+	etcd := &fakeEtcd{}
+	gateway := newTestEtcdGateway(t, fakeEtcdGateway(t, etcd).URL)
+	etcd.put("first")
+
+	_, revision, _, err := gateway.get(context.Background(), "signalmice/shutdown")
+	if err != nil {
+		t.Fatal(err)
+	}
+	etcd.put("second")
+
+	if deleted, err := gateway.consume(context.Background(), "signalmice/shutdown", revision); err != nil || deleted {
+		t.Errorf("expected a stale delete to be refused, got %v, %v", deleted, err)
+	}
+	if !etcd.present() {
+		t.Error("expected the rewritten key to survive")
+	}
+}
+
+// nextEtcdEvent reads the next watch event
+func nextEtcdEvent(t *testing.T, events <-chan etcdWatchEvent) etcdWatchEvent {
+	t.Helper()
+	select {
+	case event, ok := <-events:
+		if !ok {
+			t.Fatal("expected a watch event, the watch ended")
+		}
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("expected a watch event")
+	}
+	return etcdWatchEvent{}
+}
+
+func TestEtcdGateway_WatchStreamsPuts(t *testing.T) {
+	etcd := &fakeEtcd{}
+	gateway := newTestEtcdGateway(t, fakeEtcdGateway(t, etcd).URL)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := gateway.watch(ctx, "signalmice/shutdown", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	etcd.watching(t)
+	etcd.put("reboot")
+
+	if event := nextEtcdEvent(t, events); !event.put || event.value != "reboot" || event.revision != 1 {
+		t.Errorf("expected the put at revision 1, got %+v", event)
+	}
+	if _, err := etcd.consume(ctx, "", 0); err != nil {
+		t.Fatal(err)
+	}
+	if event := nextEtcdEvent(t, events); event.put || event.revision != 2 {
+		t.Errorf("expected the delete to only advance the revision, got %+v", event)
+	}
+}
+
+func TestEtcdGateway_WatchFromCurrentRevision(t *testing.T) {
+	etcd := &fakeEtcd{}
+	etcd.put("old")
+	gateway := newTestEtcdGateway(t, fakeEtcdGateway(t, etcd).URL)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := gateway.watch(ctx, "signalmice/shutdown", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event := nextEtcdEvent(t, events); event.put || event.revision != 1 {
+		t.Errorf("expected the watch to report the revision it started at, got %+v", event)
+	}
+}
+
+func TestEtcdGateway_WatchResumesFromRevision(t *testing.T) {
+	etcd := &fakeEtcd{}
+	etcd.put("first")
+	etcd.put("second")
+	gateway := newTestEtcdGateway(t, fakeEtcdGateway(t, etcd).URL)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := gateway.watch(ctx, "signalmice/shutdown", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event := nextEtcdEvent(t, events); !event.put || event.value != "second" || event.revision != 2 {
+		t.Errorf("expected the put at the start revision, got %+v", event)
+	}
+}
+
+func TestEtcdGateway_WatchReportsCompaction(t *testing.T) {
+	etcd := &fakeEtcd{compacted: 5}
+	gateway := newTestEtcdGateway(t, fakeEtcdGateway(t, etcd).URL)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events, err := gateway.watch(ctx, "signalmice/shutdown", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event := nextEtcdEvent(t, events); !event.compacted || event.revision != 4 {
+		t.Errorf("expected the watch to resume from the compact revision, got %+v", event)
+	}
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Error("expected the compacted watch to end")
+		}
+	case <-time.After(time.Second):
+		t.Error("expected the compacted watch to end")
+	}
+}
+
+func TestEtcdGateway_AuthenticatesAndRefreshesToken(t *testing.T) {
+	etcd := &fakeEtcd{}
+	etcd.put("reboot")
+	kv := fakeEtcdHandler(t, etcd)
+
+	var mu sync.Mutex
+	var token string
+	authentications := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.URL.Path == "/v3/auth/authenticate" {
+			var req struct{ Name, Password string }
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Name != "signalmice" || req.Password != "secret" {
+				http.Error(w, `{"error":"authentication failed"}`, http.StatusBadRequest)
+				return
+			}
+			authentications++
+			token = fmt.Sprintf("token-%d", authentications)
+			fmt.Fprintf(w, `{"token":%q}`, token)
+			return
+		}
+		if r.Header.Get("Authorization") != token {
+			http.Error(w, `{"error":"invalid auth token"}`, http.StatusUnauthorized)
+			return
+		}
+		kv.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	gateway, err := newEtcdGateway(&config.Config{
+		EtcdEndpoints: []string{server.URL},
+		EtcdTimeout:   time.Second,
+		EtcdUsername:  "signalmice",
+		EtcdPassword:  "secret",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, found, err := gateway.get(context.Background(), "signalmice/shutdown"); err != nil || !found {
+		t.Fatalf("expected an authenticated read, got %v, %v", found, err)
+	}
+	// The token expires, so the next request authenticates again
+	mu.Lock()
+	token = "expired"
+	mu.Unlock()
+	if _, _, found, err := gateway.get(context.Background(), "signalmice/shutdown"); err != nil || !found {
+		t.Fatalf("expected the read to succeed with a new token, got %v, %v", found, err)
+	}
+	if authentications != 2 {
+		t.Errorf("expected 2 authentications, got %d", authentications)
+	}
+}
+
+func TestEtcdGateway_TLSWithCAFile(t *testing.T) {
+	etcd := &fakeEtcd{}
+	etcd.put("reboot")
+	server := httptest.NewTLSServer(fakeEtcdHandler(t, etcd))
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, caPEM, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	// The test server's certificate is not in the system roots
+	if _, _, _, err := newTestEtcdGateway(t, server.URL).get(context.Background(), "signalmice/shutdown"); err == nil {
+		t.Error("expected an unknown CA to be refused")
+	}
+
+	gateway, err := newEtcdGateway(&config.Config{EtcdEndpoints: []string{server.URL}, EtcdTimeout: time.Second, EtcdCAFile: caFile})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value, _, found, err := gateway.get(context.Background(), "signalmice/shutdown"); err != nil || !found || value != "reboot" {
+		t.Errorf("expected the key to be read over TLS, got %q, %v, %v", value, found, err)
+	}
+}
+
+func TestNewEtcdGateway_InvalidCAFile(t *testing.T) {
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, []byte("not a certificate"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newEtcdGateway(&config.Config{EtcdCAFile: caFile}); err == nil {
+		t.Error("expected a CA file without certificates to be refused")
+	}
+}
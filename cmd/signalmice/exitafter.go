@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"sync"
+
+	"github.com/signalmice/signalmice/internal/shutdown"
+)
+
+// stoppingShutdowner reports the first real (non safe mode) shutdown it initiates, so the
+// monitoring loop can stop instead of polling until the host goes down
+type stoppingShutdowner struct {
+	shutdowner
+	initiated chan struct{}
+	once      sync.Once
+}
+
+// newStoppingShutdowner wraps s, reporting its first initiated shutdown
+func newStoppingShutdowner(s shutdowner) *stoppingShutdowner {
+	return &stoppingShutdowner{shutdowner: s, initiated: make(chan struct{})}
+}
+
+func (s *stoppingShutdowner) NeutralizeStuartLittle(ctx context.Context) (string, error) {
+	method, err := s.shutdowner.NeutralizeStuartLittle(ctx)
+	if err != nil {
+		return "", err
+	}
+	if !s.SafeMode() && !shutdown.DryRunFromContext(ctx) {
+		s.once.Do(func() { close(s.initiated) })
+	}
+	return method, nil
+}
+
+// Initiated returns a channel closed once a shutdown was initiated
+func (s *stoppingShutdowner) Initiated() <-chan struct{} {
+	return s.initiated
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/signalmice/signalmice/internal/config"
+	"github.com/signalmice/signalmice/internal/shutdown"
+)
+
+func TestRunMonitor_StopsAfterInitiatedShutdown(t *testing.T) {
+	cfg := &config.Config{RedisKey: "signalmice:shutdown"}
+	source := &fakeSource{keys: []string{"signalmice:shutdown"}, values: map[string]string{"signalmice:shutdown": "1"}}
+	fake := &fakeShutdowner{}
+	shutdowns := newStoppingShutdowner(fake)
+	sigChan := make(chan os.Signal, 1)
+	var checks int32
+
+	done := make(chan os.Signal)
+	go func() {
+		done <- runMonitor(context.Background(), fixedInterval(10*time.Millisecond), false, sigChan, nil, shutdowns.Initiated(), func(ctx context.Context) {
+			atomic.AddInt32(&checks, 1)
+			checkAndShutdown(ctx, cfg, source, shutdowns, createMockLogger())
+		})
+	}()
+
+	select {
+	case sig := <-done:
+		if sig != nil {
+			t.Errorf("expected monitoring to stop without a signal, got %v", sig)
+		}
+	case <-time.After(time.Second):
+		sigChan <- syscall.SIGINT
+		<-done
+		t.Fatal("expected monitoring to stop once the shutdown was initiated")
+	}
+	if fake.calls != 1 {
+		t.Errorf("expected one shutdown, got %d", fake.calls)
+	}
+	if got := atomic.LoadInt32(&checks); got != 1 {
+		t.Errorf("expected no check after the shutdown was initiated, got %d", got)
+	}
+}
+
+func TestStoppingShutdowner_KeepsMonitoring(t *testing.T) {
+	tests := []struct {
+		name   string
+		fake   *fakeShutdowner
+		dryRun bool
+	}{
+		{"failed shutdown", &fakeShutdowner{err: errors.New("all methods failed")}, false},
+		{"dry run", &fakeShutdowner{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shutdowns := newStoppingShutdowner(tt.fake)
+			ctx := context.Background()
+			if tt.dryRun {
+				ctx = shutdown.WithDryRun(ctx)
+			}
+			shutdowns.NeutralizeStuartLittle(ctx)
+
+			select {
+			case <-shutdowns.Initiated():
+				t.Error("expected monitoring to continue")
+			default:
+			}
+		})
+	}
+}
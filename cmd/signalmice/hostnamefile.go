@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/signalmice/signalmice/internal/config"
+	"github.com/signalmice/signalmice/internal/logger"
+)
+
+// hostnameTarget is a component that reports this host's name and can be renamed at runtime
+type hostnameTarget interface {
+	SetHostname(hostname string)
+}
+
+// hostnameFileWatcher re-reads SIGNALMICE_HOSTNAME_FILE and renames the host in logs, shutdown
+// reports and, with a per-host key template, the monitored signal key when its contents change
+type hostnameFileWatcher struct {
+	path    string
+	cfg     *config.Config
+	keys    keyTarget // Nil when the signal key does not depend on the hostname
+	targets []hostnameTarget
+	logger  *logger.Logger
+	current string
+}
+
+// newHostnameFileWatcher creates a watcher starting from the configured hostname. The
+// configuration, read by everything needing the hostname after startup, and the logger are
+// always renamed, in addition to targets.
+func newHostnameFileWatcher(path string, cfg *config.Config, keys keyTarget, appLogger *logger.Logger, targets ...hostnameTarget) *hostnameFileWatcher {
+	// A key file decides the signal key on its own
+	if !strings.Contains(cfg.KeyTemplate, "{hostname}") || cfg.KeyFile != "" {
+		keys = nil
+	}
+	return &hostnameFileWatcher{
+		path:    path,
+		cfg:     cfg,
+		keys:    keys,
+		targets: append([]hostnameTarget{cfg, appLogger}, targets...),
+		logger:  appLogger,
+		current: cfg.CurrentHostname(),
+	}
+}
+
+// run reloads the hostname file on every interval until ctx is cancelled
+func (w *hostnameFileWatcher) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.reload(ctx)
+		}
+	}
+}
+
+// reload reads the hostname file and renames the host if it changed. It reports whether the
+// hostname was changed.
+func (w *hostnameFileWatcher) reload(ctx context.Context) bool {
+	hostname, err := config.ReadHostnameFile(w.path)
+	if err != nil {
+		w.logger.WarnWithExtra(ctx, "Failed to read hostname file", map[string]string{
+			"path":  w.path,
+			"error": err.Error(),
+		})
+		return false
+	}
+	if hostname == w.current {
+		return false
+	}
+
+	for _, target := range w.targets {
+		target.SetHostname(hostname)
+	}
+	fields := map[string]string{
+		"path":         w.path,
+		"old_hostname": w.current,
+		"new_hostname": hostname,
+	}
+	if w.keys != nil {
+		next := w.cfg.WithRedisKey(config.RenderKeyTemplate(w.cfg.KeyTemplate, hostname))
+		w.keys.SetSignalKeys(next.SignalKey(), next.SignalKeys())
+		w.logger.SetRedisKey(next.SignalKey())
+		fields["full_key"] = next.SignalKey()
+	}
+
+	w.logger.InfoWithExtra(ctx, "Hostname changed from hostname file", fields)
+	w.current = hostname
+	return true
+}
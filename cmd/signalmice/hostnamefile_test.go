@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/signalmice/signalmice/internal/config"
+	"github.com/signalmice/signalmice/internal/logger"
+)
+
+// fakeHostnameTarget records the hostname it was renamed to
+type fakeHostnameTarget struct {
+	hostname string
+}
+
+func (f *fakeHostnameTarget) SetHostname(hostname string) {
+	f.hostname = hostname
+}
+
+func TestHostnameFileWatcher_ChangesLoggedHostname(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hostname")
+	if err := os.WriteFile(path, []byte("node-1\n"), 0644); err != nil {
+		t.Fatalf("failed to write hostname file: %v", err)
+	}
+
+	cfg := &config.Config{
+		OpensearchURL:   "http://localhost:9200",
+		OpensearchIndex: "test-logs",
+		Hostname:        "node-1",
+		KeyTemplate:     "signalmice:host:{hostname}",
+		RedisKey:        "signalmice:host:node-1",
+		LogRingSize:     8,
+	}
+	appLogger, _ := logger.NewLogger(cfg)
+	keys := &fakeKeyTarget{}
+	manager := &fakeHostnameTarget{}
+	w := newHostnameFileWatcher(path, cfg, keys, appLogger, manager)
+	ctx := context.Background()
+
+	if w.reload(ctx) {
+		t.Error("expected no change while the file holds the configured hostname")
+	}
+
+	if err := os.WriteFile(path, []byte("node-2\n"), 0644); err != nil {
+		t.Fatalf("failed to update hostname file: %v", err)
+	}
+	if !w.reload(ctx) {
+		t.Fatal("expected updating the file to change the hostname")
+	}
+	appLogger.Info(ctx, "After rename")
+
+	entries := appLogger.RecentEntries(1)
+	if len(entries) != 1 || entries[0].Message != "After rename" || entries[0].Hostname != "node-2" {
+		t.Errorf("expected the next entry to be logged as node-2, got %+v", entries)
+	}
+	if len(entries) == 1 && entries[0].RedisKey != "signalmice:host:node-2" {
+		t.Errorf("expected the next entry to carry the new key, got %q", entries[0].RedisKey)
+	}
+	if got := cfg.CurrentHostname(); got != "node-2" {
+		t.Errorf("expected the configuration to be renamed, got %q", got)
+	}
+	if manager.hostname != "node-2" {
+		t.Errorf("expected the shutdown manager to be renamed, got %q", manager.hostname)
+	}
+	if keys.key != "signalmice:host:node-2" {
+		t.Errorf("expected the per-host key to follow the hostname, got %q", keys.key)
+	}
+}
+
+func TestHostnameFileWatcher_KeepsHostnameOnInvalidFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hostname")
+	if err := os.WriteFile(path, []byte("  \n"), 0644); err != nil {
+		t.Fatalf("failed to write hostname file: %v", err)
+	}
+
+	cfg := &config.Config{RedisKey: "test-key", Hostname: "node-1"}
+	keys := &fakeKeyTarget{}
+	w := newHostnameFileWatcher(path, cfg, keys, createMockLogger())
+
+	if w.reload(context.Background()) {
+		t.Error("expected an empty hostname file to be ignored")
+	}
+	if keys.key != "" {
+		t.Errorf("expected the signal key to be left alone, got %q", keys.key)
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/signalmice/signalmice/internal/logger"
+	"github.com/signalmice/signalmice/internal/metrics"
+	"github.com/signalmice/signalmice/internal/redis"
+)
+
+// pinger is the part of the Redis client used by the keepalive
+type pinger interface {
+	Ping(ctx context.Context) error
+}
+
+// redisKeepalive pings Redis between checks so pooled connections are not closed as idle,
+// and so a lost connection is noticed before the next check needs it
+type redisKeepalive struct {
+	interval time.Duration
+	client   pinger
+	metrics  *metrics.Registry
+	logger   *logger.Logger
+
+	healthy bool // Result of the previous ping, so only transitions are logged
+}
+
+// newRedisKeepalive creates a keepalive that assumes Redis is reachable until a ping fails
+func newRedisKeepalive(interval time.Duration, client pinger, reg *metrics.Registry, appLogger *logger.Logger) *redisKeepalive {
+	return &redisKeepalive{interval: interval, client: client, metrics: reg, logger: appLogger, healthy: true}
+}
+
+// run pings Redis at the configured cadence until ctx is cancelled
+func (k *redisKeepalive) run(ctx context.Context) {
+	ticker := time.NewTicker(k.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			k.ping(ctx)
+		}
+	}
+}
+
+// ping sends one ping, bounded by the interval, and records the outcome
+func (k *redisKeepalive) ping(ctx context.Context) error {
+	pingCtx, cancel := context.WithTimeout(ctx, k.interval)
+	defer cancel()
+
+	err := k.client.Ping(pingCtx)
+	k.metrics.SetRedisUp(err == nil)
+
+	switch {
+	case err != nil && k.healthy:
+		k.logger.WarnWithExtra(ctx, "Redis keepalive ping failed", map[string]string{
+			"error":      err.Error(),
+			"error_type": redis.ErrorType(err),
+		})
+	case err == nil && !k.healthy:
+		k.logger.Info(ctx, "Redis keepalive ping succeeded, connection restored")
+	}
+	k.healthy = err == nil
+	return err
+}
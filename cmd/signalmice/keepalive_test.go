@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/signalmice/signalmice/internal/metrics"
+	"github.com/signalmice/signalmice/internal/redis"
+)
+
+// fakePinger returns the queued errors in order, then succeeds
+type fakePinger struct {
+	mu    sync.Mutex
+	calls int
+	errs  []error
+}
+
+func (f *fakePinger) Ping(context.Context) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	if len(f.errs) == 0 {
+		return nil
+	}
+	err := f.errs[0]
+	f.errs = f.errs[1:]
+	return err
+}
+
+func (f *fakePinger) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls
+}
+
+func TestRedisKeepalive_PingsOnSchedule(t *testing.T) {
+	client := &fakePinger{}
+	reg := metrics.NewRegistry()
+	keepalive := newRedisKeepalive(10*time.Millisecond, client, reg, createMockLogger())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		keepalive.run(ctx)
+		close(done)
+	}()
+
+	time.Sleep(55 * time.Millisecond)
+	cancel()
+	<-done
+
+	if got := client.count(); got < 2 {
+		t.Errorf("expected repeated pings in 55ms at a 10ms interval, got %d", got)
+	}
+	if got := reg.RedisUp(); got != 1 {
+		t.Errorf("expected Redis reported up, got %v", got)
+	}
+}
+
+func TestRedisKeepalive_FailureUpdatesHealth(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	connErr := fmt.Errorf("failed to ping Redis: %w", redis.ErrConnection)
+	client := &fakePinger{errs: []error{connErr, connErr}}
+	reg := metrics.NewRegistry()
+	keepalive := newRedisKeepalive(time.Second, client, reg, createMockLogger())
+	ctx := context.Background()
+
+	keepalive.ping(ctx)
+	if got := reg.RedisUp(); got != 0 {
+		t.Errorf("expected Redis reported down after a failed ping, got %v", got)
+	}
+	keepalive.ping(ctx)
+	if got := strings.Count(buf.String(), "[WARN] Redis keepalive ping failed"); got != 1 {
+		t.Errorf("expected 1 warning across consecutive failures, got %d", got)
+	}
+
+	keepalive.ping(ctx)
+	if got := reg.RedisUp(); got != 1 {
+		t.Errorf("expected Redis reported up after recovery, got %v", got)
+	}
+	if !strings.Contains(buf.String(), "connection restored") {
+		t.Errorf("expected a recovery log, got: %s", buf.String())
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/signalmice/signalmice/internal/config"
+	"github.com/signalmice/signalmice/internal/logger"
+)
+
+// maxSignalKeyLength bounds a signal key read from the key file
+const maxSignalKeyLength = 1024
+
+// keyTarget is the part of the Redis client whose monitored keys can be switched at runtime
+type keyTarget interface {
+	SetSignalKeys(key string, keys []string)
+}
+
+// keyFileWatcher re-reads SIGNALMICE_KEY_FILE (e.g. a mounted ConfigMap) and re-targets the
+// monitored signal key when its contents change
+type keyFileWatcher struct {
+	path    string
+	cfg     *config.Config
+	target  keyTarget
+	logger  *logger.Logger
+	current string
+}
+
+// newKeyFileWatcher creates a watcher starting from the configured signal key
+func newKeyFileWatcher(path string, cfg *config.Config, target keyTarget, appLogger *logger.Logger) *keyFileWatcher {
+	return &keyFileWatcher{
+		path:    path,
+		cfg:     cfg,
+		target:  target,
+		logger:  appLogger,
+		current: cfg.RedisKey,
+	}
+}
+
+// run reloads the key file on every interval until ctx is cancelled
+func (w *keyFileWatcher) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.reload(ctx)
+		}
+	}
+}
+
+// reload reads the key file and switches the monitored key if it changed and is valid.
+// It reports whether the key was switched.
+func (w *keyFileWatcher) reload(ctx context.Context) bool {
+	data, err := os.ReadFile(w.path)
+	if err != nil {
+		w.logger.WarnWithExtra(ctx, "Failed to read signal key file", map[string]string{
+			"path":  w.path,
+			"error": err.Error(),
+		})
+		return false
+	}
+
+	key := strings.TrimSpace(string(data))
+	if key == w.current {
+		return false
+	}
+	if err := validateSignalKey(key); err != nil {
+		w.logger.WarnWithExtra(ctx, "Ignoring invalid signal key from key file", map[string]string{
+			"path":  w.path,
+			"error": err.Error(),
+		})
+		return false
+	}
+
+	next := w.cfg.WithRedisKey(key)
+	w.target.SetSignalKeys(next.SignalKey(), next.SignalKeys())
+	w.logger.SetRedisKey(next.SignalKey())
+
+	w.logger.InfoWithExtra(ctx, "Signal key changed from key file", map[string]string{
+		"path":     w.path,
+		"old_key":  w.current,
+		"new_key":  key,
+		"full_key": next.SignalKey(),
+	})
+	w.current = key
+	return true
+}
+
+// validateSignalKey rejects keys that cannot be a deliberate signal key
+func validateSignalKey(key string) error {
+	if key == "" {
+		return fmt.Errorf("signal key is empty")
+	}
+	if len(key) > maxSignalKeyLength {
+		return fmt.Errorf("signal key exceeds %d bytes", maxSignalKeyLength)
+	}
+	for _, r := range key {
+		if unicode.IsSpace(r) || unicode.IsControl(r) {
+			return fmt.Errorf("signal key contains whitespace or control characters")
+		}
+	}
+	return nil
+}
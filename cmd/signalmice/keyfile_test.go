@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/signalmice/signalmice/internal/config"
+)
+
+// fakeKeyTarget records the keys it was switched to
+type fakeKeyTarget struct {
+	key  string
+	keys []string
+}
+
+func (f *fakeKeyTarget) SetSignalKeys(key string, keys []string) {
+	f.key = key
+	f.keys = keys
+}
+
+func TestKeyFileWatcher_SwitchesKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "signal-key")
+	if err := os.WriteFile(path, []byte("signalmice:old\n"), 0644); err != nil {
+		t.Fatalf("failed to write key file: %v", err)
+	}
+
+	cfg := &config.Config{
+		RedisKey:   "signalmice:old",
+		KeyPrefix:  "prod",
+		KeyActions: []config.KeyAction{{Key: "signalmice:reboot", Action: "reboot"}},
+	}
+	target := &fakeKeyTarget{}
+	w := newKeyFileWatcher(path, cfg, target, createMockLogger())
+	ctx := context.Background()
+
+	if w.reload(ctx) {
+		t.Error("expected no switch while the file holds the configured key")
+	}
+
+	if err := os.WriteFile(path, []byte("signalmice:new\n"), 0644); err != nil {
+		t.Fatalf("failed to update key file: %v", err)
+	}
+	if !w.reload(ctx) {
+		t.Fatal("expected updating the file to switch the active key")
+	}
+	if target.key != "prod:signalmice:new" {
+		t.Errorf("expected active key 'prod:signalmice:new', got '%s'", target.key)
+	}
+	if len(target.keys) != 2 || target.keys[1] != "prod:signalmice:reboot" {
+		t.Errorf("expected key actions to be kept, got %v", target.keys)
+	}
+}
+
+func TestKeyFileWatcher_RejectsInvalidKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "signal-key")
+	if err := os.WriteFile(path, []byte("two words"), 0644); err != nil {
+		t.Fatalf("failed to write key file: %v", err)
+	}
+
+	target := &fakeKeyTarget{}
+	w := newKeyFileWatcher(path, &config.Config{RedisKey: "signalmice:old"}, target, createMockLogger())
+
+	if w.reload(context.Background()) {
+		t.Error("expected an invalid key not to be applied")
+	}
+	if target.key != "" {
+		t.Errorf("expected target to be untouched, got '%s'", target.key)
+	}
+}
+
+func TestValidateSignalKey(t *testing.T) {
+	tests := []struct {
+		key     string
+		wantErr bool
+	}{
+		{"signalmice:abc", false},
+		{"", true},
+		{"has space", true},
+		{"tab\there", true},
+		{strings.Repeat("k", maxSignalKeyLength+1), true},
+	}
+	for _, tt := range tests {
+		if err := validateSignalKey(tt.key); (err != nil) != tt.wantErr {
+			t.Errorf("validateSignalKey(%q) error = %v, wantErr %v", tt.key, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+
+	"github.com/signalmice/signalmice/internal/logger"
+	"github.com/signalmice/signalmice/internal/redis"
+)
+
+// keyChecker reports whether an arbitrary Redis key is set
+type keyChecker interface {
+	Exists(ctx context.Context, key string) (bool, error)
+}
+
+// killSwitch disarms signalmice while its key exists, so operators can stop a whole fleet
+// from acting on signals during an incident without redeploying
+type killSwitch struct {
+	key           string
+	store         keyChecker
+	source        signalSource // Cleared while disarmed; nil leaves signal keys in place
+	logger        *logger.Logger
+	active        bool // Last observed state, so the WARN is logged once per activation
+	unreadable    bool // The last read failed, so the WARN is logged once per outage
+	deleteSignals bool
+	failOpen      bool                      // Check signals while the key cannot be read instead of skipping the check
+	fallback      func(ctx context.Context) // Checks the non-Redis sources while the key cannot be read; nil skips them too
+}
+
+func newKillSwitch(key string, store keyChecker, source signalSource, deleteSignals, failOpen bool, fallback func(context.Context), appLogger *logger.Logger) *killSwitch {
+	return &killSwitch{
+		key:           key,
+		store:         store,
+		source:        source,
+		logger:        appLogger,
+		deleteSignals: deleteSignals,
+		failOpen:      failOpen,
+		fallback:      fallback,
+	}
+}
+
+// guard wraps check so it is skipped while the kill switch key exists. While the key cannot be
+// read, Redis is likely unreachable: the non-Redis sources are still checked through fallback,
+// so they can act in the outage they exist for, but the Redis source is not, since a signal
+// found there could race a kill switch set during a partial outage. Failing open, check runs
+// as usual.
+func (k *killSwitch) guard(check func(context.Context)) func(context.Context) {
+	return func(ctx context.Context) {
+		active, err := k.store.Exists(ctx, k.key)
+		if err != nil {
+			k.readFailed(ctx, err)
+			switch {
+			case k.failOpen:
+				check(ctx)
+			case k.fallback != nil:
+				k.fallback(ctx)
+			}
+			return
+		}
+		if k.unreadable {
+			k.logger.InfoWithExtra(ctx, "Kill switch key readable again", map[string]string{"key": k.key})
+			k.unreadable = false
+		}
+
+		if !active {
+			if k.active {
+				k.logger.InfoWithExtra(ctx, "Kill switch cleared, acting on signals again", map[string]string{"key": k.key})
+			}
+			k.active = false
+			check(ctx)
+			return
+		}
+
+		if !k.active {
+			k.logger.WarnWithExtra(ctx, "Kill switch active, not acting on signals", map[string]string{"key": k.key})
+		}
+		k.active = true
+
+		if k.deleteSignals && k.source != nil {
+			k.clearSignals(ctx)
+		}
+	}
+}
+
+// readFailed logs the first failed read of an outage, saying which signals are still checked
+func (k *killSwitch) readFailed(ctx context.Context, err error) {
+	if k.unreadable {
+		return
+	}
+	k.unreadable = true
+
+	message := "Failed to read kill switch key, not acting on signals until it can be read"
+	switch {
+	case k.failOpen:
+		message = "Failed to read kill switch key, checking signals anyway"
+	case k.fallback != nil:
+		message = "Failed to read kill switch key, checking only the non-Redis signal sources until it can be read"
+	}
+	k.logger.WarnWithExtra(ctx, message, map[string]string{
+		"key":        k.key,
+		"error":      err.Error(),
+		"error_type": redis.ErrorType(err),
+	})
+}
+
+// clearSignals deletes signal keys set while disarmed so they cannot fire once the kill switch is lifted
+func (k *killSwitch) clearSignals(ctx context.Context) {
+	cleared, err := k.source.ClearSignals(ctx)
+	if err != nil {
+		k.logger.ErrorWithExtra(ctx, "Failed to clear signal keys while kill switch is active", map[string]string{
+			"error":      err.Error(),
+			"error_type": redis.ErrorType(err),
+		})
+		return
+	}
+	if len(cleared) > 0 {
+		k.logger.WarnWithExtra(ctx, "Kill switch active, signal keys deleted without shutting down", map[string]any{"keys": cleared})
+	}
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/signalmice/signalmice/internal/config"
+)
+
+// fakeKeyChecker is an in-memory keyChecker
+type fakeKeyChecker struct {
+	keys map[string]bool
+	err  error
+}
+
+func (f *fakeKeyChecker) Exists(_ context.Context, key string) (bool, error) {
+	return f.keys[key], f.err
+}
+
+func newKillSwitchFixture(deleteSignals, failOpen bool) (*fakeKeyChecker, *fakeSource, *fakeShutdowner, func(context.Context)) {
+	return newKillSwitchFallbackFixture(deleteSignals, failOpen, nil)
+}
+
+// newKillSwitchFallbackFixture is newKillSwitchFixture with fallback checked while the kill
+// switch cannot be read
+func newKillSwitchFallbackFixture(deleteSignals, failOpen bool, fallback signalSource) (*fakeKeyChecker, *fakeSource, *fakeShutdowner, func(context.Context)) {
+	cfg := &config.Config{RedisKey: "signalmice:main"}
+	store := &fakeKeyChecker{keys: map[string]bool{}}
+	source := &fakeSource{
+		keys:   []string{"signalmice:main"},
+		values: map[string]string{},
+	}
+	manager := &fakeShutdowner{}
+	appLogger := createMockLogger()
+
+	check := func(ctx context.Context) {
+		checkAndShutdown(ctx, cfg, source, manager, appLogger)
+	}
+	var checkFallback func(context.Context)
+	if fallback != nil {
+		checkFallback = func(ctx context.Context) {
+			checkAndShutdown(ctx, cfg, fallback, manager, appLogger)
+		}
+	}
+	guard := newKillSwitch(config.DefaultKillSwitchKey, store, source, deleteSignals, failOpen, checkFallback, appLogger).guard(check)
+	return store, source, manager, guard
+}
+
+func TestKillSwitch_SuppressesShutdown(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	store, source, manager, check := newKillSwitchFixture(true, false)
+	store.keys[config.DefaultKillSwitchKey] = true
+	source.values["signalmice:main"] = "shutdown"
+
+	check(context.Background())
+
+	if manager.calls != 0 {
+		t.Errorf("expected no shutdown while the kill switch is set, got %d attempts", manager.calls)
+	}
+	if _, ok := source.values["signalmice:main"]; ok {
+		t.Error("expected the signal key to be deleted while the kill switch is set")
+	}
+	if !strings.Contains(buf.String(), "[WARN] Kill switch active, not acting on signals") {
+		t.Errorf("expected a kill switch warning, got: %s", buf.String())
+	}
+
+	// Once the kill switch is lifted, a new signal is acted on
+	delete(store.keys, config.DefaultKillSwitchKey)
+	source.values["signalmice:main"] = "shutdown"
+	check(context.Background())
+	if manager.calls != 1 {
+		t.Errorf("expected 1 shutdown after the kill switch is lifted, got %d", manager.calls)
+	}
+}
+
+func TestKillSwitch_KeepsSignalKeysWhenDeleteDisabled(t *testing.T) {
+	store, source, manager, check := newKillSwitchFixture(false, false)
+	store.keys[config.DefaultKillSwitchKey] = true
+	source.values["signalmice:main"] = "shutdown"
+
+	check(context.Background())
+
+	if manager.calls != 0 {
+		t.Errorf("expected no shutdown while the kill switch is set, got %d attempts", manager.calls)
+	}
+	if _, ok := source.values["signalmice:main"]; !ok {
+		t.Error("expected the signal key to be kept when deletion is disabled")
+	}
+}
+
+func TestKillSwitch_WarnsOncePerActivation(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	store, _, _, check := newKillSwitchFixture(true, false)
+	store.keys[config.DefaultKillSwitchKey] = true
+
+	check(context.Background())
+	check(context.Background())
+
+	if got := strings.Count(buf.String(), "Kill switch active, not acting on signals"); got != 1 {
+		t.Errorf("expected 1 kill switch warning across checks, got %d", got)
+	}
+}
+
+func TestKillSwitch_ReadErrorSkipsCheck(t *testing.T) {
+	store, source, manager, check := newKillSwitchFixture(true, false)
+	store.err = errors.New("connection refused")
+	source.values["signalmice:main"] = "shutdown"
+
+	check(context.Background())
+
+	if manager.calls != 0 {
+		t.Errorf("expected no shutdown while the kill switch cannot be read, got %d attempts", manager.calls)
+	}
+	if _, ok := source.values["signalmice:main"]; !ok {
+		t.Error("expected the signal key to be left for once the kill switch can be read")
+	}
+}
+
+func TestKillSwitch_ReadErrorStillChecksWhenFailingOpen(t *testing.T) {
+	store, source, manager, check := newKillSwitchFixture(true, true)
+	store.err = errors.New("connection refused")
+	source.values["signalmice:main"] = "shutdown"
+
+	check(context.Background())
+
+	if manager.calls != 1 {
+		t.Errorf("expected the check to run when the kill switch cannot be read, got %d attempts", manager.calls)
+	}
+}
+
+func TestKillSwitch_ReadErrorStillChecksFallbackSources(t *testing.T) {
+	fallback := &fakeSource{keys: []string{"signalmice:main"}, values: map[string]string{"signalmice:main": "shutdown"}}
+	store, source, manager, check := newKillSwitchFallbackFixture(true, false, fallback)
+	store.err = errors.New("connection refused")
+	source.values["signalmice:main"] = "shutdown"
+
+	check(context.Background())
+
+	if manager.calls != 1 {
+		t.Errorf("expected the fallback source to act while the kill switch cannot be read, got %d attempts", manager.calls)
+	}
+	if _, ok := source.values["signalmice:main"]; !ok {
+		t.Error("expected the Redis signal key to be left for once the kill switch can be read")
+	}
+}
+
+func TestKillSwitch_WarnsOncePerReadOutage(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	store, _, _, check := newKillSwitchFixture(true, false)
+	store.err = errors.New("connection refused")
+	check(context.Background())
+	check(context.Background())
+	store.err = nil
+	check(context.Background())
+
+	if got := strings.Count(buf.String(), "Failed to read kill switch key"); got != 1 {
+		t.Errorf("expected 1 read failure warning across checks, got %d", got)
+	}
+	if !strings.Contains(buf.String(), "Kill switch key readable again") {
+		t.Errorf("expected the recovery to be logged, got: %s", buf.String())
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/signalmice/signalmice/internal/logger"
+)
+
+// shutdownWaitTimeout bounds how long shutdown waits for background tasks, so a hung Redis
+// call cannot keep the process from exiting
+const shutdownWaitTimeout = 10 * time.Second
+
+// taskGroup tracks the goroutines main starts, so shutdown can wait for them to return before
+// closing the clients they use
+type taskGroup struct {
+	wg sync.WaitGroup
+}
+
+// Go runs f in a goroutine tracked by the group
+func (g *taskGroup) Go(f func()) {
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+		f()
+	}()
+}
+
+// wait waits up to timeout for every task to return, reporting whether they all did
+func (g *taskGroup) wait(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		g.wg.Wait()
+		close(done)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// shutdownInOrder stops signalmice in dependency order instead of relying on defers: cancel stops
+// the loop and handlers, the background tasks are awaited (up to waitTimeout), then Redis is
+// closed and finally the logs are flushed, so no task ever uses a closed client or logger
+func shutdownInOrder(ctx context.Context, cancel context.CancelFunc, tasks *taskGroup, waitTimeout time.Duration, redisClient io.Closer, flushTimeout time.Duration, appLogger *logger.Logger) {
+	cancel()
+
+	if !tasks.wait(waitTimeout) {
+		appLogger.WarnWithExtra(ctx, "Background tasks still running, closing Redis anyway", map[string]string{
+			"timeout": waitTimeout.String(),
+		})
+	}
+
+	if err := redisClient.Close(); err != nil {
+		appLogger.WarnWithExtra(ctx, "Failed to close Redis client", map[string]string{"error": err.Error()})
+	}
+	appLogger.Info(ctx, "Graceful shutdown complete")
+
+	// Flush queued logs, but never let a dead Opensearch delay exit
+	flushCtx, flushCancel := context.WithTimeout(context.Background(), flushTimeout)
+	defer flushCancel()
+	appLogger.Close(flushCtx)
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// fakeRedisCloser stands in for the Redis client, recording every use made after Close
+type fakeRedisCloser struct {
+	mu             sync.Mutex
+	closed         bool
+	usesAfterClose int
+	events         []string
+}
+
+func (c *fakeRedisCloser) use(event string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed {
+		c.usesAfterClose++
+	}
+	c.events = append(c.events, event)
+}
+
+func (c *fakeRedisCloser) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	c.events = append(c.events, "close")
+	return nil
+}
+
+func (c *fakeRedisCloser) snapshot() ([]string, int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.events...), c.usesAfterClose
+}
+
+func TestShutdownInOrder_WaitsForInFlightCheck(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	client := &fakeRedisCloser{}
+	var tasks taskGroup
+
+	// A background task releasing state through Redis once cancelled, like the leader elector
+	tasks.Go(func() {
+		<-ctx.Done()
+		time.Sleep(20 * time.Millisecond)
+		client.use("release")
+	})
+
+	// The monitoring loop with a check still running when SIGTERM arrives
+	sigChan := make(chan os.Signal, 1)
+	started := make(chan struct{})
+	check := func(checkCtx context.Context) {
+		close(started)
+		<-checkCtx.Done()
+		time.Sleep(20 * time.Millisecond)
+		client.use("check")
+	}
+	returned := make(chan os.Signal)
+	go func() {
+		returned <- runMonitor(ctx, fixedInterval(time.Hour), false, sigChan, nil, nil, check)
+	}()
+
+	<-started
+	sigChan <- syscall.SIGTERM
+	<-returned
+	shutdownInOrder(ctx, cancel, &tasks, time.Second, client, time.Second, createMockLogger())
+
+	events, usesAfterClose := client.snapshot()
+	if usesAfterClose != 0 {
+		t.Errorf("expected no Redis use after close, got %d in %v", usesAfterClose, events)
+	}
+	if len(events) != 3 || events[2] != "close" {
+		t.Errorf("expected the check and the release before close, got %v", events)
+	}
+}
+
+func TestShutdownInOrder_StuckTask(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	client := &fakeRedisCloser{}
+	var tasks taskGroup
+	stuck := make(chan struct{})
+	defer close(stuck)
+	tasks.Go(func() { <-stuck })
+
+	shutdownInOrder(ctx, cancel, &tasks, 20*time.Millisecond, client, time.Second, createMockLogger())
+
+	if events, _ := client.snapshot(); len(events) != 1 || events[0] != "close" {
+		t.Errorf("expected Redis to be closed despite the stuck task, got %v", events)
+	}
+	if !strings.Contains(buf.String(), "[WARN] Background tasks still running") {
+		t.Errorf("expected a warning about the stuck task, got: %s", buf.String())
+	}
+	if !strings.Contains(buf.String(), "[INFO] Graceful shutdown complete") {
+		t.Errorf("expected the completion log, got: %s", buf.String())
+	}
+}
+
+func TestTaskGroup_Wait(t *testing.T) {
+	var tasks taskGroup
+	if !tasks.wait(time.Millisecond) {
+		t.Error("expected an empty group to be done immediately")
+	}
+
+	release := make(chan struct{})
+	tasks.Go(func() { <-release })
+	if tasks.wait(10 * time.Millisecond) {
+		t.Error("expected wait to time out while a task runs")
+	}
+
+	close(release)
+	if !tasks.wait(time.Second) {
+		t.Error("expected wait to return once the task finished")
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/signalmice/signalmice/internal/logger"
+)
+
+// loggingReadyRetry is how often Opensearch is probed while monitoring is held for it
+const loggingReadyRetry = 2 * time.Second
+
+// loggingWaiter blocks until the logger ships entries to Opensearch; *logger.Logger implements it
+type loggingWaiter interface {
+	WaitOpensearch(ctx context.Context, interval time.Duration) error
+}
+
+// awaitLogging holds startup until waiter reports the logger connected, for at most timeout
+// (0 waits indefinitely). It returns the error of the last attempt when the wait gives up.
+func awaitLogging(ctx context.Context, timeout time.Duration, waiter loggingWaiter, appLogger *logger.Logger) error {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	appLogger.InfoWithExtra(ctx, "Waiting for the logger to connect to Opensearch before monitoring", map[string]string{
+		"timeout": timeout.String(),
+	})
+	if err := waiter.WaitOpensearch(ctx, loggingReadyRetry); err != nil {
+		return err
+	}
+	appLogger.Info(ctx, "Logger ready, starting monitoring")
+	return nil
+}
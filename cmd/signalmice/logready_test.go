@@ -0,0 +1,59 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeWaiter reports the logger ready once ready is closed
+type fakeWaiter struct {
+	ready chan struct{}
+}
+
+func (w *fakeWaiter) WaitOpensearch(ctx context.Context, interval time.Duration) error {
+	select {
+	case <-w.ready:
+		return nil
+	case <-ctx.Done():
+		return errors.New("Opensearch still unreachable")
+	}
+}
+
+func TestAwaitLogging_HoldsUntilReady(t *testing.T) {
+	waiter := &fakeWaiter{ready: make(chan struct{})}
+	done := make(chan error, 1)
+	go func() {
+		done <- awaitLogging(context.Background(), time.Minute, waiter, createMockLogger())
+	}()
+
+	select {
+	case err := <-done:
+		t.Fatalf("expected the gate to hold until the logger is ready, returned %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(waiter.ready)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the gate to open once the logger is ready")
+	}
+}
+
+func TestAwaitLogging_TimesOut(t *testing.T) {
+	waiter := &fakeWaiter{ready: make(chan struct{})}
+
+	start := time.Now()
+	err := awaitLogging(context.Background(), 50*time.Millisecond, waiter, createMockLogger())
+	if err == nil {
+		t.Fatal("expected an error when the logger never becomes ready")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the wait to give up at the timeout, took %v", elapsed)
+	}
+}
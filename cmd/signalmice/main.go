@@ -2,17 +2,23 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
 	"github.com/signalmice/signalmice/internal/config"
+	"github.com/signalmice/signalmice/internal/instruction"
+	"github.com/signalmice/signalmice/internal/leader"
 	"github.com/signalmice/signalmice/internal/logger"
+	"github.com/signalmice/signalmice/internal/metrics"
 	"github.com/signalmice/signalmice/internal/redis"
 	"github.com/signalmice/signalmice/internal/shutdown"
+	"github.com/signalmice/signalmice/internal/tracing"
 )
 
 const (
@@ -21,8 +27,6 @@ const (
 )
 
 func main() {
-	log.Printf("%s v%s starting...", appName, appVersion)
-
 	// Load configuration
 	cfg := config.Load()
 
@@ -30,79 +34,746 @@ func main() {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Setup signal handling for graceful shutdown before any check can run, so an early
+	// SIGTERM aborts the initial check instead of racing it
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+
+	// Shutdown flows are traced when an OTLP collector is configured
+	ctx = tracing.WithTracer(ctx, tracing.NewTracerFromConfig(cfg))
+
+	// The config subcommand only prints the effective configuration, then exits
+	if len(os.Args) > 1 && os.Args[1] == configCommand {
+		os.Exit(runConfig(cfg, os.Stdout, os.Stderr))
+	}
+
 	// Initialize logger
+	logger.DeviceVersion = appVersion
 	appLogger, err := logger.NewLogger(cfg)
 	if err != nil {
 		log.Fatalf("Failed to initialize logger: %v", err)
 	}
 
-	appLogger.InfoWithExtra(ctx, fmt.Sprintf("%s starting", appName), map[string]any{
-		"version":        appVersion,
-		"check_interval": cfg.CheckInterval.String(),
-		"redis_key":      cfg.RedisKey,
-	})
+	// The test-shutdown subcommand only rehearses the shutdown path, then exits
+	if len(os.Args) > 1 && os.Args[1] == testShutdownCommand {
+		manager, err := shutdown.NewManager(cfg, appLogger)
+		if err != nil {
+			log.Fatalf("Failed to initialize shutdown manager: %v", err)
+		}
+		code := runTestShutdown(ctx, manager, os.Stdout)
+		flushCtx, flushCancel := context.WithTimeout(context.Background(), cfg.LogFlushTimeout)
+		appLogger.Close(flushCtx)
+		flushCancel()
+		os.Exit(code)
+	}
 
-	// Initialize Redis client
+	logStartup(ctx, cfg, appLogger)
+
+	switch cfg.HostnameSource {
+	case config.HostnameSourceEnv:
+		appLogger.WarnWithExtra(ctx, "Could not detect hostname, using SIGNALMICE_HOSTNAME", map[string]string{"hostname": cfg.Hostname})
+	case config.HostnameSourceGenerated:
+		appLogger.WarnWithExtra(ctx, "Could not detect hostname, using a generated identifier", map[string]string{"hostname": cfg.Hostname})
+	}
+	if cfg.HostnameFile != "" && cfg.HostnameSource != config.HostnameSourceFile {
+		appLogger.WarnWithExtra(ctx, "Could not read hostname file, using the detected hostname", map[string]string{
+			"path":     cfg.HostnameFile,
+			"hostname": cfg.Hostname,
+		})
+	}
+	if message, err := validateConfig(cfg); err != nil {
+		appLogger.ErrorWithExtra(ctx, message, map[string]string{"error": err.Error()})
+		os.Exit(1)
+	}
+	if warning := cfg.HostnameWarning(); warning != "" {
+		appLogger.WarnWithExtra(ctx, warning, map[string]string{"hostname": cfg.Hostname})
+	}
+
+	// Hold monitoring until logs are shipped, so no check goes unrecorded
+	if cfg.RequireLoggingBeforeCheck {
+		if err := awaitLogging(ctx, cfg.LoggingReadyTimeout, appLogger, appLogger); err != nil {
+			appLogger.ErrorWithExtra(ctx, "Logger not ready, refusing to start monitoring", map[string]string{"error": err.Error()})
+			os.Exit(1)
+		}
+	}
+
+	// Opensearch already degrades to stdout; fail only when it is required
+	if osErr := appLogger.OpensearchErr(); osErr != nil {
+		if err := handleDependencyFailure(ctx, cfg, config.DependencyOpensearch, osErr, appLogger); err != nil {
+			appLogger.ErrorWithExtra(ctx, "Failed to connect to Opensearch", map[string]string{"error": err.Error()})
+			os.Exit(1)
+		}
+	}
+
+	// Initialize Redis client; when optional, keep going and let it reconnect in the background
 	redisClient, err := redis.NewClient(cfg)
 	if err != nil {
-		appLogger.ErrorWithExtra(ctx, "Failed to connect to Redis", map[string]string{"error": err.Error()})
-		os.Exit(1)
+		if err := handleDependencyFailure(ctx, cfg, config.DependencyRedis, err, appLogger); err != nil {
+			appLogger.ErrorWithExtra(ctx, "Failed to connect to Redis", map[string]string{
+				"error":      err.Error(),
+				"error_type": redis.ErrorType(err),
+			})
+			os.Exit(1)
+		}
+		redisClient = redis.NewUnverifiedClient(cfg)
+	} else {
+		appLogger.Info(ctx, "Connected to Redis successfully")
 	}
-	defer redisClient.Close()
 
-	appLogger.Info(ctx, "Connected to Redis successfully")
+	// Goroutines using Redis are tracked, so shutdown can wait for them before closing it
+	var background taskGroup
+
+	// Verify the permissions needed at shutdown time before relying on them
+	if cfg.SelfTest {
+		key := cfg.SelfTestKey(cfg.Hostname)
+		if err := redisClient.SelfTest(ctx, key); err != nil {
+			appLogger.ErrorWithExtra(ctx, "Redis self-test failed", map[string]string{
+				"key":        key,
+				"error":      err.Error(),
+				"error_type": redis.ErrorType(err),
+			})
+		} else {
+			appLogger.InfoWithExtra(ctx, "Redis self-test passed", map[string]string{"key": key})
+		}
+	}
 
 	// Initialize shutdown manager
-	shutdownManager := shutdown.NewManager(cfg, appLogger)
+	shutdownManager, err := shutdown.NewManager(cfg, appLogger)
+	if err != nil {
+		appLogger.ErrorWithExtra(ctx, "Failed to initialize shutdown manager", map[string]string{"error": err.Error()})
+		os.Exit(1)
+	}
 
-	// Setup signal handling for graceful shutdown
-	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	// Report which shutdown commands are available before an incident needs them
+	shutdownManager.Preflight(ctx)
+
+	// Expose liveness gauges for dashboards
+	registry := metrics.NewRegistry()
+	shutdownManager.SetMetrics(registry)
+	registry.SetLogQueue(appLogger)
+	registry.SetLogHistory(loggerHistory{appLogger})
+	registry.SetRedisTarget(cfg.RedisAddr(), cfg.RedisDB)
+	if cfg.MetricsAddr != "" {
+		redisClient.Instrument(registry)
+	}
+
+	// With a watchdog, a host that lingers after a successful shutdown makes signalmice exit non-zero
+	var shutdowns shutdowner = shutdownManager
+	if cfg.PostShutdownWatchdog > 0 {
+		watchdog := newShutdownWatchdog(cfg.PostShutdownWatchdog, cfg.LogFlushTimeout, appLogger)
+		shutdowns = &watchedShutdowner{shutdowner: shutdownManager, watchdog: watchdog}
+	}
+	// With exit after shutdown, a successfully initiated shutdown ends the monitoring loop
+	var initiated <-chan struct{}
+	if cfg.ExitAfterShutdown {
+		stopping := newStoppingShutdowner(shutdowns)
+		initiated = stopping.Initiated()
+		shutdowns = stopping
+	}
+	// With a token, POST /simulate runs a signal through the pipeline as a dry run
+	var simulate http.Handler
+	if cfg.SimulateToken != "" {
+		simulate = newSimulateHandler(cfg.SimulateToken, cfg.SignalKey(), func(ctx context.Context, source signalSource) {
+			checkAndShutdown(ctx, cfg, source, shutdowns, appLogger)
+		})
+		if cfg.MetricsAddr == "" {
+			appLogger.Warn(ctx, "SIGNALMICE_SIMULATE_TOKEN is set but SIGNALMICE_METRICS_ADDR is not, simulate endpoint disabled")
+		}
+	}
+	if cfg.MetricsAddr != "" {
+		background.Go(func() { serveMetrics(ctx, cfg.MetricsAddr, registry, simulate, appLogger) })
+	}
+
+	// Keep the Redis connection warm between checks and notice disconnects early
+	if cfg.RedisKeepalive > 0 {
+		keepalive := newRedisKeepalive(cfg.RedisKeepalive, redisClient, registry, appLogger)
+		background.Go(func() { keepalive.run(ctx) })
+	}
+
+	// With an HTTP, Consul or etcd source configured, it takes over when Redis is unreachable
+	var primary signalSource = redisClient
+	sources := []namedSource{{name: "redis", source: redisClient}}
+	if cfg.HTTPSourceURL != "" {
+		sources = append(sources, namedSource{name: "http", source: newHTTPSource(cfg.HTTPSourceURL, cfg.HTTPSourceTimeout)})
+	}
+	var consul *consulSource
+	if cfg.ConsulKey != "" {
+		consul = newConsulSource(cfg.ConsulAddr, cfg.ConsulKey, cfg.ConsulToken, cfg.ConsulDelete, cfg.ConsulTimeout, appLogger)
+		sources = append(sources, namedSource{name: "consul", source: consul})
+	}
+	var etcd *etcdSource
+	if cfg.EtcdKey != "" {
+		gateway, err := newEtcdGateway(cfg)
+		if err != nil {
+			appLogger.ErrorWithExtra(ctx, "Failed to configure the etcd source", map[string]string{"error": err.Error()})
+			os.Exit(1)
+		}
+		etcd = newEtcdSource(gateway, cfg.EtcdKey, cfg.EtcdDelete, appLogger)
+		sources = append(sources, namedSource{name: "etcd", source: etcd})
+	}
+	if len(sources) > 1 {
+		primary = newCompositeSource(appLogger, sources...)
+	}
+	// The sources other than Redis, still checked while the kill switch cannot be read
+	var fallback signalSource
+	switch {
+	case len(sources) == 2:
+		fallback = sources[1].source
+	case len(sources) > 2:
+		fallback = newCompositeSource(appLogger, sources[1:]...)
+	}
+
+	// Messages published on the signal channel act as signals too, no keyspace notifications needed
+	var channel *channelSource
+	if cfg.Channel != "" {
+		channel = newChannelSource(primary, cfg.Channel, redisClient, appLogger)
+		primary = channel
+		background.Go(func() { channel.run(ctx) })
+	}
+
+	var source signalSource = &observedSource{signalSource: primary, metrics: registry}
+
+	// With backoff, checks space out while Redis is unreachable, up to SIGNALMICE_MAX_INTERVAL
+	nextInterval := fixedInterval(cfg.CheckInterval)
+	if cfg.Backoff {
+		backoff := newCheckBackoff(cfg.CheckInterval, cfg.MaxInterval, appLogger)
+		source = &backoffSource{signalSource: source, backoff: backoff}
+		nextInterval = backoff.interval
+	}
+
+	// Follow a mounted key file so the signal key can be re-targeted without a redeploy
+	if cfg.KeyFile != "" {
+		watcher := newKeyFileWatcher(cfg.KeyFile, cfg, redisClient, appLogger)
+		watcher.reload(ctx)
+		background.Go(func() { watcher.run(ctx, cfg.KeyFileInterval) })
+	}
+
+	// Follow the hostname file so a renamed host is reported, and keyed, under its new name
+	if cfg.HostnameFile != "" && cfg.HostnameFileInterval > 0 {
+		watcher := newHostnameFileWatcher(cfg.HostnameFile, cfg, redisClient, appLogger, shutdownManager)
+		background.Go(func() { watcher.run(ctx, cfg.HostnameFileInterval) })
+	}
+
+	// Clear keys left over from before startup so they cannot cause a reboot loop
+	if cfg.IgnoreExisting && cfg.Mode == config.ModeSignal {
+		ignoreExistingSignals(ctx, source, appLogger)
+	}
 
 	// Start the main monitoring loop
-	ticker := time.NewTicker(cfg.CheckInterval)
-	defer ticker.Stop()
+	appLogger.Info(ctx, fmt.Sprintf("Starting Redis key monitoring (key: %s, interval: %s)", cfg.SignalKey(), cfg.CheckInterval))
+	if cfg.SkipInitialCheck {
+		appLogger.Info(ctx, "Skipping initial check, first check will run after one interval")
+	}
+
+	check := func(ctx context.Context) {
+		checkAndShutdown(ctx, cfg, source, shutdowns, appLogger)
+	}
+	if cfg.Mode == config.ModeDeadmans {
+		dms := newDeadmansSwitch(cfg.DeadmansGrace)
+		appLogger.InfoWithExtra(ctx, "Dead man's switch mode: shutdown when the key is missing", map[string]string{
+			"grace": cfg.DeadmansGrace.String(),
+		})
+		check = func(ctx context.Context) {
+			checkDeadmans(ctx, cfg, source, dms, shutdowns, appLogger)
+		}
+	}
+	if cfg.Mode == config.ModeSemaphore {
+		appLogger.Info(ctx, "Semaphore mode: shutdown when a slot of the budget in the key is claimed")
+		check = func(ctx context.Context) {
+			checkSemaphore(ctx, cfg, redisClient, shutdowns, appLogger)
+		}
+	}
+
+	// While the kill switch key exists, the whole fleet is disarmed; in deadmans mode the
+	// heartbeat key is never cleared
+	if key := cfg.KillSwitchKey(); key != "" {
+		var clear signalSource
+		var checkFallback func(context.Context)
+		if cfg.Mode == config.ModeSignal {
+			clear = source
+			if fallback != nil {
+				checkFallback = func(ctx context.Context) {
+					checkAndShutdown(ctx, cfg, fallback, shutdowns, appLogger)
+				}
+			}
+		}
+		check = newKillSwitch(key, redisClient, clear, cfg.KillSwitchDelete, cfg.KillSwitchFailOpen, checkFallback, appLogger).guard(check)
+
+		// Checked once more right before the terminal command, so a kill switch set after the
+		// signal was acted on still stops the shutdown
+		shutdownManager.SetAbortCheck(func(ctx context.Context) (bool, error) {
+			return redisClient.Exists(ctx, key)
+		})
+	}
+
+	// With leader election, only the instance holding the lock acts on signals
+	if cfg.LeaderElection {
+		elector, err := leader.NewElector(cfg, redisClient, appLogger)
+		if err != nil {
+			appLogger.ErrorWithExtra(ctx, "Failed to initialize leader election", map[string]string{"error": err.Error()})
+			os.Exit(1)
+		}
+		background.Go(func() { elector.Run(ctx) })
+		check = leaderOnly(elector, appLogger, check)
+	}
+
+	check = trackChecks(registry, check)
+
+	// Emit a periodic pulse so quiet fleets can tell signalmice is alive
+	if cfg.AliveLogInterval > 0 {
+		alive := newAliveReporter(cfg.AliveLogInterval, appLogger)
+		check = alive.countChecks(check)
+		background.Go(func() { alive.run(ctx) })
+	}
 
-	appLogger.Info(ctx, fmt.Sprintf("Starting Redis key monitoring (key: %s, interval: %s)", cfg.RedisKey, cfg.CheckInterval))
+	// With keyspace notifications, a change to a signal key triggers a check without waiting for the tick
+	var events <-chan struct{}
+	if cfg.Subscribe {
+		subscription := newKeyspaceSubscription(redisClient, cfg.SubscribeRetry, cfg.SubscribeMaxRetry, registry, appLogger)
+		background.Go(func() { subscription.run(ctx) })
+		events = debounce(ctx, subscription.Events(), cfg.SubscribeDebounce)
+	}
+	if channel != nil {
+		events = mergeEvents(ctx, events, channel.Events())
+	}
+	if consul != nil && cfg.ConsulWatch {
+		background.Go(func() { consul.run(ctx) })
+		events = mergeEvents(ctx, events, consul.Events())
+	}
+	if etcd != nil {
+		background.Go(func() { etcd.run(ctx) })
+		events = mergeEvents(ctx, events, etcd.Events())
+	}
+
+	monitorCtx := ctx
+	if cfg.NotFoundLogInterval > 0 {
+		monitorCtx = withNotFoundThrottle(monitorCtx, newDebugThrottle(cfg.NotFoundLogInterval))
+	}
+	if cfg.ArmDelay > 0 {
+		monitorCtx = withArmState(monitorCtx, newArmState(cfg.ArmDelay))
+	}
+	sig, stopped := waitStartupDelay(ctx, cfg.StartupDelay, sigChan, appLogger)
+	if !stopped {
+		sig = runMonitor(monitorCtx, nextInterval, cfg.SkipInitialCheck, sigChan, events, initiated, check)
+	}
+	if sig == nil {
+		appLogger.Info(ctx, "Shutdown initiated, monitoring stopped, waiting for the host to go down")
+		flushCtx, flushCancel := context.WithTimeout(ctx, cfg.LogFlushTimeout)
+		appLogger.Flush(flushCtx)
+		flushCancel()
+		sig = <-sigChan
+	}
+
+	appLogger.InfoWithExtra(ctx, "Received shutdown signal", map[string]string{"signal": sig.String()})
+	shutdownInOrder(ctx, cancel, &background, shutdownWaitTimeout, redisClient, cfg.LogFlushTimeout, appLogger)
+}
+
+// logStartup prints the plain-text banner (unless quieted) and emits the structured startup event,
+// so the first lines of a run are indexed in Opensearch like everything else
+func logStartup(ctx context.Context, cfg *config.Config, appLogger *logger.Logger) {
+	if !cfg.QuietBanner {
+		log.Printf("%s v%s starting...", appName, appVersion)
+	}
+	appLogger.InfoWithExtra(ctx, fmt.Sprintf("%s starting", appName), startupFields(cfg))
+}
+
+// startupFields returns the version, process and configuration summary of the startup event
+func startupFields(cfg *config.Config) map[string]any {
+	return map[string]any{
+		"event":           "startup",
+		"version":         appVersion,
+		"pid":             os.Getpid(),
+		"check_interval":  cfg.CheckInterval.String(),
+		"redis_key":       cfg.SignalKey(),
+		"redis_addr":      cfg.RedisAddr(),
+		"redis_db":        cfg.RedisDB,
+		"signal_keys":     cfg.SignalKeys(),
+		"safe_mode":       cfg.SafeMode,
+		"mode":            cfg.Mode,
+		"leader_election": cfg.LeaderElection,
+	}
+}
+
+// runMonitor calls check on every interval tick and on every event until a signal is received,
+// which it returns. A nil events channel means polling only. interval is consulted after each
+// periodic check, so the spacing can change at runtime. Once stop is closed, runMonitor returns
+// nil without checking again; a nil stop never stops it.
+// Unless skipInitial is set, the first check runs immediately instead of waiting for the first tick.
+// A signal received while a check runs cancels that check before it is returned.
+func runMonitor(ctx context.Context, interval func() time.Duration, skipInitial bool, sigChan <-chan os.Signal, events <-chan struct{}, stop <-chan struct{}, check func(context.Context)) os.Signal {
+	ticker := time.NewTicker(interval())
+	defer ticker.Stop()
 
 	// Run the initial check immediately
-	checkAndShutdown(ctx, redisClient, shutdownManager, appLogger)
+	if !skipInitial {
+		if sig, ok := runCheck(ctx, sigChan, check); ok {
+			return sig
+		}
+		ticker.Reset(interval())
+	}
 
 	for {
+		// A check that just initiated a shutdown ends monitoring before any other case is taken
 		select {
+		case <-stop:
+			return nil
+		default:
+		}
+
+		select {
+		case <-stop:
+			return nil
+
 		case <-ticker.C:
-			checkAndShutdown(ctx, redisClient, shutdownManager, appLogger)
+			if sig, ok := runCheck(ctx, sigChan, check); ok {
+				return sig
+			}
+			ticker.Reset(interval())
+
+		case _, ok := <-events:
+			if !ok {
+				events = nil
+				continue
+			}
+			if sig, ok := runCheck(ctx, sigChan, check); ok {
+				return sig
+			}
 
 		case sig := <-sigChan:
-			appLogger.InfoWithExtra(ctx, "Received shutdown signal", map[string]string{"signal": sig.String()})
-			cancel()
-			appLogger.Info(ctx, "Graceful shutdown complete")
+			return sig
+		}
+	}
+}
+
+// waitStartupDelay waits out the warmup before monitoring starts. It returns early, reporting
+// the signal, if one arrives during the warmup.
+func waitStartupDelay(ctx context.Context, delay time.Duration, sigChan <-chan os.Signal, appLogger *logger.Logger) (os.Signal, bool) {
+	if delay <= 0 {
+		return nil, false
+	}
+
+	appLogger.InfoWithExtra(ctx, "Waiting for startup delay before monitoring", map[string]string{"delay": delay.String()})
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		appLogger.Info(ctx, "Startup delay elapsed, starting monitoring")
+		return nil, false
+	case sig := <-sigChan:
+		return sig, true
+	}
+}
+
+// runCheck runs check, cancelling its context if a signal arrives meanwhile, so a check
+// interrupted by SIGTERM aborts instead of powering off the node. Once check has returned,
+// it reports the signal received, if any.
+func runCheck(ctx context.Context, sigChan <-chan os.Signal, check func(context.Context)) (os.Signal, bool) {
+	checkCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		check(checkCtx)
+	}()
+
+	select {
+	case <-done:
+		return nil, false
+	case sig := <-sigChan:
+		cancel()
+		<-done
+		return sig, true
+	}
+}
+
+// leaderOnly wraps check so it only runs while this instance holds the leader lock
+func leaderOnly(elector *leader.Elector, appLogger *logger.Logger, check func(context.Context)) func(context.Context) {
+	return func(ctx context.Context) {
+		if !elector.IsLeader() {
+			appLogger.Debug(ctx, "Not the leader, skipping check")
 			return
 		}
+		check(ctx)
+	}
+}
+
+// signalSource is the part of the Redis client used by the monitoring loop
+type signalSource interface {
+	CheckAndDeleteSignal(ctx context.Context) (redis.Signal, bool, error)
+	ClearSignals(ctx context.Context) ([]string, error)
+	KeyExists(ctx context.Context) (bool, error)
+	ConfirmDeleted(ctx context.Context, key string) (bool, error)
+	PeekSignal(ctx context.Context) (redis.Signal, bool, error)
+	DeleteSignal(ctx context.Context, key string) error
+}
+
+// shutdowner is the part of the shutdown manager used by the monitoring loop
+type shutdowner interface {
+	NeutralizeStuartLittle(ctx context.Context) (string, error)
+	SafeMode() bool
+}
+
+// ignoreExistingSignals deletes signal keys that are already set at startup without acting on them
+func ignoreExistingSignals(ctx context.Context, source signalSource, appLogger *logger.Logger) {
+	cleared, err := source.ClearSignals(ctx)
+	if err != nil {
+		appLogger.ErrorWithExtra(ctx, "Failed to clear pre-existing signal keys", map[string]string{
+			"error":      err.Error(),
+			"error_type": redis.ErrorType(err),
+		})
+		return
+	}
+
+	if len(cleared) > 0 {
+		appLogger.WarnWithExtra(ctx, "Signal key already set at startup, cleared without shutting down", map[string]any{"keys": cleared})
 	}
 }
 
-// checkAndShutdown checks for the signal key and initiates shutdown if found
-func checkAndShutdown(ctx context.Context, redisClient *redis.Client, shutdownManager *shutdown.Manager, appLogger *logger.Logger) {
-	found, err := redisClient.CheckAndDeleteKey(ctx)
+// checkAndShutdown checks for the signal keys and initiates shutdown if one is found
+func checkAndShutdown(ctx context.Context, cfg *config.Config, source signalSource, shutdownManager shutdowner, appLogger *logger.Logger) {
+	// By default the key is consumed up front; otherwise only once it is armed, once a JSON
+	// payload allows this host, or once shutdown is initiated
+	arming := armStateFrom(ctx)
+	fetch := source.CheckAndDeleteSignal
+	peeked := cfg.DeleteAfterShutdown || arming != nil || cfg.SignalJSON
+	if peeked {
+		fetch = source.PeekSignal
+	}
+
+	// The process is being stopped; never consume a signal from a cancelled check
+	if ctx.Err() != nil {
+		appLogger.Warn(ctx, "Check cancelled, not reading signals")
+		return
+	}
+
+	var sig redis.Signal
+	var found bool
+	err := retryTransient(ctx, cfg.CheckRetries, appLogger, func(ctx context.Context) error {
+		var err error
+		sig, found, err = fetch(ctx)
+		return err
+	})
 	if err != nil {
-		appLogger.ErrorWithExtra(ctx, "Error checking Redis key", map[string]string{"error": err.Error()})
+		appLogger.ErrorWithExtra(ctx, "Error checking Redis key", map[string]string{
+			"error":      err.Error(),
+			"error_type": redis.ErrorType(err),
+		})
 		return
 	}
 
+	// The not-found line repeats every check; throttled, it still shows right after a signal
+	throttle := notFoundThrottle(ctx)
 	if !found {
-		appLogger.Debug(ctx, "Redis key not found, continuing to monitor...")
+		if key, armedFor := arming.disarm(); key != "" {
+			appLogger.InfoWithExtra(ctx, "Signal key vanished before the arm delay elapsed, not acting", map[string]string{
+				"key":       key,
+				"armed_for": armedFor.String(),
+			})
+		}
+		if ok, suppressed := throttle.allow(); ok && suppressed > 0 {
+			appLogger.DebugWithExtra(ctx, "Redis key not found, continuing to monitor...", map[string]any{"suppressed": suppressed})
+		} else if ok {
+			appLogger.Debug(ctx, "Redis key not found, continuing to monitor...")
+		}
 		return
 	}
+	throttle.reset()
 
-	// Signal key was found and deleted
-	appLogger.InfoWithExtra(ctx, "Shutdown signal received! Key found and deleted.", map[string]string{"key": redisClient.GetKey()})
+	// Once consumed, the key is gone: stopping now would drop the signal, so it is seen through
+	// even if the process is being stopped
+	if !peeked {
+		ctx = context.WithoutCancel(ctx)
+	}
 
-	// Initiate host shutdown
-	if err := shutdownManager.NeutralizeStuartLittle(ctx); err != nil {
-		appLogger.ErrorWithExtra(ctx, "Failed to initiate host shutdown", map[string]string{"error": err.Error()})
+	// Act only on a key that stayed present for the whole arm delay, so flicker is ignored
+	armed, started, remaining := arming.arm(sig.Key)
+	if !armed {
+		if started {
+			appLogger.InfoWithExtra(ctx, "Signal key found, arming before acting", map[string]string{
+				"key":       sig.Key,
+				"arm_delay": arming.delay.String(),
+			})
+		} else {
+			appLogger.DebugWithExtra(ctx, "Signal key still present, arming", map[string]string{
+				"key":       sig.Key,
+				"remaining": remaining.String(),
+			})
+		}
 		return
 	}
 
-	appLogger.Info(ctx, "Host shutdown initiated successfully")
+	// Signal key was found; tag every log line of this flow with one ID and trace it end to end
+	ctx = logger.WithCorrelationID(ctx, logger.NewCorrelationID())
+	ctx, span := tracing.Start(ctx, "signal")
+	span.SetAttribute("key", sig.Key)
+	span.SetAttribute("correlation_id", logger.CorrelationID(ctx))
+	var flowErr error
+	defer func() { span.End(flowErr) }()
+
+	// Only an authentic, unexpired instruction for this host may trigger a shutdown
+	inst, err := instruction.Parse(cfg, sig.Key, sig.Value, cfg.CurrentHostname(), time.Now())
+	switch {
+	case errors.Is(err, instruction.ErrOtherHost):
+		// The signal is meant for another host, which must still find the key
+		appLogger.DebugWithExtra(ctx, "Signal payload does not allow this host, leaving the key in place", map[string]any{
+			"key":           sig.Key,
+			"hostname":      cfg.CurrentHostname(),
+			"allowed_hosts": inst.AllowedHosts,
+		})
+		return
+	case errors.Is(err, instruction.ErrUnverified):
+		flowErr = err
+		dropSignal(ctx, source, sig.Key, peeked, "Signal signature verification failed, key deleted without shutting down", err, appLogger)
+		return
+	case errors.Is(err, instruction.ErrMalformed):
+		flowErr = err
+		dropSignal(ctx, source, sig.Key, peeked, "Malformed JSON signal payload, key deleted without shutting down", err, appLogger)
+		return
+	case errors.Is(err, instruction.ErrExpired):
+		flowErr = err
+		dropSignal(ctx, source, sig.Key, peeked, "Signal lease expired, key deleted without shutting down", err, appLogger)
+		return
+	}
+	if inst.Empty {
+		appLogger.DebugWithExtra(ctx, "Signal value is empty, using the default action and reason", map[string]string{"key": sig.Key})
+	}
+	action, reason := inst.Action, inst.Reason
+	if inst.Delay != "" {
+		ctx = shutdown.WithWhen(ctx, inst.Delay)
+	}
+
+	// The process is being stopped; the key is still in place for the next run to act on
+	if ctx.Err() != nil {
+		appLogger.WarnWithExtra(ctx, "Check cancelled, leaving the signal key in place", map[string]string{"key": sig.Key})
+		return
+	}
+
+	// This host acts on the signal: consume the key like an immediate check would have, unless
+	// it is kept until shutdown is initiated
+	if peeked && !cfg.DeleteAfterShutdown {
+		if err := source.DeleteSignal(ctx, sig.Key); err != nil {
+			flowErr = err
+			appLogger.ErrorWithExtra(ctx, "Failed to delete signal key, not acting", map[string]string{
+				"key":        sig.Key,
+				"error":      err.Error(),
+				"error_type": redis.ErrorType(err),
+			})
+			return
+		}
+		peeked = false
+		ctx = context.WithoutCancel(ctx)
+	}
+
+	if cfg.VerifyDelete && !cfg.DeleteAfterShutdown && !confirmDeleted(ctx, source, sig.Key, appLogger) {
+		return
+	}
+
+	// A signal firing right after boot is likely stale; acting on it would loop reboots
+	if cfg.MinUptime > 0 && !hostUpLongEnough(ctx, cfg, appLogger) {
+		if peeked {
+			deleteSignal(ctx, source, sig.Key, appLogger)
+		}
+		return
+	}
+
+	span.SetAttribute("action", string(action))
+	message := "Shutdown signal received! Key found and deleted."
+	if cfg.DeleteAfterShutdown {
+		message = "Shutdown signal received! Key found, deleting once shutdown is initiated."
+	}
+	appLogger.InfoWithExtra(ctx, message, map[string]string{
+		"key":    sig.Key,
+		"action": string(action),
+		"reason": reason,
+	})
+
+	err = initiateShutdown(shutdown.WithSignalValue(shutdown.WithReason(ctx, reason), inst.Value), action, shutdownManager, appLogger)
+	flowErr = err
+	if !cfg.DeleteAfterShutdown {
+		return
+	}
+	if err != nil {
+		appLogger.WarnWithExtra(ctx, "Shutdown not initiated, leaving signal key in place for retry", map[string]string{"key": sig.Key})
+		return
+	}
+	deleteSignal(ctx, source, sig.Key, appLogger)
+}
+
+// dropSignal discards a signal that must not be acted on, consuming its key if PeekSignal
+// left it in place
+func dropSignal(ctx context.Context, source signalSource, key string, peeked bool, message string, err error, appLogger *logger.Logger) {
+	if peeked {
+		deleteSignal(ctx, source, key, appLogger)
+	}
+	appLogger.WarnWithExtra(ctx, message, map[string]string{
+		"key":   key,
+		"error": err.Error(),
+	})
+}
+
+// deleteSignal consumes a signal key that was left in place by PeekSignal
+func deleteSignal(ctx context.Context, source signalSource, key string, appLogger *logger.Logger) {
+	if err := source.DeleteSignal(ctx, key); err != nil {
+		appLogger.ErrorWithExtra(ctx, "Failed to delete signal key", map[string]string{
+			"key":        key,
+			"error":      err.Error(),
+			"error_type": redis.ErrorType(err),
+		})
+	}
+}
+
+// confirmDeleted verifies the consumed signal key is truly gone. A key that reappeared is
+// deleted again and the shutdown is skipped, since the controller is still maintaining it.
+func confirmDeleted(ctx context.Context, source signalSource, key string, appLogger *logger.Logger) bool {
+	reappeared, err := source.ConfirmDeleted(ctx, key)
+	if err != nil {
+		appLogger.ErrorWithExtra(ctx, "Failed to verify signal key deletion, skipping shutdown", map[string]string{
+			"key":        key,
+			"error":      err.Error(),
+			"error_type": redis.ErrorType(err),
+		})
+		return false
+	}
+
+	if reappeared {
+		appLogger.WarnWithExtra(ctx, "Signal key reappeared after deletion, a setter is racing; deleted again and skipping shutdown", map[string]string{
+			"key": key,
+		})
+		return false
+	}
+
+	return true
+}
+
+// initiateShutdown runs the shutdown manager for the given action and logs the outcome.
+// It returns the manager's error, or nil once shutdown was initiated (or skipped in safe mode).
+func initiateShutdown(ctx context.Context, action shutdown.Action, shutdownManager shutdowner, appLogger *logger.Logger) error {
+	method, err := shutdownManager.NeutralizeStuartLittle(shutdown.WithAction(ctx, action))
+	if err != nil {
+		if !errors.Is(err, shutdown.ErrRateLimited) && !errors.Is(err, shutdown.ErrAborted) {
+			// Rate limiting and aborts are already logged as warnings by the manager
+			appLogger.ErrorWithExtra(ctx, "Failed to initiate host shutdown", map[string]string{"error": err.Error()})
+		}
+		return err
+	}
+
+	if shutdownManager.SafeMode() || shutdown.DryRunFromContext(ctx) {
+		appLogger.Info(ctx, "Safe mode: host shutdown skipped")
+		return nil
+	}
+
+	appLogger.InfoWithExtra(ctx, fmt.Sprintf("Host shutdown initiated successfully via %s", method), map[string]string{"method": method})
+	return nil
+}
+
+// validateKeyActions ensures every configured key action names a known shutdown action
+func validateKeyActions(cfg *config.Config) error {
+	for _, ka := range cfg.KeyActions {
+		if _, err := shutdown.ParseAction(ka.Action); err != nil {
+			return fmt.Errorf("invalid action for key %s: %w", ka.Key, err)
+		}
+	}
+	return nil
 }
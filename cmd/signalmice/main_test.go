@@ -0,0 +1,617 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/signalmice/signalmice/internal/config"
+	"github.com/signalmice/signalmice/internal/logger"
+	"github.com/signalmice/signalmice/internal/redis"
+	"github.com/signalmice/signalmice/internal/shutdown"
+	"github.com/signalmice/signalmice/internal/tracing"
+)
+
+// createMockLogger creates a logger that doesn't connect to Opensearch
+func createMockLogger() *logger.Logger {
+	cfg := &config.Config{
+		OpensearchURL:   "http://localhost:9200",
+		OpensearchIndex: "test-logs",
+		RedisKey:        "test-key",
+	}
+	l, _ := logger.NewLogger(cfg)
+	return l
+}
+
+// fakeSource is an in-memory signalSource
+type fakeSource struct {
+	keys   []string
+	values map[string]string
+	errs   []error // returned, in order, by the next checks before consulting values
+
+	racing map[string]string // keys a racing setter re-creates right after they are consumed
+}
+
+func (f *fakeSource) nextErr() error {
+	if len(f.errs) == 0 {
+		return nil
+	}
+	err := f.errs[0]
+	f.errs = f.errs[1:]
+	return err
+}
+
+func (f *fakeSource) CheckAndDeleteSignal(context.Context) (redis.Signal, bool, error) {
+	if err := f.nextErr(); err != nil {
+		return redis.Signal{}, false, err
+	}
+	for _, key := range f.keys {
+		if value, ok := f.values[key]; ok {
+			delete(f.values, key)
+			if racingValue, ok := f.racing[key]; ok {
+				f.values[key] = racingValue
+			}
+			return redis.Signal{Key: key, Value: value}, true, nil
+		}
+	}
+	return redis.Signal{}, false, nil
+}
+
+func (f *fakeSource) ClearSignals(context.Context) ([]string, error) {
+	var cleared []string
+	for _, key := range f.keys {
+		if _, ok := f.values[key]; ok {
+			delete(f.values, key)
+			cleared = append(cleared, key)
+		}
+	}
+	return cleared, nil
+}
+
+func (f *fakeSource) KeyExists(context.Context) (bool, error) {
+	if err := f.nextErr(); err != nil {
+		return false, err
+	}
+	if len(f.keys) == 0 {
+		return false, nil
+	}
+	_, ok := f.values[f.keys[0]]
+	return ok, nil
+}
+
+func (f *fakeSource) PeekSignal(context.Context) (redis.Signal, bool, error) {
+	if err := f.nextErr(); err != nil {
+		return redis.Signal{}, false, err
+	}
+	for _, key := range f.keys {
+		if value, ok := f.values[key]; ok {
+			return redis.Signal{Key: key, Value: value}, true, nil
+		}
+	}
+	return redis.Signal{}, false, nil
+}
+
+func (f *fakeSource) DeleteSignal(_ context.Context, key string) error {
+	delete(f.values, key)
+	return nil
+}
+
+func (f *fakeSource) ConfirmDeleted(_ context.Context, key string) (bool, error) {
+	if _, ok := f.values[key]; ok {
+		delete(f.values, key)
+		return true, nil
+	}
+	return false, nil
+}
+
+// fakeShutdowner records shutdown attempts instead of powering off
+type fakeShutdowner struct {
+	calls   int
+	actions []shutdown.Action
+	reasons []string
+	dryRuns []bool
+	whens   []string
+	err     error
+}
+
+func (f *fakeShutdowner) NeutralizeStuartLittle(ctx context.Context) (string, error) {
+	f.calls++
+	f.actions = append(f.actions, shutdown.ActionFromContext(ctx))
+	f.reasons = append(f.reasons, shutdown.ReasonFromContext(ctx))
+	f.dryRuns = append(f.dryRuns, shutdown.DryRunFromContext(ctx))
+	f.whens = append(f.whens, shutdown.WhenFromContext(ctx))
+	if f.err != nil {
+		return "", f.err
+	}
+	return "fake", nil
+}
+
+func (f *fakeShutdowner) SafeMode() bool { return false }
+
+func TestRunMonitor_InitialCheck(t *testing.T) {
+	sigChan := make(chan os.Signal, 1)
+	var checks int32
+
+	done := make(chan os.Signal)
+	go func() {
+		done <- runMonitor(context.Background(), fixedInterval(time.Hour), false, sigChan, nil, nil, func(context.Context) {
+			atomic.AddInt32(&checks, 1)
+		})
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	sigChan <- syscall.SIGTERM
+
+	if sig := <-done; sig != syscall.SIGTERM {
+		t.Errorf("expected SIGTERM to be returned, got %v", sig)
+	}
+	if got := atomic.LoadInt32(&checks); got != 1 {
+		t.Errorf("expected 1 immediate check, got %d", got)
+	}
+}
+
+func TestRunMonitor_SkipInitialCheck(t *testing.T) {
+	sigChan := make(chan os.Signal, 1)
+	var checks int32
+
+	done := make(chan os.Signal)
+	go func() {
+		done <- runMonitor(context.Background(), fixedInterval(50*time.Millisecond), true, sigChan, nil, nil, func(context.Context) {
+			atomic.AddInt32(&checks, 1)
+		})
+	}()
+
+	// Before the first tick, no check must have run
+	time.Sleep(20 * time.Millisecond)
+	if got := atomic.LoadInt32(&checks); got != 0 {
+		t.Errorf("expected no check before the first tick, got %d", got)
+	}
+
+	// After the first tick, the check runs
+	time.Sleep(50 * time.Millisecond)
+	sigChan <- syscall.SIGINT
+	<-done
+
+	if got := atomic.LoadInt32(&checks); got < 1 {
+		t.Errorf("expected a check after the first tick, got %d", got)
+	}
+}
+
+func TestStartupFields(t *testing.T) {
+	cfg := &config.Config{
+		RedisHost:     "redis",
+		RedisPort:     "6379",
+		RedisPassword: "s3cret",
+		RedisDB:       3,
+		RedisKey:      "signalmice:main",
+		CheckInterval: 30 * time.Second,
+		Mode:          config.ModeSignal,
+	}
+
+	fields := startupFields(cfg)
+
+	expected := map[string]any{
+		"event":          "startup",
+		"version":        appVersion,
+		"pid":            os.Getpid(),
+		"check_interval": "30s",
+		"redis_key":      "signalmice:main",
+		"redis_addr":     "redis:6379",
+		"redis_db":       3,
+		"mode":           config.ModeSignal,
+		"safe_mode":      false,
+	}
+	for key, want := range expected {
+		if got, ok := fields[key]; !ok || got != want {
+			t.Errorf("expected startup field %s=%v, got %v", key, want, got)
+		}
+	}
+	for key, value := range fields {
+		if s, ok := value.(string); ok && strings.Contains(s, "s3cret") {
+			t.Errorf("expected no Redis password in the startup event, got %s=%s", key, s)
+		}
+	}
+}
+
+func TestLogStartup(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	cfg := &config.Config{RedisKey: "signalmice:main"}
+	appLogger := createMockLogger()
+
+	logStartup(context.Background(), cfg, appLogger)
+	if !strings.Contains(buf.String(), "starting...") {
+		t.Errorf("expected plain-text banner, got: %s", buf.String())
+	}
+	if !strings.Contains(buf.String(), "[INFO] signalmice starting") {
+		t.Errorf("expected structured startup event, got: %s", buf.String())
+	}
+
+	buf.Reset()
+	cfg.QuietBanner = true
+	logStartup(context.Background(), cfg, appLogger)
+	if strings.Contains(buf.String(), "starting...") {
+		t.Errorf("expected plain-text banner to be suppressed, got: %s", buf.String())
+	}
+	if !strings.Contains(buf.String(), "[INFO] signalmice starting") {
+		t.Errorf("expected structured startup event with quiet banner, got: %s", buf.String())
+	}
+}
+
+func TestWaitStartupDelay_NoChecksDuringWarmup(t *testing.T) {
+	sigChan := make(chan os.Signal, 1)
+	var checks int32
+	check := func(context.Context) { atomic.AddInt32(&checks, 1) }
+
+	done := make(chan os.Signal)
+	go func() {
+		sig, stopped := waitStartupDelay(context.Background(), 50*time.Millisecond, sigChan, createMockLogger())
+		if !stopped {
+			sig = runMonitor(context.Background(), fixedInterval(time.Hour), false, sigChan, nil, nil, check)
+		}
+		done <- sig
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	if got := atomic.LoadInt32(&checks); got != 0 {
+		t.Errorf("expected no check during the warmup, got %d", got)
+	}
+
+	// Once the warmup has elapsed, the initial check runs
+	time.Sleep(60 * time.Millisecond)
+	if got := atomic.LoadInt32(&checks); got != 1 {
+		t.Errorf("expected the initial check after the warmup, got %d", got)
+	}
+	sigChan <- syscall.SIGTERM
+	<-done
+}
+
+func TestWaitStartupDelay_SignalDuringWarmup(t *testing.T) {
+	sigChan := make(chan os.Signal, 1)
+	sigChan <- syscall.SIGTERM
+
+	start := time.Now()
+	sig, stopped := waitStartupDelay(context.Background(), time.Hour, sigChan, createMockLogger())
+	if !stopped || sig != syscall.SIGTERM {
+		t.Errorf("expected SIGTERM to end the warmup, got %v (stopped %v)", sig, stopped)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the warmup to end promptly on SIGTERM, took %v", elapsed)
+	}
+}
+
+func TestRunMonitor_SignalCancelsInitialCheck(t *testing.T) {
+	sigChan := make(chan os.Signal, 1)
+	started := make(chan struct{})
+	var cancelled int32
+
+	done := make(chan os.Signal)
+	go func() {
+		done <- runMonitor(context.Background(), fixedInterval(time.Hour), false, sigChan, nil, nil, func(ctx context.Context) {
+			close(started)
+			<-ctx.Done()
+			atomic.StoreInt32(&cancelled, 1)
+		})
+	}()
+
+	<-started
+	sigChan <- syscall.SIGTERM
+
+	select {
+	case sig := <-done:
+		if sig != syscall.SIGTERM {
+			t.Errorf("expected SIGTERM, got %v", sig)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("runMonitor did not return after SIGTERM during the initial check")
+	}
+	if atomic.LoadInt32(&cancelled) != 1 {
+		t.Error("expected the initial check's context to be cancelled")
+	}
+}
+
+func TestCheckAndShutdown_CancelledContextSkipsShutdown(t *testing.T) {
+	cfg := &config.Config{RedisKey: "signalmice:main"}
+	source := &fakeSource{
+		keys:   []string{"signalmice:main"},
+		values: map[string]string{"signalmice:main": "shutdown"},
+	}
+	manager := &fakeShutdowner{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	checkAndShutdown(ctx, cfg, source, manager, createMockLogger())
+
+	if manager.calls != 0 {
+		t.Errorf("expected no shutdown from a cancelled check, got %d attempts", manager.calls)
+	}
+	if _, ok := source.values["signalmice:main"]; !ok {
+		t.Error("expected a cancelled check not to consume the signal key")
+	}
+}
+
+// cancellingSource cancels the check right after a signal was fetched, like a SIGTERM
+// arriving mid-check
+type cancellingSource struct {
+	*fakeSource
+	cancel context.CancelFunc
+}
+
+func (c *cancellingSource) CheckAndDeleteSignal(ctx context.Context) (redis.Signal, bool, error) {
+	defer c.cancel()
+	return c.fakeSource.CheckAndDeleteSignal(ctx)
+}
+
+func (c *cancellingSource) PeekSignal(ctx context.Context) (redis.Signal, bool, error) {
+	defer c.cancel()
+	return c.fakeSource.PeekSignal(ctx)
+}
+
+func TestCheckAndShutdown_CancelledAfterConsumingActsOnSignal(t *testing.T) {
+	cfg := &config.Config{RedisKey: "signalmice:main"}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	source := &cancellingSource{
+		fakeSource: &fakeSource{keys: []string{"signalmice:main"}, values: map[string]string{"signalmice:main": "reboot"}},
+		cancel:     cancel,
+	}
+	manager := &fakeShutdowner{}
+
+	checkAndShutdown(ctx, cfg, source, manager, createMockLogger())
+
+	// The key is gone, so bailing out now would drop the signal
+	if manager.calls != 1 {
+		t.Errorf("expected the consumed signal to be acted on, got %d attempts", manager.calls)
+	}
+}
+
+func TestCheckAndShutdown_CancelledAfterPeekingKeepsKey(t *testing.T) {
+	cfg := &config.Config{RedisKey: "signalmice:main", SignalJSON: true}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	source := &cancellingSource{
+		fakeSource: &fakeSource{keys: []string{"signalmice:main"}, values: map[string]string{"signalmice:main": `{"action":"reboot"}`}},
+		cancel:     cancel,
+	}
+	manager := &fakeShutdowner{}
+
+	checkAndShutdown(ctx, cfg, source, manager, createMockLogger())
+
+	if manager.calls != 0 {
+		t.Errorf("expected no shutdown from a cancelled check, got %d attempts", manager.calls)
+	}
+	if _, ok := source.values["signalmice:main"]; !ok {
+		t.Error("expected the signal key to be left for the next run")
+	}
+}
+
+func TestCheckAndShutdown_PropagatesReason(t *testing.T) {
+	cfg := &config.Config{RedisKey: "signalmice:main", ShutdownReason: config.DefaultShutdownReason}
+	source := &fakeSource{
+		keys:   []string{"signalmice:main"},
+		values: map[string]string{"signalmice:main": "decommissioning node"},
+	}
+	manager := &fakeShutdowner{}
+
+	checkAndShutdown(context.Background(), cfg, source, manager, createMockLogger())
+
+	if len(manager.reasons) != 1 || manager.reasons[0] != "decommissioning node" {
+		t.Errorf("expected reason 'decommissioning node' to reach the shutdown manager, got %v", manager.reasons)
+	}
+}
+
+func TestCheckAndShutdown_SignalSpanCarriesOutcome(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		wantErr string
+	}{
+		{name: "shutdown initiated"},
+		{name: "shutdown failed", err: errors.New("no method succeeded"), wantErr: "no method succeeded"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := &tracing.InMemoryExporter{}
+			ctx := tracing.WithTracer(context.Background(), tracing.NewTracer(exporter))
+			cfg := &config.Config{RedisKey: "signalmice:main"}
+			source := &fakeSource{keys: []string{"signalmice:main"}, values: map[string]string{"signalmice:main": ""}}
+
+			checkAndShutdown(ctx, cfg, source, &fakeShutdowner{err: tt.err}, createMockLogger())
+
+			spans := exporter.Spans()
+			if len(spans) != 1 || spans[0].Name != "signal" {
+				t.Fatalf("expected the signal span to be exported, got %+v", spans)
+			}
+			if spans[0].Err != tt.wantErr {
+				t.Errorf("expected the span error %q, got %q", tt.wantErr, spans[0].Err)
+			}
+		})
+	}
+}
+
+func TestCheckAndShutdown_EmptyValueUsesDefaults(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      *config.Config
+		key      string
+		value    string
+		expected shutdown.Action
+	}{
+		{"empty value", &config.Config{}, "signalmice:main", "", shutdown.ActionPoweroff},
+		{"whitespace value", &config.Config{}, "signalmice:main", "  \n", shutdown.ActionPoweroff},
+		{"empty value on a mapped key", &config.Config{
+			KeyActions: []config.KeyAction{{Key: "signalmice:reboot", Action: "reboot"}},
+		}, "signalmice:reboot", "", shutdown.ActionReboot},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.cfg.RedisKey = "signalmice:main"
+			tt.cfg.ShutdownReason = "scheduled maintenance"
+			source := &fakeSource{keys: []string{tt.key}, values: map[string]string{tt.key: tt.value}}
+			manager := &fakeShutdowner{}
+
+			checkAndShutdown(context.Background(), tt.cfg, source, manager, createMockLogger())
+
+			if manager.calls != 1 {
+				t.Fatalf("expected an empty value to trigger a shutdown, got %d attempts", manager.calls)
+			}
+			if manager.actions[0] != tt.expected {
+				t.Errorf("expected action %q, got %q", tt.expected, manager.actions[0])
+			}
+			if manager.reasons[0] != "scheduled maintenance" {
+				t.Errorf("expected the configured reason, got %q", manager.reasons[0])
+			}
+		})
+	}
+}
+
+func TestInitiateShutdown_LogsSucceededMethod(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	if err := initiateShutdown(context.Background(), shutdown.ActionPoweroff, &fakeShutdowner{}, createMockLogger()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "[INFO] Host shutdown initiated successfully via fake") {
+		t.Errorf("expected the success log to name the method, got: %s", buf.String())
+	}
+}
+
+func TestValidateKeyActions(t *testing.T) {
+	cfg := &config.Config{KeyActions: []config.KeyAction{{Key: "signalmice:reboot", Action: "reboot"}}}
+	if err := validateKeyActions(cfg); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+
+	cfg.KeyActions = append(cfg.KeyActions, config.KeyAction{Key: "signalmice:boom", Action: "explode"})
+	if err := validateKeyActions(cfg); err == nil {
+		t.Error("expected error for unknown action")
+	}
+}
+
+func TestIgnoreExistingSignals_ClearsWithoutShutdown(t *testing.T) {
+	cfg := &config.Config{RedisKey: "signalmice:main"}
+	source := &fakeSource{
+		keys:   []string{"signalmice:main"},
+		values: map[string]string{"signalmice:main": "shutdown"},
+	}
+	manager := &fakeShutdowner{}
+	appLogger := createMockLogger()
+	ctx := context.Background()
+
+	ignoreExistingSignals(ctx, source, appLogger)
+
+	if _, ok := source.values["signalmice:main"]; ok {
+		t.Error("expected pre-existing key to be cleared")
+	}
+
+	checkAndShutdown(ctx, cfg, source, manager, appLogger)
+	if manager.calls != 0 {
+		t.Errorf("expected no shutdown for a key present at startup, got %d attempts", manager.calls)
+	}
+
+	// A key set after startup is a real signal
+	source.values["signalmice:main"] = "shutdown"
+	checkAndShutdown(ctx, cfg, source, manager, appLogger)
+	if manager.calls != 1 {
+		t.Errorf("expected 1 shutdown for a key set after startup, got %d", manager.calls)
+	}
+}
+
+func TestCheckAndShutdown_VerifyDeleteReappearingKey(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	cfg := &config.Config{RedisKey: "signalmice:main", VerifyDelete: true}
+	source := &fakeSource{
+		keys:   []string{"signalmice:main"},
+		values: map[string]string{"signalmice:main": "shutdown"},
+		racing: map[string]string{"signalmice:main": "shutdown"},
+	}
+	manager := &fakeShutdowner{}
+
+	checkAndShutdown(context.Background(), cfg, source, manager, createMockLogger())
+
+	if manager.calls != 0 {
+		t.Errorf("expected no shutdown while a setter is racing, got %d attempts", manager.calls)
+	}
+	if _, ok := source.values["signalmice:main"]; ok {
+		t.Error("expected reappeared key to be deleted again")
+	}
+	if !strings.Contains(buf.String(), "[WARN] Signal key reappeared after deletion") {
+		t.Errorf("expected racing setter warning, got: %s", buf.String())
+	}
+}
+
+func TestCheckAndShutdown_VerifyDeleteConfirmed(t *testing.T) {
+	cfg := &config.Config{RedisKey: "signalmice:main", VerifyDelete: true}
+	source := &fakeSource{
+		keys:   []string{"signalmice:main"},
+		values: map[string]string{"signalmice:main": "shutdown"},
+	}
+	manager := &fakeShutdowner{}
+
+	checkAndShutdown(context.Background(), cfg, source, manager, createMockLogger())
+
+	if manager.calls != 1 {
+		t.Errorf("expected shutdown once deletion is confirmed, got %d attempts", manager.calls)
+	}
+}
+
+func TestCheckAndShutdown_DeleteAfterShutdownKeepsKeyOnFailure(t *testing.T) {
+	cfg := &config.Config{RedisKey: "signalmice:main", DeleteAfterShutdown: true}
+	source := &fakeSource{
+		keys:   []string{"signalmice:main"},
+		values: map[string]string{"signalmice:main": "shutdown"},
+	}
+	manager := &fakeShutdowner{err: errors.New("all shutdown methods failed")}
+	appLogger := createMockLogger()
+
+	checkAndShutdown(context.Background(), cfg, source, manager, appLogger)
+
+	if manager.calls != 1 {
+		t.Fatalf("expected 1 shutdown attempt, got %d", manager.calls)
+	}
+	if _, ok := source.values["signalmice:main"]; !ok {
+		t.Error("expected the signal key to be left intact after a failed shutdown")
+	}
+
+	// The next cycle retries against the same key and consumes it once shutdown succeeds
+	manager.err = nil
+	checkAndShutdown(context.Background(), cfg, source, manager, appLogger)
+
+	if manager.calls != 2 {
+		t.Errorf("expected the shutdown to be retried, got %d attempts", manager.calls)
+	}
+	if _, ok := source.values["signalmice:main"]; ok {
+		t.Error("expected the signal key to be deleted after a successful shutdown")
+	}
+}
+
+func TestCheckAndShutdown_DeleteFirstByDefault(t *testing.T) {
+	cfg := &config.Config{RedisKey: "signalmice:main"}
+	source := &fakeSource{
+		keys:   []string{"signalmice:main"},
+		values: map[string]string{"signalmice:main": "shutdown"},
+	}
+	manager := &fakeShutdowner{err: errors.New("all shutdown methods failed")}
+
+	checkAndShutdown(context.Background(), cfg, source, manager, createMockLogger())
+
+	if _, ok := source.values["signalmice:main"]; ok {
+		t.Error("expected the signal key to be deleted before shutdown by default")
+	}
+}
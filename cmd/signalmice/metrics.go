@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/signalmice/signalmice/internal/logger"
+	"github.com/signalmice/signalmice/internal/metrics"
+	"github.com/signalmice/signalmice/internal/redis"
+)
+
+// observedSource records every consumed signal on the registry's last-signal gauge, and the
+// outcome of every lookup on its check status
+type observedSource struct {
+	signalSource
+	metrics *metrics.Registry
+}
+
+func (s *observedSource) CheckAndDeleteSignal(ctx context.Context) (redis.Signal, bool, error) {
+	sig, found, err := s.signalSource.CheckAndDeleteSignal(ctx)
+	s.metrics.CheckCompleted(err)
+	if found {
+		s.metrics.SignalObserved()
+	}
+	return sig, found, err
+}
+
+func (s *observedSource) PeekSignal(ctx context.Context) (redis.Signal, bool, error) {
+	sig, found, err := s.signalSource.PeekSignal(ctx)
+	s.metrics.CheckCompleted(err)
+	return sig, found, err
+}
+
+func (s *observedSource) KeyExists(ctx context.Context) (bool, error) {
+	exists, err := s.signalSource.KeyExists(ctx)
+	s.metrics.CheckCompleted(err)
+	return exists, err
+}
+
+// loggerHistory serves the logger's latest entries in the status document
+type loggerHistory struct {
+	logger *logger.Logger
+}
+
+func (h loggerHistory) RecentLogs(n int) any {
+	return h.logger.RecentEntries(n)
+}
+
+// trackChecks wraps check so the registry's in-flight gauge covers its execution
+func trackChecks(reg *metrics.Registry, check func(context.Context)) func(context.Context) {
+	return func(ctx context.Context) {
+		defer reg.BeginCheck()()
+		check(ctx)
+	}
+}
+
+// serveMetrics serves the registry on addr until ctx is cancelled, as Prometheus gauges and a
+// JSON status document, along with the simulate endpoint when one is given. Once cancelled, it
+// returns after in-flight requests finished.
+func serveMetrics(ctx context.Context, addr string, reg *metrics.Registry, simulate http.Handler, appLogger *logger.Logger) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", reg.Handler())
+	mux.Handle("/status", reg.StatusHandler())
+	mux.Handle("/readyz", reg.ReadyHandler())
+	if simulate != nil {
+		mux.Handle("/simulate", simulate)
+	}
+	server := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownWaitTimeout)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	appLogger.InfoWithExtra(ctx, "Serving metrics", map[string]string{"addr": addr})
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		appLogger.ErrorWithExtra(ctx, "Metrics endpoint failed", map[string]string{"error": err.Error()})
+		return
+	}
+	<-stopped
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"testing"
+
+	"github.com/signalmice/signalmice/internal/metrics"
+	"github.com/signalmice/signalmice/internal/redis"
+)
+
+func TestTrackChecks_InFlightGauge(t *testing.T) {
+	reg := metrics.NewRegistry()
+
+	var during int64
+	check := trackChecks(reg, func(context.Context) {
+		// A long-running check observes itself as in flight
+		during = reg.ChecksInFlight()
+	})
+	check(context.Background())
+
+	if during != 1 {
+		t.Errorf("expected in-flight gauge 1 during the check, got %d", during)
+	}
+	if got := reg.ChecksInFlight(); got != 0 {
+		t.Errorf("expected in-flight gauge 0 afterward, got %d", got)
+	}
+}
+
+func TestObservedSource_RecordsSignal(t *testing.T) {
+	reg := metrics.NewRegistry()
+	source := &observedSource{
+		signalSource: &fakeSource{keys: []string{"signalmice:main"}, values: map[string]string{}},
+		metrics:      reg,
+	}
+
+	source.CheckAndDeleteSignal(context.Background())
+	if got := reg.SecondsSinceLastSignal(); got != -1 {
+		t.Errorf("expected no signal recorded when the key is absent, got %v", got)
+	}
+
+	source.signalSource.(*fakeSource).values["signalmice:main"] = "shutdown"
+	source.CheckAndDeleteSignal(context.Background())
+	if got := reg.SecondsSinceLastSignal(); got < 0 {
+		t.Errorf("expected signal to be recorded, got %v", got)
+	}
+}
+
+func TestObservedSource_RecordsCheckOutcome(t *testing.T) {
+	reg := metrics.NewRegistry()
+	source := &observedSource{
+		signalSource: &fakeSource{errs: []error{redis.ErrConnection, redis.ErrConnection}, values: map[string]string{}},
+		metrics:      reg,
+	}
+
+	source.CheckAndDeleteSignal(context.Background())
+	source.PeekSignal(context.Background())
+	if got := reg.ConsecutiveFailures(); got != 2 {
+		t.Errorf("expected 2 consecutive failures, got %d", got)
+	}
+	if reg.Healthy() {
+		t.Error("expected unhealthy after failed lookups")
+	}
+
+	source.KeyExists(context.Background())
+	if got := reg.ConsecutiveFailures(); got != 0 || !reg.Healthy() {
+		t.Errorf("expected a successful lookup to restore health, got %d failures", got)
+	}
+	if got := reg.SecondsSinceLastCheck(); got < 0 {
+		t.Errorf("expected the last check to be recorded, got %v", got)
+	}
+}
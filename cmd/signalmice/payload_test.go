@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/signalmice/signalmice/internal/config"
+	"github.com/signalmice/signalmice/internal/shutdown"
+)
+
+func TestCheckAndShutdown_JSONPayloadRebootWithDelay(t *testing.T) {
+	cfg := &config.Config{RedisKey: "signalmice:main", SignalJSON: true, Hostname: "node-1", ShutdownReason: "default reason"}
+	source := &fakeSource{keys: []string{"signalmice:main"}, values: map[string]string{
+		"signalmice:main": `{"action":"reboot","reason":"kernel upgrade","delay":"+5","allowed_hosts":["node-1","node-2"]}`,
+	}}
+	manager := &fakeShutdowner{}
+
+	checkAndShutdown(context.Background(), cfg, source, manager, createMockLogger())
+
+	if manager.calls != 1 {
+		t.Fatalf("expected one shutdown, got %d", manager.calls)
+	}
+	if manager.actions[0] != shutdown.ActionReboot {
+		t.Errorf("expected action reboot, got %q", manager.actions[0])
+	}
+	if manager.reasons[0] != "kernel upgrade" {
+		t.Errorf("expected the payload reason, got %q", manager.reasons[0])
+	}
+	if manager.whens[0] != "+5" {
+		t.Errorf("expected the payload delay, got %q", manager.whens[0])
+	}
+}
+
+func TestCheckAndShutdown_JSONPayloadDefaults(t *testing.T) {
+	cfg := &config.Config{RedisKey: "signalmice:main", SignalJSON: true, ShutdownReason: "default reason"}
+	source := &fakeSource{keys: []string{"signalmice:main"}, values: map[string]string{"signalmice:main": `{}`}}
+	manager := &fakeShutdowner{}
+
+	checkAndShutdown(context.Background(), cfg, source, manager, createMockLogger())
+
+	if manager.calls != 1 {
+		t.Fatalf("expected one shutdown, got %d", manager.calls)
+	}
+	if manager.actions[0] != shutdown.ActionPoweroff || manager.reasons[0] != "default reason" || manager.whens[0] != "" {
+		t.Errorf("expected the configured defaults, got action %q, reason %q, delay %q", manager.actions[0], manager.reasons[0], manager.whens[0])
+	}
+}
+
+func TestCheckAndShutdown_MalformedJSONPayload(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	// With deletion deferred, the key must still be consumed
+	cfg := &config.Config{RedisKey: "signalmice:main", SignalJSON: true, DeleteAfterShutdown: true}
+	source := &fakeSource{keys: []string{"signalmice:main"}, values: map[string]string{"signalmice:main": `{"action":`}}
+	manager := &fakeShutdowner{}
+
+	checkAndShutdown(context.Background(), cfg, source, manager, createMockLogger())
+
+	if manager.calls != 0 {
+		t.Errorf("expected no shutdown for malformed JSON, got %d attempts", manager.calls)
+	}
+	if _, ok := source.values["signalmice:main"]; ok {
+		t.Error("expected the malformed signal key to be deleted")
+	}
+	if !strings.Contains(buf.String(), "[WARN] Malformed JSON signal payload") {
+		t.Errorf("expected malformed payload warning, got: %s", buf.String())
+	}
+}
+
+func TestCheckAndShutdown_JSONPayloadOtherHost(t *testing.T) {
+	cfg := &config.Config{RedisKey: "signalmice:main", SignalJSON: true, Hostname: "node-3"}
+	source := &fakeSource{keys: []string{"signalmice:main"}, values: map[string]string{
+		"signalmice:main": `{"action":"reboot","allowed_hosts":["node-1"]}`,
+	}}
+	manager := &fakeShutdowner{}
+
+	checkAndShutdown(context.Background(), cfg, source, manager, createMockLogger())
+
+	if manager.calls != 0 {
+		t.Errorf("expected no shutdown on a host outside allowed_hosts, got %d attempts", manager.calls)
+	}
+	if _, ok := source.values["signalmice:main"]; !ok {
+		t.Fatal("expected the signal key to be left for the allowed host")
+	}
+
+	// The allowed host polling next still finds and consumes it
+	allowed := &config.Config{RedisKey: "signalmice:main", SignalJSON: true, Hostname: "node-1"}
+	checkAndShutdown(context.Background(), allowed, source, manager, createMockLogger())
+
+	if manager.calls != 1 {
+		t.Errorf("expected the allowed host to shut down, got %d attempts", manager.calls)
+	}
+	if _, ok := source.values["signalmice:main"]; ok {
+		t.Error("expected the allowed host to consume the signal key")
+	}
+}
+
+func TestCheckAndShutdown_SignalLease(t *testing.T) {
+	future := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	past := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+
+	tests := []struct {
+		name          string
+		value         string
+		requireExpiry bool
+		wantShutdown  bool
+	}{
+		{name: "unexpired signal acts", value: `{"expires_at":"` + future + `"}`, wantShutdown: true},
+		{name: "expired signal is ignored", value: `{"expires_at":"` + past + `"}`},
+		{name: "signal without expiry acts", value: `{}`, wantShutdown: true},
+		{name: "signal without required expiry is ignored", value: `{}`, requireExpiry: true},
+		{name: "empty value with required expiry is ignored", value: "", requireExpiry: true},
+		{name: "empty value is ignored", value: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			log.SetOutput(&buf)
+			defer log.SetOutput(os.Stderr)
+
+			cfg := &config.Config{RedisKey: "signalmice:main", SignalJSON: true, SignalRequireExpiry: tt.requireExpiry, DeleteAfterShutdown: true}
+			source := &fakeSource{keys: []string{"signalmice:main"}, values: map[string]string{"signalmice:main": tt.value}}
+			manager := &fakeShutdowner{}
+
+			checkAndShutdown(context.Background(), cfg, source, manager, createMockLogger())
+
+			if tt.wantShutdown {
+				if manager.calls != 1 {
+					t.Errorf("expected a shutdown, got %d attempts", manager.calls)
+				}
+				return
+			}
+			if manager.calls != 0 {
+				t.Errorf("expected no shutdown, got %d attempts", manager.calls)
+			}
+			if _, ok := source.values["signalmice:main"]; ok {
+				t.Error("expected the refused signal key to be deleted")
+			}
+			if !strings.Contains(buf.String(), "[WARN]") {
+				t.Errorf("expected a warning for the refused signal, got: %s", buf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/signalmice/signalmice/internal/logger"
+	"github.com/signalmice/signalmice/internal/redis"
+)
+
+// checkRetryBackoff is the delay before the first in-cycle retry; it doubles on each attempt
+var checkRetryBackoff = 200 * time.Millisecond
+
+// retryTransient runs op, retrying up to retries times within the same cycle while it fails
+// with a connection error. Success, "not found", and server errors end the cycle immediately.
+func retryTransient(ctx context.Context, retries int, appLogger *logger.Logger, op func(context.Context) error) error {
+	backoff := checkRetryBackoff
+	for attempt := 0; ; attempt++ {
+		err := op(ctx)
+		if err == nil || !errors.Is(err, redis.ErrConnection) || attempt >= retries {
+			return err
+		}
+
+		appLogger.WarnWithExtra(ctx, "Transient Redis error, retrying within this cycle", map[string]any{
+			"error":   err.Error(),
+			"attempt": attempt + 1,
+			"retries": retries,
+			"backoff": backoff.String(),
+		})
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
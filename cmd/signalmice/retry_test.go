@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/signalmice/signalmice/internal/config"
+	"github.com/signalmice/signalmice/internal/redis"
+)
+
+func init() {
+	checkRetryBackoff = time.Millisecond
+}
+
+func TestCheckAndShutdown_RetriesTransientError(t *testing.T) {
+	cfg := &config.Config{RedisKey: "signalmice:main", CheckRetries: 2}
+	source := &fakeSource{
+		keys:   []string{"signalmice:main"},
+		values: map[string]string{"signalmice:main": "shutdown"},
+		errs:   []error{fmt.Errorf("%w: connection reset", redis.ErrConnection)},
+	}
+	manager := &fakeShutdowner{}
+
+	checkAndShutdown(context.Background(), cfg, source, manager, createMockLogger())
+
+	if manager.calls != 1 {
+		t.Errorf("expected shutdown after the in-cycle retry succeeded, got %d attempts", manager.calls)
+	}
+}
+
+func TestCheckAndShutdown_NoRetryWithoutBudget(t *testing.T) {
+	cfg := &config.Config{RedisKey: "signalmice:main"}
+	source := &fakeSource{
+		keys:   []string{"signalmice:main"},
+		values: map[string]string{"signalmice:main": "shutdown"},
+		errs:   []error{fmt.Errorf("%w: connection reset", redis.ErrConnection)},
+	}
+	manager := &fakeShutdowner{}
+
+	checkAndShutdown(context.Background(), cfg, source, manager, createMockLogger())
+
+	if manager.calls != 0 {
+		t.Errorf("expected no shutdown when retries are disabled, got %d attempts", manager.calls)
+	}
+}
+
+func TestRetryTransient_StopsOnServerError(t *testing.T) {
+	var calls int
+	serverErr := fmt.Errorf("%w: NOPERM", redis.ErrServer)
+
+	err := retryTransient(context.Background(), 3, createMockLogger(), func(context.Context) error {
+		calls++
+		return serverErr
+	})
+
+	if !errors.Is(err, redis.ErrServer) {
+		t.Errorf("expected server error, got: %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("expected server errors not to be retried, got %d calls", calls)
+	}
+}
+
+func TestRetryTransient_GivesUpAfterRetries(t *testing.T) {
+	var calls int
+	connErr := fmt.Errorf("%w: timeout", redis.ErrConnection)
+
+	err := retryTransient(context.Background(), 2, createMockLogger(), func(context.Context) error {
+		calls++
+		return connErr
+	})
+
+	if !errors.Is(err, redis.ErrConnection) {
+		t.Errorf("expected connection error, got: %v", err)
+	}
+	if calls != 3 {
+		t.Errorf("expected 1 attempt plus 2 retries, got %d calls", calls)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"context"
+
+	"github.com/signalmice/signalmice/internal/config"
+	"github.com/signalmice/signalmice/internal/instruction"
+	"github.com/signalmice/signalmice/internal/logger"
+	"github.com/signalmice/signalmice/internal/redis"
+	"github.com/signalmice/signalmice/internal/shutdown"
+)
+
+// slotClaimer takes shutdown slots from the integer budget a controller sets in the signal key
+type slotClaimer interface {
+	ClaimSlot(ctx context.Context, holder string) (redis.SlotClaim, int64, error)
+	ReleaseSlot(ctx context.Context, holder string) error
+}
+
+// checkSemaphore shuts down the host only when it claims a slot from the shutdown budget, so a
+// controller can power off a limited number of hosts out of a fleet watching the same key. The
+// claim is recorded under the hostname, so a host that is still up never takes a second slot.
+func checkSemaphore(ctx context.Context, cfg *config.Config, claimer slotClaimer, shutdownManager shutdowner, appLogger *logger.Logger) {
+	holder := cfg.CurrentHostname()
+	// Never retried: a claim whose reply was lost may have taken a slot already
+	claim, remaining, err := claimer.ClaimSlot(ctx, holder)
+	if err != nil {
+		appLogger.ErrorWithExtra(ctx, "Error claiming shutdown slot", map[string]string{
+			"error":      err.Error(),
+			"error_type": redis.ErrorType(err),
+		})
+		return
+	}
+
+	switch claim {
+	case redis.SlotNoBudget:
+		appLogger.Debug(ctx, "Shutdown budget key not found, continuing to monitor...")
+		return
+	case redis.SlotExhausted:
+		appLogger.Debug(ctx, "Shutdown budget exhausted, continuing to monitor...")
+		return
+	case redis.SlotHeld:
+		appLogger.Debug(ctx, "Already holding a slot of this shutdown budget, continuing to monitor...")
+		return
+	}
+
+	ctx = logger.WithCorrelationID(ctx, logger.NewCorrelationID())
+	key := cfg.SignalKey()
+	action := instruction.SelectAction(cfg, key, "")
+	reason := instruction.SelectReason(cfg, "")
+	appLogger.InfoWithExtra(ctx, "Claimed a shutdown slot! Initiating shutdown.", map[string]any{
+		"key":       key,
+		"remaining": remaining,
+		"action":    string(action),
+		"reason":    reason,
+	})
+
+	if err := initiateShutdown(shutdown.WithReason(ctx, reason), action, shutdownManager, appLogger); err != nil {
+		// Hand the slot back so another host can take it
+		if err := claimer.ReleaseSlot(ctx, holder); err != nil {
+			appLogger.ErrorWithExtra(ctx, "Failed to return the shutdown slot", map[string]string{
+				"key":        key,
+				"error":      err.Error(),
+				"error_type": redis.ErrorType(err),
+			})
+			return
+		}
+		appLogger.WarnWithExtra(ctx, "Shutdown not initiated, returned the shutdown slot", map[string]string{"key": key})
+	}
+}
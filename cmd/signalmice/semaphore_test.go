@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/signalmice/signalmice/internal/config"
+	"github.com/signalmice/signalmice/internal/redis"
+	"github.com/signalmice/signalmice/internal/shutdown"
+)
+
+// fakeBudget is a shutdown budget shared by every instance, claimed like the Redis script does
+type fakeBudget struct {
+	mu      sync.Mutex
+	set     bool
+	budget  int64
+	err     error
+	holders map[string]bool
+}
+
+func (b *fakeBudget) ClaimSlot(_ context.Context, holder string) (redis.SlotClaim, int64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch {
+	case b.err != nil:
+		return redis.SlotNoBudget, 0, b.err
+	case !b.set:
+		return redis.SlotNoBudget, 0, nil
+	case b.holders[holder]:
+		return redis.SlotHeld, 0, nil
+	case b.budget <= 0:
+		return redis.SlotExhausted, 0, nil
+	}
+	if b.holders == nil {
+		b.holders = map[string]bool{}
+	}
+	b.holders[holder] = true
+	b.budget--
+	return redis.SlotClaimed, b.budget, nil
+}
+
+func (b *fakeBudget) ReleaseSlot(_ context.Context, holder string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.holders[holder] {
+		delete(b.holders, holder)
+		b.budget++
+	}
+	return nil
+}
+
+func (b *fakeBudget) remaining() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.budget
+}
+
+func TestCheckSemaphore_OnlyBudgetedNodesShutDown(t *testing.T) {
+	const nodes, budget = 20, 5
+	shared := &fakeBudget{set: true, budget: budget}
+
+	managers := make([]*fakeShutdowner, nodes)
+	var wg sync.WaitGroup
+	for i := range managers {
+		managers[i] = &fakeShutdowner{}
+		cfg := &config.Config{RedisKey: "signalmice:fleet", Mode: config.ModeSemaphore, Hostname: fmt.Sprintf("node-%d", i)}
+		wg.Add(1)
+		go func(manager *fakeShutdowner) {
+			defer wg.Done()
+			// Every node checks a few times, as it would on successive ticks
+			for j := 0; j < 3; j++ {
+				checkSemaphore(context.Background(), cfg, shared, manager, createMockLogger())
+			}
+		}(managers[i])
+	}
+	wg.Wait()
+
+	shutDown := 0
+	for _, manager := range managers {
+		if manager.calls > 1 {
+			t.Errorf("expected a node to shut down at most once, got %d", manager.calls)
+		}
+		shutDown += manager.calls
+	}
+	if shutDown != budget {
+		t.Errorf("expected exactly %d nodes to claim a slot, got %d", budget, shutDown)
+	}
+	if got := shared.remaining(); got != 0 {
+		t.Errorf("expected the budget to be used up, got %d", got)
+	}
+}
+
+func TestCheckSemaphore_NoBudget(t *testing.T) {
+	cfg := &config.Config{RedisKey: "signalmice:fleet"}
+	for name, budget := range map[string]*fakeBudget{
+		"key not set": {},
+		"exhausted":   {set: true},
+		"error":       {err: redis.ErrConnection},
+	} {
+		t.Run(name, func(t *testing.T) {
+			manager := &fakeShutdowner{}
+			checkSemaphore(context.Background(), cfg, budget, manager, createMockLogger())
+			if manager.calls != 0 {
+				t.Errorf("expected no shutdown, got %d attempts", manager.calls)
+			}
+		})
+	}
+}
+
+func TestCheckSemaphore_ActionAndReason(t *testing.T) {
+	cfg := &config.Config{
+		RedisKey:       "signalmice:fleet",
+		ShutdownReason: "fleet downsizing",
+		KeyActions:     []config.KeyAction{{Key: "signalmice:fleet", Action: "reboot"}},
+	}
+	manager := &fakeShutdowner{}
+
+	checkSemaphore(context.Background(), cfg, &fakeBudget{set: true, budget: 1}, manager, createMockLogger())
+
+	if manager.calls != 1 {
+		t.Fatalf("expected one shutdown, got %d", manager.calls)
+	}
+	if manager.actions[0] != shutdown.ActionReboot || manager.reasons[0] != "fleet downsizing" {
+		t.Errorf("expected the key's action and the configured reason, got %q, %q", manager.actions[0], manager.reasons[0])
+	}
+}
+
+func TestCheckSemaphore_FailedShutdownReturnsSlot(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	cfg := &config.Config{RedisKey: "signalmice:fleet"}
+	budget := &fakeBudget{set: true, budget: 2}
+	manager := &fakeShutdowner{err: errors.New("all shutdown methods failed")}
+
+	checkSemaphore(context.Background(), cfg, budget, manager, createMockLogger())
+
+	if got := budget.remaining(); got != 2 {
+		t.Errorf("expected the slot to be returned, got a budget of %d", got)
+	}
+	if !strings.Contains(buf.String(), "[WARN] Shutdown not initiated, returned the shutdown slot") {
+		t.Errorf("expected a warning about the returned slot, got: %s", buf.String())
+	}
+}
+
+func TestCheckSemaphore_HolderDoesNotClaimTwice(t *testing.T) {
+	cfg := &config.Config{RedisKey: "signalmice:fleet", Hostname: "node-1"}
+	budget := &fakeBudget{set: true, budget: 3}
+	manager := &fakeShutdowner{}
+
+	// Still up on later ticks, e.g. with a delayed shutdown or after a reboot
+	for i := 0; i < 3; i++ {
+		checkSemaphore(context.Background(), cfg, budget, manager, createMockLogger())
+	}
+
+	if manager.calls != 1 {
+		t.Errorf("expected one shutdown, got %d", manager.calls)
+	}
+	if got := budget.remaining(); got != 2 {
+		t.Errorf("expected a single slot claimed, got a budget of %d", got)
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/signalmice/signalmice/internal/config"
+	"github.com/signalmice/signalmice/internal/instruction"
+)
+
+func TestCheckAndShutdown_SignedValue(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	cfg := &config.Config{RedisKey: "signalmice:main", SigningKey: "s3cret"}
+	source := &fakeSource{keys: []string{"signalmice:main"}, values: map[string]string{}}
+	manager := &fakeShutdowner{}
+	appLogger := createMockLogger()
+
+	// Tampered token: the key is consumed but nothing is powered off
+	source.values["signalmice:main"] = "poweroff." + instruction.Sign([]byte("s3cret"), "reboot")
+	checkAndShutdown(context.Background(), cfg, source, manager, appLogger)
+	if manager.calls != 0 {
+		t.Errorf("expected no shutdown for a tampered token, got %d attempts", manager.calls)
+	}
+	if _, ok := source.values["signalmice:main"]; ok {
+		t.Error("expected tampered signal key to be deleted")
+	}
+	if !strings.Contains(buf.String(), "[WARN] Signal signature verification failed") {
+		t.Errorf("expected signature warning, got: %s", buf.String())
+	}
+
+	// An empty value is not a token, so signing still refuses it
+	source.values["signalmice:main"] = ""
+	checkAndShutdown(context.Background(), cfg, source, manager, appLogger)
+	if manager.calls != 0 {
+		t.Errorf("expected no shutdown for an unsigned empty value, got %d attempts", manager.calls)
+	}
+
+	// Valid token: the verified payload selects the action
+	source.values["signalmice:main"] = "reboot." + instruction.Sign([]byte("s3cret"), "reboot")
+	checkAndShutdown(context.Background(), cfg, source, manager, appLogger)
+	if manager.calls != 1 {
+		t.Fatalf("expected shutdown for a valid token, got %d attempts", manager.calls)
+	}
+	if manager.actions[0] != "reboot" {
+		t.Errorf("expected payload action reboot, got %q", manager.actions[0])
+	}
+}
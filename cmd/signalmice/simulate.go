@@ -0,0 +1,82 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/signalmice/signalmice/internal/redis"
+	"github.com/signalmice/signalmice/internal/shutdown"
+)
+
+// maxSimulateBodyBytes bounds the request body accepted by the simulate endpoint
+const maxSimulateBodyBytes = 64 * 1024
+
+// simulateRequest is the body of POST /simulate. An empty key simulates the primary signal key.
+type simulateRequest struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+// simulateResponse is returned once the simulated signal went through the pipeline
+type simulateResponse struct {
+	Key    string `json:"key"`
+	DryRun bool   `json:"dry_run"`
+}
+
+// simulatedSource serves a single signal handed in over HTTP instead of read from Redis.
+// Deletes are no-ops, since nothing was stored.
+type simulatedSource struct {
+	sig redis.Signal
+}
+
+func (s *simulatedSource) CheckAndDeleteSignal(context.Context) (redis.Signal, bool, error) {
+	return s.sig, true, nil
+}
+
+func (s *simulatedSource) PeekSignal(context.Context) (redis.Signal, bool, error) {
+	return s.sig, true, nil
+}
+
+func (s *simulatedSource) ClearSignals(context.Context) ([]string, error) { return nil, nil }
+
+func (s *simulatedSource) KeyExists(context.Context) (bool, error) { return true, nil }
+
+func (s *simulatedSource) ConfirmDeleted(context.Context, string) (bool, error) { return false, nil }
+
+func (s *simulatedSource) DeleteSignal(context.Context, string) error { return nil }
+
+// newSimulateHandler serves POST /simulate, running the signal in the body through check as a
+// dry run. Requests must carry "Authorization: Bearer <token>".
+func newSimulateHandler(token, defaultKey string, check func(context.Context, signalSource)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+token)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		var req simulateRequest
+		body, err := io.ReadAll(io.LimitReader(r.Body, maxSimulateBodyBytes))
+		if err != nil || json.Unmarshal(body, &req) != nil {
+			http.Error(w, "invalid request body", http.StatusBadRequest)
+			return
+		}
+		if req.Key == "" {
+			req.Key = defaultKey
+		}
+
+		// Whatever the configuration, a simulated signal never powers off the host
+		ctx := shutdown.WithDryRun(context.WithoutCancel(r.Context()))
+		check(ctx, &simulatedSource{sig: redis.Signal{Key: req.Key, Value: req.Value}})
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(simulateResponse{Key: req.Key, DryRun: true})
+	})
+}
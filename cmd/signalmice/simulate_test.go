@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/signalmice/signalmice/internal/config"
+	"github.com/signalmice/signalmice/internal/shutdown"
+)
+
+// newTestSimulateHandler returns a simulate handler running the real check flow against manager
+func newTestSimulateHandler(cfg *config.Config, manager shutdowner) http.Handler {
+	appLogger := createMockLogger()
+	return newSimulateHandler("secret", cfg.SignalKey(), func(ctx context.Context, source signalSource) {
+		checkAndShutdown(ctx, cfg, source, manager, appLogger)
+	})
+}
+
+func TestSimulateHandler_RunsPipelineAsDryRun(t *testing.T) {
+	cfg := &config.Config{RedisKey: "signalmice:main", ShutdownReason: config.DefaultShutdownReason}
+	manager := &fakeShutdowner{}
+	handler := newTestSimulateHandler(cfg, manager)
+
+	req := httptest.NewRequest(http.MethodPost, "/simulate", strings.NewReader(`{"value":"reboot"}`))
+	req.Header.Set("Authorization", "Bearer secret")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp simulateResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil || resp.Key != "signalmice:main" || !resp.DryRun {
+		t.Errorf("unexpected response %+v (err %v)", resp, err)
+	}
+
+	if manager.calls != 1 {
+		t.Fatalf("expected the pipeline to reach the shutdown manager once, got %d", manager.calls)
+	}
+	if !manager.dryRuns[0] {
+		t.Error("expected the simulated shutdown to be a dry run")
+	}
+	if manager.actions[0] != shutdown.ActionReboot {
+		t.Errorf("expected the simulated value to select the reboot action, got %s", manager.actions[0])
+	}
+}
+
+func TestSimulateHandler_RequiresToken(t *testing.T) {
+	manager := &fakeShutdowner{}
+	handler := newTestSimulateHandler(&config.Config{RedisKey: "signalmice:main"}, manager)
+
+	for _, auth := range []string{"", "Bearer wrong", "secret"} {
+		req := httptest.NewRequest(http.MethodPost, "/simulate", strings.NewReader(`{}`))
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("expected 401 for Authorization %q, got %d", auth, rec.Code)
+		}
+	}
+	if manager.calls != 0 {
+		t.Errorf("expected no pipeline run without a valid token, got %d", manager.calls)
+	}
+}
+
+func TestSimulateHandler_RejectsInvalidRequests(t *testing.T) {
+	manager := &fakeShutdowner{}
+	handler := newTestSimulateHandler(&config.Config{RedisKey: "signalmice:main"}, manager)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/simulate", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("expected 405 for GET, got %d", rec.Code)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/simulate", strings.NewReader(`not json`))
+	req.Header.Set("Authorization", "Bearer secret")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("expected 400 for an invalid body, got %d", rec.Code)
+	}
+
+	if manager.calls != 0 {
+		t.Errorf("expected no pipeline run for invalid requests, got %d", manager.calls)
+	}
+}
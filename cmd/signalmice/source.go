@@ -0,0 +1,270 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/signalmice/signalmice/internal/logger"
+	"github.com/signalmice/signalmice/internal/redis"
+)
+
+// maxHTTPSignalBytes bounds the signal value read from the HTTP source
+const maxHTTPSignalBytes = 4096
+
+// namedSource is a signal source identified in logs and health tracking
+type namedSource struct {
+	name   string
+	source signalSource
+}
+
+// compositeSource tries its sources in order, so one unreachable dependency does not gate
+// shutdowns. A source that errors is marked unhealthy and the next one is consulted.
+type compositeSource struct {
+	sources []namedSource
+	logger  *logger.Logger
+
+	mu      sync.Mutex
+	healthy map[string]bool
+}
+
+// newCompositeSource creates a composite over sources, all initially healthy
+func newCompositeSource(appLogger *logger.Logger, sources ...namedSource) *compositeSource {
+	healthy := make(map[string]bool, len(sources))
+	for _, s := range sources {
+		healthy[s.name] = true
+	}
+	return &compositeSource{sources: sources, logger: appLogger, healthy: healthy}
+}
+
+// Healthy reports whether the named source succeeded on its last call
+func (c *compositeSource) Healthy(name string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.healthy[name]
+}
+
+// record updates the health of a source after a call, logging transitions
+func (c *compositeSource) record(ctx context.Context, name string, err error) {
+	c.mu.Lock()
+	was := c.healthy[name]
+	c.healthy[name] = err == nil
+	c.mu.Unlock()
+
+	switch {
+	case was && err != nil:
+		c.logger.WarnWithExtra(ctx, "Signal source unhealthy, failing over", map[string]string{
+			"source":     name,
+			"error":      err.Error(),
+			"error_type": redis.ErrorType(err),
+		})
+	case !was && err == nil:
+		c.logger.InfoWithExtra(ctx, "Signal source recovered", map[string]string{"source": name})
+	}
+}
+
+// each calls fn on every source in order until it reports done. It returns the last error
+// only if every source failed.
+func (c *compositeSource) each(ctx context.Context, fn func(namedSource) (bool, error)) error {
+	var lastErr error
+	failed := 0
+	for _, s := range c.sources {
+		done, err := fn(s)
+		c.record(ctx, s.name, err)
+		if err != nil {
+			lastErr = err
+			failed++
+			continue
+		}
+		if done {
+			return nil
+		}
+	}
+	if failed == len(c.sources) {
+		return lastErr
+	}
+	return nil
+}
+
+func (c *compositeSource) CheckAndDeleteSignal(ctx context.Context) (redis.Signal, bool, error) {
+	var sig redis.Signal
+	var found bool
+	err := c.each(ctx, func(s namedSource) (bool, error) {
+		var err error
+		sig, found, err = s.source.CheckAndDeleteSignal(ctx)
+		return found, err
+	})
+	return sig, found && err == nil, err
+}
+
+func (c *compositeSource) ClearSignals(ctx context.Context) ([]string, error) {
+	var cleared []string
+	err := c.each(ctx, func(s namedSource) (bool, error) {
+		keys, err := s.source.ClearSignals(ctx)
+		cleared = append(cleared, keys...)
+		return false, err
+	})
+	return cleared, err
+}
+
+// KeyExists reports the key present as soon as one source has it, but absent only when every
+// source answered: a failed source might hold it, and in deadmans mode absence powers off
+func (c *compositeSource) KeyExists(ctx context.Context) (bool, error) {
+	var present bool
+	var failed error
+	c.each(ctx, func(s namedSource) (bool, error) {
+		var err error
+		present, err = s.source.KeyExists(ctx)
+		if err != nil && failed == nil {
+			failed = fmt.Errorf("signal source %s: %w", s.name, err)
+		}
+		return present, err
+	})
+	if present {
+		return true, nil
+	}
+	return false, failed
+}
+
+func (c *compositeSource) ConfirmDeleted(ctx context.Context, key string) (bool, error) {
+	var reappeared bool
+	err := c.each(ctx, func(s namedSource) (bool, error) {
+		var err error
+		reappeared, err = s.source.ConfirmDeleted(ctx, key)
+		return reappeared, err
+	})
+	return reappeared && err == nil, err
+}
+
+func (c *compositeSource) PeekSignal(ctx context.Context) (redis.Signal, bool, error) {
+	var sig redis.Signal
+	var found bool
+	err := c.each(ctx, func(s namedSource) (bool, error) {
+		var err error
+		sig, found, err = s.source.PeekSignal(ctx)
+		return found, err
+	})
+	return sig, found && err == nil, err
+}
+
+func (c *compositeSource) DeleteSignal(ctx context.Context, key string) error {
+	return c.each(ctx, func(s namedSource) (bool, error) {
+		return false, s.source.DeleteSignal(ctx, key)
+	})
+}
+
+// httpSource is a secondary trigger: a GET returning 200 means the signal is set (the body is
+// its value), 404 or 204 means it is not, and a DELETE consumes it
+type httpSource struct {
+	url    string
+	client *http.Client
+}
+
+// newHTTPSource creates an HTTP signal source for url
+func newHTTPSource(url string, timeout time.Duration) *httpSource {
+	return &httpSource{url: url, client: &http.Client{Timeout: timeout}}
+}
+
+// get fetches the signal, returning its value and whether it is set
+func (h *httpSource) get(ctx context.Context) (string, bool, error) {
+	res, err := h.do(ctx, http.MethodGet)
+	if err != nil {
+		return "", false, err
+	}
+	defer res.Body.Close()
+
+	switch res.StatusCode {
+	case http.StatusOK:
+		body, err := io.ReadAll(io.LimitReader(res.Body, maxHTTPSignalBytes))
+		if err != nil {
+			return "", false, fmt.Errorf("failed to read HTTP signal: %w", err)
+		}
+		return strings.TrimSpace(string(body)), true, nil
+	case http.StatusNotFound, http.StatusNoContent:
+		return "", false, nil
+	default:
+		return "", false, fmt.Errorf("HTTP signal source returned status %d", res.StatusCode)
+	}
+}
+
+// consume deletes the signal
+func (h *httpSource) consume(ctx context.Context) error {
+	res, err := h.do(ctx, http.MethodDelete)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	if res.StatusCode >= 300 && res.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("HTTP signal source returned status %d on delete", res.StatusCode)
+	}
+	return nil
+}
+
+func (h *httpSource) do(ctx context.Context, method string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, h.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := h.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", redis.ErrConnection, err)
+	}
+	return res, nil
+}
+
+func (h *httpSource) CheckAndDeleteSignal(ctx context.Context) (redis.Signal, bool, error) {
+	value, found, err := h.get(ctx)
+	if err != nil || !found {
+		return redis.Signal{}, false, err
+	}
+	if err := h.consume(ctx); err != nil {
+		return redis.Signal{}, false, err
+	}
+	return redis.Signal{Key: h.url, Value: value}, true, nil
+}
+
+func (h *httpSource) PeekSignal(ctx context.Context) (redis.Signal, bool, error) {
+	value, found, err := h.get(ctx)
+	if err != nil || !found {
+		return redis.Signal{}, false, err
+	}
+	return redis.Signal{Key: h.url, Value: value}, true, nil
+}
+
+func (h *httpSource) DeleteSignal(ctx context.Context, key string) error {
+	if key != h.url {
+		return nil
+	}
+	return h.consume(ctx)
+}
+
+func (h *httpSource) ClearSignals(ctx context.Context) ([]string, error) {
+	_, found, err := h.get(ctx)
+	if err != nil || !found {
+		return nil, err
+	}
+	if err := h.consume(ctx); err != nil {
+		return nil, err
+	}
+	return []string{h.url}, nil
+}
+
+func (h *httpSource) KeyExists(ctx context.Context) (bool, error) {
+	_, found, err := h.get(ctx)
+	return found, err
+}
+
+func (h *httpSource) ConfirmDeleted(ctx context.Context, key string) (bool, error) {
+	if key != h.url {
+		return false, nil
+	}
+	_, found, err := h.get(ctx)
+	if err != nil || !found {
+		return false, err
+	}
+	return true, h.consume(ctx)
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/signalmice/signalmice/internal/config"
+	"github.com/signalmice/signalmice/internal/redis"
+)
+
+// newTestHTTPSignal serves a settable signal at / with GET/DELETE semantics
+func newTestHTTPSignal(t *testing.T, value string) *httptest.Server {
+	t.Helper()
+	var mu sync.Mutex
+	set := value != ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case http.MethodGet:
+			if !set {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			fmt.Fprint(w, value)
+		case http.MethodDelete:
+			set = false
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestCompositeSource_FailsOverToSecondary(t *testing.T) {
+	primary := &fakeSource{
+		keys:   []string{"signalmice:main"},
+		values: map[string]string{},
+		errs:   []error{fmt.Errorf("%w: connection refused", redis.ErrConnection)},
+	}
+	server := newTestHTTPSignal(t, "reboot")
+
+	source := newCompositeSource(createMockLogger(),
+		namedSource{name: "redis", source: primary},
+		namedSource{name: "http", source: newHTTPSource(server.URL, time.Second)},
+	)
+
+	sig, found, err := source.CheckAndDeleteSignal(context.Background())
+	if err != nil {
+		t.Fatalf("expected the secondary to answer, got error: %v", err)
+	}
+	if !found || sig.Value != "reboot" || sig.Key != server.URL {
+		t.Errorf("expected signal from the HTTP source, got %+v (found=%v)", sig, found)
+	}
+	if source.Healthy("redis") {
+		t.Error("expected the erroring primary to be marked unhealthy")
+	}
+	if !source.Healthy("http") {
+		t.Error("expected the secondary to be healthy")
+	}
+
+	// The HTTP signal was consumed; the recovered primary reports nothing
+	if _, found, err := source.CheckAndDeleteSignal(context.Background()); found || err != nil {
+		t.Errorf("expected no signal after consumption, got found=%v err=%v", found, err)
+	}
+	if !source.Healthy("redis") {
+		t.Error("expected the primary to recover after a successful call")
+	}
+}
+
+func TestCompositeSource_AllSourcesFail(t *testing.T) {
+	failing := func() *fakeSource {
+		return &fakeSource{values: map[string]string{}, errs: []error{errors.New("down")}}
+	}
+	source := newCompositeSource(createMockLogger(),
+		namedSource{name: "a", source: failing()},
+		namedSource{name: "b", source: failing()},
+	)
+
+	if _, found, err := source.CheckAndDeleteSignal(context.Background()); err == nil || found {
+		t.Errorf("expected an error when every source fails, got found=%v err=%v", found, err)
+	}
+}
+
+func TestCompositeSource_KeyExistsUnknownWhileASourceFails(t *testing.T) {
+	primary := &fakeSource{
+		keys:   []string{"signalmice:main"},
+		values: map[string]string{},
+		errs:   []error{fmt.Errorf("%w: connection refused", redis.ErrConnection)},
+	}
+	source := newCompositeSource(createMockLogger(),
+		namedSource{name: "redis", source: primary},
+		namedSource{name: "http", source: newHTTPSource(newTestHTTPSignal(t, "").URL, time.Second)},
+	)
+
+	// The key may well be in Redis; absence must not be reported while it cannot be read
+	present, err := source.KeyExists(context.Background())
+	if err == nil || present {
+		t.Errorf("expected an error while Redis is down, got present=%v err=%v", present, err)
+	}
+}
+
+func TestCompositeSource_KeyExistsFoundDespiteFailure(t *testing.T) {
+	primary := &fakeSource{values: map[string]string{}, errs: []error{errors.New("down")}}
+	source := newCompositeSource(createMockLogger(),
+		namedSource{name: "redis", source: primary},
+		namedSource{name: "http", source: newHTTPSource(newTestHTTPSignal(t, "alive").URL, time.Second)},
+	)
+
+	if present, err := source.KeyExists(context.Background()); err != nil || !present {
+		t.Errorf("expected the key found through the secondary, got present=%v err=%v", present, err)
+	}
+}
+
+func TestCheckAndShutdown_HTTPSecondary(t *testing.T) {
+	cfg := &config.Config{RedisKey: "signalmice:main"}
+	primary := &fakeSource{
+		keys:   []string{"signalmice:main"},
+		values: map[string]string{},
+		errs:   []error{fmt.Errorf("%w: connection refused", redis.ErrConnection)},
+	}
+	server := newTestHTTPSignal(t, "maintenance window")
+	source := newCompositeSource(createMockLogger(),
+		namedSource{name: "redis", source: primary},
+		namedSource{name: "http", source: newHTTPSource(server.URL, time.Second)},
+	)
+	manager := &fakeShutdowner{}
+
+	checkAndShutdown(context.Background(), cfg, source, manager, createMockLogger())
+
+	if manager.calls != 1 {
+		t.Fatalf("expected shutdown via the HTTP source, got %d attempts", manager.calls)
+	}
+	if manager.reasons[0] != "maintenance window" {
+		t.Errorf("expected reason from the HTTP signal value, got %q", manager.reasons[0])
+	}
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/signalmice/signalmice/internal/logger"
+	"github.com/signalmice/signalmice/internal/metrics"
+	"github.com/signalmice/signalmice/internal/redis"
+)
+
+// keyEventSubscriber is the part of the Redis client used to follow keyspace notifications
+type keyEventSubscriber interface {
+	KeyEvents(ctx context.Context) (<-chan struct{}, error)
+}
+
+// keyspaceSubscription keeps a keyspace notification subscription alive, re-subscribing with
+// backoff whenever it drops. Every re-subscription emits an event, so the check it triggers
+// catches signal keys set while notifications were missed.
+type keyspaceSubscription struct {
+	subscriber keyEventSubscriber
+	minDelay   time.Duration // Pause before the first re-subscription attempt
+	maxDelay   time.Duration // Ceiling for the doubled pause between failed attempts
+	metrics    *metrics.Registry
+	logger     *logger.Logger
+	events     chan struct{}
+}
+
+// newKeyspaceSubscription creates a subscription retrying after minDelay, backing off up to maxDelay
+func newKeyspaceSubscription(subscriber keyEventSubscriber, minDelay, maxDelay time.Duration, reg *metrics.Registry, appLogger *logger.Logger) *keyspaceSubscription {
+	if maxDelay < minDelay {
+		maxDelay = minDelay
+	}
+	return &keyspaceSubscription{
+		subscriber: subscriber,
+		minDelay:   minDelay,
+		maxDelay:   maxDelay,
+		metrics:    reg,
+		logger:     appLogger,
+		events:     make(chan struct{}, 1),
+	}
+}
+
+// Events emits once per keyspace notification and once per re-subscription, coalescing while
+// a check is pending
+func (s *keyspaceSubscription) Events() <-chan struct{} {
+	return s.events
+}
+
+// run subscribes until ctx is cancelled, re-subscribing whenever the subscription drops
+func (s *keyspaceSubscription) run(ctx context.Context) {
+	delay := s.minDelay
+	first := true
+	for {
+		keyEvents, err := s.subscriber.KeyEvents(ctx)
+		if err != nil {
+			s.metrics.SetKeyspaceSubscribed(false)
+			s.logger.WarnWithExtra(ctx, "Failed to subscribe to keyspace events, retrying", map[string]string{
+				"error":       err.Error(),
+				"error_type":  redis.ErrorType(err),
+				"retry_after": delay.String(),
+			})
+		} else {
+			s.metrics.SetKeyspaceSubscribed(true)
+			if first {
+				s.logger.Info(ctx, "Subscribed to keyspace events")
+			} else {
+				s.logger.Info(ctx, "Re-subscribed to keyspace events, checking for signals missed meanwhile")
+				s.nudge()
+			}
+			delay = s.minDelay
+			for range keyEvents {
+				s.nudge()
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			s.metrics.SetKeyspaceSubscribed(false)
+			s.logger.WarnWithExtra(ctx, "Keyspace event subscription dropped, re-subscribing", map[string]string{"retry_after": delay.String()})
+		}
+		first = false
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, s.maxDelay)
+	}
+}
+
+// nudge asks the monitor for a check, unless one is already pending
+func (s *keyspaceSubscription) nudge() {
+	select {
+	case s.events <- struct{}{}:
+	default:
+	}
+}
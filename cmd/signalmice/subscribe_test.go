@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/signalmice/signalmice/internal/metrics"
+)
+
+// fakeKeyEvents hands out one prepared event channel per subscription, failing when none is left
+type fakeKeyEvents struct {
+	subscriptions chan chan struct{}
+}
+
+func (f *fakeKeyEvents) KeyEvents(context.Context) (<-chan struct{}, error) {
+	select {
+	case events := <-f.subscriptions:
+		return events, nil
+	default:
+		return nil, errors.New("connection refused")
+	}
+}
+
+// waitEvent fails the test unless the subscription emits an event within a second
+func waitEvent(t *testing.T, s *keyspaceSubscription, what string) {
+	t.Helper()
+	select {
+	case <-s.Events():
+	case <-time.After(time.Second):
+		t.Fatalf("expected %s", what)
+	}
+}
+
+// waitSubscribed waits until the registry reports the subscription state want
+func waitSubscribed(t *testing.T, reg *metrics.Registry, want float64) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for reg.KeyspaceSubscribed() != want {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected subscription state %v, got %v", want, reg.KeyspaceSubscribed())
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestKeyspaceSubscription_RecoversFromDrop(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	first := make(chan struct{}, 1)
+	second := make(chan struct{}, 1)
+	subscriber := &fakeKeyEvents{subscriptions: make(chan chan struct{}, 2)}
+	subscriber.subscriptions <- first
+	reg := metrics.NewRegistry()
+
+	subscription := newKeyspaceSubscription(subscriber, time.Millisecond, 4*time.Millisecond, reg, createMockLogger())
+	go subscription.run(ctx)
+
+	waitSubscribed(t, reg, 1)
+	first <- struct{}{}
+	waitEvent(t, subscription, "a notification to nudge the monitor")
+
+	// Drop the subscription; failed attempts back off until a new one is available
+	close(first)
+	waitSubscribed(t, reg, 0)
+	time.Sleep(10 * time.Millisecond)
+	subscriber.subscriptions <- second
+
+	waitSubscribed(t, reg, 1)
+	waitEvent(t, subscription, "re-subscribing to trigger a check for missed signals")
+
+	second <- struct{}{}
+	waitEvent(t, subscription, "notifications to resume after re-subscribing")
+}
+
+func TestKeyspaceSubscription_FirstSubscriptionDoesNotNudge(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	subscriber := &fakeKeyEvents{subscriptions: make(chan chan struct{}, 1)}
+	subscriber.subscriptions <- make(chan struct{})
+	reg := metrics.NewRegistry()
+
+	subscription := newKeyspaceSubscription(subscriber, time.Millisecond, time.Millisecond, reg, createMockLogger())
+	go subscription.run(ctx)
+
+	waitSubscribed(t, reg, 1)
+	select {
+	case <-subscription.Events():
+		t.Error("expected no check from the initial subscription")
+	case <-time.After(20 * time.Millisecond):
+	}
+}
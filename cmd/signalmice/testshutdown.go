@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/signalmice/signalmice/internal/shutdown"
+)
+
+// testShutdownCommand is the subcommand that rehearses the shutdown path without powering off
+const testShutdownCommand = "test-shutdown"
+
+// testShutdownReason is the reason recorded for the rehearsal's dry-run shutdown
+const testShutdownReason = "test-shutdown pre-flight"
+
+// runTestShutdown runs the shutdown sequence in forced dry-run, then prints to out whether each
+// configured method would likely succeed. It returns the process exit code: 0 when the dry run
+// passed and at least one method is ready, 1 otherwise.
+func runTestShutdown(ctx context.Context, manager *shutdown.Manager, out io.Writer) int {
+	ctx = shutdown.WithDryRun(shutdown.WithReason(ctx, testShutdownReason))
+	if _, err := manager.NeutralizeStuartLittle(ctx); err != nil {
+		fmt.Fprintf(out, "Dry-run shutdown failed: %v\n", err)
+		return 1
+	}
+	fmt.Fprintln(out, "Dry-run shutdown passed")
+
+	reports := manager.CheckMethods()
+	ready := 0
+	for _, report := range reports {
+		if !report.Ready() {
+			fmt.Fprintf(out, "  %-16s NOT READY: %s\n", report.Method, strings.Join(report.Problems, "; "))
+			continue
+		}
+		ready++
+
+		var found []string
+		for _, check := range report.Commands {
+			if check.Found() {
+				found = append(found, check.Path)
+			}
+		}
+		if len(found) == 0 {
+			fmt.Fprintf(out, "  %-16s ready\n", report.Method)
+		} else {
+			fmt.Fprintf(out, "  %-16s ready (%s)\n", report.Method, strings.Join(found, ", "))
+		}
+	}
+
+	fmt.Fprintf(out, "%d of %d shutdown methods would likely succeed\n", ready, len(reports))
+	if ready == 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/signalmice/signalmice/internal/config"
+	"github.com/signalmice/signalmice/internal/shutdown"
+)
+
+// newTestShutdownManager creates a manager for the given methods JSON, running commands from a
+// PATH holding only the named fake executables
+func newTestShutdownManager(t *testing.T, cfg *config.Config, executables ...string) *shutdown.Manager {
+	t.Helper()
+	binDir := t.TempDir()
+	for _, name := range executables {
+		if err := os.WriteFile(filepath.Join(binDir, name), []byte("#!/bin/sh\nexit 1\n"), 0755); err != nil {
+			t.Fatalf("failed to create %s: %v", name, err)
+		}
+	}
+	t.Setenv("PATH", binDir)
+
+	manager, err := shutdown.NewManager(cfg, createMockLogger())
+	if err != nil {
+		t.Fatalf("unexpected error creating manager: %v", err)
+	}
+	return manager
+}
+
+func TestRunTestShutdown_Ready(t *testing.T) {
+	hostProc := t.TempDir()
+	if err := os.WriteFile(filepath.Join(hostProc, "sysrq-trigger"), nil, 0200); err != nil {
+		t.Fatalf("failed to create sysrq-trigger: %v", err)
+	}
+	cfg := &config.Config{
+		HostProcPath:        hostProc,
+		ShutdownMethodsJSON: `[{"type":"sysrq"},{"type":"custom","name":"halt","command":"halt-host --now"}]`,
+	}
+	manager := newTestShutdownManager(t, cfg, "halt-host")
+
+	var out bytes.Buffer
+	if code := runTestShutdown(context.Background(), manager, &out); code != 0 {
+		t.Fatalf("expected exit code 0, got %d:\n%s", code, out.String())
+	}
+
+	// The dry run must not have written any sysrq command
+	if data, _ := os.ReadFile(filepath.Join(hostProc, "sysrq-trigger")); len(data) != 0 {
+		t.Errorf("expected sysrq-trigger untouched, got %q", data)
+	}
+
+	report := out.String()
+	for _, want := range []string{"Dry-run shutdown passed", "sysrq-trigger    ready", "halt             ready (", "2 of 2 shutdown methods"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("expected report to contain %q, got:\n%s", want, report)
+		}
+	}
+}
+
+func TestRunTestShutdown_NotReady(t *testing.T) {
+	cfg := &config.Config{
+		HostProcPath:        filepath.Join(t.TempDir(), "missing"),
+		ShutdownMethodsJSON: `[{"type":"sysrq"},{"type":"direct"}]`,
+	}
+	manager := newTestShutdownManager(t, cfg)
+
+	var out bytes.Buffer
+	if code := runTestShutdown(context.Background(), manager, &out); code != 1 {
+		t.Fatalf("expected exit code 1 when no method is ready, got %d", code)
+	}
+
+	report := out.String()
+	for _, want := range []string{"sysrq-trigger    NOT READY: host proc path not mounted", "direct-command   NOT READY: poweroff, reboot, shutdown not found on PATH", "0 of 2 shutdown methods"} {
+		if !strings.Contains(report, want) {
+			t.Errorf("expected report to contain %q, got:\n%s", want, report)
+		}
+	}
+}
+
+func TestRunTestShutdown_PartiallyReady(t *testing.T) {
+	cfg := &config.Config{
+		HostProcPath:        filepath.Join(t.TempDir(), "missing"),
+		ShutdownMethodsJSON: `[{"type":"sysrq"},{"type":"direct"}]`,
+	}
+	manager := newTestShutdownManager(t, cfg, "shutdown")
+
+	var out bytes.Buffer
+	if code := runTestShutdown(context.Background(), manager, &out); code != 0 {
+		t.Fatalf("expected exit code 0 with one ready method, got %d:\n%s", code, out.String())
+	}
+	if !strings.Contains(out.String(), "1 of 2 shutdown methods") {
+		t.Errorf("expected one ready method, got:\n%s", out.String())
+	}
+}
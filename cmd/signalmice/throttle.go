@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// debugThrottle limits a DEBUG line repeated on every check to once per window, while letting
+// it through immediately after the state it reports changed
+type debugThrottle struct {
+	window time.Duration
+	now    func() time.Time
+
+	mu         sync.Mutex
+	last       time.Time // Zero until logged, and again after a state change
+	suppressed int
+}
+
+// newDebugThrottle creates a throttle logging at most once per window
+func newDebugThrottle(window time.Duration) *debugThrottle {
+	return &debugThrottle{window: window, now: time.Now}
+}
+
+// allow reports whether the line may be logged now, and how many were suppressed since the
+// previous one. A nil throttle allows every line.
+func (t *debugThrottle) allow() (bool, int) {
+	if t == nil {
+		return true, 0
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	if !t.last.IsZero() && now.Sub(t.last) < t.window {
+		t.suppressed++
+		return false, 0
+	}
+	suppressed := t.suppressed
+	t.last = now
+	t.suppressed = 0
+	return true, suppressed
+}
+
+// reset lets the next line through immediately, once the state it reports changed
+func (t *debugThrottle) reset() {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.last = time.Time{}
+	t.suppressed = 0
+}
+
+type notFoundThrottleKey struct{}
+
+// withNotFoundThrottle returns a context whose checks throttle the "key not found" DEBUG line
+func withNotFoundThrottle(ctx context.Context, t *debugThrottle) context.Context {
+	return context.WithValue(ctx, notFoundThrottleKey{}, t)
+}
+
+// notFoundThrottle returns the throttle stored in ctx, or nil to log on every check
+func notFoundThrottle(ctx context.Context) *debugThrottle {
+	t, _ := ctx.Value(notFoundThrottleKey{}).(*debugThrottle)
+	return t
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/signalmice/signalmice/internal/config"
+)
+
+func TestDebugThrottle(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	throttle := newDebugThrottle(time.Minute)
+	throttle.now = func() time.Time { return now }
+
+	if ok, _ := throttle.allow(); !ok {
+		t.Fatal("expected the first line to be allowed")
+	}
+	for i := 0; i < 5; i++ {
+		now = now.Add(time.Second)
+		if ok, _ := throttle.allow(); ok {
+			t.Fatal("expected lines within the window to be suppressed")
+		}
+	}
+
+	now = now.Add(time.Minute)
+	if ok, suppressed := throttle.allow(); !ok || suppressed != 5 {
+		t.Errorf("expected a line after the window reporting 5 suppressed, got %v, %d", ok, suppressed)
+	}
+
+	throttle.reset()
+	if ok, suppressed := throttle.allow(); !ok || suppressed != 0 {
+		t.Errorf("expected a line right after a reset, got %v, %d", ok, suppressed)
+	}
+
+	var nilThrottle *debugThrottle
+	nilThrottle.reset()
+	if ok, _ := nilThrottle.allow(); !ok {
+		t.Error("expected a nil throttle to allow every line")
+	}
+}
+
+func TestCheckAndShutdown_ThrottlesNotFoundLog(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	throttle := newDebugThrottle(time.Minute)
+	throttle.now = func() time.Time { return now }
+	ctx := withNotFoundThrottle(context.Background(), throttle)
+
+	cfg := &config.Config{RedisKey: "signalmice:main"}
+	source := &fakeSource{keys: []string{"signalmice:main"}, values: map[string]string{}}
+	manager := &fakeShutdowner{}
+	appLogger := createMockLogger()
+	notFoundLines := func() int { return strings.Count(buf.String(), "Redis key not found") }
+
+	// Rapid no-op checks log the line once
+	for i := 0; i < 10; i++ {
+		checkAndShutdown(ctx, cfg, source, manager, appLogger)
+		now = now.Add(time.Second)
+	}
+	if got := notFoundLines(); got != 1 {
+		t.Errorf("expected 1 not-found line for 10 rapid checks, got %d:\n%s", got, buf.String())
+	}
+
+	// A signal changes the state, so the next not-found line shows immediately
+	source.values["signalmice:main"] = "shutdown"
+	checkAndShutdown(ctx, cfg, source, manager, appLogger)
+	checkAndShutdown(ctx, cfg, source, manager, appLogger)
+	if got := notFoundLines(); got != 2 {
+		t.Errorf("expected the not-found line right after a signal, got %d lines", got)
+	}
+
+	// Once the window elapsed, the line shows again
+	checkAndShutdown(ctx, cfg, source, manager, appLogger)
+	now = now.Add(time.Minute)
+	checkAndShutdown(ctx, cfg, source, manager, appLogger)
+	if got := notFoundLines(); got != 3 {
+		t.Errorf("expected the not-found line after the window, got %d lines", got)
+	}
+}
+
+func TestCheckAndShutdown_UnthrottledNotFoundLog(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	cfg := &config.Config{RedisKey: "signalmice:main"}
+	source := &fakeSource{keys: []string{"signalmice:main"}, values: map[string]string{}}
+	for i := 0; i < 3; i++ {
+		checkAndShutdown(context.Background(), cfg, source, &fakeShutdowner{}, createMockLogger())
+	}
+
+	if got := strings.Count(buf.String(), "Redis key not found"); got != 3 {
+		t.Errorf("expected a not-found line per check without a throttle, got %d", got)
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/signalmice/signalmice/internal/config"
+	"github.com/signalmice/signalmice/internal/logger"
+)
+
+// uptimePaths returns the uptime files tried in order: the host's, then the container's own
+func uptimePaths(cfg *config.Config) []string {
+	return []string{filepath.Join(cfg.HostProcPath, "uptime"), "/proc/uptime"}
+}
+
+// readUptime returns the uptime reported by the first readable file of paths, which hold
+// /proc/uptime's "<uptime seconds> <idle seconds>" format
+func readUptime(paths []string) (time.Duration, error) {
+	var lastErr error
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		fields := strings.Fields(string(data))
+		if len(fields) == 0 {
+			return 0, fmt.Errorf("empty uptime file %s", path)
+		}
+		seconds, err := strconv.ParseFloat(fields[0], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid uptime in %s: %w", path, err)
+		}
+		return time.Duration(seconds * float64(time.Second)), nil
+	}
+	if lastErr == nil {
+		lastErr = errors.New("no uptime file configured")
+	}
+	return 0, fmt.Errorf("failed to read uptime: %w", lastErr)
+}
+
+// hostUpLongEnough reports whether the host has been up for SIGNALMICE_MIN_UPTIME, so a stale
+// signal firing right after boot cannot start a reboot loop. An unreadable uptime allows the
+// shutdown: the guard must not disable signalmice where /proc is unavailable.
+func hostUpLongEnough(ctx context.Context, cfg *config.Config, appLogger *logger.Logger) bool {
+	uptime, err := readUptime(uptimePaths(cfg))
+	if err != nil {
+		appLogger.WarnWithExtra(ctx, "Could not read host uptime, not enforcing minimum uptime", map[string]string{"error": err.Error()})
+		return true
+	}
+	if uptime >= cfg.MinUptime {
+		return true
+	}
+	appLogger.WarnWithExtra(ctx, "Host up for less than the minimum uptime, not shutting down", map[string]string{
+		"uptime":     uptime.Truncate(time.Second).String(),
+		"min_uptime": cfg.MinUptime.String(),
+	})
+	return false
+}
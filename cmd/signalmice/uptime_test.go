@@ -0,0 +1,77 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/signalmice/signalmice/internal/config"
+)
+
+// writeUptime creates a fake host proc directory whose uptime file reports seconds
+func writeUptime(t *testing.T, seconds string) string {
+	t.Helper()
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "uptime"), []byte(seconds+" 1234.56\n"), 0644); err != nil {
+		t.Fatalf("failed to write uptime: %v", err)
+	}
+	return dir
+}
+
+func TestReadUptime(t *testing.T) {
+	dir := writeUptime(t, "90.50")
+
+	uptime, err := readUptime([]string{filepath.Join(t.TempDir(), "missing"), filepath.Join(dir, "uptime")})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if uptime != 90500*time.Millisecond {
+		t.Errorf("expected 90.5s from the first readable file, got %s", uptime)
+	}
+
+	if _, err := readUptime([]string{filepath.Join(t.TempDir(), "missing")}); err == nil {
+		t.Error("expected error when no uptime file is readable")
+	}
+	if _, err := readUptime([]string{filepath.Join(writeUptime(t, "garbage"), "uptime")}); err == nil {
+		t.Error("expected error for an unparseable uptime")
+	}
+}
+
+func TestCheckAndShutdown_BelowMinUptime(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	cfg := &config.Config{RedisKey: "signalmice:main", MinUptime: 10 * time.Minute, HostProcPath: writeUptime(t, "42.00"), DeleteAfterShutdown: true}
+	source := &fakeSource{keys: []string{"signalmice:main"}, values: map[string]string{"signalmice:main": "poweroff"}}
+	manager := &fakeShutdowner{}
+
+	checkAndShutdown(context.Background(), cfg, source, manager, createMockLogger())
+
+	if manager.calls != 0 {
+		t.Errorf("expected no shutdown below the minimum uptime, got %d attempts", manager.calls)
+	}
+	if _, ok := source.values["signalmice:main"]; ok {
+		t.Error("expected the stale signal key to be deleted")
+	}
+	if !strings.Contains(buf.String(), "[WARN] Host up for less than the minimum uptime") {
+		t.Errorf("expected minimum uptime warning, got: %s", buf.String())
+	}
+}
+
+func TestCheckAndShutdown_AboveMinUptime(t *testing.T) {
+	cfg := &config.Config{RedisKey: "signalmice:main", MinUptime: 10 * time.Minute, HostProcPath: writeUptime(t, "3600.00")}
+	source := &fakeSource{keys: []string{"signalmice:main"}, values: map[string]string{"signalmice:main": "poweroff"}}
+	manager := &fakeShutdowner{}
+
+	checkAndShutdown(context.Background(), cfg, source, manager, createMockLogger())
+
+	if manager.calls != 1 {
+		t.Errorf("expected shutdown above the minimum uptime, got %d attempts", manager.calls)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/signalmice/signalmice/internal/logger"
+	"github.com/signalmice/signalmice/internal/shutdown"
+)
+
+// shutdownWatchdog exits the process when the host is still up long after a shutdown was
+// initiated, so a supervisor or orchestrator can step in (e.g. a stuck unmount)
+type shutdownWatchdog struct {
+	timeout      time.Duration
+	flushTimeout time.Duration
+	logger       *logger.Logger
+	exit         func(code int)
+
+	once sync.Once
+}
+
+// newShutdownWatchdog creates a watchdog that fires timeout after it is armed
+func newShutdownWatchdog(timeout, flushTimeout time.Duration, appLogger *logger.Logger) *shutdownWatchdog {
+	return &shutdownWatchdog{
+		timeout:      timeout,
+		flushTimeout: flushTimeout,
+		logger:       appLogger,
+		exit:         os.Exit,
+	}
+}
+
+// arm starts the countdown; later calls are ignored. The countdown survives ctx cancellation,
+// since a host that does not go down must still be reported.
+func (w *shutdownWatchdog) arm(ctx context.Context) {
+	w.once.Do(func() {
+		w.logger.InfoWithExtra(ctx, "Post-shutdown watchdog armed", map[string]string{"timeout": w.timeout.String()})
+		go func() {
+			time.Sleep(w.timeout)
+			w.fire(context.WithoutCancel(ctx))
+		}()
+	})
+}
+
+// fire reports that the shutdown did not take, flushes logs, and exits non-zero
+func (w *shutdownWatchdog) fire(ctx context.Context) {
+	w.logger.ErrorWithExtra(ctx, "Host still running after shutdown was initiated, exiting so a supervisor can intervene", map[string]string{
+		"timeout": w.timeout.String(),
+	})
+
+	flushCtx, cancel := context.WithTimeout(context.Background(), w.flushTimeout)
+	defer cancel()
+	w.logger.Close(flushCtx)
+
+	w.exit(1)
+}
+
+// watchedShutdowner arms the watchdog once a real (non safe mode) shutdown is initiated
+type watchedShutdowner struct {
+	shutdowner
+	watchdog *shutdownWatchdog
+}
+
+func (s *watchedShutdowner) NeutralizeStuartLittle(ctx context.Context) (string, error) {
+	method, err := s.shutdowner.NeutralizeStuartLittle(ctx)
+	if err != nil {
+		return "", err
+	}
+	if !s.SafeMode() && !shutdown.DryRunFromContext(ctx) {
+		s.watchdog.arm(ctx)
+	}
+	return method, nil
+}
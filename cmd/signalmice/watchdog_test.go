@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestWatchdog returns a watchdog whose exit code is delivered on the returned channel
+func newTestWatchdog(timeout time.Duration) (*shutdownWatchdog, chan int) {
+	exits := make(chan int, 1)
+	w := newShutdownWatchdog(timeout, time.Second, createMockLogger())
+	w.exit = func(code int) { exits <- code }
+	return w, exits
+}
+
+func TestWatchdog_FiresWhenShutdownIsNoop(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	watchdog, exits := newTestWatchdog(20 * time.Millisecond)
+	shutdowns := &watchedShutdowner{shutdowner: &fakeShutdowner{}, watchdog: watchdog}
+
+	if _, err := shutdowns.NeutralizeStuartLittle(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	select {
+	case code := <-exits:
+		if code == 0 {
+			t.Error("expected a non-zero exit code")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the watchdog to exit the process")
+	}
+	if !strings.Contains(buf.String(), "[ERROR] Host still running after shutdown was initiated") {
+		t.Errorf("expected a critical log before exiting, got: %s", buf.String())
+	}
+}
+
+func TestWatchdog_NotArmedOnFailedShutdown(t *testing.T) {
+	watchdog, exits := newTestWatchdog(10 * time.Millisecond)
+	shutdowns := &watchedShutdowner{shutdowner: &fakeShutdowner{err: errors.New("all methods failed")}, watchdog: watchdog}
+
+	if _, err := shutdowns.NeutralizeStuartLittle(context.Background()); err == nil {
+		t.Fatal("expected the shutdown error to be returned")
+	}
+
+	select {
+	case <-exits:
+		t.Error("expected the watchdog not to fire after a failed shutdown")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestWatchdog_ArmsOnce(t *testing.T) {
+	watchdog, exits := newTestWatchdog(10 * time.Millisecond)
+	ctx, cancel := context.WithCancel(context.Background())
+
+	watchdog.arm(ctx)
+	watchdog.arm(ctx)
+	cancel() // The countdown must survive the monitor shutting down
+
+	<-exits
+	select {
+	case <-exits:
+		t.Error("expected the watchdog to fire only once")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
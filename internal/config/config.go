@@ -3,61 +3,451 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
 // Config holds all configuration for the application
 type Config struct {
 	// Redis configuration
-	RedisHost     string
-	RedisPort     string
-	RedisPassword string
-	RedisDB       int
+	RedisHost          string
+	RedisPort          string
+	RedisPassword      string
+	RedisDB            int
+	RedisKeepalive     time.Duration // Interval of the ping keeping the Redis connection warm; 0 disables it
+	RedisTLS           bool          // Connect to Redis over TLS
+	RedisTLSMinVersion string        // Oldest TLS version accepted from Redis, "1.0" to "1.3"
 
 	// Opensearch configuration
-	OpensearchURL           string
-	OpensearchUsername      string
-	OpensearchPassword      string
-	OpensearchIndex         string
-	OpensearchUseDailyIndex bool
+	LogBackend               string // Search backend of the Opensearch settings: opensearch or elasticsearch
+	OpensearchURL            string
+	OpensearchUsername       string
+	OpensearchPassword       string
+	OpensearchIndex          string
+	OpensearchUseDailyIndex  bool
+	OpensearchCAFile         string        // PEM CA bundle used to verify the Opensearch server
+	OpensearchClientCertFile string        // PEM client certificate for mutual TLS
+	OpensearchClientKeyFile  string        // PEM client private key for mutual TLS
+	OpensearchTLSMinVersion  string        // Oldest TLS version accepted from Opensearch, "1.0" to "1.3"
+	OpensearchCreateTemplate bool          // Install an index template with mappings for known fields at startup
+	OpensearchSpillFile      string        // JSON lines file receiving log entries that overflow the queue
+	OpensearchMaxDocBytes    int           // Maximum indexed document size; larger entries lose their extra field
+	OpensearchRedactErrors   bool          // Log only the error type of Opensearch error responses, not the reason
+	OpensearchPipeline       string        // Ingest pipeline log documents are routed through; empty uses none
+	OpensearchRefresh        string        // Refresh policy of signal flow documents: false, true or wait_for
+	OpensearchRefreshAll     bool          // Apply OpensearchRefresh to every log document, not just the signal flow
+	OpensearchRouting        string        // Entry field whose value routes documents to a shard; empty uses none
+	OpensearchSendTimeout    time.Duration // Budget of sending one log entry, retries included; 0 leaves it unbounded
+
+	// Syslog configuration
+	Syslog        bool   // Also write log entries to syslog
+	SyslogNetwork string // "udp", "tcp", "unix" or "unixgram"; empty targets the local syslog socket
+	SyslogAddress string // Remote syslog address, or the local socket path to use instead of /dev/log
 
 	// Application configuration
-	RedisKey      string
-	CheckInterval time.Duration
+	Hostname            string // Name of this host in logs, keys, traces and commands
+	HostnameSource      string // One of the HostnameSource values
+	RedisKey            string
+	KeyTemplate         string        // Template, e.g. "signalmice:host:{hostname}", rendered into RedisKey at startup
+	KeyPrefix           string        // Optional namespace prepended to all Redis keys
+	KeyActions          []KeyAction   // Additional signal keys, each mapped to a shutdown action
+	KeyPattern          string        // Glob whose matching keys, found with SCAN, are checked besides the signal keys
+	ScanCount           int           // COUNT hint of each SCAN call in pattern mode; 0 uses the server default
+	MaxKeysPerCycle     int           // Matched keys checked per cycle, resuming from the SCAN cursor next cycle; 0 is unbounded
+	KeyFile             string        // File whose contents are the active signal key, re-read at runtime
+	KeyFileInterval     time.Duration // How often KeyFile is re-read
+	CheckInterval       time.Duration
+	CheckRetries        int           // In-cycle retries of a check that fails with a transient Redis error
+	CheckCommand        string        // Redis command used to read signal keys: get, exists or getdel
+	KeyConcurrency      int           // Signal keys checked in parallel each cycle; 1 checks them in order
+	UseUnlink           bool          // Delete collected signal keys with UNLINK instead of DEL
+	Backoff             bool          // Double the check interval on consecutive Redis connection errors
+	MaxInterval         time.Duration // Ceiling for the backed-off check interval
+	Subscribe           bool          // Also check on Redis keyspace notifications for the signal keys
+	SubscribeDebounce   time.Duration // Quiet window coalescing bursts of keyspace notifications
+	SubscribeRetry      time.Duration // Pause before re-subscribing to dropped keyspace notifications
+	SubscribeMaxRetry   time.Duration // Ceiling for the doubled pause between failed re-subscriptions
+	Channel             string        // Redis pub/sub channel whose messages are signals; empty disables it
+	Mode                string        // Trigger mode: ModeSignal, ModeDeadmans or ModeSemaphore
+	DeadmansGrace       time.Duration // Startup grace period before a missing key triggers in deadmans mode
+	SkipInitialCheck    bool          // Wait for the first tick instead of checking immediately at startup
+	StartupDelay        time.Duration // Warmup before monitoring starts; no check runs until it has elapsed
+	IgnoreExisting      bool          // Clear signal keys already present at startup without acting on them
+	MinUptime           time.Duration // Host uptime required before a signal may shut it down; 0 disables the check
+	SelfTest            bool          // Verify SET/GET/DEL permissions with a probe key at startup
+	VerifyDelete        bool          // Confirm a consumed signal key stays deleted before shutting down
+	DeleteAfterShutdown bool          // Delete the signal key only once a shutdown method succeeds
+	SigningKey          string        // Shared HMAC key; when set, signal values must be <payload>.<hmac> tokens
+	SignalJSON          bool          // Parse signal values as JSON {action, reason, delay, allowed_hosts, expires_at} instructions
+	SignalRequireExpiry bool          // Refuse JSON signals without a future expires_at
+	QuietBanner         bool          // Suppress the plain-text startup banner (the structured event is still emitted)
+	LogTimeFormat       string        // Layout for stdout log timestamps
+	LogSchema           string        // Field naming of indexed log documents: default or ecs
+	LogFormat           string        // Line format of stdout and syslog entries: text or cef
+	LogFields           string        // JSON object of static fields added to every log entry's extra
+	LogRedisTarget      bool          // Add redis_addr and redis_db to every log entry's extra
+	LogFlushTimeout     time.Duration // Maximum time spent flushing queued logs on exit
+	StdoutBufferSize    int           // Bytes of stdout log lines buffered before a write; 0 writes each line
+	StdoutFlushInterval time.Duration // Interval at which buffered stdout lines are written
+	LogRingSize         int           // Latest log entries kept in memory for /status?logs=N; 0 keeps none
+	MetricsAddr         string        // Listen address for the /metrics endpoint; empty disables it
+	SimulateToken       string        // Bearer token enabling POST /simulate on the metrics server; empty disables it
+	AliveLogInterval    time.Duration // Cadence of the "still alive" INFO log; 0 disables it
+	NotFoundLogInterval time.Duration // Minimum time between "key not found" DEBUG lines; 0 logs every check
+	ArmDelay            time.Duration // How long a signal key must stay present across checks before it is acted on; 0 acts at once
+	Required            []string      // Dependencies whose failure at startup is fatal; others degrade
+
+	HostnameFile         string        // File whose contents are the hostname, taking precedence over detection
+	HostnameFileInterval time.Duration // How often HostnameFile is re-read; 0 reads it once at startup
+
+	RequireLoggingBeforeCheck bool          // Hold the first check until the logger is connected to Opensearch
+	LoggingReadyTimeout       time.Duration // How long to wait for Opensearch before giving up and exiting
+
+	// HTTP source configuration
+	HTTPSourceURL     string        // Secondary signal source consulted when Redis is unreachable; empty disables it
+	HTTPSourceTimeout time.Duration // Timeout of a single HTTP source request
+
+	// Consul source configuration
+	ConsulAddr    string        // Consul agent address, with or without scheme
+	ConsulKey     string        // Consul KV key consulted after Redis; empty disables the Consul source
+	ConsulToken   string        // ACL token sent as X-Consul-Token; empty sends none
+	ConsulDelete  bool          // Delete the Consul key once it was read as a signal
+	ConsulWatch   bool          // Also check as soon as the key changes, with a blocking query
+	ConsulTimeout time.Duration // Timeout of a single Consul request
+
+	// etcd source configuration
+	EtcdEndpoints []string      // etcd v3 gateway endpoints, tried in order
+	EtcdKey       string        // etcd key whose puts are signals; empty disables the etcd source
+	EtcdDelete    bool          // Delete the etcd key once it was read as a signal
+	EtcdTimeout   time.Duration // Timeout of a single etcd request
+	EtcdUsername  string        // etcd user to authenticate as; empty sends requests unauthenticated
+	EtcdPassword  string        // Password of EtcdUsername
+	EtcdCAFile    string        // PEM CA bundle used to verify https endpoints instead of the system roots
+	EtcdCertFile  string        // PEM client certificate for mutual TLS
+	EtcdKeyFile   string        // PEM client private key for mutual TLS
+
+	// Leader election configuration
+	LeaderElection      bool          // Only the instance holding the leader lock acts on signals
+	LeaderKeyName       string        // Redis key used as the leader lock (prefixed)
+	LeaderTTL           time.Duration // Leader lock TTL
+	LeaderRenewFraction float64       // Fraction of the TTL after which the lock is renewed (jittered)
+
+	// Kill switch configuration
+	KillSwitchKeyName  string // Redis key that, while set, disarms signalmice fleet-wide (prefixed); empty disables it
+	KillSwitchDelete   bool   // Keep deleting signal keys while disarmed so they do not pile up
+	KillSwitchFailOpen bool   // Act on signals while the kill switch key cannot be read
+
+	// Webhook configuration
+	WebhookURL         string            // Endpoint notified with a JSON POST before shutdown; empty disables it
+	WebhookTimeout     time.Duration     // Timeout of a single webhook attempt
+	WebhookRetries     int               // Retries after a failed webhook attempt
+	WebhookMaxDuration time.Duration     // Cap on the total time spent on the webhook, retries included
+	WebhookHeaders     map[string]string // Extra request headers, e.g. for auth tokens
+	WebhooksJSON       string            // JSON array of additional webhooks with their own method, headers, body template and timeout
+	PostNotifyURL      string            // Endpoint notified right before the terminal shutdown command runs; empty disables it
+	PostNotifyTimeout  time.Duration     // Timeout of the single post-notification attempt
+
+	// Tracing configuration
+	OTLPEndpoint string            // OTLP/HTTP collector receiving shutdown flow traces; empty disables tracing
+	OTLPHeaders  map[string]string // Extra request headers for the collector, e.g. for auth tokens
 
 	// Host configuration
 	HostProcPath string // Path to host's /proc for shutdown
+
+	// Shutdown configuration
+	ShutdownMethodsJSON   string        // Ordered JSON array of shutdown methods; empty uses built-in order
+	ShutdownDeadline      time.Duration // Overall budget for the shutdown sequence; 0 disables it
+	SafeMode              bool          // Run the full pipeline but skip the actual poweroff
+	MinShutdownInterval   time.Duration // Minimum time between shutdown attempts; 0 disables it
+	ShutdownRetryCooldown time.Duration // Time after a failed shutdown before another attempt; 0 disables it
+	PostShutdownWatchdog  time.Duration // Exit non-zero if still running this long after shutdown was initiated; 0 disables it
+	ExitAfterShutdown     bool          // Stop monitoring once a shutdown was initiated, waiting for the host to go down
+	ShutdownReason        string        // Reason recorded for a shutdown when the signal value carries none
+	ShutdownWhen          string        // Time argument for the direct shutdown command, e.g. "now" or "+1"
+	ParallelMethods       bool          // Run shutdown methods concurrently; the first success cancels the rest
+	ExecInheritEnv        bool          // Pass the full environment to shutdown commands, not just the SIGNALMICE_* set
+	SysrqSyncCount        int           // Sync writes to sysrq-trigger before remount and poweroff
+	SysrqSyncDelay        time.Duration // Pause after each sysrq sync write
+	NoHostAccess          string        // When neither nsenter nor sysrq can reach the host: fail or attempt
+
+	renamedHostname string // Set by SetHostname, guarded by hostnameMu; empty keeps Hostname
+}
+
+// KeyAction maps a signal key to the shutdown action it triggers
+type KeyAction struct {
+	Key    string
+	Action string
 }
 
+// Trigger modes
+const (
+	// ModeSignal shuts down when the signal key appears (and deletes it)
+	ModeSignal = "signal"
+	// ModeDeadmans shuts down when the signal key is missing (the key is never deleted)
+	ModeDeadmans = "deadmans"
+	// ModeSemaphore shuts down when a slot is claimed by decrementing the integer budget in the signal key
+	ModeSemaphore = "semaphore"
+)
+
+// Startup dependencies that can be listed in SIGNALMICE_REQUIRED
+const (
+	DependencyRedis      = "redis"
+	DependencyOpensearch = "opensearch"
+)
+
 // DefaultRedisKey is the default key to check in Redis
 const DefaultRedisKey = "signalmice:00000000-0000-0000-0000-000000000000"
 
+// DefaultShutdownReason is the reason recorded when neither the signal nor the config provides one
+const DefaultShutdownReason = "signalmice signal received"
+
+// DefaultLeaderKey is the default Redis key used as the leader lock
+const DefaultLeaderKey = "signalmice:leader"
+
+// DefaultKillSwitchKey is the default Redis key that disarms signalmice while set
+const DefaultKillSwitchKey = "signalmice:disabled"
+
 // Load loads configuration from environment variables
 func Load() *Config {
 	checkInterval, _ := strconv.Atoi(getEnv("SIGNALMICE_CHECK_INTERVAL", "60"))
 	redisDB, _ := strconv.Atoi(getEnv("REDIS_DB", "0"))
+	checkRetries, _ := strconv.Atoi(getEnv("SIGNALMICE_CHECK_RETRIES", "0"))
+	keyConcurrency, _ := strconv.Atoi(getEnv("SIGNALMICE_KEY_CONCURRENCY", "1"))
+	scanCount, _ := strconv.Atoi(getEnv("SIGNALMICE_SCAN_COUNT", "100"))
+	maxKeysPerCycle, _ := strconv.Atoi(getEnv("SIGNALMICE_MAX_KEYS_PER_CYCLE", "1000"))
+	sysrqSyncCount, _ := strconv.Atoi(getEnv("SIGNALMICE_SYSRQ_SYNC_COUNT", "1"))
+	webhookRetries, _ := strconv.Atoi(getEnv("SIGNALMICE_WEBHOOK_RETRIES", "2"))
+	opensearchMaxDocBytes, _ := strconv.Atoi(getEnv("OPENSEARCH_MAX_DOC_BYTES", "1048576"))
+	stdoutBufferSize, _ := strconv.Atoi(getEnv("SIGNALMICE_STDOUT_BUFFER", "0"))
+	logRingSize, _ := strconv.Atoi(getEnv("SIGNALMICE_LOG_RING_SIZE", "0"))
+
+	hostnameFile := getEnv("SIGNALMICE_HOSTNAME_FILE", "")
+	hostname, hostnameSource := resolveHostname(os.Hostname, getEnv("SIGNALMICE_HOSTNAME", ""))
+	if hostnameFile != "" {
+		if name, err := ReadHostnameFile(hostnameFile); err == nil {
+			hostname, hostnameSource = name, HostnameSourceFile
+		}
+	}
+
+	// A key template gives each host its own key and takes precedence over SIGNALMICE_KEY
+	redisKey := getEnv("SIGNALMICE_KEY", DefaultRedisKey)
+	keyTemplate := getEnv("SIGNALMICE_KEY_TEMPLATE", "")
+	if keyTemplate != "" {
+		redisKey = RenderKeyTemplate(keyTemplate, hostname)
+	}
 
 	return &Config{
 		// Redis
-		RedisHost:     getEnv("REDIS_HOST", "localhost"),
-		RedisPort:     getEnv("REDIS_PORT", "6379"),
-		RedisPassword: getEnv("REDIS_PASSWORD", ""),
-		RedisDB:       redisDB,
+		RedisHost:          getEnv("REDIS_HOST", "localhost"),
+		RedisPort:          getEnv("REDIS_PORT", "6379"),
+		RedisPassword:      getEnv("REDIS_PASSWORD", ""),
+		RedisDB:            redisDB,
+		RedisKeepalive:     getEnvDuration("SIGNALMICE_REDIS_KEEPALIVE", 0),
+		RedisTLS:           getEnvBool("REDIS_TLS", false),
+		RedisTLSMinVersion: getEnv("REDIS_TLS_MIN_VERSION", DefaultTLSMinVersion),
 
 		// Opensearch
-		OpensearchURL:           getEnv("OPENSEARCH_URL", "http://localhost:9200"),
-		OpensearchUsername:      getEnv("OPENSEARCH_USERNAME", ""),
-		OpensearchPassword:      getEnv("OPENSEARCH_PASSWORD", ""),
-		OpensearchIndex:         getEnv("OPENSEARCH_INDEX", "signalmice-logs"),
-		OpensearchUseDailyIndex: getEnvBool("OPENSEARCH_USE_DAILY_INDEX", true),
+		LogBackend:               strings.ToLower(getEnv("LOG_BACKEND", "opensearch")),
+		OpensearchURL:            getEnv("OPENSEARCH_URL", "http://localhost:9200"),
+		OpensearchUsername:       getEnv("OPENSEARCH_USERNAME", ""),
+		OpensearchPassword:       getEnv("OPENSEARCH_PASSWORD", ""),
+		OpensearchIndex:          getEnv("OPENSEARCH_INDEX", "signalmice-logs"),
+		OpensearchUseDailyIndex:  getEnvBool("OPENSEARCH_USE_DAILY_INDEX", true),
+		OpensearchCAFile:         getEnv("OPENSEARCH_CA_FILE", ""),
+		OpensearchClientCertFile: getEnv("OPENSEARCH_CLIENT_CERT_FILE", ""),
+		OpensearchClientKeyFile:  getEnv("OPENSEARCH_CLIENT_KEY_FILE", ""),
+		OpensearchTLSMinVersion:  getEnv("OPENSEARCH_TLS_MIN_VERSION", DefaultTLSMinVersion),
+		OpensearchCreateTemplate: getEnvBool("OPENSEARCH_CREATE_INDEX_TEMPLATE", false),
+		OpensearchSpillFile:      getEnv("OPENSEARCH_SPILL_FILE", ""),
+		OpensearchMaxDocBytes:    opensearchMaxDocBytes,
+		OpensearchRedactErrors:   getEnvBool("OPENSEARCH_REDACT_ERROR_BODY", false),
+		OpensearchPipeline:       getEnv("OPENSEARCH_PIPELINE", ""),
+		OpensearchRefresh:        strings.ToLower(getEnv("OPENSEARCH_REFRESH", "false")),
+		OpensearchRefreshAll:     getEnvBool("OPENSEARCH_REFRESH_ALL_LOGS", false),
+		OpensearchRouting:        strings.ToLower(getEnv("OPENSEARCH_ROUTING", "")),
+		OpensearchSendTimeout:    getEnvDuration("OPENSEARCH_SEND_TIMEOUT", 30*time.Second),
+
+		// Syslog
+		Syslog:        getEnvBool("SIGNALMICE_SYSLOG", false),
+		SyslogNetwork: getEnv("SIGNALMICE_SYSLOG_NETWORK", ""),
+		SyslogAddress: getEnv("SIGNALMICE_SYSLOG_ADDRESS", ""),
 
 		// Application
-		RedisKey:      getEnv("SIGNALMICE_KEY", DefaultRedisKey),
-		CheckInterval: time.Duration(checkInterval) * time.Second,
+		Hostname:            hostname,
+		HostnameSource:      hostnameSource,
+		RedisKey:            redisKey,
+		KeyTemplate:         keyTemplate,
+		KeyPrefix:           getEnv("SIGNALMICE_KEY_PREFIX", ""),
+		KeyActions:          parseKeyActions(getEnv("SIGNALMICE_KEY_ACTIONS", "")),
+		KeyPattern:          getEnv("SIGNALMICE_KEY_PATTERN", ""),
+		ScanCount:           scanCount,
+		MaxKeysPerCycle:     maxKeysPerCycle,
+		KeyFile:             getEnv("SIGNALMICE_KEY_FILE", ""),
+		KeyFileInterval:     getEnvDuration("SIGNALMICE_KEY_FILE_INTERVAL", 10*time.Second),
+		CheckInterval:       time.Duration(checkInterval) * time.Second,
+		Backoff:             getEnvBool("SIGNALMICE_BACKOFF", false),
+		MaxInterval:         getEnvDuration("SIGNALMICE_MAX_INTERVAL", 10*time.Minute),
+		CheckRetries:        checkRetries,
+		CheckCommand:        strings.ToLower(getEnv("SIGNALMICE_CHECK_COMMAND", "get")),
+		KeyConcurrency:      keyConcurrency,
+		UseUnlink:           getEnvBool("SIGNALMICE_USE_UNLINK", false),
+		Subscribe:           getEnvBool("SIGNALMICE_SUBSCRIBE", false),
+		SubscribeDebounce:   getEnvDuration("SIGNALMICE_SUBSCRIBE_DEBOUNCE", 250*time.Millisecond),
+		SubscribeRetry:      getEnvDuration("SIGNALMICE_SUBSCRIBE_RETRY", time.Second),
+		SubscribeMaxRetry:   getEnvDuration("SIGNALMICE_SUBSCRIBE_MAX_RETRY", 30*time.Second),
+		Channel:             getEnv("SIGNALMICE_CHANNEL", ""),
+		Mode:                getEnv("SIGNALMICE_MODE", ModeSignal),
+		DeadmansGrace:       getEnvDuration("SIGNALMICE_DEADMANS_GRACE", 5*time.Minute),
+		SkipInitialCheck:    getEnvBool("SIGNALMICE_SKIP_INITIAL_CHECK", false),
+		StartupDelay:        getEnvDuration("SIGNALMICE_STARTUP_DELAY", 0),
+		IgnoreExisting:      getEnvBool("SIGNALMICE_IGNORE_EXISTING_AT_START", false),
+		MinUptime:           getEnvDuration("SIGNALMICE_MIN_UPTIME", 0),
+		SelfTest:            getEnvBool("SIGNALMICE_SELFTEST", false),
+		VerifyDelete:        getEnvBool("SIGNALMICE_VERIFY_DELETE", false),
+		DeleteAfterShutdown: getEnvBool("SIGNALMICE_DELETE_AFTER_SHUTDOWN", false),
+		SigningKey:          getEnv("SIGNALMICE_SIGNING_KEY", ""),
+		SignalJSON:          getEnvBool("SIGNALMICE_SIGNAL_JSON", false),
+		SignalRequireExpiry: getEnvBool("SIGNALMICE_SIGNAL_REQUIRE_EXPIRY", false),
+		QuietBanner:         getEnvBool("SIGNALMICE_QUIET_BANNER", false),
+		LogTimeFormat:       getEnv("SIGNALMICE_LOG_TIME_FORMAT", time.RFC3339),
+		LogSchema:           strings.ToLower(getEnv("SIGNALMICE_LOG_SCHEMA", "default")),
+		LogFormat:           strings.ToLower(getEnv("SIGNALMICE_LOG_FORMAT", "text")),
+		LogFields:           getEnv("SIGNALMICE_LOG_FIELDS", ""),
+		LogRedisTarget:      getEnvBool("SIGNALMICE_LOG_REDIS_TARGET", false),
+		LogFlushTimeout:     getEnvDuration("SIGNALMICE_LOG_FLUSH_TIMEOUT", 5*time.Second),
+		StdoutBufferSize:    stdoutBufferSize,
+		StdoutFlushInterval: getEnvDuration("SIGNALMICE_STDOUT_FLUSH_INTERVAL", time.Second),
+		LogRingSize:         logRingSize,
+		MetricsAddr:         getEnv("SIGNALMICE_METRICS_ADDR", ""),
+		SimulateToken:       getEnv("SIGNALMICE_SIMULATE_TOKEN", ""),
+		AliveLogInterval:    getEnvDuration("SIGNALMICE_ALIVE_LOG_INTERVAL", 0),
+		NotFoundLogInterval: getEnvDuration("SIGNALMICE_NOT_FOUND_LOG_INTERVAL", 0),
+		ArmDelay:            getEnvDuration("SIGNALMICE_ARM_DELAY", 0),
+		Required:            parseList(getEnv("SIGNALMICE_REQUIRED", DependencyRedis)),
+
+		HostnameFile:         hostnameFile,
+		HostnameFileInterval: getEnvDuration("SIGNALMICE_HOSTNAME_FILE_INTERVAL", 0),
+
+		RequireLoggingBeforeCheck: getEnvBool("SIGNALMICE_REQUIRE_LOGGING_BEFORE_CHECK", false),
+		LoggingReadyTimeout:       getEnvDuration("SIGNALMICE_LOGGING_READY_TIMEOUT", 2*time.Minute),
+
+		// HTTP source
+		HTTPSourceURL:     getEnv("SIGNALMICE_HTTP_SOURCE_URL", ""),
+		HTTPSourceTimeout: getEnvDuration("SIGNALMICE_HTTP_SOURCE_TIMEOUT", 5*time.Second),
+
+		// Consul source
+		ConsulAddr:    getEnv("SIGNALMICE_CONSUL_ADDR", "127.0.0.1:8500"),
+		ConsulKey:     getEnv("SIGNALMICE_CONSUL_KEY", ""),
+		ConsulToken:   getEnv("SIGNALMICE_CONSUL_TOKEN", ""),
+		ConsulDelete:  getEnvBool("SIGNALMICE_CONSUL_DELETE", true),
+		ConsulWatch:   getEnvBool("SIGNALMICE_CONSUL_WATCH", false),
+		ConsulTimeout: getEnvDuration("SIGNALMICE_CONSUL_TIMEOUT", 5*time.Second),
+
+		// etcd source
+		EtcdEndpoints: parseList(getEnv("SIGNALMICE_ETCD_ENDPOINTS", "127.0.0.1:2379")),
+		EtcdKey:       getEnv("SIGNALMICE_ETCD_KEY", ""),
+		EtcdDelete:    getEnvBool("SIGNALMICE_ETCD_DELETE", true),
+		EtcdTimeout:   getEnvDuration("SIGNALMICE_ETCD_TIMEOUT", 5*time.Second),
+		EtcdUsername:  getEnv("SIGNALMICE_ETCD_USERNAME", ""),
+		EtcdPassword:  getEnv("SIGNALMICE_ETCD_PASSWORD", ""),
+		EtcdCAFile:    getEnv("SIGNALMICE_ETCD_CA_FILE", ""),
+		EtcdCertFile:  getEnv("SIGNALMICE_ETCD_CERT_FILE", ""),
+		EtcdKeyFile:   getEnv("SIGNALMICE_ETCD_KEY_FILE", ""),
+
+		// Leader election
+		LeaderElection:      getEnvBool("SIGNALMICE_LEADER_ELECTION", false),
+		LeaderKeyName:       getEnv("SIGNALMICE_LEADER_KEY", DefaultLeaderKey),
+		LeaderTTL:           getEnvDuration("SIGNALMICE_LEADER_TTL", 30*time.Second),
+		LeaderRenewFraction: getEnvFloat("SIGNALMICE_LEADER_RENEW_FRACTION", 0.5),
+
+		// Kill switch
+		KillSwitchKeyName:  getEnv("SIGNALMICE_KILLSWITCH_KEY", DefaultKillSwitchKey),
+		KillSwitchDelete:   getEnvBool("SIGNALMICE_KILLSWITCH_DELETE", true),
+		KillSwitchFailOpen: getEnvBool("SIGNALMICE_KILLSWITCH_FAIL_OPEN", false),
+
+		// Webhook
+		WebhookURL:         getEnv("SIGNALMICE_WEBHOOK_URL", ""),
+		WebhookTimeout:     getEnvDuration("SIGNALMICE_WEBHOOK_TIMEOUT", 2*time.Second),
+		WebhookRetries:     webhookRetries,
+		WebhookMaxDuration: getEnvDuration("SIGNALMICE_WEBHOOK_MAX_DURATION", 5*time.Second),
+		WebhookHeaders:     parseHeaders(getEnv("SIGNALMICE_WEBHOOK_HEADERS", "")),
+		WebhooksJSON:       getEnv("SIGNALMICE_WEBHOOKS_JSON", ""),
+		PostNotifyURL:      getEnv("SIGNALMICE_POST_NOTIFY_URL", ""),
+		PostNotifyTimeout:  getEnvDuration("SIGNALMICE_POST_NOTIFY_TIMEOUT", 500*time.Millisecond),
+
+		// Tracing
+		OTLPEndpoint: getEnv("SIGNALMICE_OTLP_ENDPOINT", ""),
+		OTLPHeaders:  parseHeaders(getEnv("SIGNALMICE_OTLP_HEADERS", "")),
 
 		// Host
 		HostProcPath: getEnv("HOST_PROC_PATH", "/host/proc"),
+
+		// Shutdown
+		ShutdownMethodsJSON:   getEnv("SIGNALMICE_METHODS_JSON", ""),
+		ShutdownDeadline:      getEnvDuration("SIGNALMICE_SHUTDOWN_DEADLINE", 0),
+		SafeMode:              getEnvBool("SIGNALMICE_SAFE_MODE", false),
+		MinShutdownInterval:   getEnvDuration("SIGNALMICE_MIN_SHUTDOWN_INTERVAL", 0),
+		ShutdownRetryCooldown: getEnvDuration("SIGNALMICE_SHUTDOWN_RETRY_COOLDOWN", 0),
+		PostShutdownWatchdog:  getEnvDuration("SIGNALMICE_POST_SHUTDOWN_WATCHDOG", 0),
+		ExitAfterShutdown:     getEnvBool("SIGNALMICE_EXIT_AFTER_SHUTDOWN", false),
+		ShutdownReason:        getEnv("SIGNALMICE_SHUTDOWN_REASON", DefaultShutdownReason),
+		ShutdownWhen:          getEnv("SIGNALMICE_SHUTDOWN_WHEN", "now"),
+		ParallelMethods:       getEnvBool("SIGNALMICE_PARALLEL_METHODS", false),
+		ExecInheritEnv:        getEnvBool("SIGNALMICE_EXEC_INHERIT_ENV", false),
+		SysrqSyncCount:        sysrqSyncCount,
+		SysrqSyncDelay:        getEnvDuration("SIGNALMICE_SYSRQ_SYNC_DELAY", 0),
+		NoHostAccess:          strings.ToLower(getEnv("SIGNALMICE_NO_HOST_ACCESS", "attempt")),
+	}
+}
+
+// RenderKeyTemplate replaces the {hostname} placeholder in template with hostname
+func RenderKeyTemplate(template, hostname string) string {
+	return strings.ReplaceAll(template, "{hostname}", hostname)
+}
+
+// parseList parses a comma-separated list, trimming and lowercasing entries. Empty entries are skipped.
+func parseList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.ToLower(strings.TrimSpace(item)); item != "" {
+			items = append(items, item)
+		}
 	}
+	return items
+}
+
+// parseKeyActions parses a comma-separated list of key=action pairs, preserving order.
+// Malformed entries are skipped.
+func parseKeyActions(value string) []KeyAction {
+	var actions []KeyAction
+	for _, pair := range strings.Split(value, ",") {
+		idx := strings.LastIndex(pair, "=")
+		if idx <= 0 {
+			continue
+		}
+		key := strings.TrimSpace(pair[:idx])
+		action := strings.TrimSpace(pair[idx+1:])
+		if key == "" || action == "" {
+			continue
+		}
+		actions = append(actions, KeyAction{Key: key, Action: action})
+	}
+	return actions
+}
+
+// parseHeaders parses a comma-separated list of Name=Value headers. Values may contain "=".
+// Malformed entries are skipped.
+func parseHeaders(value string) map[string]string {
+	headers := map[string]string{}
+	for _, pair := range strings.Split(value, ",") {
+		name, val, ok := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			continue
+		}
+		headers[name] = strings.TrimSpace(val)
+	}
+	return headers
 }
 
 // getEnv returns the value of an environment variable or a default value
@@ -76,7 +466,111 @@ func getEnvBool(key string, defaultValue bool) bool {
 	return defaultValue
 }
 
+// getEnvFloat returns the float value of an environment variable or a default value
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value, exists := os.LookupEnv(key); exists {
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+	return defaultValue
+}
+
+// getEnvDuration returns the duration value of an environment variable or a default value.
+// Plain integers are interpreted as seconds; Go duration strings (e.g. "1m30s") are also accepted.
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value, exists := os.LookupEnv(key)
+	if !exists {
+		return defaultValue
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if duration, err := time.ParseDuration(value); err == nil {
+		return duration
+	}
+	return defaultValue
+}
+
 // RedisAddr returns the Redis address in host:port format
 func (c *Config) RedisAddr() string {
 	return c.RedisHost + ":" + c.RedisPort
 }
+
+// PrefixKey returns the given key namespaced with the configured key prefix.
+// When no prefix is set, the key is returned unchanged.
+func (c *Config) PrefixKey(key string) string {
+	if c.KeyPrefix == "" {
+		return key
+	}
+	if strings.HasSuffix(c.KeyPrefix, ":") {
+		return c.KeyPrefix + key
+	}
+	return c.KeyPrefix + ":" + key
+}
+
+// SignalKey returns the fully-qualified Redis key to monitor for the shutdown signal
+func (c *Config) SignalKey() string {
+	return c.PrefixKey(c.RedisKey)
+}
+
+// LeaderKey returns the fully-qualified Redis key used as the leader lock
+func (c *Config) LeaderKey() string {
+	return c.PrefixKey(c.LeaderKeyName)
+}
+
+// KillSwitchKey returns the fully-qualified kill switch key, or "" when the kill switch is disabled
+func (c *Config) KillSwitchKey() string {
+	if c.KillSwitchKeyName == "" {
+		return ""
+	}
+	return c.PrefixKey(c.KillSwitchKeyName)
+}
+
+// SelfTestKey returns the fully-qualified probe key used by the startup self-test
+func (c *Config) SelfTestKey(hostname string) string {
+	return c.PrefixKey("signalmice:selftest:" + hostname)
+}
+
+// SignalKeys returns all fully-qualified signal keys: the main signal key followed by
+// any keys from KeyActions, without duplicates
+func (c *Config) SignalKeys() []string {
+	keys := []string{c.SignalKey()}
+	seen := map[string]bool{keys[0]: true}
+	for _, ka := range c.KeyActions {
+		key := c.PrefixKey(ka.Key)
+		if !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// SignalKeyPattern returns the fully-qualified glob of pattern mode, or "" when it is disabled
+func (c *Config) SignalKeyPattern() string {
+	if c.KeyPattern == "" {
+		return ""
+	}
+	return c.PrefixKey(c.KeyPattern)
+}
+
+// Requires reports whether dependency is listed in SIGNALMICE_REQUIRED
+func (c *Config) Requires(dependency string) bool {
+	for _, required := range c.Required {
+		if required == dependency {
+			return true
+		}
+	}
+	return false
+}
+
+// ActionForKey returns the configured action for a fully-qualified signal key, if any
+func (c *Config) ActionForKey(key string) (string, bool) {
+	for _, ka := range c.KeyActions {
+		if c.PrefixKey(ka.Key) == key {
+			return ka.Action, true
+		}
+	}
+	return "", false
+}
@@ -11,8 +11,11 @@ func TestLoad_Defaults(t *testing.T) {
 	envVars := []string{
 		"REDIS_HOST", "REDIS_PORT", "REDIS_PASSWORD", "REDIS_DB",
 		"OPENSEARCH_URL", "OPENSEARCH_USERNAME", "OPENSEARCH_PASSWORD", "OPENSEARCH_INDEX",
-		"OPENSEARCH_USE_DAILY_INDEX",
-		"SIGNALMICE_KEY", "SIGNALMICE_CHECK_INTERVAL", "HOST_PROC_PATH",
+		"OPENSEARCH_USE_DAILY_INDEX", "OPENSEARCH_CA_FILE", "OPENSEARCH_CLIENT_CERT_FILE", "OPENSEARCH_CLIENT_KEY_FILE",
+		"OPENSEARCH_CREATE_INDEX_TEMPLATE",
+		"SIGNALMICE_KEY", "SIGNALMICE_KEY_PREFIX", "SIGNALMICE_KEY_ACTIONS", "SIGNALMICE_CHECK_INTERVAL", "HOST_PROC_PATH",
+		"SIGNALMICE_MODE", "SIGNALMICE_DEADMANS_GRACE", "SIGNALMICE_SKIP_INITIAL_CHECK", "SIGNALMICE_IGNORE_EXISTING_AT_START", "SIGNALMICE_SELFTEST", "SIGNALMICE_LOG_TIME_FORMAT", "SIGNALMICE_LOG_FLUSH_TIMEOUT", "SIGNALMICE_METHODS_JSON", "SIGNALMICE_SHUTDOWN_DEADLINE", "SIGNALMICE_SAFE_MODE",
+		"SIGNALMICE_MIN_SHUTDOWN_INTERVAL", "SIGNALMICE_NO_HOST_ACCESS",
 	}
 	for _, v := range envVars {
 		os.Unsetenv(v)
@@ -50,17 +53,86 @@ func TestLoad_Defaults(t *testing.T) {
 	if !cfg.OpensearchUseDailyIndex {
 		t.Errorf("expected OpensearchUseDailyIndex true by default, got false")
 	}
+	if cfg.OpensearchCreateTemplate {
+		t.Errorf("expected OpensearchCreateTemplate false by default, got true")
+	}
 
 	// Test Application defaults
 	if cfg.RedisKey != DefaultRedisKey {
 		t.Errorf("expected RedisKey '%s', got '%s'", DefaultRedisKey, cfg.RedisKey)
 	}
+	if cfg.KeyPrefix != "" {
+		t.Errorf("expected empty KeyPrefix, got '%s'", cfg.KeyPrefix)
+	}
 	if cfg.CheckInterval != 60*time.Second {
 		t.Errorf("expected CheckInterval 60s, got %v", cfg.CheckInterval)
 	}
 	if cfg.HostProcPath != "/host/proc" {
 		t.Errorf("expected HostProcPath '/host/proc', got '%s'", cfg.HostProcPath)
 	}
+	if cfg.NoHostAccess != "attempt" {
+		t.Errorf("expected NoHostAccess 'attempt', got '%s'", cfg.NoHostAccess)
+	}
+	if cfg.Mode != ModeSignal {
+		t.Errorf("expected Mode '%s', got '%s'", ModeSignal, cfg.Mode)
+	}
+	if cfg.DeadmansGrace != 5*time.Minute {
+		t.Errorf("expected DeadmansGrace 5m, got %v", cfg.DeadmansGrace)
+	}
+	if cfg.SkipInitialCheck {
+		t.Errorf("expected SkipInitialCheck false by default, got true")
+	}
+	if cfg.IgnoreExisting {
+		t.Errorf("expected IgnoreExisting false by default, got true")
+	}
+	if cfg.SelfTest {
+		t.Errorf("expected SelfTest false by default, got true")
+	}
+	if cfg.LogTimeFormat != time.RFC3339 {
+		t.Errorf("expected LogTimeFormat '%s', got '%s'", time.RFC3339, cfg.LogTimeFormat)
+	}
+	if cfg.LogFlushTimeout != 5*time.Second {
+		t.Errorf("expected LogFlushTimeout 5s, got %v", cfg.LogFlushTimeout)
+	}
+
+	// Test Leader election defaults
+	if cfg.LeaderElection {
+		t.Errorf("expected LeaderElection false by default, got true")
+	}
+	if cfg.LeaderKeyName != DefaultLeaderKey {
+		t.Errorf("expected LeaderKeyName '%s', got '%s'", DefaultLeaderKey, cfg.LeaderKeyName)
+	}
+	if cfg.LeaderTTL != 30*time.Second {
+		t.Errorf("expected LeaderTTL 30s, got %v", cfg.LeaderTTL)
+	}
+	if cfg.LeaderRenewFraction != 0.5 {
+		t.Errorf("expected LeaderRenewFraction 0.5, got %v", cfg.LeaderRenewFraction)
+	}
+
+	// Test kill switch and key concurrency defaults
+	if cfg.KillSwitchKeyName != DefaultKillSwitchKey || !cfg.KillSwitchDelete {
+		t.Errorf("expected kill switch '%s' deleting signals, got '%s' (delete %v)", DefaultKillSwitchKey, cfg.KillSwitchKeyName, cfg.KillSwitchDelete)
+	}
+	if cfg.KeyConcurrency != 1 {
+		t.Errorf("expected KeyConcurrency 1, got %d", cfg.KeyConcurrency)
+	}
+	if cfg.OpensearchTLSMinVersion != DefaultTLSMinVersion || cfg.RedisTLSMinVersion != DefaultTLSMinVersion || cfg.RedisTLS {
+		t.Errorf("expected TLS %s minimums and Redis TLS off, got %q/%q (Redis TLS %v)", DefaultTLSMinVersion, cfg.OpensearchTLSMinVersion, cfg.RedisTLSMinVersion, cfg.RedisTLS)
+	}
+
+	// Test Shutdown defaults
+	if cfg.ShutdownMethodsJSON != "" {
+		t.Errorf("expected empty ShutdownMethodsJSON, got '%s'", cfg.ShutdownMethodsJSON)
+	}
+	if cfg.ShutdownDeadline != 0 {
+		t.Errorf("expected ShutdownDeadline 0, got %v", cfg.ShutdownDeadline)
+	}
+	if cfg.SafeMode {
+		t.Errorf("expected SafeMode false by default, got true")
+	}
+	if cfg.MinShutdownInterval != 0 {
+		t.Errorf("expected MinShutdownInterval 0, got %v", cfg.MinShutdownInterval)
+	}
 }
 
 func TestLoad_CustomValues(t *testing.T) {
@@ -204,3 +276,406 @@ func TestGetEnvBool(t *testing.T) {
 		})
 	}
 }
+
+func TestLoad_KeyPrefix(t *testing.T) {
+	os.Setenv("SIGNALMICE_KEY_PREFIX", "prod")
+	defer os.Unsetenv("SIGNALMICE_KEY_PREFIX")
+
+	cfg := Load()
+
+	if cfg.KeyPrefix != "prod" {
+		t.Errorf("expected KeyPrefix 'prod', got '%s'", cfg.KeyPrefix)
+	}
+}
+
+func TestPrefixKey(t *testing.T) {
+	tests := []struct {
+		name     string
+		prefix   string
+		key      string
+		expected string
+	}{
+		{"no prefix", "", "signalmice:abc", "signalmice:abc"},
+		{"prefix without separator", "prod", "signalmice:abc", "prod:signalmice:abc"},
+		{"prefix with separator", "prod:", "signalmice:abc", "prod:signalmice:abc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &Config{KeyPrefix: tt.prefix}
+			if got := cfg.PrefixKey(tt.key); got != tt.expected {
+				t.Errorf("PrefixKey(%q) with prefix %q = %q, expected %q", tt.key, tt.prefix, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRenderKeyTemplate(t *testing.T) {
+	if got := RenderKeyTemplate("signalmice:host:{hostname}", "node-7"); got != "signalmice:host:node-7" {
+		t.Errorf("expected 'signalmice:host:node-7', got '%s'", got)
+	}
+	if got := RenderKeyTemplate("signalmice:static", "node-7"); got != "signalmice:static" {
+		t.Errorf("expected a template without placeholder to be kept, got '%s'", got)
+	}
+}
+
+func TestLoad_KeyTemplate(t *testing.T) {
+	os.Setenv("SIGNALMICE_KEY", "signalmice:shared")
+	os.Setenv("SIGNALMICE_KEY_TEMPLATE", "signalmice:host:{hostname}")
+	defer func() {
+		os.Unsetenv("SIGNALMICE_KEY")
+		os.Unsetenv("SIGNALMICE_KEY_TEMPLATE")
+	}()
+
+	hostname, _ := os.Hostname()
+	cfg := Load()
+
+	if want := "signalmice:host:" + hostname; cfg.RedisKey != want {
+		t.Errorf("expected the template to take precedence, want '%s', got '%s'", want, cfg.RedisKey)
+	}
+}
+
+func TestSignalKey(t *testing.T) {
+	cfg := &Config{RedisKey: "signalmice:abc"}
+	if cfg.SignalKey() != "signalmice:abc" {
+		t.Errorf("expected SignalKey to equal RedisKey without prefix, got '%s'", cfg.SignalKey())
+	}
+
+	cfg.KeyPrefix = "staging"
+	if cfg.SignalKey() != "staging:signalmice:abc" {
+		t.Errorf("expected SignalKey 'staging:signalmice:abc', got '%s'", cfg.SignalKey())
+	}
+}
+
+func TestGetEnvDuration(t *testing.T) {
+	tests := []struct {
+		name         string
+		envValue     string
+		set          bool
+		defaultValue time.Duration
+		expected     time.Duration
+	}{
+		{"unset uses default", "", false, 5 * time.Second, 5 * time.Second},
+		{"integer seconds", "30", true, 0, 30 * time.Second},
+		{"duration string", "1m30s", true, 0, 90 * time.Second},
+		{"sub-second duration", "250ms", true, 0, 250 * time.Millisecond},
+		{"invalid uses default", "soon", true, 7 * time.Second, 7 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.set {
+				os.Setenv("TEST_DURATION", tt.envValue)
+				defer os.Unsetenv("TEST_DURATION")
+			} else {
+				os.Unsetenv("TEST_DURATION")
+			}
+
+			result := getEnvDuration("TEST_DURATION", tt.defaultValue)
+			if result != tt.expected {
+				t.Errorf("getEnvDuration(%q, %v) = %v, expected %v", tt.envValue, tt.defaultValue, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestLoad_ShutdownDeadline(t *testing.T) {
+	os.Setenv("SIGNALMICE_SHUTDOWN_DEADLINE", "120")
+	defer os.Unsetenv("SIGNALMICE_SHUTDOWN_DEADLINE")
+
+	cfg := Load()
+
+	if cfg.ShutdownDeadline != 120*time.Second {
+		t.Errorf("expected ShutdownDeadline 120s, got %v", cfg.ShutdownDeadline)
+	}
+}
+
+func TestLoad_SafeMode(t *testing.T) {
+	os.Setenv("SIGNALMICE_SAFE_MODE", "true")
+	defer os.Unsetenv("SIGNALMICE_SAFE_MODE")
+
+	cfg := Load()
+
+	if !cfg.SafeMode {
+		t.Errorf("expected SafeMode true when set to 'true', got false")
+	}
+}
+
+func TestLoad_MinShutdownInterval(t *testing.T) {
+	os.Setenv("SIGNALMICE_MIN_SHUTDOWN_INTERVAL", "10m")
+	defer os.Unsetenv("SIGNALMICE_MIN_SHUTDOWN_INTERVAL")
+
+	cfg := Load()
+
+	if cfg.MinShutdownInterval != 10*time.Minute {
+		t.Errorf("expected MinShutdownInterval 10m, got %v", cfg.MinShutdownInterval)
+	}
+}
+
+func TestLoad_Backoff(t *testing.T) {
+	cfg := Load()
+	if cfg.Backoff || cfg.MaxInterval != 10*time.Minute {
+		t.Errorf("expected backoff disabled with a 10m cap by default, got %v/%v", cfg.Backoff, cfg.MaxInterval)
+	}
+
+	os.Setenv("SIGNALMICE_BACKOFF", "true")
+	os.Setenv("SIGNALMICE_MAX_INTERVAL", "5m")
+	defer os.Unsetenv("SIGNALMICE_BACKOFF")
+	defer os.Unsetenv("SIGNALMICE_MAX_INTERVAL")
+
+	cfg = Load()
+	if !cfg.Backoff || cfg.MaxInterval != 5*time.Minute {
+		t.Errorf("expected backoff enabled with a 5m cap, got %v/%v", cfg.Backoff, cfg.MaxInterval)
+	}
+}
+
+func TestLoad_Required(t *testing.T) {
+	cfg := Load()
+	if !cfg.Requires(DependencyRedis) || cfg.Requires(DependencyOpensearch) {
+		t.Errorf("expected only redis to be required by default, got %v", cfg.Required)
+	}
+
+	os.Setenv("SIGNALMICE_REQUIRED", " Opensearch, ,redis")
+	defer os.Unsetenv("SIGNALMICE_REQUIRED")
+
+	cfg = Load()
+	if !cfg.Requires(DependencyRedis) || !cfg.Requires(DependencyOpensearch) || len(cfg.Required) != 2 {
+		t.Errorf("expected redis and opensearch to be required, got %v", cfg.Required)
+	}
+
+	os.Setenv("SIGNALMICE_REQUIRED", "")
+	if cfg = Load(); len(cfg.Required) != 0 {
+		t.Errorf("expected nothing required when empty, got %v", cfg.Required)
+	}
+}
+
+func TestLoad_ShutdownRetryCooldown(t *testing.T) {
+	os.Setenv("SIGNALMICE_SHUTDOWN_RETRY_COOLDOWN", "120")
+	defer os.Unsetenv("SIGNALMICE_SHUTDOWN_RETRY_COOLDOWN")
+
+	cfg := Load()
+
+	if cfg.ShutdownRetryCooldown != 2*time.Minute {
+		t.Errorf("expected ShutdownRetryCooldown 2m, got %v", cfg.ShutdownRetryCooldown)
+	}
+}
+
+func TestLoad_OpensearchTLSFiles(t *testing.T) {
+	os.Setenv("OPENSEARCH_CA_FILE", "/etc/ssl/opensearch-ca.pem")
+	os.Setenv("OPENSEARCH_CLIENT_CERT_FILE", "/etc/ssl/client.pem")
+	os.Setenv("OPENSEARCH_CLIENT_KEY_FILE", "/etc/ssl/client-key.pem")
+	defer func() {
+		os.Unsetenv("OPENSEARCH_CA_FILE")
+		os.Unsetenv("OPENSEARCH_CLIENT_CERT_FILE")
+		os.Unsetenv("OPENSEARCH_CLIENT_KEY_FILE")
+	}()
+
+	cfg := Load()
+
+	if cfg.OpensearchCAFile != "/etc/ssl/opensearch-ca.pem" {
+		t.Errorf("expected OpensearchCAFile '/etc/ssl/opensearch-ca.pem', got '%s'", cfg.OpensearchCAFile)
+	}
+	if cfg.OpensearchClientCertFile != "/etc/ssl/client.pem" {
+		t.Errorf("expected OpensearchClientCertFile '/etc/ssl/client.pem', got '%s'", cfg.OpensearchClientCertFile)
+	}
+	if cfg.OpensearchClientKeyFile != "/etc/ssl/client-key.pem" {
+		t.Errorf("expected OpensearchClientKeyFile '/etc/ssl/client-key.pem', got '%s'", cfg.OpensearchClientKeyFile)
+	}
+}
+
+func TestLoad_SkipInitialCheck(t *testing.T) {
+	os.Setenv("SIGNALMICE_SKIP_INITIAL_CHECK", "true")
+	defer os.Unsetenv("SIGNALMICE_SKIP_INITIAL_CHECK")
+
+	cfg := Load()
+
+	if !cfg.SkipInitialCheck {
+		t.Errorf("expected SkipInitialCheck true when set to 'true', got false")
+	}
+}
+
+func TestParseKeyActions(t *testing.T) {
+	actions := parseKeyActions("signalmice:reboot=reboot, signalmice:off=poweroff,malformed,=poweroff,signalmice:empty=")
+
+	expected := []KeyAction{
+		{Key: "signalmice:reboot", Action: "reboot"},
+		{Key: "signalmice:off", Action: "poweroff"},
+	}
+	if len(actions) != len(expected) {
+		t.Fatalf("expected %d key actions, got %d: %+v", len(expected), len(actions), actions)
+	}
+	for i, want := range expected {
+		if actions[i] != want {
+			t.Errorf("expected key action %d to be %+v, got %+v", i, want, actions[i])
+		}
+	}
+
+	if got := parseKeyActions(""); len(got) != 0 {
+		t.Errorf("expected no key actions for empty value, got %+v", got)
+	}
+}
+
+func TestSignalKeys_WithKeyActions(t *testing.T) {
+	cfg := &Config{
+		RedisKey:  "signalmice:main",
+		KeyPrefix: "prod",
+		KeyActions: []KeyAction{
+			{Key: "signalmice:reboot", Action: "reboot"},
+			{Key: "signalmice:main", Action: "poweroff"},
+			{Key: "signalmice:off", Action: "poweroff"},
+		},
+	}
+
+	expected := []string{"prod:signalmice:main", "prod:signalmice:reboot", "prod:signalmice:off"}
+	keys := cfg.SignalKeys()
+	if len(keys) != len(expected) {
+		t.Fatalf("expected %d keys, got %d: %v", len(expected), len(keys), keys)
+	}
+	for i, want := range expected {
+		if keys[i] != want {
+			t.Errorf("expected key %d to be '%s', got '%s'", i, want, keys[i])
+		}
+	}
+}
+
+func TestActionForKey(t *testing.T) {
+	cfg := &Config{
+		KeyPrefix: "prod",
+		KeyActions: []KeyAction{
+			{Key: "signalmice:reboot", Action: "reboot"},
+			{Key: "signalmice:off", Action: "poweroff"},
+		},
+	}
+
+	if action, ok := cfg.ActionForKey("prod:signalmice:reboot"); !ok || action != "reboot" {
+		t.Errorf("expected 'reboot' for reboot key, got '%s' (found=%v)", action, ok)
+	}
+	if action, ok := cfg.ActionForKey("prod:signalmice:off"); !ok || action != "poweroff" {
+		t.Errorf("expected 'poweroff' for off key, got '%s' (found=%v)", action, ok)
+	}
+	if _, ok := cfg.ActionForKey("signalmice:reboot"); ok {
+		t.Error("expected unprefixed key not to match")
+	}
+}
+
+func TestLoad_IgnoreExisting(t *testing.T) {
+	os.Setenv("SIGNALMICE_IGNORE_EXISTING_AT_START", "true")
+	defer os.Unsetenv("SIGNALMICE_IGNORE_EXISTING_AT_START")
+
+	cfg := Load()
+
+	if !cfg.IgnoreExisting {
+		t.Errorf("expected IgnoreExisting true when set to 'true', got false")
+	}
+}
+
+func TestLoad_DeadmansMode(t *testing.T) {
+	os.Setenv("SIGNALMICE_MODE", "deadmans")
+	os.Setenv("SIGNALMICE_DEADMANS_GRACE", "90")
+	defer os.Unsetenv("SIGNALMICE_MODE")
+	defer os.Unsetenv("SIGNALMICE_DEADMANS_GRACE")
+
+	cfg := Load()
+
+	if cfg.Mode != ModeDeadmans {
+		t.Errorf("expected Mode '%s', got '%s'", ModeDeadmans, cfg.Mode)
+	}
+	if cfg.DeadmansGrace != 90*time.Second {
+		t.Errorf("expected DeadmansGrace 90s, got %v", cfg.DeadmansGrace)
+	}
+}
+
+func TestLoad_LeaderElection(t *testing.T) {
+	os.Setenv("SIGNALMICE_LEADER_ELECTION", "true")
+	os.Setenv("SIGNALMICE_LEADER_TTL", "10s")
+	os.Setenv("SIGNALMICE_LEADER_RENEW_FRACTION", "0.3")
+	os.Setenv("SIGNALMICE_KEY_PREFIX", "prod")
+	defer func() {
+		os.Unsetenv("SIGNALMICE_LEADER_ELECTION")
+		os.Unsetenv("SIGNALMICE_LEADER_TTL")
+		os.Unsetenv("SIGNALMICE_LEADER_RENEW_FRACTION")
+		os.Unsetenv("SIGNALMICE_KEY_PREFIX")
+	}()
+
+	cfg := Load()
+
+	if !cfg.LeaderElection {
+		t.Errorf("expected LeaderElection true, got false")
+	}
+	if cfg.LeaderTTL != 10*time.Second {
+		t.Errorf("expected LeaderTTL 10s, got %v", cfg.LeaderTTL)
+	}
+	if cfg.LeaderRenewFraction != 0.3 {
+		t.Errorf("expected LeaderRenewFraction 0.3, got %v", cfg.LeaderRenewFraction)
+	}
+	if cfg.LeaderKey() != "prod:signalmice:leader" {
+		t.Errorf("expected prefixed leader key 'prod:signalmice:leader', got '%s'", cfg.LeaderKey())
+	}
+}
+
+func TestKillSwitchKey(t *testing.T) {
+	cfg := &Config{KillSwitchKeyName: DefaultKillSwitchKey, KeyPrefix: "prod"}
+	if got := cfg.KillSwitchKey(); got != "prod:signalmice:disabled" {
+		t.Errorf("expected prefixed kill switch key 'prod:signalmice:disabled', got '%s'", got)
+	}
+
+	cfg.KillSwitchKeyName = ""
+	if got := cfg.KillSwitchKey(); got != "" {
+		t.Errorf("expected an empty kill switch key to disable it, got '%s'", got)
+	}
+}
+
+func TestSelfTestKey(t *testing.T) {
+	cfg := &Config{}
+	if got := cfg.SelfTestKey("node-1"); got != "signalmice:selftest:node-1" {
+		t.Errorf("expected 'signalmice:selftest:node-1', got '%s'", got)
+	}
+
+	cfg.KeyPrefix = "prod"
+	if got := cfg.SelfTestKey("node-1"); got != "prod:signalmice:selftest:node-1" {
+		t.Errorf("expected 'prod:signalmice:selftest:node-1', got '%s'", got)
+	}
+}
+
+func TestParseHeaders(t *testing.T) {
+	headers := parseHeaders("Authorization=Bearer abc==, X-Team = ops,malformed,=empty")
+
+	if len(headers) != 2 {
+		t.Fatalf("expected 2 headers, got %d: %v", len(headers), headers)
+	}
+	if headers["Authorization"] != "Bearer abc==" {
+		t.Errorf("expected Authorization 'Bearer abc==', got '%s'", headers["Authorization"])
+	}
+	if headers["X-Team"] != "ops" {
+		t.Errorf("expected X-Team 'ops', got '%s'", headers["X-Team"])
+	}
+}
+
+func TestLoad_Webhook(t *testing.T) {
+	os.Setenv("SIGNALMICE_WEBHOOK_URL", "https://hooks.example.com/signalmice")
+	os.Setenv("SIGNALMICE_WEBHOOK_RETRIES", "1")
+	os.Setenv("SIGNALMICE_WEBHOOK_HEADERS", "Authorization=Bearer t0ken")
+	defer func() {
+		os.Unsetenv("SIGNALMICE_WEBHOOK_URL")
+		os.Unsetenv("SIGNALMICE_WEBHOOK_RETRIES")
+		os.Unsetenv("SIGNALMICE_WEBHOOK_HEADERS")
+	}()
+
+	cfg := Load()
+
+	if cfg.WebhookURL != "https://hooks.example.com/signalmice" {
+		t.Errorf("expected WebhookURL to be set, got '%s'", cfg.WebhookURL)
+	}
+	if cfg.WebhookRetries != 1 {
+		t.Errorf("expected WebhookRetries 1, got %d", cfg.WebhookRetries)
+	}
+	if cfg.WebhookTimeout != 2*time.Second {
+		t.Errorf("expected default WebhookTimeout 2s, got %v", cfg.WebhookTimeout)
+	}
+	if cfg.WebhookMaxDuration != 5*time.Second {
+		t.Errorf("expected default WebhookMaxDuration 5s, got %v", cfg.WebhookMaxDuration)
+	}
+	if cfg.WebhookHeaders["Authorization"] != "Bearer t0ken" {
+		t.Errorf("expected Authorization header, got %v", cfg.WebhookHeaders)
+	}
+}
@@ -0,0 +1,105 @@
+package config
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// hostnameMu guards the runtime hostname of every Config; renames are rare, so one lock will do
+var hostnameMu sync.RWMutex
+
+// Where the effective hostname came from, recorded in Config.HostnameSource
+const (
+	HostnameSourceOS        = "os"        // Detected with os.Hostname
+	HostnameSourceEnv       = "env"       // Taken from SIGNALMICE_HOSTNAME after detection failed
+	HostnameSourceGenerated = "generated" // Generated because neither was available
+	HostnameSourceFile      = "file"      // Read from SIGNALMICE_HOSTNAME_FILE
+)
+
+// resolveHostname returns the hostname reported by detect, falling back to override and then
+// to a generated identifier, along with the source it came from
+func resolveHostname(detect func() (string, error), override string) (string, string) {
+	if hostname, err := detect(); err == nil && hostname != "" {
+		return hostname, HostnameSourceOS
+	}
+	if override != "" {
+		return override, HostnameSourceEnv
+	}
+	return generateHostname(), HostnameSourceGenerated
+}
+
+// ReadHostnameFile returns the trimmed contents of a hostname file, which must name a host
+func ReadHostnameFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read hostname file: %w", err)
+	}
+	hostname := strings.TrimSpace(string(data))
+	if hostname == "" {
+		return "", fmt.Errorf("hostname file %s is empty", path)
+	}
+	if strings.ContainsAny(hostname, " \t\r\n") {
+		return "", fmt.Errorf("hostname file %s holds more than one word", path)
+	}
+	return hostname, nil
+}
+
+// generateHostname returns a random identifier standing in for an undetectable hostname
+func generateHostname() string {
+	b := make([]byte, 6)
+	rand.Read(b)
+	return "signalmice-" + hex.EncodeToString(b)
+}
+
+// CurrentHostname returns this host's name as renamed since startup with SetHostname, otherwise
+// Hostname. Everything reading the hostname after startup goes through it.
+func (c *Config) CurrentHostname() string {
+	hostnameMu.RLock()
+	defer hostnameMu.RUnlock()
+	if c.renamedHostname != "" {
+		return c.renamedHostname
+	}
+	return c.Hostname
+}
+
+// SetHostname renames the host at runtime, e.g. from SIGNALMICE_HOSTNAME_FILE. Hostname keeps
+// the name resolved at startup.
+func (c *Config) SetHostname(hostname string) {
+	hostnameMu.Lock()
+	defer hostnameMu.Unlock()
+	c.renamedHostname = hostname
+}
+
+// WithRedisKey returns a copy of the configuration monitoring key in place of RedisKey
+func (c *Config) WithRedisKey(key string) *Config {
+	hostnameMu.RLock()
+	next := *c
+	hostnameMu.RUnlock()
+	next.RedisKey = key
+	return &next
+}
+
+// ValidateHostname returns an error when a feature that targets this host by name is enabled
+// but no real hostname is known. A generated identifier is unique but cannot be targeted.
+func (c *Config) ValidateHostname() error {
+	if !strings.Contains(c.KeyTemplate, "{hostname}") {
+		return nil
+	}
+	if c.Hostname == "" || c.HostnameSource == HostnameSourceGenerated {
+		return fmt.Errorf("SIGNALMICE_KEY_TEMPLATE needs the hostname, which could not be detected; set SIGNALMICE_HOSTNAME")
+	}
+	return nil
+}
+
+// HostnameWarning describes a feature that matches this host by name while only a generated
+// identifier is known, which no operator can target. It returns "" otherwise.
+func (c *Config) HostnameWarning() string {
+	if c.SignalJSON && c.HostnameSource == HostnameSourceGenerated {
+		return "JSON signals restricted with allowed_hosts can never match a generated hostname; set SIGNALMICE_HOSTNAME"
+	}
+	return ""
+}
@@ -0,0 +1,97 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolveHostname(t *testing.T) {
+	detected := func() (string, error) { return "node-1", nil }
+	failing := func() (string, error) { return "", errors.New("uname failed") }
+
+	if name, source := resolveHostname(detected, "override"); name != "node-1" || source != HostnameSourceOS {
+		t.Errorf("expected the detected hostname, got %q from %s", name, source)
+	}
+	if name, source := resolveHostname(failing, "override"); name != "override" || source != HostnameSourceEnv {
+		t.Errorf("expected the SIGNALMICE_HOSTNAME override, got %q from %s", name, source)
+	}
+
+	name, source := resolveHostname(failing, "")
+	if source != HostnameSourceGenerated || !strings.HasPrefix(name, "signalmice-") || len(name) <= len("signalmice-") {
+		t.Errorf("expected a generated identifier, got %q from %s", name, source)
+	}
+}
+
+func TestValidateHostname(t *testing.T) {
+	cfg := &Config{KeyTemplate: "signalmice:host:{hostname}", Hostname: "signalmice-0a1b2c", HostnameSource: HostnameSourceGenerated}
+	if err := cfg.ValidateHostname(); err == nil {
+		t.Error("expected a per-host key with a generated hostname to be rejected")
+	}
+
+	cfg.HostnameSource = HostnameSourceEnv
+	if err := cfg.ValidateHostname(); err != nil {
+		t.Errorf("unexpected error with an overridden hostname: %v", err)
+	}
+
+	// Without a per-host key, any hostname will do
+	cfg = &Config{HostnameSource: HostnameSourceGenerated}
+	if err := cfg.ValidateHostname(); err != nil {
+		t.Errorf("unexpected error without a per-host key: %v", err)
+	}
+}
+
+func TestSetHostname(t *testing.T) {
+	cfg := &Config{Hostname: "node-1"}
+	if got := cfg.CurrentHostname(); got != "node-1" {
+		t.Errorf("expected the startup hostname, got %q", got)
+	}
+
+	cfg.SetHostname("node-2")
+	if got := cfg.CurrentHostname(); got != "node-2" {
+		t.Errorf("expected the renamed hostname, got %q", got)
+	}
+	if cfg.Hostname != "node-1" {
+		t.Errorf("expected Hostname to keep the startup name, got %q", cfg.Hostname)
+	}
+}
+
+func TestHostnameWarning(t *testing.T) {
+	cfg := &Config{SignalJSON: true, Hostname: "signalmice-0a1b2c", HostnameSource: HostnameSourceGenerated}
+	if cfg.HostnameWarning() == "" {
+		t.Error("expected a warning for JSON signals with a generated hostname")
+	}
+
+	cfg.HostnameSource = HostnameSourceOS
+	if warning := cfg.HostnameWarning(); warning != "" {
+		t.Errorf("unexpected warning with a detected hostname: %s", warning)
+	}
+
+	cfg = &Config{HostnameSource: HostnameSourceGenerated}
+	if warning := cfg.HostnameWarning(); warning != "" {
+		t.Errorf("unexpected warning without JSON signals: %s", warning)
+	}
+}
+
+func TestReadHostnameFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "hostname")
+
+	os.WriteFile(path, []byte("node-1\n"), 0644)
+	if name, err := ReadHostnameFile(path); err != nil || name != "node-1" {
+		t.Errorf("expected node-1, got %q (%v)", name, err)
+	}
+
+	for _, contents := range []string{"", " \n", "node 1"} {
+		os.WriteFile(path, []byte(contents), 0644)
+		if _, err := ReadHostnameFile(path); err == nil {
+			t.Errorf("expected %q to be rejected", contents)
+		}
+	}
+
+	if _, err := ReadHostnameFile(filepath.Join(dir, "missing")); err == nil {
+		t.Error("expected a missing file to be an error")
+	}
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+)
+
+// RedactedValue replaces secrets in a redacted configuration
+const RedactedValue = "REDACTED"
+
+// Redacted returns a copy of the configuration safe to print: passwords, keys, tokens,
+// header values, passwords embedded in URLs and everything but the scheme and host of webhook
+// URLs are replaced with RedactedValue
+func (c *Config) Redacted() *Config {
+	r := *c
+	r.RedisPassword = redact(c.RedisPassword)
+	r.OpensearchPassword = redact(c.OpensearchPassword)
+	r.SigningKey = redact(c.SigningKey)
+	r.SimulateToken = redact(c.SimulateToken)
+	r.ConsulToken = redact(c.ConsulToken)
+	r.EtcdPassword = redact(c.EtcdPassword)
+	r.WebhookHeaders = redactHeaders(c.WebhookHeaders)
+	r.OTLPHeaders = redactHeaders(c.OTLPHeaders)
+	r.WebhooksJSON = redactWebhooksJSON(c.WebhooksJSON)
+	r.OpensearchURL = redactURL(c.OpensearchURL)
+	r.WebhookURL = redactWebhookURL(c.WebhookURL)
+	r.PostNotifyURL = redactWebhookURL(c.PostNotifyURL)
+	r.HTTPSourceURL = redactURL(c.HTTPSourceURL)
+	r.OTLPEndpoint = redactURL(c.OTLPEndpoint)
+	return &r
+}
+
+// redact hides a set secret, keeping an unset one visibly empty
+func redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return RedactedValue
+}
+
+// redactHeaders hides every header value, since any of them may carry credentials
+func redactHeaders(headers map[string]string) map[string]string {
+	if headers == nil {
+		return nil
+	}
+	redacted := make(map[string]string, len(headers))
+	for name, value := range headers {
+		redacted[name] = redact(value)
+	}
+	return redacted
+}
+
+// redactURL hides the password of a URL's user info
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.User == nil {
+		return raw
+	}
+	if _, ok := u.User.Password(); ok {
+		u.User = url.UserPassword(u.User.Username(), RedactedValue)
+	}
+	return u.String()
+}
+
+// redactWebhookURL keeps only the scheme and host of a webhook URL, since incoming-webhook
+// services (Slack, Teams, ...) carry the secret in the path or query. An unparsable URL is
+// hidden entirely.
+func redactWebhookURL(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" {
+		return RedactedValue
+	}
+	redacted := &url.URL{Scheme: u.Scheme, Host: u.Host}
+	if u.User != nil || strings.Trim(u.Path, "/") != "" || u.RawQuery != "" || u.Fragment != "" {
+		redacted.Path = "/" + RedactedValue
+	}
+	return redacted.String()
+}
+
+// redactWebhooksJSON hides the header values and URLs of SIGNALMICE_WEBHOOKS_JSON.
+// Unparsable JSON is hidden entirely, since where its secrets are cannot be told.
+func redactWebhooksJSON(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	var specs []map[string]any
+	if err := json.Unmarshal([]byte(raw), &specs); err != nil {
+		return RedactedValue
+	}
+	for _, spec := range specs {
+		if u, ok := spec["url"].(string); ok {
+			spec["url"] = redactWebhookURL(u)
+		}
+		if headers, ok := spec["headers"].(map[string]any); ok {
+			for name := range headers {
+				headers[name] = RedactedValue
+			}
+		}
+	}
+	data, err := json.Marshal(specs)
+	if err != nil {
+		return RedactedValue
+	}
+	return string(data)
+}
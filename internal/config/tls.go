@@ -0,0 +1,31 @@
+package config
+
+import (
+	"crypto/tls"
+	"fmt"
+	"strings"
+)
+
+// DefaultTLSMinVersion is the oldest TLS version accepted unless *_TLS_MIN_VERSION says otherwise
+const DefaultTLSMinVersion = "1.2"
+
+// tlsVersions maps the accepted *_TLS_MIN_VERSION values to crypto/tls versions
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// ParseTLSVersion returns the crypto/tls version for a "1.0" to "1.3" version string.
+// An empty string selects DefaultTLSMinVersion.
+func ParseTLSVersion(version string) (uint16, error) {
+	if version == "" {
+		version = DefaultTLSMinVersion
+	}
+	v, ok := tlsVersions[strings.TrimPrefix(strings.ToLower(version), "tls")]
+	if !ok {
+		return 0, fmt.Errorf("unknown TLS version %q (expected 1.0, 1.1, 1.2 or 1.3)", version)
+	}
+	return v, nil
+}
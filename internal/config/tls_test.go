@@ -0,0 +1,39 @@
+package config
+
+import (
+	"crypto/tls"
+	"testing"
+)
+
+func TestParseTLSVersion(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected uint16
+	}{
+		{"", tls.VersionTLS12},
+		{"1.0", tls.VersionTLS10},
+		{"1.1", tls.VersionTLS11},
+		{"1.2", tls.VersionTLS12},
+		{"1.3", tls.VersionTLS13},
+		{"TLS1.3", tls.VersionTLS13},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseTLSVersion(tt.version)
+		if err != nil {
+			t.Errorf("ParseTLSVersion(%q): unexpected error: %v", tt.version, err)
+			continue
+		}
+		if got != tt.expected {
+			t.Errorf("ParseTLSVersion(%q) = %#x, expected %#x", tt.version, got, tt.expected)
+		}
+	}
+}
+
+func TestParseTLSVersion_Invalid(t *testing.T) {
+	for _, version := range []string{"1", "1.4", "ssl3", "1.2.0"} {
+		if _, err := ParseTLSVersion(version); err == nil {
+			t.Errorf("ParseTLSVersion(%q): expected error, got nil", version)
+		}
+	}
+}
@@ -0,0 +1,110 @@
+package instruction
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/signalmice/signalmice/internal/config"
+	"github.com/signalmice/signalmice/internal/shutdown"
+)
+
+// Reasons Parse refuses a signal, wrapping the underlying error
+var (
+	ErrUnverified = errors.New("signal signature verification failed")
+	ErrMalformed  = errors.New("malformed JSON signal payload")
+	ErrExpired    = errors.New("signal lease expired")
+	ErrOtherHost  = errors.New("signal payload does not allow this host")
+)
+
+// Instruction is what a fired signal asks this host to do
+type Instruction struct {
+	Action       shutdown.Action
+	Reason       string
+	Delay        string   // Shutdown time from a JSON payload; empty uses SIGNALMICE_SHUTDOWN_WHEN
+	Value        string   // Signal value with its signature stripped
+	Empty        bool     // The value carried no instruction, so the defaults apply
+	AllowedHosts []string // Hosts a JSON payload applies to; set even when refused with ErrOtherHost
+}
+
+// Parse verifies and interprets the value of the signal key fired on hostname, the way both
+// the binary and the library act on it. A signed value must carry a valid signature, and with
+// SIGNALMICE_SIGNAL_JSON a JSON payload overrides the key's action and the configured reason.
+// A refused signal returns an error wrapping ErrUnverified, ErrMalformed, ErrExpired or
+// ErrOtherHost.
+func Parse(cfg *config.Config, key, value, hostname string, now time.Time) (Instruction, error) {
+	if cfg.SigningKey != "" {
+		payload, err := VerifySigned([]byte(cfg.SigningKey), value)
+		if err != nil {
+			return Instruction{}, fmt.Errorf("%w: %w", ErrUnverified, err)
+		}
+		value = payload
+	}
+
+	inst := Instruction{
+		Action: SelectAction(cfg, key, value),
+		Reason: SelectReason(cfg, value),
+		Value:  value,
+	}
+	if !cfg.SignalJSON {
+		// An empty value carries no instruction: the signal fires with the key's action and the
+		// configured reason
+		inst.Empty = EmptyValue(value)
+		return inst, nil
+	}
+	// With JSON payloads, an empty value would bypass allowed_hosts and fire on every host
+	if EmptyValue(value) {
+		return Instruction{}, fmt.Errorf("%w: empty value carries no payload", ErrMalformed)
+	}
+
+	payload, err := ParsePayload(value)
+	if err != nil {
+		return Instruction{}, fmt.Errorf("%w: %w", ErrMalformed, err)
+	}
+	// A forgotten key must not act long after it was set
+	if err := payload.CheckLease(now, cfg.SignalRequireExpiry); err != nil {
+		return Instruction{}, fmt.Errorf("%w: %w", ErrExpired, err)
+	}
+	inst.AllowedHosts = payload.AllowedHosts
+	if !payload.Allows(hostname) {
+		return inst, ErrOtherHost
+	}
+	inst.Action = payload.action(cfg, key)
+	inst.Reason = payload.reason(cfg)
+	inst.Delay = payload.Delay
+	return inst, nil
+}
+
+// SelectAction chooses the shutdown action for a fired signal: the action mapped to its key,
+// otherwise the signal value when it names an action, otherwise poweroff
+func SelectAction(cfg *config.Config, key, value string) shutdown.Action {
+	if name, ok := cfg.ActionForKey(key); ok {
+		if action, err := shutdown.ParseAction(name); err == nil {
+			return action
+		}
+	}
+	if action, err := shutdown.ParseAction(value); err == nil {
+		return action
+	}
+	return shutdown.ActionPoweroff
+}
+
+// SelectReason chooses the reason recorded for a fired signal: the signal value unless it is
+// empty or names an action, otherwise the configured default
+func SelectReason(cfg *config.Config, value string) string {
+	value = strings.TrimSpace(value)
+	if _, err := shutdown.ParseAction(value); value != "" && err != nil {
+		return value
+	}
+	if cfg.ShutdownReason != "" {
+		return cfg.ShutdownReason
+	}
+	return config.DefaultShutdownReason
+}
+
+// EmptyValue reports whether a signal value carries no instruction, e.g. one set with
+// SET key "". Such a signal still fires, with the default action and reason.
+func EmptyValue(value string) bool {
+	return strings.TrimSpace(value) == ""
+}
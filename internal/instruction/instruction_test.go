@@ -0,0 +1,118 @@
+package instruction
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/signalmice/signalmice/internal/config"
+	"github.com/signalmice/signalmice/internal/shutdown"
+)
+
+func TestSelectAction(t *testing.T) {
+	cfg := &config.Config{
+		RedisKey: "signalmice:main",
+		KeyActions: []config.KeyAction{
+			{Key: "signalmice:reboot", Action: "reboot"},
+			{Key: "signalmice:off", Action: "poweroff"},
+		},
+	}
+
+	tests := []struct {
+		name     string
+		key      string
+		value    string
+		expected shutdown.Action
+	}{
+		{"reboot key", "signalmice:reboot", "1", shutdown.ActionReboot},
+		{"poweroff key", "signalmice:off", "reboot", shutdown.ActionPoweroff},
+		{"main key with action value", "signalmice:main", "reboot", shutdown.ActionReboot},
+		{"main key with arbitrary value", "signalmice:main", "shutdown", shutdown.ActionPoweroff},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SelectAction(cfg, tt.key, tt.value); got != tt.expected {
+				t.Errorf("SelectAction(%q, %q) = %q, expected %q", tt.key, tt.value, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSelectReason(t *testing.T) {
+	cfg := &config.Config{ShutdownReason: "scheduled maintenance"}
+
+	tests := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{"free-text value", "rack 4 power work", "rack 4 power work"},
+		{"action value", "reboot", "scheduled maintenance"},
+		{"empty value", "", "scheduled maintenance"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SelectReason(cfg, tt.value); got != tt.expected {
+				t.Errorf("SelectReason(%q) = %q, expected %q", tt.value, got, tt.expected)
+			}
+		})
+	}
+
+	if got := SelectReason(&config.Config{}, ""); got != config.DefaultShutdownReason {
+		t.Errorf("expected default reason %q, got %q", config.DefaultShutdownReason, got)
+	}
+}
+
+func TestEmptySignalValue(t *testing.T) {
+	for value, expected := range map[string]bool{"": true, " \t\n": true, "reboot": false, "{}": false} {
+		if got := EmptyValue(value); got != expected {
+			t.Errorf("EmptyValue(%q) = %v, expected %v", value, got, expected)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	signed := func(value string) string { return value + "." + Sign([]byte("s3cret"), value) }
+
+	tests := []struct {
+		name    string
+		cfg     config.Config
+		value   string
+		want    Instruction
+		wantErr error
+	}{
+		{name: "action value", value: "reboot", want: Instruction{Action: shutdown.ActionReboot, Reason: config.DefaultShutdownReason, Value: "reboot"}},
+		{name: "free-text value", cfg: config.Config{ShutdownReason: "maintenance"}, value: "rack 4", want: Instruction{Action: shutdown.ActionPoweroff, Reason: "rack 4", Value: "rack 4"}},
+		{name: "empty value", value: "", want: Instruction{Action: shutdown.ActionPoweroff, Reason: config.DefaultShutdownReason, Empty: true}},
+		{name: "signed value", cfg: config.Config{SigningKey: "s3cret"}, value: signed("reboot"), want: Instruction{Action: shutdown.ActionReboot, Reason: config.DefaultShutdownReason, Value: "reboot"}},
+		{name: "unsigned value", cfg: config.Config{SigningKey: "s3cret"}, value: "reboot", wantErr: ErrUnverified},
+		{name: "signed JSON payload", cfg: config.Config{SigningKey: "s3cret", SignalJSON: true}, value: signed(`{"action":"reboot","reason":"kernel upgrade","delay":"+5"}`),
+			want: Instruction{Action: shutdown.ActionReboot, Reason: "kernel upgrade", Delay: "+5", Value: `{"action":"reboot","reason":"kernel upgrade","delay":"+5"}`}},
+		{name: "empty value with JSON payloads", cfg: config.Config{SignalJSON: true}, value: " ", wantErr: ErrMalformed},
+		{name: "malformed JSON payload", cfg: config.Config{SignalJSON: true}, value: `{"action":`, wantErr: ErrMalformed},
+		{name: "expired JSON payload", cfg: config.Config{SignalJSON: true}, value: `{"expires_at":"2024-01-01T11:00:00Z"}`, wantErr: ErrExpired},
+		{name: "JSON payload for another host", cfg: config.Config{SignalJSON: true}, value: `{"allowed_hosts":["node-2"]}`, wantErr: ErrOtherHost},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(&tt.cfg, "signalmice:main", tt.value, "node-1", now)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("expected %v, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
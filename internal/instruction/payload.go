@@ -0,0 +1,94 @@
+package instruction
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/signalmice/signalmice/internal/config"
+	"github.com/signalmice/signalmice/internal/shutdown"
+)
+
+// Payload is a JSON signal value carrying a full shutdown instruction. Empty fields
+// fall back to the configured defaults.
+type Payload struct {
+	Action       string   `json:"action"`
+	Reason       string   `json:"reason"`
+	Delay        string   `json:"delay"`         // Shutdown time in SIGNALMICE_SHUTDOWN_WHEN syntax
+	AllowedHosts []string `json:"allowed_hosts"` // Hosts the instruction applies to; empty applies to all
+	ExpiresAt    string   `json:"expires_at"`    // RFC 3339 time after which the instruction must not act; empty never expires
+}
+
+// ParsePayload decodes and validates a JSON signal value
+func ParsePayload(value string) (Payload, error) {
+	var payload Payload
+	if err := json.Unmarshal([]byte(value), &payload); err != nil {
+		return Payload{}, fmt.Errorf("invalid JSON signal payload: %w", err)
+	}
+	if payload.Action != "" {
+		if _, err := shutdown.ParseAction(payload.Action); err != nil {
+			return Payload{}, err
+		}
+	}
+	if payload.Delay != "" {
+		if err := shutdown.ValidateWhen(payload.Delay); err != nil {
+			return Payload{}, fmt.Errorf("invalid delay: %w", err)
+		}
+	}
+	if payload.ExpiresAt != "" {
+		if _, err := time.Parse(time.RFC3339, payload.ExpiresAt); err != nil {
+			return Payload{}, fmt.Errorf("invalid expires_at: %w", err)
+		}
+	}
+	return payload, nil
+}
+
+// CheckLease returns an error when the payload expired at now, or carries no expiry while one
+// is required
+func (p Payload) CheckLease(now time.Time, required bool) error {
+	if p.ExpiresAt == "" {
+		if required {
+			return errors.New("signal has no expires_at, but SIGNALMICE_SIGNAL_REQUIRE_EXPIRY is set")
+		}
+		return nil
+	}
+	expiresAt, err := time.Parse(time.RFC3339, p.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("invalid expires_at: %w", err)
+	}
+	if !now.Before(expiresAt) {
+		return fmt.Errorf("signal expired at %s", expiresAt.UTC().Format(time.RFC3339))
+	}
+	return nil
+}
+
+// Allows reports whether the payload applies to hostname
+func (p Payload) Allows(hostname string) bool {
+	if len(p.AllowedHosts) == 0 {
+		return true
+	}
+	for _, host := range p.AllowedHosts {
+		if strings.EqualFold(host, hostname) {
+			return true
+		}
+	}
+	return false
+}
+
+// action returns the payload's action, otherwise the action mapped to the signal key, otherwise poweroff
+func (p Payload) action(cfg *config.Config, key string) shutdown.Action {
+	if p.Action != "" {
+		return shutdown.Action(p.Action)
+	}
+	return SelectAction(cfg, key, "")
+}
+
+// reason returns the payload's reason, otherwise the configured default
+func (p Payload) reason(cfg *config.Config) string {
+	if p.Reason != "" {
+		return p.Reason
+	}
+	return SelectReason(cfg, "")
+}
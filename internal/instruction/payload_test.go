@@ -0,0 +1,74 @@
+package instruction
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSignalPayload(t *testing.T) {
+	payload, err := ParsePayload(`{"action":"reboot","reason":"kernel upgrade","delay":"+5","allowed_hosts":["node-1"]}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if payload.Action != "reboot" || payload.Reason != "kernel upgrade" || payload.Delay != "+5" || len(payload.AllowedHosts) != 1 {
+		t.Errorf("unexpected payload: %+v", payload)
+	}
+
+	for _, value := range []string{
+		`reboot`,
+		`{"action":"reboot"`,
+		`{"action":"halt"}`,
+		`{"delay":"5 minutes"}`,
+		`{"allowed_hosts":"node-1"}`,
+	} {
+		if _, err := ParsePayload(value); err == nil {
+			t.Errorf("expected error for %s", value)
+		}
+	}
+}
+
+func TestSignalPayload_Allows(t *testing.T) {
+	if !(Payload{}).Allows("node-1") {
+		t.Error("expected a payload without allowed_hosts to apply to every host")
+	}
+	payload := Payload{AllowedHosts: []string{"node-1", "Node-2"}}
+	if !payload.Allows("node-2") {
+		t.Error("expected allowed_hosts to match case-insensitively")
+	}
+	if payload.Allows("node-3") {
+		t.Error("expected a host missing from allowed_hosts to be excluded")
+	}
+}
+
+func TestSignalPayload_CheckLease(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		expiresAt string
+		required  bool
+		wantErr   bool
+	}{
+		{name: "future expiry", expiresAt: "2024-01-01T12:05:00Z"},
+		{name: "past expiry", expiresAt: "2024-01-01T11:55:00Z", wantErr: true},
+		{name: "expiring now", expiresAt: "2024-01-01T12:00:00Z", wantErr: true},
+		{name: "other time zone", expiresAt: "2024-01-01T13:05:00+01:00"},
+		{name: "no expiry", expiresAt: ""},
+		{name: "no expiry when required", expiresAt: "", required: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Payload{ExpiresAt: tt.expiresAt}.CheckLease(now, tt.required)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckLease() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseSignalPayload_InvalidExpiry(t *testing.T) {
+	if _, err := ParsePayload(`{"expires_at":"tomorrow"}`); err == nil {
+		t.Error("expected error for a non-RFC 3339 expires_at")
+	}
+}
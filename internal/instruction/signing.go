@@ -0,0 +1,43 @@
+package instruction
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+)
+
+// Reasons VerifySigned rejects a value
+var (
+	ErrUnsignedValue    = errors.New("signal value is not a <payload>.<hmac> token")
+	ErrInvalidSignature = errors.New("signal value signature does not match")
+)
+
+// Sign returns the hex-encoded HMAC-SHA256 of payload under key
+func Sign(key []byte, payload string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySigned checks that value is a <payload>.<hmac> token signed with key
+// and returns the payload
+func VerifySigned(key []byte, value string) (string, error) {
+	idx := strings.LastIndex(value, ".")
+	if idx < 0 {
+		return "", ErrUnsignedValue
+	}
+	payload, signature := value[:idx], value[idx+1:]
+
+	got, err := hex.DecodeString(signature)
+	if err != nil {
+		return "", ErrInvalidSignature
+	}
+	want, _ := hex.DecodeString(Sign(key, payload))
+	if !hmac.Equal(got, want) {
+		return "", ErrInvalidSignature
+	}
+
+	return payload, nil
+}
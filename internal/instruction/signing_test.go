@@ -0,0 +1,37 @@
+package instruction
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestVerifySignedValue(t *testing.T) {
+	key := []byte("s3cret")
+	valid := "reboot." + Sign(key, "reboot")
+
+	payload, err := VerifySigned(key, valid)
+	if err != nil {
+		t.Fatalf("unexpected error for valid token: %v", err)
+	}
+	if payload != "reboot" {
+		t.Errorf("expected payload 'reboot', got %q", payload)
+	}
+
+	tests := []struct {
+		name  string
+		value string
+		want  error
+	}{
+		{"unsigned", "reboot", ErrUnsignedValue},
+		{"tampered payload", "poweroff." + Sign(key, "reboot"), ErrInvalidSignature},
+		{"wrong key", "reboot." + Sign([]byte("other"), "reboot"), ErrInvalidSignature},
+		{"non-hex signature", "reboot.zz", ErrInvalidSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := VerifySigned(key, tt.value); !errors.Is(err, tt.want) {
+				t.Errorf("VerifySigned(%q) error = %v, expected %v", tt.value, err, tt.want)
+			}
+		})
+	}
+}
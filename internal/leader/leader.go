@@ -0,0 +1,153 @@
+package leader
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/signalmice/signalmice/internal/config"
+	"github.com/signalmice/signalmice/internal/logger"
+)
+
+// renewJitter is the +/- fraction applied to each renewal delay so nodes do not renew in lockstep
+const renewJitter = 0.2
+
+// Store is the lock backend used for leader election
+type Store interface {
+	// AcquireLock sets key to token with the given TTL if the key is not set
+	AcquireLock(ctx context.Context, key, token string, ttl time.Duration) (bool, error)
+	// RenewLock extends the TTL of key only if it still holds token
+	RenewLock(ctx context.Context, key, token string, ttl time.Duration) (bool, error)
+	// ReleaseLock deletes key only if it still holds token
+	ReleaseLock(ctx context.Context, key, token string) error
+}
+
+// Elector holds a leader lock so only one signalmice instance acts on signals
+type Elector struct {
+	store    Store
+	key      string
+	token    string
+	ttl      time.Duration
+	fraction float64
+	logger   *logger.Logger
+
+	// OnChange, if set, is called with the new state on every leadership transition
+	OnChange func(isLeader bool)
+
+	mu       sync.RWMutex
+	isLeader bool
+}
+
+// NewElector creates an elector for the configured leader key
+func NewElector(cfg *config.Config, store Store, log *logger.Logger) (*Elector, error) {
+	if cfg.LeaderTTL <= 0 {
+		return nil, fmt.Errorf("leader TTL must be positive")
+	}
+	if cfg.LeaderRenewFraction <= 0 || cfg.LeaderRenewFraction*(1+renewJitter) >= 1 {
+		return nil, fmt.Errorf("leader renew fraction must be between 0 and %.2f", 1/(1+renewJitter))
+	}
+
+	return &Elector{
+		store:    store,
+		key:      cfg.LeaderKey(),
+		token:    fmt.Sprintf("%s-%s", cfg.Hostname, logger.NewCorrelationID()),
+		ttl:      cfg.LeaderTTL,
+		fraction: cfg.LeaderRenewFraction,
+		logger:   log,
+	}, nil
+}
+
+// IsLeader reports whether this instance currently holds the leader lock
+func (e *Elector) IsLeader() bool {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.isLeader
+}
+
+// Run acquires and renews the leader lock until ctx is done, then releases it
+func (e *Elector) Run(ctx context.Context) {
+	for {
+		if e.IsLeader() {
+			e.renew(ctx)
+		} else {
+			e.acquire(ctx)
+		}
+
+		select {
+		case <-ctx.Done():
+			e.release()
+			return
+		case <-time.After(e.nextDelay()):
+		}
+	}
+}
+
+// nextDelay returns how long to wait before the next renewal or acquisition attempt
+func (e *Elector) nextDelay() time.Duration {
+	base := float64(e.ttl) * e.fraction
+	jitter := (rand.Float64()*2 - 1) * renewJitter * base // #nosec G404 -- jitter does not need crypto randomness
+	return time.Duration(base + jitter)
+}
+
+// acquire tries to take the lock
+func (e *Elector) acquire(ctx context.Context) {
+	ok, err := e.store.AcquireLock(ctx, e.key, e.token, e.ttl)
+	if err != nil {
+		e.logger.WarnWithExtra(ctx, "Failed to acquire leader lock", map[string]string{"error": err.Error()})
+		return
+	}
+	if ok {
+		e.setLeader(ctx, true)
+	}
+}
+
+// renew extends the lock, giving up leadership if it was lost or cannot be renewed
+func (e *Elector) renew(ctx context.Context) {
+	ok, err := e.store.RenewLock(ctx, e.key, e.token, e.ttl)
+	if err != nil {
+		e.logger.WarnWithExtra(ctx, "Failed to renew leader lock", map[string]string{"error": err.Error()})
+		e.setLeader(ctx, false)
+		return
+	}
+	if !ok {
+		e.setLeader(ctx, false)
+		// Re-acquire promptly: the lock may have expired without another holder
+		e.acquire(ctx)
+	}
+}
+
+// release gives up the lock on exit so another instance can take over immediately
+func (e *Elector) release() {
+	if !e.IsLeader() {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), e.ttl)
+	defer cancel()
+	if err := e.store.ReleaseLock(ctx, e.key, e.token); err != nil {
+		e.logger.WarnWithExtra(ctx, "Failed to release leader lock", map[string]string{"error": err.Error()})
+	}
+	e.setLeader(ctx, false)
+}
+
+// setLeader records a leadership transition and logs it
+func (e *Elector) setLeader(ctx context.Context, isLeader bool) {
+	e.mu.Lock()
+	changed := e.isLeader != isLeader
+	e.isLeader = isLeader
+	e.mu.Unlock()
+
+	if !changed {
+		return
+	}
+
+	if isLeader {
+		e.logger.InfoWithExtra(ctx, "Acquired leader lock", map[string]string{"key": e.key})
+	} else {
+		e.logger.WarnWithExtra(ctx, "Lost leader lock", map[string]string{"key": e.key})
+	}
+	if e.OnChange != nil {
+		e.OnChange(isLeader)
+	}
+}
@@ -0,0 +1,217 @@
+package leader
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/signalmice/signalmice/internal/config"
+	"github.com/signalmice/signalmice/internal/logger"
+)
+
+// createMockLogger creates a logger that doesn't connect to Opensearch
+func createMockLogger() *logger.Logger {
+	cfg := &config.Config{
+		OpensearchURL:   "http://localhost:9200",
+		OpensearchIndex: "test-logs",
+		RedisKey:        "test-key",
+	}
+	l, _ := logger.NewLogger(cfg)
+	return l
+}
+
+// fakeStore is an in-memory lock store that tracks expiry
+type fakeStore struct {
+	mu        sync.Mutex
+	holder    string
+	expiresAt time.Time
+	renewals  []time.Time
+	denyRenew bool
+}
+
+func (f *fakeStore) AcquireLock(_ context.Context, _, token string, ttl time.Duration) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.holder != "" && time.Now().Before(f.expiresAt) {
+		return false, nil
+	}
+	f.holder = token
+	f.expiresAt = time.Now().Add(ttl)
+	return true, nil
+}
+
+func (f *fakeStore) RenewLock(_ context.Context, _, token string, ttl time.Duration) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.denyRenew || f.holder != token || !time.Now().Before(f.expiresAt) {
+		return false, nil
+	}
+	f.expiresAt = time.Now().Add(ttl)
+	f.renewals = append(f.renewals, time.Now())
+	return true, nil
+}
+
+func (f *fakeStore) ReleaseLock(_ context.Context, _, token string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.holder == token {
+		f.holder = ""
+	}
+	return nil
+}
+
+// stealLock simulates another node taking over the lock
+func (f *fakeStore) stealLock() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.holder = "someone-else"
+	f.expiresAt = time.Now().Add(time.Hour)
+	f.denyRenew = true
+}
+
+func newTestElector(t *testing.T, store Store, ttl time.Duration) *Elector {
+	t.Helper()
+	cfg := &config.Config{
+		LeaderKeyName:       config.DefaultLeaderKey,
+		LeaderTTL:           ttl,
+		LeaderRenewFraction: 0.5,
+	}
+	elector, err := NewElector(cfg, store, createMockLogger())
+	if err != nil {
+		t.Fatalf("unexpected error creating elector: %v", err)
+	}
+	return elector
+}
+
+func TestNewElector_Validation(t *testing.T) {
+	tests := []struct {
+		name     string
+		ttl      time.Duration
+		fraction float64
+	}{
+		{"zero TTL", 0, 0.5},
+		{"zero fraction", time.Second, 0},
+		{"fraction too close to TTL", time.Second, 0.9},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{LeaderTTL: tt.ttl, LeaderRenewFraction: tt.fraction}
+			if _, err := NewElector(cfg, &fakeStore{}, createMockLogger()); err == nil {
+				t.Error("expected validation error")
+			}
+		})
+	}
+}
+
+func TestElector_NextDelayIsJitteredBeforeExpiry(t *testing.T) {
+	elector := newTestElector(t, &fakeStore{}, 10*time.Second)
+
+	minDelay := time.Duration(float64(10*time.Second) * 0.5 * (1 - renewJitter))
+	maxDelay := time.Duration(float64(10*time.Second) * 0.5 * (1 + renewJitter))
+	seen := map[time.Duration]bool{}
+	for i := 0; i < 100; i++ {
+		delay := elector.nextDelay()
+		if delay < minDelay || delay > maxDelay {
+			t.Fatalf("delay %v outside jitter bounds [%v, %v]", delay, minDelay, maxDelay)
+		}
+		seen[delay] = true
+	}
+	if len(seen) < 2 {
+		t.Error("expected renewal delays to be jittered")
+	}
+}
+
+func TestElector_RenewsBeforeExpiry(t *testing.T) {
+	store := &fakeStore{}
+	ttl := 100 * time.Millisecond
+	elector := newTestElector(t, store, ttl)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		elector.Run(ctx)
+		close(done)
+	}()
+
+	time.Sleep(350 * time.Millisecond)
+	if !elector.IsLeader() {
+		t.Error("expected elector to hold the lock")
+	}
+	cancel()
+	<-done
+
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if len(store.renewals) < 2 {
+		t.Fatalf("expected multiple renewals, got %d", len(store.renewals))
+	}
+	for i := 1; i < len(store.renewals); i++ {
+		if gap := store.renewals[i].Sub(store.renewals[i-1]); gap >= ttl {
+			t.Errorf("renewal gap %v is not shorter than the TTL %v", gap, ttl)
+		}
+	}
+	if store.holder != "" {
+		t.Error("expected the lock to be released on exit")
+	}
+}
+
+func TestElector_DetectsLostLock(t *testing.T) {
+	store := &fakeStore{}
+	elector := newTestElector(t, store, 100*time.Millisecond)
+
+	var mu sync.Mutex
+	var transitions []bool
+	elector.OnChange = func(isLeader bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		transitions = append(transitions, isLeader)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go elector.Run(ctx)
+
+	time.Sleep(20 * time.Millisecond)
+	if !elector.IsLeader() {
+		t.Fatal("expected elector to acquire the lock")
+	}
+
+	store.stealLock()
+	time.Sleep(100 * time.Millisecond)
+
+	if elector.IsLeader() {
+		t.Error("expected lost lock to be detected")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(transitions) != 2 || !transitions[0] || transitions[1] {
+		t.Errorf("expected transitions [true false], got %v", transitions)
+	}
+}
+
+// errStore fails every renewal
+type errStore struct{ fakeStore }
+
+func (e *errStore) RenewLock(context.Context, string, string, time.Duration) (bool, error) {
+	return false, errors.New("connection refused")
+}
+
+func TestElector_RenewErrorDropsLeadership(t *testing.T) {
+	store := &errStore{}
+	elector := newTestElector(t, store, time.Second)
+	ctx := context.Background()
+
+	elector.acquire(ctx)
+	if !elector.IsLeader() {
+		t.Fatal("expected elector to acquire the lock")
+	}
+
+	elector.renew(ctx)
+	if elector.IsLeader() {
+		t.Error("expected leadership to be dropped when renewal fails")
+	}
+}
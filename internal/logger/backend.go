@@ -0,0 +1,247 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/opensearch-project/opensearch-go/v2"
+	"github.com/opensearch-project/opensearch-go/v2/opensearchapi"
+	"github.com/signalmice/signalmice/internal/config"
+)
+
+// Backends accepted in LOG_BACKEND
+const (
+	BackendOpensearch    = "opensearch"
+	BackendElasticsearch = "elasticsearch"
+)
+
+// ValidateBackend returns an error unless backend is a known log backend
+func ValidateBackend(backend string) error {
+	switch backend {
+	case "", BackendOpensearch, BackendElasticsearch:
+		return nil
+	default:
+		return fmt.Errorf("unknown log backend %q (expected %s or %s)", backend, BackendOpensearch, BackendElasticsearch)
+	}
+}
+
+// indexer is the search backend log documents are indexed into
+type indexer interface {
+	// Info checks that the backend is reachable and is the expected product
+	Info(ctx context.Context) error
+	// PutIndexTemplate creates the composable index template name, failing if it already exists
+	PutIndexTemplate(ctx context.Context, name string, body []byte) (*backendResponse, error)
+	// Index indexes one document into index
+	Index(ctx context.Context, index string, body []byte, opts indexOptions) (*backendResponse, error)
+}
+
+// indexOptions are the per-document parameters of an index request; empty ones are not sent
+type indexOptions struct {
+	DocumentID string
+	Pipeline   string
+	Refresh    string
+	Routing    string
+}
+
+// backendResponse is the status and body of a backend response; the caller closes Body
+type backendResponse struct {
+	StatusCode int
+	Body       io.ReadCloser
+}
+
+// IsError reports whether the response is an error
+func (r *backendResponse) IsError() bool {
+	return r.StatusCode > 299
+}
+
+// Status returns the response status, e.g. "429 Too Many Requests"
+func (r *backendResponse) Status() string {
+	return fmt.Sprintf("%d %s", r.StatusCode, http.StatusText(r.StatusCode))
+}
+
+// newIndexer creates the client of the backend selected by cfg.LogBackend
+func newIndexer(cfg *config.Config, transport http.RoundTripper) (indexer, error) {
+	if cfg.LogBackend == BackendElasticsearch {
+		return newElasticsearchIndexer(cfg, transport), nil
+	}
+
+	osConfig := opensearch.Config{
+		Addresses: []string{cfg.OpensearchURL},
+		Transport: transport,
+	}
+	if cfg.OpensearchUsername != "" {
+		osConfig.Username = cfg.OpensearchUsername
+		osConfig.Password = cfg.OpensearchPassword
+	}
+
+	client, err := opensearch.NewClient(osConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Opensearch client: %w", err)
+	}
+	return &opensearchIndexer{client: client}, nil
+}
+
+// opensearchIndexer indexes through the opensearch-go client
+type opensearchIndexer struct {
+	client *opensearch.Client
+}
+
+func (o *opensearchIndexer) Info(ctx context.Context) error {
+	res, err := o.client.Info(o.client.Info.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	return nil
+}
+
+func (o *opensearchIndexer) PutIndexTemplate(ctx context.Context, name string, body []byte) (*backendResponse, error) {
+	res, err := o.client.Indices.PutIndexTemplate(
+		name,
+		bytes.NewReader(body),
+		o.client.Indices.PutIndexTemplate.WithContext(ctx),
+		o.client.Indices.PutIndexTemplate.WithCreate(true),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &backendResponse{StatusCode: res.StatusCode, Body: res.Body}, nil
+}
+
+func (o *opensearchIndexer) Index(ctx context.Context, index string, body []byte, opts indexOptions) (*backendResponse, error) {
+	reqOpts := []func(*opensearchapi.IndexRequest){
+		o.client.Index.WithContext(ctx),
+		o.client.Index.WithDocumentID(opts.DocumentID),
+	}
+	if opts.Pipeline != "" {
+		reqOpts = append(reqOpts, o.client.Index.WithPipeline(opts.Pipeline))
+	}
+	if opts.Refresh != "" {
+		reqOpts = append(reqOpts, o.client.Index.WithRefresh(opts.Refresh))
+	}
+	if opts.Routing != "" {
+		reqOpts = append(reqOpts, o.client.Index.WithRouting(opts.Routing))
+	}
+
+	res, err := o.client.Index(index, bytes.NewReader(body), reqOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return &backendResponse{StatusCode: res.StatusCode, Body: res.Body}, nil
+}
+
+// elasticsearchProductHeader is sent by Elasticsearch 7.14 and later to identify the product
+const elasticsearchProductHeader = "X-Elastic-Product"
+
+// elasticsearchIndexer indexes through the Elasticsearch REST API, which the opensearch-go
+// client only mostly speaks
+type elasticsearchIndexer struct {
+	baseURL  string
+	username string
+	password string
+	client   *http.Client
+}
+
+// newElasticsearchIndexer creates an indexer for the Elasticsearch cluster at OPENSEARCH_URL
+func newElasticsearchIndexer(cfg *config.Config, transport http.RoundTripper) *elasticsearchIndexer {
+	return &elasticsearchIndexer{
+		baseURL:  strings.TrimSuffix(cfg.OpensearchURL, "/"),
+		username: cfg.OpensearchUsername,
+		password: cfg.OpensearchPassword,
+		client:   &http.Client{Transport: transport},
+	}
+}
+
+// Info checks the cluster answers and, when it names its product, that it is Elasticsearch
+func (e *elasticsearchIndexer) Info(ctx context.Context) error {
+	res, err := e.do(ctx, http.MethodGet, "/", nil, nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return fmt.Errorf("elasticsearch info returned %s", res.Status())
+	}
+	if product := res.header.Get(elasticsearchProductHeader); product != "" && product != "Elasticsearch" {
+		return fmt.Errorf("unexpected product %q at %s, expected Elasticsearch", product, e.baseURL)
+	}
+	return nil
+}
+
+func (e *elasticsearchIndexer) PutIndexTemplate(ctx context.Context, name string, body []byte) (*backendResponse, error) {
+	res, err := e.do(ctx, http.MethodPut, "/_index_template/"+url.PathEscape(name), url.Values{"create": {"true"}}, body)
+	if err != nil {
+		return nil, err
+	}
+	return &res.backendResponse, nil
+}
+
+func (e *elasticsearchIndexer) Index(ctx context.Context, index string, body []byte, opts indexOptions) (*backendResponse, error) {
+	query := url.Values{}
+	if opts.Pipeline != "" {
+		query.Set("pipeline", opts.Pipeline)
+	}
+	if opts.Refresh != "" {
+		query.Set("refresh", opts.Refresh)
+	}
+	if opts.Routing != "" {
+		query.Set("routing", opts.Routing)
+	}
+
+	path := "/" + url.PathEscape(index) + "/_doc"
+	method := http.MethodPost
+	if opts.DocumentID != "" {
+		path += "/" + url.PathEscape(opts.DocumentID)
+		method = http.MethodPut
+	}
+
+	res, err := e.do(ctx, method, path, query, body)
+	if err != nil {
+		return nil, err
+	}
+	return &res.backendResponse, nil
+}
+
+// elasticsearchResponse is a backend response along with its headers
+type elasticsearchResponse struct {
+	backendResponse
+	header http.Header
+}
+
+// do sends a request with an optional JSON body to the cluster
+func (e *elasticsearchIndexer) do(ctx context.Context, method, path string, query url.Values, body []byte) (*elasticsearchResponse, error) {
+	target := e.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if e.username != "" {
+		req.SetBasicAuth(e.username, e.password)
+	}
+
+	res, err := e.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	return &elasticsearchResponse{
+		backendResponse: backendResponse{StatusCode: res.StatusCode, Body: res.Body},
+		header:          res.Header,
+	}, nil
+}
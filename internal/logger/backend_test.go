@@ -0,0 +1,149 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestValidateBackend(t *testing.T) {
+	for _, backend := range []string{"", BackendOpensearch, BackendElasticsearch} {
+		if err := ValidateBackend(backend); err != nil {
+			t.Errorf("unexpected error for %q: %v", backend, err)
+		}
+	}
+	if err := ValidateBackend("solr"); err == nil {
+		t.Error("expected error for unknown backend")
+	}
+}
+
+func TestNewIndexer(t *testing.T) {
+	cfg := createTestConfig()
+
+	client, err := newIndexer(cfg, http.DefaultTransport)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := client.(*opensearchIndexer); !ok {
+		t.Errorf("expected the opensearch client by default, got %T", client)
+	}
+
+	cfg.LogBackend = BackendElasticsearch
+	client, err = newIndexer(cfg, http.DefaultTransport)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := client.(*elasticsearchIndexer); !ok {
+		t.Errorf("expected the elasticsearch client, got %T", client)
+	}
+}
+
+// esRequest is a request received by the fake Elasticsearch endpoint
+type esRequest struct {
+	method, path, query, user, body string
+}
+
+// newTestElasticsearchServer serves GET / as the given product and records every other request
+func newTestElasticsearchServer(t *testing.T, product string) (*httptest.Server, func() []esRequest) {
+	t.Helper()
+	var mu sync.Mutex
+	var requests []esRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(elasticsearchProductHeader, product)
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == http.MethodGet && r.URL.Path == "/" {
+			_, _ = w.Write([]byte(`{"version":{"number":"8.13.0"}}`))
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		user, _, _ := r.BasicAuth()
+		mu.Lock()
+		requests = append(requests, esRequest{r.Method, r.URL.Path, r.URL.RawQuery, user, string(body)})
+		mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"result":"created"}`))
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []esRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]esRequest(nil), requests...)
+	}
+}
+
+func TestLogger_Elasticsearch_IndexesDocument(t *testing.T) {
+	server, requests := newTestElasticsearchServer(t, "Elasticsearch")
+
+	cfg := createTestConfig()
+	cfg.LogBackend = BackendElasticsearch
+	cfg.OpensearchURL = server.URL
+	cfg.OpensearchUsername = "elastic"
+	cfg.OpensearchPassword = "changeme"
+	cfg.OpensearchCreateTemplate = true
+	cfg.OpensearchPipeline = "signalmice"
+	l, err := NewLogger(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer l.Close(context.Background())
+	if err := l.OpensearchErr(); err != nil {
+		t.Fatalf("expected Elasticsearch to be reachable, got %v", err)
+	}
+
+	entry := l.newEntry(context.Background(), time.Now(), LevelInfo, "indexed in Elasticsearch", nil)
+	l.sendToOpensearch(context.Background(), entry)
+
+	got := requests()
+	if len(got) != 2 {
+		t.Fatalf("expected the template and the document requests, got %+v", got)
+	}
+	if got[0].method != http.MethodPut || got[0].path != "/_index_template/test-logs-template" || got[0].query != "create=true" {
+		t.Errorf("expected the index template to be created, got %+v", got[0])
+	}
+
+	doc := got[1]
+	if doc.method != http.MethodPut || doc.path != "/"+l.getIndexName()+"/_doc/"+entry.DocumentID() {
+		t.Errorf("expected PUT /%s/_doc/%s, got %s %s", l.getIndexName(), entry.DocumentID(), doc.method, doc.path)
+	}
+	if doc.query != "pipeline=signalmice" {
+		t.Errorf("expected the pipeline parameter, got %q", doc.query)
+	}
+	if doc.user != "elastic" {
+		t.Errorf("expected basic auth as elastic, got %q", doc.user)
+	}
+	var indexed LogEntry
+	if err := json.Unmarshal([]byte(doc.body), &indexed); err != nil || indexed.Message != "indexed in Elasticsearch" {
+		t.Errorf("expected the entry as document, got %s (%v)", doc.body, err)
+	}
+}
+
+func TestLogger_Elasticsearch_UnexpectedProduct(t *testing.T) {
+	server, _ := newTestElasticsearchServer(t, "Kibana")
+
+	cfg := createTestConfig()
+	cfg.LogBackend = BackendElasticsearch
+	cfg.OpensearchURL = server.URL
+	l, err := NewLogger(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := l.OpensearchErr(); err == nil || !strings.Contains(err.Error(), "unexpected product") {
+		t.Errorf("expected an unexpected product error, got %v", err)
+	}
+}
+
+func TestBackendResponse_Status(t *testing.T) {
+	res := &backendResponse{StatusCode: http.StatusTooManyRequests}
+	if !res.IsError() || res.Status() != "429 Too Many Requests" {
+		t.Errorf("expected an error status 429 Too Many Requests, got %v %q", res.IsError(), res.Status())
+	}
+	if (&backendResponse{StatusCode: http.StatusCreated}).IsError() {
+		t.Error("expected 201 not to be an error")
+	}
+}
@@ -0,0 +1,148 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Line formats selectable with SIGNALMICE_LOG_FORMAT, used for stdout and syslog
+const (
+	// FormatText writes the plain "<time> [LEVEL] message" lines
+	FormatText = "text"
+	// FormatCEF writes ArcSight Common Event Format lines with the entry's fields as extensions
+	FormatCEF = "cef"
+)
+
+// DeviceVersion is the CEF Device Version header field; main sets it to the application version
+var DeviceVersion = "unknown"
+
+// ValidateFormat returns an error unless format is a known line format
+func ValidateFormat(format string) error {
+	switch format {
+	case FormatText, FormatCEF:
+		return nil
+	default:
+		return fmt.Errorf("unknown log format %q (expected %s or %s)", format, FormatText, FormatCEF)
+	}
+}
+
+// cefSeverity maps a log level to a CEF severity (0-10)
+func cefSeverity(level Level) int {
+	switch level {
+	case LevelError:
+		return 8
+	case LevelWarn:
+		return 6
+	case LevelDebug:
+		return 1
+	default:
+		return 3
+	}
+}
+
+// cefHeaderEscaper escapes the characters that are special in CEF header fields
+var cefHeaderEscaper = strings.NewReplacer(`\`, `\\`, `|`, `\|`, "\r", " ", "\n", " ")
+
+// cefValueEscaper escapes the characters that are special in CEF extension values
+var cefValueEscaper = strings.NewReplacer(`\`, `\\`, `=`, `\=`, "\r", `\r`, "\n", `\n`)
+
+// formatCEF renders entry as a CEF line: the level is the signature ID, the message the name,
+// and the timestamp, host, correlation and extra fields follow as extensions
+func formatCEF(entry LogEntry) string {
+	var ext []string
+	add := func(key, value string) {
+		if value != "" {
+			ext = append(ext, key+"="+cefValueEscaper.Replace(value))
+		}
+	}
+
+	if ts, err := time.Parse(time.RFC3339, entry.Timestamp); err == nil {
+		add("rt", fmt.Sprintf("%d", ts.UnixMilli()))
+	}
+	add("dvchost", entry.Hostname)
+	add("msg", entry.Message)
+
+	// Fields without a CEF key go to the custom string labels, six at most
+	labels := []struct{ name, value string }{
+		{"redis_key", entry.RedisKey},
+		{"correlation_id", entry.CorrelationID},
+		{"trace_id", entry.TraceID},
+	}
+	n := 0
+	for _, label := range labels {
+		if label.value == "" {
+			continue
+		}
+		n++
+		add(fmt.Sprintf("cs%d", n), label.value)
+		add(fmt.Sprintf("cs%dLabel", n), label.name)
+	}
+
+	// Extra fields are extensions of their own, named after the field
+	for _, kv := range extraFields(entry.Extra) {
+		add(kv[0], kv[1])
+	}
+
+	return fmt.Sprintf("CEF:0|signalmice|%s|%s|%s|%s|%d|%s",
+		cefHeaderEscaper.Replace(entry.Service),
+		cefHeaderEscaper.Replace(DeviceVersion),
+		entry.Level,
+		cefHeaderEscaper.Replace(entry.Message),
+		cefSeverity(entry.Level),
+		strings.Join(ext, " "))
+}
+
+// extraFields flattens an entry's extra into sorted key/value pairs. Keys are reduced to the
+// characters CEF extension keys allow; non-string values are JSON encoded.
+func extraFields(extra any) [][2]string {
+	var fields map[string]any
+	switch e := extra.(type) {
+	case nil:
+		return nil
+	case map[string]any:
+		fields = e
+	case map[string]string:
+		fields = make(map[string]any, len(e))
+		for k, v := range e {
+			fields[k] = v
+		}
+	default:
+		data, err := json.Marshal(extra)
+		if err != nil {
+			return nil
+		}
+		return [][2]string{{"extra", string(data)}}
+	}
+
+	pairs := make([][2]string, 0, len(fields))
+	for k, v := range fields {
+		key := cefKey(k)
+		if key == "" {
+			continue
+		}
+		value, ok := v.(string)
+		if !ok {
+			data, err := json.Marshal(v)
+			if err != nil {
+				continue
+			}
+			value = string(data)
+		}
+		pairs = append(pairs, [2]string{key, value})
+	}
+	sort.Slice(pairs, func(i, j int) bool { return pairs[i][0] < pairs[j][0] })
+	return pairs
+}
+
+// cefKey keeps the letters, digits and underscores of key
+func cefKey(key string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, key)
+}
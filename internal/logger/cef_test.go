@@ -0,0 +1,114 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestValidateFormat(t *testing.T) {
+	for _, format := range []string{FormatText, FormatCEF} {
+		if err := ValidateFormat(format); err != nil {
+			t.Errorf("expected %q to be valid, got %v", format, err)
+		}
+	}
+	if err := ValidateFormat("leef"); err == nil {
+		t.Error("expected an unknown format to be rejected")
+	}
+}
+
+func TestFormatCEF_HeaderAndExtensions(t *testing.T) {
+	entry := LogEntry{
+		Timestamp:     "2024-01-02T03:04:05Z",
+		Level:         LevelWarn,
+		Message:       "Signal key found | arming",
+		Hostname:      "node-1",
+		Service:       "signalmice",
+		RedisKey:      "signalmice:shutdown",
+		CorrelationID: "abc123",
+		Extra:         map[string]any{"action": "reboot", "query": "a=b", "attempt": 2},
+	}
+
+	line := formatCEF(entry)
+
+	header := `CEF:0|signalmice|signalmice|` + DeviceVersion + `|WARN|Signal key found \| arming|6|`
+	if !strings.HasPrefix(line, header) {
+		t.Fatalf("expected header %q, got %q", header, line)
+	}
+	for _, ext := range []string{
+		"rt=1704164645000",
+		"dvchost=node-1",
+		"cs1=signalmice:shutdown cs1Label=redis_key",
+		"cs2=abc123 cs2Label=correlation_id",
+		"action=reboot",
+		"attempt=2",
+		`query=a\=b`,
+	} {
+		if !strings.Contains(line, ext) {
+			t.Errorf("expected extension %q, got %q", ext, line)
+		}
+	}
+}
+
+func TestCEFSeverity(t *testing.T) {
+	tests := []struct {
+		level Level
+		want  int
+	}{
+		{LevelDebug, 1},
+		{LevelInfo, 3},
+		{LevelWarn, 6},
+		{LevelError, 8},
+	}
+
+	for _, tt := range tests {
+		if got := cefSeverity(tt.level); got != tt.want {
+			t.Errorf("cefSeverity(%s) = %d, want %d", tt.level, got, tt.want)
+		}
+	}
+}
+
+func TestLogger_StdoutCEF(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	logger := &Logger{hostname: "test-host", redisKey: "test-key", format: FormatCEF}
+	logger.ErrorWithExtra(context.Background(), "Shutdown failed", map[string]string{"method": "systemctl"})
+
+	line := strings.TrimSuffix(buf.String(), "\n")
+	if !strings.HasPrefix(line, "CEF:0|signalmice|signalmice|") || !strings.Contains(line, "|ERROR|Shutdown failed|8|") {
+		t.Errorf("expected a CEF line, got %q", line)
+	}
+	if !strings.Contains(line, "method=systemctl") {
+		t.Errorf("expected the extra field as an extension, got %q", line)
+	}
+}
+
+func TestSyslogSink_CEF(t *testing.T) {
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	sink, err := NewSyslogSink("udp", listener.LocalAddr().String(), "node-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer sink.Close()
+	sink.lineFormat = FormatCEF
+
+	if err := sink.Write(LogEntry{Level: LevelInfo, Message: "Signal key found", Hostname: "node-1", Service: "signalmice"}); err != nil {
+		t.Fatalf("unexpected write error: %v", err)
+	}
+
+	msg := readPacket(t, listener)
+	if !strings.Contains(msg, " - CEF:0|signalmice|signalmice|") || !strings.Contains(msg, "|INFO|Signal key found|3|") {
+		t.Errorf("expected a CEF message body, got %q", msg)
+	}
+}
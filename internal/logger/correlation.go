@@ -0,0 +1,35 @@
+package logger
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+)
+
+// correlationIDKey is the context key for the correlation ID of a flow
+type correlationIDKey struct{}
+
+// NewCorrelationID returns a random RFC 4122 version 4 UUID
+func NewCorrelationID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return ""
+	}
+	b[6] = (b[6] & 0x0f) | 0x40 // version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // variant 10
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
+
+// WithCorrelationID returns a context carrying the given correlation ID.
+// Every log entry written with that context is tagged with it.
+func WithCorrelationID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, correlationIDKey{}, id)
+}
+
+// CorrelationID returns the correlation ID stored in ctx, or an empty string
+func CorrelationID(ctx context.Context) string {
+	if id, ok := ctx.Value(correlationIDKey{}).(string); ok {
+		return id
+	}
+	return ""
+}
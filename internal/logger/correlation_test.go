@@ -0,0 +1,57 @@
+package logger
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+)
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+
+func TestNewCorrelationID(t *testing.T) {
+	first := NewCorrelationID()
+	second := NewCorrelationID()
+
+	if !uuidPattern.MatchString(first) {
+		t.Errorf("expected a v4 UUID, got '%s'", first)
+	}
+	if first == second {
+		t.Error("expected correlation IDs to be unique")
+	}
+}
+
+func TestCorrelationID_Context(t *testing.T) {
+	if id := CorrelationID(context.Background()); id != "" {
+		t.Errorf("expected empty correlation ID, got '%s'", id)
+	}
+
+	ctx := WithCorrelationID(context.Background(), "abc-123")
+	if id := CorrelationID(ctx); id != "abc-123" {
+		t.Errorf("expected correlation ID 'abc-123', got '%s'", id)
+	}
+}
+
+func TestLogger_EntriesShareCorrelationID(t *testing.T) {
+	logger := &Logger{hostname: "test-host", redisKey: "test-key"}
+
+	now := time.Now().UTC()
+	id := NewCorrelationID()
+	ctx := WithCorrelationID(context.Background(), id)
+
+	entries := []LogEntry{
+		logger.newEntry(ctx, now, LevelInfo, "Shutdown signal received", nil),
+		logger.newEntry(ctx, now, LevelInfo, "Attempting shutdown via nsenter", nil),
+		logger.newEntry(ctx, now, LevelError, "Failed to initiate host shutdown", nil),
+	}
+	for _, entry := range entries {
+		if entry.CorrelationID != id {
+			t.Errorf("expected entry %q to carry correlation ID '%s', got '%s'", entry.Message, id, entry.CorrelationID)
+		}
+	}
+
+	outside := logger.newEntry(context.Background(), now, LevelDebug, "Redis key not found", nil)
+	if outside.CorrelationID != "" {
+		t.Errorf("expected no correlation ID outside the flow, got '%s'", outside.CorrelationID)
+	}
+}
@@ -1,18 +1,25 @@
 package logger
 
 import (
-	"bytes"
 	"context"
+	"crypto/sha256"
 	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/opensearch-project/opensearch-go/v2"
 	"github.com/signalmice/signalmice/internal/config"
+	"github.com/signalmice/signalmice/internal/tracing"
 )
 
 // Level represents the log level
@@ -25,6 +32,13 @@ const (
 	LevelDebug Level = "DEBUG"
 )
 
+// Retry policy for log entries throttled by Opensearch (HTTP 429)
+const (
+	opensearchMaxRetries       = 3
+	opensearchRetryBaseBackoff = 500 * time.Millisecond
+	opensearchRetryMaxBackoff  = 5 * time.Second
+)
+
 // LogEntry represents a log entry to be sent to Opensearch
 type LogEntry struct {
 	Timestamp string `json:"@timestamp"`
@@ -34,65 +48,265 @@ type LogEntry struct {
 	Service   string `json:"service"`
 	RedisKey  string `json:"redis_key,omitempty"`
 	Extra     any    `json:"extra,omitempty"`
+
+	CorrelationID string `json:"correlation_id,omitempty"`
+	TraceID       string `json:"trace_id,omitempty"`
+	Truncated     bool   `json:"truncated,omitempty"`
+
+	// Sequence distinguishes otherwise identical entries logged by this process; it only
+	// feeds the document ID and is not indexed
+	Sequence uint64 `json:"-"`
+}
+
+// DocumentID returns a deterministic Opensearch document ID for the entry, so re-indexing
+// it on retry overwrites the first attempt instead of creating a duplicate
+func (e LogEntry) DocumentID() string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s\x00%s\x00%s\x00%d", e.Timestamp, e.Hostname, e.Message, e.Sequence)))
+	return hex.EncodeToString(sum[:16])
 }
 
 // Logger handles logging to both stdout and Opensearch
 type Logger struct {
-	client        *opensearch.Client
-	baseIndex     string
-	useDailyIndex bool
-	hostname      string
-	redisKey      string
+	client          indexer // Opensearch or Elasticsearch, per LOG_BACKEND; nil logs to stdout only
+	pending         indexer // Client unreachable at startup, until WaitOpensearch connects it
+	createTemplate  bool    // Install the index template once connected
+	baseIndex       string
+	useDailyIndex   bool
+	hostname        string
+	redisKey        string
+	timeFormat      string
+	format          string // Line format of stdout, FormatText or FormatCEF
+	spillPath       string
+	maxDocBytes     int
+	redactErrorBody bool
+	pipeline        string
+	refresh         string        // Refresh policy sent with signal flow documents; "false" sends none
+	refreshAll      bool          // Send the refresh policy with every document
+	routing         string        // Entry field routing documents to a shard, one of the Routing values; empty uses none
+	sendTimeout     time.Duration // Budget of sending one entry, retries included; 0 leaves it unbounded
+	schema          string
+	fields          map[string]any // Static fields merged into every entry's extra; nil adds none
+	worker          *logWorker
+	stdout          *bufferedWriter // Batches stdout lines; nil writes each line as it is logged
+	ring            *entryRing      // Latest entries for the status endpoint; nil keeps none
+
+	sinksMu sync.RWMutex
+	sinks   []Sink
+
+	identityMu sync.RWMutex // Guards hostname and redisKey, which change at runtime
+
+	seq atomic.Uint64 // Last entry sequence number
+
+	connectErr error // Why Opensearch was unreachable at startup; nil when connected
 }
 
 // NewLogger creates a new logger that writes to Opensearch
 func NewLogger(cfg *config.Config) (*Logger, error) {
-	hostname, _ := os.Hostname()
+	hostname := cfg.Hostname
+
+	tlsConfig, err := buildTLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := staticFields(cfg)
+	if err != nil {
+		return nil, err
+	}
 
-	// Create Opensearch client
+	// Create the Opensearch (or Elasticsearch) client
 	transport := &http.Transport{
-		TLSClientConfig: &tls.Config{
-			InsecureSkipVerify: true, // Allow self-signed certificates
-		},
+		TLSClientConfig: tlsConfig,
+	}
+	client, err := newIndexer(cfg, transport)
+	if err != nil {
+		return nil, err
 	}
 
-	osConfig := opensearch.Config{
-		Addresses: []string{cfg.OpensearchURL},
-		Transport: transport,
+	l := &Logger{
+		baseIndex:       cfg.OpensearchIndex,
+		useDailyIndex:   cfg.OpensearchUseDailyIndex,
+		hostname:        hostname,
+		redisKey:        cfg.SignalKey(),
+		timeFormat:      resolveTimeFormat(cfg.LogTimeFormat),
+		format:          cfg.LogFormat,
+		spillPath:       cfg.OpensearchSpillFile,
+		maxDocBytes:     cfg.OpensearchMaxDocBytes,
+		redactErrorBody: cfg.OpensearchRedactErrors,
+		pipeline:        cfg.OpensearchPipeline,
+		refresh:         cfg.OpensearchRefresh,
+		refreshAll:      cfg.OpensearchRefreshAll,
+		routing:         cfg.OpensearchRouting,
+		sendTimeout:     cfg.OpensearchSendTimeout,
+		schema:          cfg.LogSchema,
+		fields:          fields,
+		createTemplate:  cfg.OpensearchCreateTemplate,
+		ring:            newEntryRing(cfg.LogRingSize),
 	}
 
-	// Add authentication if provided
-	if cfg.OpensearchUsername != "" {
-		osConfig.Username = cfg.OpensearchUsername
-		osConfig.Password = cfg.OpensearchPassword
+	// Test connection
+	if err := client.Info(context.Background()); err != nil {
+		log.Printf("[WARN] Could not connect to Opensearch: %v. Logging will continue to stdout only.", err)
+		l.pending = client
+		l.connectErr = err
+	} else {
+		l.connect(client)
 	}
+	l.startStdout(cfg)
+	l.addConfiguredSinks(cfg)
 
-	client, err := opensearch.NewClient(osConfig)
+	return l, nil
+}
+
+// connect starts shipping entries to the reachable client, installing the index template first
+// when configured
+func (l *Logger) connect(client indexer) {
+	if l.createTemplate {
+		if err := bootstrapIndexTemplate(client, l.baseIndex, l.schema); err != nil {
+			log.Printf("[WARN] Could not install Opensearch index template: %v", err)
+		}
+	}
+	l.client = client
+	l.pending = nil
+	l.connectErr = nil
+	l.startWorker()
+}
+
+// WaitOpensearch blocks until Opensearch is reachable, probing it every interval, so entries
+// logged from then on are shipped. It returns nil right away when Opensearch was reachable at
+// startup, and an error when it still is not once ctx is done. As it swaps in the client, it
+// must be called before the logger is shared between goroutines.
+func (l *Logger) WaitOpensearch(ctx context.Context, interval time.Duration) error {
+	if l.client != nil {
+		return nil
+	}
+	if l.pending == nil {
+		return fmt.Errorf("no Opensearch client: %w", l.connectErr)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	lastErr := l.connectErr
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("Opensearch still unreachable: %w", lastErr)
+		case <-ticker.C:
+		}
+
+		if lastErr = l.pending.Info(ctx); lastErr == nil {
+			log.Printf("[INFO] Connected to Opensearch, shipping logs from now on")
+			l.connect(l.pending)
+			return nil
+		}
+	}
+}
+
+// indexTemplate returns the index template body mapping the known LogEntry fields, named
+// after schema, for indices named after baseIndex (static or daily)
+func indexTemplate(baseIndex, schema string) map[string]any {
+	return map[string]any{
+		"index_patterns": []string{baseIndex, baseIndex + "-*"},
+		"template": map[string]any{
+			"mappings": map[string]any{
+				"properties": templateProperties(schema),
+			},
+		},
+	}
+}
+
+// bootstrapIndexTemplate installs the index template once, ignoring "already exists"
+func bootstrapIndexTemplate(client indexer, baseIndex, schema string) error {
+	data, err := json.Marshal(indexTemplate(baseIndex, schema))
 	if err != nil {
-		return nil, fmt.Errorf("failed to create Opensearch client: %w", err)
+		return fmt.Errorf("failed to marshal index template: %w", err)
 	}
 
-	// Test connection
-	res, err := client.Info()
+	res, err := client.PutIndexTemplate(context.Background(), baseIndex+"-template", data)
 	if err != nil {
-		log.Printf("[WARN] Could not connect to Opensearch: %v. Logging will continue to stdout only.", err)
-		return &Logger{
-			client:        nil,
-			baseIndex:     cfg.OpensearchIndex,
-			useDailyIndex: cfg.OpensearchUseDailyIndex,
-			hostname:      hostname,
-			redisKey:      cfg.RedisKey,
-		}, nil
+		return fmt.Errorf("failed to put index template: %w", err)
 	}
 	defer res.Body.Close()
 
-	return &Logger{
-		client:        client,
-		baseIndex:     cfg.OpensearchIndex,
-		useDailyIndex: cfg.OpensearchUseDailyIndex,
-		hostname:      hostname,
-		redisKey:      cfg.RedisKey,
-	}, nil
+	if res.IsError() {
+		body, _ := io.ReadAll(res.Body)
+		if strings.Contains(string(body), "already exists") {
+			return nil
+		}
+		return fmt.Errorf("put index template returned %s: %s", res.Status(), string(body))
+	}
+
+	log.Printf("[INFO] Installed Opensearch index template %s-template", baseIndex)
+	return nil
+}
+
+// buildTLSConfig builds the TLS configuration for Opensearch.
+// Without a CA file, server certificates are not verified (allows self-signed certificates).
+func buildTLSConfig(cfg *config.Config) (*tls.Config, error) {
+	minVersion, err := config.ParseTLSVersion(cfg.OpensearchTLSMinVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid OPENSEARCH_TLS_MIN_VERSION: %w", err)
+	}
+
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: true, // Allow self-signed certificates
+		MinVersion:         minVersion,
+	}
+
+	if cfg.OpensearchCAFile != "" {
+		caPEM, err := os.ReadFile(cfg.OpensearchCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Opensearch CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no valid certificates found in Opensearch CA file: %s", cfg.OpensearchCAFile)
+		}
+		tlsConfig.RootCAs = pool
+		tlsConfig.InsecureSkipVerify = false
+	}
+
+	if cfg.OpensearchClientCertFile != "" || cfg.OpensearchClientKeyFile != "" {
+		if cfg.OpensearchClientCertFile == "" || cfg.OpensearchClientKeyFile == "" {
+			return nil, fmt.Errorf("both OPENSEARCH_CLIENT_CERT_FILE and OPENSEARCH_CLIENT_KEY_FILE are required for mutual TLS")
+		}
+		cert, err := tls.LoadX509KeyPair(cfg.OpensearchClientCertFile, cfg.OpensearchClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load Opensearch client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// retryBackoff returns an exponential, capped backoff for the given retry attempt (starting at 1)
+func retryBackoff(attempt int) time.Duration {
+	backoff := opensearchRetryBaseBackoff << (attempt - 1)
+	if backoff <= 0 || backoff > opensearchRetryMaxBackoff {
+		return opensearchRetryMaxBackoff
+	}
+	return backoff
+}
+
+// namedTimeFormats maps well-known layout names to their Go layouts
+var namedTimeFormats = map[string]string{
+	"RFC3339":     time.RFC3339,
+	"RFC3339Nano": time.RFC3339Nano,
+	"RFC1123":     time.RFC1123,
+	"DateTime":    time.DateTime,
+	"StampMilli":  time.StampMilli,
+}
+
+// resolveTimeFormat returns the Go layout for a configured format, defaulting to RFC3339
+func resolveTimeFormat(format string) string {
+	if format == "" {
+		return time.RFC3339
+	}
+	if layout, ok := namedTimeFormats[format]; ok {
+		return layout
+	}
+	return format
 }
 
 // getIndexName returns the index name, optionally with a date suffix for daily indexing
@@ -103,48 +317,313 @@ func (l *Logger) getIndexName() string {
 	return l.baseIndex
 }
 
+// newEntry builds a log entry, tagging it with the correlation ID from ctx if present
+func (l *Logger) newEntry(ctx context.Context, now time.Time, level Level, message string, extra any) LogEntry {
+	return LogEntry{
+		Timestamp:     now.Format(time.RFC3339),
+		Level:         level,
+		Message:       message,
+		Hostname:      l.Hostname(),
+		Service:       "signalmice",
+		RedisKey:      l.RedisKey(),
+		Extra:         l.withFields(extra),
+		CorrelationID: CorrelationID(ctx),
+		TraceID:       tracing.TraceID(ctx),
+		Sequence:      l.seq.Add(1),
+	}
+}
+
+// Hostname returns the hostname stamped on log entries
+func (l *Logger) Hostname() string {
+	l.identityMu.RLock()
+	defer l.identityMu.RUnlock()
+	return l.hostname
+}
+
+// SetHostname changes the hostname stamped on subsequent log entries
+func (l *Logger) SetHostname(hostname string) {
+	l.identityMu.Lock()
+	defer l.identityMu.Unlock()
+	l.hostname = hostname
+}
+
+// RedisKey returns the signal key stamped on log entries
+func (l *Logger) RedisKey() string {
+	l.identityMu.RLock()
+	defer l.identityMu.RUnlock()
+	return l.redisKey
+}
+
+// SetRedisKey changes the signal key stamped on subsequent log entries, once the monitored
+// key was switched at runtime
+func (l *Logger) SetRedisKey(key string) {
+	l.identityMu.Lock()
+	defer l.identityMu.Unlock()
+	l.redisKey = key
+}
+
+// staticFields returns the fields added to every entry: SIGNALMICE_LOG_FIELDS, plus the watched
+// Redis address and DB with SIGNALMICE_LOG_REDIS_TARGET unless SIGNALMICE_LOG_FIELDS sets them
+func staticFields(cfg *config.Config) (map[string]any, error) {
+	fields, err := parseFields(cfg.LogFields)
+	if err != nil || !cfg.LogRedisTarget {
+		return fields, err
+	}
+	if fields == nil {
+		fields = make(map[string]any, 2)
+	}
+	if _, ok := fields["redis_addr"]; !ok {
+		fields["redis_addr"] = cfg.RedisAddr()
+	}
+	if _, ok := fields["redis_db"]; !ok {
+		fields["redis_db"] = cfg.RedisDB
+	}
+	return fields, nil
+}
+
+// parseFields parses the SIGNALMICE_LOG_FIELDS JSON object; empty means no static fields
+func parseFields(raw string) (map[string]any, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	var fields map[string]any
+	if err := json.Unmarshal([]byte(raw), &fields); err != nil {
+		return nil, fmt.Errorf("invalid SIGNALMICE_LOG_FIELDS: %w", err)
+	}
+	return fields, nil
+}
+
+// withFields merges the static fields into extra, with extra's fields winning on conflict.
+// Extra values other than string or any maps are left as they are.
+func (l *Logger) withFields(extra any) any {
+	if len(l.fields) == 0 {
+		return extra
+	}
+	merged := make(map[string]any, len(l.fields))
+	for k, v := range l.fields {
+		merged[k] = v
+	}
+	switch e := extra.(type) {
+	case nil:
+	case map[string]string:
+		for k, v := range e {
+			merged[k] = v
+		}
+	case map[string]any:
+		for k, v := range e {
+			merged[k] = v
+		}
+	default:
+		return extra
+	}
+	return merged
+}
+
 // log sends a log entry to Opensearch and prints to stdout
 func (l *Logger) log(ctx context.Context, level Level, message string, extra any) {
-	entry := LogEntry{
-		Timestamp: time.Now().UTC().Format(time.RFC3339),
-		Level:     level,
-		Message:   message,
-		Hostname:  l.hostname,
-		Service:   "signalmice",
-		RedisKey:  l.redisKey,
-		Extra:     extra,
+	now := time.Now().UTC()
+	entry := l.newEntry(ctx, now, level, message, extra)
+
+	// Always log to stdout, timestamped so lines can be correlated with indexed documents
+	if l.format == FormatCEF {
+		l.writeStdout(level, []byte(formatCEF(entry)+"\n"))
+	} else {
+		l.writeStdout(level, []byte(fmt.Sprintf("%s [%s] %s\n", now.Format(resolveTimeFormat(l.timeFormat)), level, message)))
 	}
 
-	// Always log to stdout
-	log.Printf("[%s] %s", level, message)
+	l.writeSinks(entry)
+	l.ring.add(entry)
 
-	// Send to Opensearch if client is available
-	if l.client != nil {
-		go l.sendToOpensearch(ctx, entry)
+	// Queue for Opensearch if client is available
+	if l.client != nil && l.worker != nil {
+		l.enqueue(entry)
 	}
 }
 
-// sendToOpensearch sends a log entry to Opensearch
+// OpensearchErr returns the error that left the logger writing to stdout only, or nil if
+// Opensearch was reachable at startup
+func (l *Logger) OpensearchErr() error {
+	return l.connectErr
+}
+
+// maxErrorBodyBytes bounds how much of an Opensearch error response is logged
+const maxErrorBodyBytes = 2048
+
+// errorBody reads a bounded Opensearch error response for logging. With redaction enabled,
+// only the error type is kept, since reasons can quote the rejected document's contents.
+func (l *Logger) errorBody(body io.Reader) string {
+	data, err := io.ReadAll(io.LimitReader(body, maxErrorBodyBytes))
+	if err != nil {
+		return fmt.Sprintf("(failed to read error body: %v)", err)
+	}
+
+	if l.redactErrorBody {
+		var parsed struct {
+			Error struct {
+				Type string `json:"type"`
+			} `json:"error"`
+		}
+		if json.Unmarshal(data, &parsed) != nil || parsed.Error.Type == "" {
+			return "(error body redacted)"
+		}
+		return fmt.Sprintf("error type %s (reason redacted)", parsed.Error.Type)
+	}
+
+	return strings.TrimSpace(string(data))
+}
+
+// marshalEntry encodes entry for indexing. When the document exceeds the configured maximum,
+// the extra field is dropped (and then the message shortened) and the entry is marked truncated,
+// so the essential message is still indexed instead of the whole document being rejected.
+func (l *Logger) marshalEntry(entry LogEntry) ([]byte, error) {
+	data, err := encodeEntry(entry, l.schema)
+	if err != nil || l.maxDocBytes <= 0 || len(data) <= l.maxDocBytes {
+		return data, err
+	}
+
+	entry.Extra = nil
+	entry.Truncated = true
+	if data, err = encodeEntry(entry, l.schema); err != nil || len(data) <= l.maxDocBytes {
+		return data, err
+	}
+
+	// Still too large: shorten the message by the overflow (JSON escaping may need a second pass)
+	for len(data) > l.maxDocBytes && entry.Message != "" {
+		keep := len(entry.Message) - (len(data) - l.maxDocBytes)
+		if keep < 0 {
+			keep = 0
+		}
+		entry.Message = strings.ToValidUTF8(entry.Message[:keep], "")
+		if data, err = encodeEntry(entry, l.schema); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// sendToOpensearch sends a log entry to Opensearch. The send, retries included, is abandoned
+// once the logger's send timeout elapses, so a slow index cannot hold the worker.
 func (l *Logger) sendToOpensearch(ctx context.Context, entry LogEntry) {
-	data, err := json.Marshal(entry)
+	data, err := l.marshalEntry(entry)
 	if err != nil {
 		log.Printf("[ERROR] Failed to marshal log entry: %v", err)
 		return
 	}
 
-	res, err := l.client.Index(
-		l.getIndexName(),
-		bytes.NewReader(data),
-		l.client.Index.WithContext(ctx),
-	)
-	if err != nil {
-		log.Printf("[ERROR] Failed to send log to Opensearch: %v", err)
+	if l.sendTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, l.sendTimeout)
+		defer cancel()
+	}
+
+	opts := indexOptions{
+		DocumentID: entry.DocumentID(),
+		Pipeline:   l.pipeline,
+		Refresh:    l.refreshFor(entry),
+		Routing:    l.routingFor(entry),
+	}
+
+	for attempt := 1; ; attempt++ {
+		res, err := l.client.Index(ctx, l.getIndexName(), data, opts)
+		if err != nil {
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				log.Printf("[ERROR] Abandoned sending log to Opensearch after %s, dropping log entry", l.sendTimeout)
+				return
+			}
+			log.Printf("[ERROR] Failed to send log to Opensearch: %v", err)
+			return
+		}
+		var errBody string
+		if res.IsError() && res.StatusCode != http.StatusTooManyRequests {
+			errBody = l.errorBody(res.Body)
+		}
+		res.Body.Close()
+
+		// Throttled entries are requeued with backoff; permanent 4xx are dropped
+		switch {
+		case res.StatusCode == http.StatusTooManyRequests && attempt <= opensearchMaxRetries:
+			backoff := retryBackoff(attempt)
+			log.Printf("[WARN] Opensearch throttled log entry, retrying in %s", backoff)
+			select {
+			case <-time.After(backoff):
+				continue
+			case <-ctx.Done():
+				log.Printf("[ERROR] Gave up retrying throttled log entry: %v", ctx.Err())
+				return
+			}
+		case res.StatusCode == http.StatusTooManyRequests:
+			log.Printf("[ERROR] Opensearch still throttling after %d retries, dropping log entry", opensearchMaxRetries)
+		case res.StatusCode >= 400 && res.StatusCode < 500:
+			log.Printf("[ERROR] Opensearch rejected log entry, dropping: %s: %s", res.Status(), errBody)
+		case res.IsError():
+			log.Printf("[ERROR] Opensearch returned error: %s: %s", res.Status(), errBody)
+		}
 		return
 	}
-	defer res.Body.Close()
+}
 
-	if res.IsError() {
-		log.Printf("[ERROR] Opensearch returned error: %s", res.Status())
+// Refresh policies accepted in OPENSEARCH_REFRESH
+const (
+	RefreshFalse   = "false"
+	RefreshTrue    = "true"
+	RefreshWaitFor = "wait_for"
+)
+
+// ValidateRefresh returns an error unless refresh is a known refresh policy
+func ValidateRefresh(refresh string) error {
+	switch refresh {
+	case "", RefreshFalse, RefreshTrue, RefreshWaitFor:
+		return nil
+	default:
+		return fmt.Errorf("unknown refresh policy %q (expected %s, %s or %s)", refresh, RefreshFalse, RefreshTrue, RefreshWaitFor)
+	}
+}
+
+// refreshFor returns the refresh policy to index entry with, or "" for none. Entries of a
+// signal flow carry a correlation ID; they are the audit trail the controller searches for
+// right after a shutdown, so only they are refreshed unless refreshAll is set.
+func (l *Logger) refreshFor(entry LogEntry) string {
+	if l.refresh == "" || l.refresh == RefreshFalse {
+		return ""
+	}
+	if entry.CorrelationID == "" && !l.refreshAll {
+		return ""
+	}
+	return l.refresh
+}
+
+// Entry fields accepted in OPENSEARCH_ROUTING
+const (
+	RoutingHostname = "hostname"
+	RoutingService  = "service"
+	RoutingLevel    = "level"
+	RoutingRedisKey = "redis_key"
+)
+
+// ValidateRouting returns an error unless routing is empty or a known routing field
+func ValidateRouting(routing string) error {
+	switch routing {
+	case "", RoutingHostname, RoutingService, RoutingLevel, RoutingRedisKey:
+		return nil
+	default:
+		return fmt.Errorf("unknown routing field %q (expected %s, %s, %s or %s)", routing, RoutingHostname, RoutingService, RoutingLevel, RoutingRedisKey)
+	}
+}
+
+// routingFor returns the routing value to index entry with, or "" to let Opensearch route
+// it by document ID
+func (l *Logger) routingFor(entry LogEntry) string {
+	switch l.routing {
+	case RoutingHostname:
+		return entry.Hostname
+	case RoutingService:
+		return entry.Service
+	case RoutingLevel:
+		return string(entry.Level)
+	case RoutingRedisKey:
+		return entry.RedisKey
+	default:
+		return ""
 	}
 }
 
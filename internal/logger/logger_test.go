@@ -3,13 +3,29 @@ package logger
 import (
 	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"io"
 	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/signalmice/signalmice/internal/config"
+	"github.com/signalmice/signalmice/internal/tracing"
 )
 
 func createTestConfig() *config.Config {
@@ -80,6 +96,107 @@ func TestNewLogger_WithoutOpensearch(t *testing.T) {
 	}
 }
 
+func TestLogger_WaitOpensearch_ConnectsOnceReachable(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	// Connections are dropped until reachable is set, as if Opensearch were still starting
+	var reachable atomic.Bool
+	indexed := make(chan []byte, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !reachable.Load() {
+			panic(http.ErrAbortHandler)
+		}
+		if r.Method == http.MethodGet && r.URL.Path == "/" {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"version":{"number":"2.11.0"}}`))
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		select {
+		case indexed <- body:
+		default:
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	cfg := createTestConfig()
+	cfg.OpensearchURL = server.URL
+	l, err := NewLogger(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer l.Close(context.Background())
+	if l.OpensearchErr() == nil {
+		t.Fatal("expected the logger to start disconnected")
+	}
+	time.AfterFunc(50*time.Millisecond, func() { reachable.Store(true) })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := l.WaitOpensearch(ctx, 10*time.Millisecond); err != nil {
+		t.Fatalf("expected the wait to succeed once Opensearch answers, got %v", err)
+	}
+	if l.OpensearchErr() != nil {
+		t.Errorf("expected no connection error once connected, got %v", l.OpensearchErr())
+	}
+
+	l.Info(context.Background(), "after connect")
+	select {
+	case body := <-indexed:
+		if !strings.Contains(string(body), "after connect") {
+			t.Errorf("expected the entry to be indexed, got %s", body)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected entries to be shipped once connected")
+	}
+}
+
+func TestLogger_WaitOpensearch_TimesOut(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+	defer server.Close()
+
+	cfg := createTestConfig()
+	cfg.OpensearchURL = server.URL
+	l, err := NewLogger(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := l.WaitOpensearch(ctx, 10*time.Millisecond); err == nil {
+		t.Fatal("expected an error while Opensearch stays unreachable")
+	}
+	if l.client != nil {
+		t.Error("expected the logger to keep writing to stdout only")
+	}
+}
+
+func TestLogger_WaitOpensearch_AlreadyConnected(t *testing.T) {
+	server := newTestOpensearchServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	cfg := createTestConfig()
+	cfg.OpensearchURL = server.URL
+	l, err := NewLogger(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer l.Close(context.Background())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := l.WaitOpensearch(ctx, time.Hour); err != nil {
+		t.Errorf("expected no wait when connected at startup, got %v", err)
+	}
+}
+
 func TestLogger_Info(t *testing.T) {
 	var buf bytes.Buffer
 	log.SetOutput(&buf)
@@ -345,3 +462,796 @@ func TestLogger_GetIndexName_UsesUTC(t *testing.T) {
 		t.Errorf("expected UTC-based index name '%s', got '%s'", expectedIndexName, indexName)
 	}
 }
+
+// newTestOpensearchServer starts a fake Opensearch that answers the startup info request
+// and delegates index requests to the given handler
+func newTestOpensearchServer(t *testing.T, indexHandler http.HandlerFunc) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet && r.URL.Path == "/" {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"version":{"number":"2.11.0"}}`))
+			return
+		}
+		indexHandler(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestLogger_SendToOpensearch_AbandonedAtSendTimeout(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	// Slow index: it answers only once the test is over, far later than the send timeout
+	done := make(chan struct{})
+	server := newTestOpensearchServer(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+	t.Cleanup(func() { close(done) })
+
+	cfg := createTestConfig()
+	cfg.OpensearchURL = server.URL
+	cfg.OpensearchSendTimeout = 100 * time.Millisecond
+	l, err := NewLogger(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer l.Close(context.Background())
+
+	start := time.Now()
+	l.sendToOpensearch(context.Background(), l.newEntry(context.Background(), time.Now(), LevelInfo, "slow entry", nil))
+	elapsed := time.Since(start)
+
+	if elapsed > time.Second {
+		t.Errorf("expected the send to be abandoned near the 100ms timeout, took %v", elapsed)
+	}
+	if !strings.Contains(buf.String(), "Abandoned sending log to Opensearch after 100ms") {
+		t.Errorf("expected the abandoned send to be reported, got: %s", buf.String())
+	}
+}
+
+func TestLogger_SendToOpensearch_RetriesOn429(t *testing.T) {
+	var requests int32
+	var indexed []byte
+	server := newTestOpensearchServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&requests, 1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		indexed, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"result":"created"}`))
+	})
+
+	cfg := createTestConfig()
+	cfg.OpensearchURL = server.URL
+	logger, err := NewLogger(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	entry := LogEntry{Level: LevelInfo, Message: "throttled entry", Service: "signalmice"}
+	logger.sendToOpensearch(context.Background(), entry)
+
+	if got := atomic.LoadInt32(&requests); got != 2 {
+		t.Errorf("expected 2 index requests (429 then success), got %d", got)
+	}
+	if !strings.Contains(string(indexed), "throttled entry") {
+		t.Errorf("expected entry to be eventually indexed, got body: %s", string(indexed))
+	}
+}
+
+func TestLogger_SendToOpensearch_DropsPermanent4xx(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	var requests int32
+	server := newTestOpensearchServer(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	})
+
+	cfg := createTestConfig()
+	cfg.OpensearchURL = server.URL
+	logger, err := NewLogger(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	logger.sendToOpensearch(context.Background(), LogEntry{Level: LevelInfo, Message: "bad entry"})
+
+	if got := atomic.LoadInt32(&requests); got != 1 {
+		t.Errorf("expected permanent 4xx not to be retried, got %d requests", got)
+	}
+	if !strings.Contains(buf.String(), "rejected log entry, dropping") {
+		t.Errorf("expected drop to be logged, got: %s", buf.String())
+	}
+}
+
+func TestLogger_SendToOpensearch_Pipeline(t *testing.T) {
+	tests := []struct {
+		name     string
+		pipeline string
+	}{
+		{name: "configured", pipeline: "signalmice-enrich"},
+		{name: "unset", pipeline: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pipelines := make(chan string, 1)
+			server := newTestOpensearchServer(t, func(w http.ResponseWriter, r *http.Request) {
+				pipelines <- r.URL.Query().Get("pipeline")
+				w.WriteHeader(http.StatusCreated)
+			})
+
+			cfg := createTestConfig()
+			cfg.OpensearchURL = server.URL
+			cfg.OpensearchPipeline = tt.pipeline
+			logger, err := NewLogger(cfg)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			logger.sendToOpensearch(context.Background(), LogEntry{Level: LevelInfo, Message: "enriched"})
+
+			if got := <-pipelines; got != tt.pipeline {
+				t.Errorf("expected pipeline %q, got %q", tt.pipeline, got)
+			}
+		})
+	}
+}
+
+func TestLogger_SendToOpensearch_Routing(t *testing.T) {
+	tests := []struct {
+		name     string
+		routing  string
+		expected string
+	}{
+		{name: "hostname", routing: RoutingHostname, expected: "test-host"},
+		{name: "level", routing: RoutingLevel, expected: "WARN"},
+		{name: "empty field value", routing: RoutingRedisKey, expected: ""},
+		{name: "unset", routing: "", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			routings := make(chan string, 1)
+			server := newTestOpensearchServer(t, func(w http.ResponseWriter, r *http.Request) {
+				routings <- r.URL.Query().Get("routing")
+				w.WriteHeader(http.StatusCreated)
+			})
+
+			cfg := createTestConfig()
+			cfg.OpensearchURL = server.URL
+			cfg.OpensearchRouting = tt.routing
+			logger, err := NewLogger(cfg)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			logger.sendToOpensearch(context.Background(), LogEntry{Level: LevelWarn, Message: "routed", Hostname: "test-host", Service: "signalmice"})
+
+			if got := <-routings; got != tt.expected {
+				t.Errorf("expected routing %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestValidateRouting(t *testing.T) {
+	for _, routing := range []string{"", RoutingHostname, RoutingService, RoutingLevel, RoutingRedisKey} {
+		if err := ValidateRouting(routing); err != nil {
+			t.Errorf("expected %q to be valid, got %v", routing, err)
+		}
+	}
+	if err := ValidateRouting("message"); err == nil {
+		t.Error("expected an unknown routing field to be rejected")
+	}
+}
+
+func TestLogger_SendToOpensearch_Refresh(t *testing.T) {
+	tests := []struct {
+		name          string
+		refresh       string
+		refreshAll    bool
+		correlationID string
+		want          string
+	}{
+		{name: "signal flow entry", refresh: RefreshWaitFor, correlationID: "abc", want: RefreshWaitFor},
+		{name: "ordinary entry", refresh: RefreshWaitFor, want: ""},
+		{name: "ordinary entry with all logs", refresh: RefreshTrue, refreshAll: true, want: RefreshTrue},
+		{name: "disabled", refresh: RefreshFalse, correlationID: "abc", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			refreshes := make(chan string, 1)
+			server := newTestOpensearchServer(t, func(w http.ResponseWriter, r *http.Request) {
+				refreshes <- r.URL.Query().Get("refresh")
+				w.WriteHeader(http.StatusCreated)
+			})
+
+			cfg := createTestConfig()
+			cfg.OpensearchURL = server.URL
+			cfg.OpensearchRefresh = tt.refresh
+			cfg.OpensearchRefreshAll = tt.refreshAll
+			logger, err := NewLogger(cfg)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			logger.sendToOpensearch(context.Background(), LogEntry{Level: LevelInfo, Message: "Host shutdown initiated successfully", CorrelationID: tt.correlationID})
+
+			if got := <-refreshes; got != tt.want {
+				t.Errorf("expected refresh %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestValidateRefresh(t *testing.T) {
+	for _, refresh := range []string{RefreshFalse, RefreshTrue, RefreshWaitFor} {
+		if err := ValidateRefresh(refresh); err != nil {
+			t.Errorf("unexpected error for %q: %v", refresh, err)
+		}
+	}
+	if err := ValidateRefresh("sometimes"); err == nil {
+		t.Error("expected an error for an unknown refresh policy")
+	}
+}
+
+func TestLogger_SendToOpensearch_IdempotentDocumentID(t *testing.T) {
+	var mu sync.Mutex
+	docs := make(map[string]string)
+	server := newTestOpensearchServer(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		docs[r.URL.Path] = string(body)
+		mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	cfg := createTestConfig()
+	cfg.OpensearchURL = server.URL
+	cfg.OpensearchUseDailyIndex = false
+	logger, err := NewLogger(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	entry := logger.newEntry(context.Background(), time.Now(), LevelInfo, "retried entry", nil)
+	logger.sendToOpensearch(context.Background(), entry)
+	logger.sendToOpensearch(context.Background(), entry)
+
+	if len(docs) != 1 {
+		t.Fatalf("expected re-indexing the same entry to produce 1 document, got %d: %v", len(docs), docs)
+	}
+	want := "/" + cfg.OpensearchIndex + "/_doc/" + entry.DocumentID()
+	if _, ok := docs[want]; !ok {
+		t.Errorf("expected document at %s, got %v", want, docs)
+	}
+}
+
+func TestLogEntry_DocumentID_DistinguishesSequence(t *testing.T) {
+	logger := &Logger{hostname: "test-host"}
+	now := time.Now()
+	first := logger.newEntry(context.Background(), now, LevelInfo, "same message", nil)
+	second := logger.newEntry(context.Background(), now, LevelInfo, "same message", nil)
+
+	if first.DocumentID() == second.DocumentID() {
+		t.Error("expected identical messages logged twice to get distinct document IDs")
+	}
+	if retried := first; retried.DocumentID() != first.DocumentID() {
+		t.Error("expected the document ID to be deterministic")
+	}
+}
+
+func TestLogger_NewEntry_TraceID(t *testing.T) {
+	logger := &Logger{hostname: "test-host"}
+	ctx := tracing.WithTracer(context.Background(), tracing.NewTracer(&tracing.InMemoryExporter{}))
+	ctx, span := tracing.Start(ctx, "signal")
+	defer span.End(nil)
+
+	entry := logger.newEntry(ctx, time.Now(), LevelInfo, "traced", nil)
+	if entry.TraceID == "" || entry.TraceID != tracing.TraceID(ctx) {
+		t.Errorf("expected entry to carry trace ID %q, got %q", tracing.TraceID(ctx), entry.TraceID)
+	}
+
+	if untraced := logger.newEntry(context.Background(), time.Now(), LevelInfo, "untraced", nil); untraced.TraceID != "" {
+		t.Errorf("expected no trace ID outside a span, got %q", untraced.TraceID)
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	if got := retryBackoff(1); got != opensearchRetryBaseBackoff {
+		t.Errorf("expected first backoff %v, got %v", opensearchRetryBaseBackoff, got)
+	}
+	if got := retryBackoff(2); got != 2*opensearchRetryBaseBackoff {
+		t.Errorf("expected second backoff %v, got %v", 2*opensearchRetryBaseBackoff, got)
+	}
+	if got := retryBackoff(100); got != opensearchRetryMaxBackoff {
+		t.Errorf("expected backoff to be capped at %v, got %v", opensearchRetryMaxBackoff, got)
+	}
+}
+
+// writeTestCertificate writes a self-signed certificate and key as PEM files into dir
+func writeTestCertificate(t *testing.T, dir string) (certFile, keyFile string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "signalmice-test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("failed to marshal key: %v", err)
+	}
+
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatalf("failed to write cert: %v", err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+	return certFile, keyFile
+}
+
+func TestBuildTLSConfig_Default(t *testing.T) {
+	tlsConfig, err := buildTLSConfig(createTestConfig())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !tlsConfig.InsecureSkipVerify {
+		t.Error("expected InsecureSkipVerify without a CA file")
+	}
+	if tlsConfig.RootCAs != nil || len(tlsConfig.Certificates) != 0 {
+		t.Error("expected no RootCAs or client certificates by default")
+	}
+	if tlsConfig.MinVersion != tls.VersionTLS12 {
+		t.Errorf("expected TLS 1.2 minimum by default, got %#x", tlsConfig.MinVersion)
+	}
+}
+
+func TestBuildTLSConfig_MinVersion(t *testing.T) {
+	cfg := createTestConfig()
+	cfg.OpensearchTLSMinVersion = "1.3"
+
+	tlsConfig, err := buildTLSConfig(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tlsConfig.MinVersion != tls.VersionTLS13 {
+		t.Errorf("expected TLS 1.3 minimum, got %#x", tlsConfig.MinVersion)
+	}
+
+	cfg.OpensearchTLSMinVersion = "1.4"
+	if _, err := buildTLSConfig(cfg); err == nil || !strings.Contains(err.Error(), "OPENSEARCH_TLS_MIN_VERSION") {
+		t.Errorf("expected an invalid OPENSEARCH_TLS_MIN_VERSION error, got %v", err)
+	}
+}
+
+func TestBuildTLSConfig_CAAndClientCert(t *testing.T) {
+	certFile, keyFile := writeTestCertificate(t, t.TempDir())
+
+	cfg := createTestConfig()
+	cfg.OpensearchCAFile = certFile
+	cfg.OpensearchClientCertFile = certFile
+	cfg.OpensearchClientKeyFile = keyFile
+
+	tlsConfig, err := buildTLSConfig(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tlsConfig.InsecureSkipVerify {
+		t.Error("expected certificate verification when a CA file is set")
+	}
+	if tlsConfig.RootCAs == nil {
+		t.Error("expected RootCAs to be set")
+	}
+	if len(tlsConfig.Certificates) != 1 {
+		t.Errorf("expected 1 client certificate, got %d", len(tlsConfig.Certificates))
+	}
+}
+
+func TestBuildTLSConfig_Errors(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := writeTestCertificate(t, dir)
+	garbage := filepath.Join(dir, "garbage.pem")
+	if err := os.WriteFile(garbage, []byte("not a certificate"), 0600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		ca       string
+		cert     string
+		key      string
+		contains string
+	}{
+		{"missing CA file", filepath.Join(dir, "missing.pem"), "", "", "failed to read Opensearch CA file"},
+		{"invalid CA file", garbage, "", "", "no valid certificates"},
+		{"cert without key", "", certFile, "", "both OPENSEARCH_CLIENT_CERT_FILE and OPENSEARCH_CLIENT_KEY_FILE"},
+		{"key without cert", "", "", keyFile, "both OPENSEARCH_CLIENT_CERT_FILE and OPENSEARCH_CLIENT_KEY_FILE"},
+		{"invalid key pair", "", certFile, garbage, "failed to load Opensearch client certificate"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := createTestConfig()
+			cfg.OpensearchCAFile = tt.ca
+			cfg.OpensearchClientCertFile = tt.cert
+			cfg.OpensearchClientKeyFile = tt.key
+
+			_, err := buildTLSConfig(cfg)
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.contains) {
+				t.Errorf("expected error containing %q, got: %v", tt.contains, err)
+			}
+		})
+	}
+}
+
+func TestNewLogger_InvalidCAFile(t *testing.T) {
+	cfg := createTestConfig()
+	cfg.OpensearchCAFile = filepath.Join(t.TempDir(), "missing.pem")
+
+	if _, err := NewLogger(cfg); err == nil {
+		t.Error("expected NewLogger to fail with an unreadable CA file")
+	}
+}
+
+func TestLogger_StaticFields(t *testing.T) {
+	cfg := createTestConfig()
+	cfg.LogFields = `{"env": "prod", "region": "eu-west-1"}`
+	l, err := NewLogger(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	now := time.Now()
+
+	entry := l.newEntry(context.Background(), now, LevelInfo, "Signal key not found", nil)
+	extra, ok := entry.Extra.(map[string]any)
+	if !ok || extra["env"] != "prod" || extra["region"] != "eu-west-1" {
+		t.Errorf("expected the static fields on an entry without extra, got %#v", entry.Extra)
+	}
+
+	entry = l.newEntry(context.Background(), now, LevelWarn, "Redis unreachable", map[string]string{"env": "staging", "error": "timeout"})
+	extra, ok = entry.Extra.(map[string]any)
+	if !ok || extra["env"] != "staging" || extra["region"] != "eu-west-1" || extra["error"] != "timeout" {
+		t.Errorf("expected extra to be merged over the static fields, got %#v", entry.Extra)
+	}
+	if l.fields["env"] != "prod" {
+		t.Error("expected merging not to modify the static fields")
+	}
+}
+
+func TestLogger_RedisTargetFields(t *testing.T) {
+	cfg := createTestConfig()
+	cfg.RedisHost = "redis"
+	cfg.RedisPort = "6379"
+	cfg.RedisDB = 3
+	cfg.LogFields = `{"env": "prod"}`
+
+	l, err := NewLogger(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, ok := l.fields["redis_db"]; ok {
+		t.Error("expected no Redis target fields unless enabled")
+	}
+
+	cfg.LogRedisTarget = true
+	l, err = NewLogger(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	extra, _ := l.newEntry(context.Background(), time.Now(), LevelInfo, "Signal key not found", nil).Extra.(map[string]any)
+	if extra["redis_addr"] != "redis:6379" || extra["redis_db"] != 3 || extra["env"] != "prod" {
+		t.Errorf("expected the Redis target alongside the static fields, got %#v", extra)
+	}
+
+	// An explicitly configured field wins
+	cfg.LogFields = `{"redis_db": "cache"}`
+	l, err = NewLogger(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if l.fields["redis_db"] != "cache" {
+		t.Errorf("expected SIGNALMICE_LOG_FIELDS to win, got %v", l.fields["redis_db"])
+	}
+}
+
+func TestNewLogger_InvalidLogFields(t *testing.T) {
+	cfg := createTestConfig()
+	cfg.LogFields = `["env", "prod"]`
+
+	if _, err := NewLogger(cfg); err == nil || !strings.Contains(err.Error(), "SIGNALMICE_LOG_FIELDS") {
+		t.Errorf("expected an invalid SIGNALMICE_LOG_FIELDS error, got %v", err)
+	}
+}
+
+func TestLogger_StdoutTimestampFormat(t *testing.T) {
+	tests := []struct {
+		name   string
+		format string
+		layout string
+	}{
+		{"default RFC3339", "", time.RFC3339},
+		{"named layout", "RFC3339Nano", time.RFC3339Nano},
+		{"custom layout", "2006-01-02 15:04:05.000", "2006-01-02 15:04:05.000"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			log.SetOutput(&buf)
+			defer log.SetOutput(os.Stderr)
+
+			logger := &Logger{
+				client:     nil,
+				baseIndex:  "test",
+				hostname:   "test-host",
+				redisKey:   "test-key",
+				timeFormat: tt.format,
+			}
+
+			logger.Info(context.Background(), "Timestamped message")
+
+			line := strings.TrimSuffix(buf.String(), "\n")
+			idx := strings.Index(line, " [INFO] ")
+			if idx < 0 {
+				t.Fatalf("expected line to contain ' [INFO] ', got: %s", line)
+			}
+			if _, err := time.Parse(tt.layout, line[:idx]); err != nil {
+				t.Errorf("timestamp %q does not match layout %q: %v", line[:idx], tt.layout, err)
+			}
+			if !strings.HasSuffix(line, "Timestamped message") {
+				t.Errorf("expected line to end with message, got: %s", line)
+			}
+		})
+	}
+}
+
+func TestNewLogger_CreatesIndexTemplate(t *testing.T) {
+	var method, path, query string
+	var body []byte
+	server := newTestOpensearchServer(t, func(w http.ResponseWriter, r *http.Request) {
+		method, path, query = r.Method, r.URL.Path, r.URL.RawQuery
+		body, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"acknowledged":true}`))
+	})
+
+	cfg := createTestConfig()
+	cfg.OpensearchURL = server.URL
+	cfg.OpensearchCreateTemplate = true
+
+	if _, err := NewLogger(cfg); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if method != http.MethodPut || path != "/_index_template/test-logs-template" {
+		t.Errorf("expected PUT /_index_template/test-logs-template, got %s %s", method, path)
+	}
+	if !strings.Contains(query, "create=true") {
+		t.Errorf("expected create=true query, got '%s'", query)
+	}
+
+	var template struct {
+		IndexPatterns []string `json:"index_patterns"`
+		Template      struct {
+			Mappings struct {
+				Properties map[string]struct {
+					Type string `json:"type"`
+				} `json:"properties"`
+			} `json:"mappings"`
+		} `json:"template"`
+	}
+	if err := json.Unmarshal(body, &template); err != nil {
+		t.Fatalf("failed to decode template body: %v", err)
+	}
+
+	if len(template.IndexPatterns) != 2 || template.IndexPatterns[1] != "test-logs-*" {
+		t.Errorf("unexpected index patterns: %v", template.IndexPatterns)
+	}
+	expected := map[string]string{
+		"@timestamp": "date",
+		"level":      "keyword",
+		"hostname":   "keyword",
+		"service":    "keyword",
+	}
+	for field, fieldType := range expected {
+		if got := template.Template.Mappings.Properties[field].Type; got != fieldType {
+			t.Errorf("expected field '%s' to be mapped as '%s', got '%s'", field, fieldType, got)
+		}
+	}
+}
+
+func TestBootstrapIndexTemplate_IgnoresAlreadyExists(t *testing.T) {
+	server := newTestOpensearchServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"error":{"type":"illegal_argument_exception","reason":"index template [test-logs-template] already exists"}}`))
+	})
+
+	cfg := createTestConfig()
+	cfg.OpensearchURL = server.URL
+	logger, err := NewLogger(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := bootstrapIndexTemplate(logger.client, "test-logs", SchemaDefault); err != nil {
+		t.Errorf("expected 'already exists' to be ignored, got: %v", err)
+	}
+}
+
+func TestBootstrapIndexTemplate_Error(t *testing.T) {
+	server := newTestOpensearchServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"error":"forbidden"}`))
+	})
+
+	cfg := createTestConfig()
+	cfg.OpensearchURL = server.URL
+	logger, err := NewLogger(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if err := bootstrapIndexTemplate(logger.client, "test-logs", SchemaDefault); err == nil {
+		t.Error("expected error for a rejected template")
+	}
+}
+
+func TestLogger_SendToOpensearch_TruncatesOversizedExtra(t *testing.T) {
+	var indexed []byte
+	server := newTestOpensearchServer(t, func(w http.ResponseWriter, r *http.Request) {
+		indexed, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	cfg := createTestConfig()
+	cfg.OpensearchURL = server.URL
+	cfg.OpensearchMaxDocBytes = 1024
+	logger, err := NewLogger(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	entry := LogEntry{
+		Level:   LevelInfo,
+		Message: "oversized entry",
+		Service: "signalmice",
+		Extra:   map[string]string{"blob": strings.Repeat("x", 4096)},
+	}
+	logger.sendToOpensearch(context.Background(), entry)
+
+	if len(indexed) == 0 {
+		t.Fatal("expected the truncated entry to still be indexed")
+	}
+	if len(indexed) > 1024 {
+		t.Errorf("expected document within 1024 bytes, got %d", len(indexed))
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal(indexed, &doc); err != nil {
+		t.Fatalf("failed to decode indexed document: %v", err)
+	}
+	if doc["truncated"] != true {
+		t.Errorf("expected truncated flag, got %v", doc["truncated"])
+	}
+	if _, ok := doc["extra"]; ok {
+		t.Error("expected oversized extra to be dropped")
+	}
+	if doc["message"] != "oversized entry" {
+		t.Errorf("expected message to be kept, got %v", doc["message"])
+	}
+}
+
+func TestLogger_MarshalEntry_ShortensHugeMessage(t *testing.T) {
+	logger := &Logger{maxDocBytes: 512}
+	entry := LogEntry{Level: LevelInfo, Message: strings.Repeat("é", 1000), Service: "signalmice"}
+
+	data, err := logger.marshalEntry(entry)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(data) > 512 {
+		t.Errorf("expected document within 512 bytes, got %d", len(data))
+	}
+	if !strings.Contains(string(data), `"truncated":true`) {
+		t.Errorf("expected truncated flag, got %s", data)
+	}
+}
+
+func TestLogger_MarshalEntry_SmallEntryUnchanged(t *testing.T) {
+	logger := &Logger{maxDocBytes: 4096}
+	entry := LogEntry{Level: LevelInfo, Message: "small", Extra: map[string]string{"k": "v"}}
+
+	data, err := logger.marshalEntry(entry)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Contains(string(data), "truncated") || !strings.Contains(string(data), `"extra"`) {
+		t.Errorf("expected small entry to be unchanged, got %s", data)
+	}
+}
+
+func TestLogger_SendToOpensearch_LogsErrorBody(t *testing.T) {
+	const errorBody = `{"error":{"type":"mapper_parsing_exception","reason":"failed to parse field [extra.count] with value [secret-value]"},"status":400}`
+
+	tests := []struct {
+		name    string
+		redact  bool
+		want    string
+		notWant string
+	}{
+		{name: "full body", want: "failed to parse field [extra.count]"},
+		{name: "redacted", redact: true, want: "error type mapper_parsing_exception", notWant: "secret-value"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			log.SetOutput(&buf)
+			defer log.SetOutput(os.Stderr)
+
+			server := newTestOpensearchServer(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(errorBody))
+			})
+
+			cfg := createTestConfig()
+			cfg.OpensearchURL = server.URL
+			cfg.OpensearchRedactErrors = tt.redact
+			logger, err := NewLogger(cfg)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			logger.sendToOpensearch(context.Background(), LogEntry{Level: LevelInfo, Message: "bad entry"})
+
+			if !strings.Contains(buf.String(), tt.want) {
+				t.Errorf("expected %q in log, got: %s", tt.want, buf.String())
+			}
+			if tt.notWant != "" && strings.Contains(buf.String(), tt.notWant) {
+				t.Errorf("expected %q to be redacted, got: %s", tt.notWant, buf.String())
+			}
+		})
+	}
+}
+
+func TestLogger_ErrorBody_Bounded(t *testing.T) {
+	logger := &Logger{}
+	body := logger.errorBody(strings.NewReader(strings.Repeat("x", maxErrorBodyBytes*2)))
+	if len(body) != maxErrorBodyBytes {
+		t.Errorf("expected error body bounded to %d bytes, got %d", maxErrorBodyBytes, len(body))
+	}
+}
@@ -0,0 +1,63 @@
+package logger
+
+import "sync"
+
+// entryRing keeps the most recent log entries in memory, overwriting the oldest once full, so
+// recent history stays available when Opensearch is down and stdout is gone
+type entryRing struct {
+	mu      sync.Mutex
+	entries []LogEntry
+	next    int  // Slot the next entry is written to
+	full    bool // Every slot holds an entry
+}
+
+// newEntryRing returns a ring holding size entries, or nil when size is not positive
+func newEntryRing(size int) *entryRing {
+	if size <= 0 {
+		return nil
+	}
+	return &entryRing{entries: make([]LogEntry, size)}
+}
+
+// add records entry, dropping the oldest one when the ring is full
+func (r *entryRing) add(entry LogEntry) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries[r.next] = entry
+	r.next = (r.next + 1) % len(r.entries)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// last returns up to n of the latest entries, oldest first
+func (r *entryRing) last(n int) []LogEntry {
+	if r == nil || n <= 0 {
+		return []LogEntry{}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	count := r.next
+	if r.full {
+		count = len(r.entries)
+	}
+	if n > count {
+		n = count
+	}
+	out := make([]LogEntry, n)
+	start := r.next - n + len(r.entries)
+	for i := range out {
+		out[i] = r.entries[(start+i)%len(r.entries)]
+	}
+	return out
+}
+
+// RecentEntries returns up to n of the latest log entries, oldest first. It is empty unless
+// SIGNALMICE_LOG_RING_SIZE keeps entries in memory.
+func (l *Logger) RecentEntries(n int) []LogEntry {
+	return l.ring.last(n)
+}
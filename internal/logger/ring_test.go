@@ -0,0 +1,70 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func messages(entries []LogEntry) []string {
+	out := make([]string, len(entries))
+	for i, entry := range entries {
+		out[i] = entry.Message
+	}
+	return out
+}
+
+func TestEntryRing_RetainsLastEntries(t *testing.T) {
+	ring := newEntryRing(3)
+	if got := ring.last(10); len(got) != 0 {
+		t.Fatalf("expected an empty ring, got %v", messages(got))
+	}
+
+	ring.add(LogEntry{Message: "1"})
+	ring.add(LogEntry{Message: "2"})
+	if got := fmt.Sprint(messages(ring.last(10))); got != "[1 2]" {
+		t.Errorf("expected the entries so far, oldest first, got %s", got)
+	}
+
+	for i := 3; i <= 7; i++ {
+		ring.add(LogEntry{Message: fmt.Sprint(i)})
+	}
+	if got := fmt.Sprint(messages(ring.last(10))); got != "[5 6 7]" {
+		t.Errorf("expected the last 3 entries with older ones dropped, got %s", got)
+	}
+	if got := fmt.Sprint(messages(ring.last(2))); got != "[6 7]" {
+		t.Errorf("expected the latest 2 entries, got %s", got)
+	}
+}
+
+func TestEntryRing_Disabled(t *testing.T) {
+	ring := newEntryRing(0)
+	if ring != nil {
+		t.Fatal("expected no ring for size 0")
+	}
+	ring.add(LogEntry{Message: "dropped"})
+	if got := ring.last(5); got == nil || len(got) != 0 {
+		t.Errorf("expected an empty, non-nil list, got %v", got)
+	}
+}
+
+func TestLogger_RecentEntries(t *testing.T) {
+	cfg := createTestConfig()
+	cfg.LogRingSize = 2
+	l, err := NewLogger(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	l.Info(context.Background(), "first")
+	l.Warn(context.Background(), "second")
+	l.Error(context.Background(), "third")
+
+	entries := l.RecentEntries(50)
+	if got := fmt.Sprint(messages(entries)); got != "[second third]" {
+		t.Fatalf("expected the 2 latest entries, got %s", got)
+	}
+	if entries[1].Level != LevelError || entries[1].Service == "" {
+		t.Errorf("expected complete entries, got %+v", entries[1])
+	}
+}
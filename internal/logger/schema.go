@@ -0,0 +1,101 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Log document schemas selectable with SIGNALMICE_LOG_SCHEMA
+const (
+	// SchemaDefault indexes LogEntry with its own flat field names (level, hostname, ...)
+	SchemaDefault = "default"
+	// SchemaECS indexes entries with Elastic Common Schema field names (log.level, host.name, ...)
+	SchemaECS = "ecs"
+)
+
+// ecsVersion is the ECS version the ECS documents conform to
+const ecsVersion = "8.11.0"
+
+// ValidateSchema returns an error unless schema is a known log schema
+func ValidateSchema(schema string) error {
+	switch schema {
+	case SchemaDefault, SchemaECS:
+		return nil
+	default:
+		return fmt.Errorf("unknown log schema %q (expected %s or %s)", schema, SchemaDefault, SchemaECS)
+	}
+}
+
+// encodeEntry serializes entry using the given schema's field names
+func encodeEntry(entry LogEntry, schema string) ([]byte, error) {
+	if schema != SchemaECS {
+		return json.Marshal(entry)
+	}
+	return json.Marshal(ecsDocument(entry))
+}
+
+// ecsDocument maps entry onto ECS fields. Fields without an ECS equivalent go under the
+// signalmice namespace; the Redis key is a label.
+func ecsDocument(entry LogEntry) map[string]any {
+	doc := map[string]any{
+		"@timestamp": entry.Timestamp,
+		"message":    entry.Message,
+		"ecs":        map[string]string{"version": ecsVersion},
+		"log":        map[string]any{"level": entry.Level},
+		"host":       map[string]string{"name": entry.Hostname},
+		"service":    map[string]string{"name": entry.Service},
+	}
+	if entry.RedisKey != "" {
+		doc["labels"] = map[string]string{"redis_key": entry.RedisKey}
+	}
+	if entry.TraceID != "" {
+		doc["trace"] = map[string]string{"id": entry.TraceID}
+	}
+
+	custom := map[string]any{}
+	if entry.Extra != nil {
+		custom["extra"] = entry.Extra
+	}
+	if entry.CorrelationID != "" {
+		custom["correlation_id"] = entry.CorrelationID
+	}
+	if entry.Truncated {
+		custom["truncated"] = true
+	}
+	if len(custom) > 0 {
+		doc["signalmice"] = custom
+	}
+
+	return doc
+}
+
+// templateProperties returns the index mappings of the known fields for schema
+func templateProperties(schema string) map[string]any {
+	keyword := map[string]string{"type": "keyword"}
+	if schema == SchemaECS {
+		object := func(fields map[string]any) map[string]any {
+			return map[string]any{"properties": fields}
+		}
+		return map[string]any{
+			"@timestamp": map[string]string{"type": "date"},
+			"message":    map[string]string{"type": "text"},
+			"ecs":        object(map[string]any{"version": keyword}),
+			"log":        object(map[string]any{"level": keyword}),
+			"host":       object(map[string]any{"name": keyword}),
+			"service":    object(map[string]any{"name": keyword}),
+			"labels":     object(map[string]any{"redis_key": keyword}),
+			"trace":      object(map[string]any{"id": keyword}),
+			"signalmice": object(map[string]any{"truncated": map[string]string{"type": "boolean"}}),
+		}
+	}
+
+	return map[string]any{
+		"@timestamp": map[string]string{"type": "date"},
+		"level":      keyword,
+		"message":    map[string]string{"type": "text"},
+		"hostname":   keyword,
+		"service":    keyword,
+		"redis_key":  keyword,
+		"truncated":  map[string]string{"type": "boolean"},
+	}
+}
@@ -0,0 +1,99 @@
+package logger
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestEncodeEntry_Schemas(t *testing.T) {
+	entry := LogEntry{
+		Timestamp:     "2024-05-01T12:00:00Z",
+		Level:         LevelWarn,
+		Message:       "disk almost full",
+		Hostname:      "node-1",
+		Service:       "signalmice",
+		RedisKey:      "signalmice:node-1",
+		Extra:         map[string]string{"free": "1%"},
+		CorrelationID: "abc123",
+		TraceID:       "0af7651916cd43dd8448eb211c80319c",
+	}
+
+	tests := []struct {
+		schema string
+		want   string
+	}{
+		{
+			schema: SchemaDefault,
+			want: `{"@timestamp":"2024-05-01T12:00:00Z","level":"WARN","message":"disk almost full","hostname":"node-1",` +
+				`"service":"signalmice","redis_key":"signalmice:node-1","extra":{"free":"1%"},"correlation_id":"abc123",` +
+				`"trace_id":"0af7651916cd43dd8448eb211c80319c"}`,
+		},
+		{
+			schema: SchemaECS,
+			want: `{"@timestamp":"2024-05-01T12:00:00Z","ecs":{"version":"8.11.0"},"host":{"name":"node-1"},` +
+				`"labels":{"redis_key":"signalmice:node-1"},"log":{"level":"WARN"},"message":"disk almost full",` +
+				`"service":{"name":"signalmice"},"signalmice":{"correlation_id":"abc123","extra":{"free":"1%"}},` +
+				`"trace":{"id":"0af7651916cd43dd8448eb211c80319c"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.schema, func(t *testing.T) {
+			data, err := encodeEntry(entry, tt.schema)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("unexpected %s document:\n got: %s\nwant: %s", tt.schema, data, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeEntry_ECSOmitsEmptyFields(t *testing.T) {
+	data, err := encodeEntry(LogEntry{Level: LevelInfo, Message: "started", Truncated: true}, SchemaECS)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	for _, field := range []string{"labels", "trace"} {
+		if _, ok := doc[field]; ok {
+			t.Errorf("expected empty %s to be omitted, got %s", field, data)
+		}
+	}
+	if custom, _ := doc["signalmice"].(map[string]any); custom["truncated"] != true {
+		t.Errorf("expected signalmice.truncated, got %s", data)
+	}
+}
+
+func TestLogger_MarshalEntry_UsesSchema(t *testing.T) {
+	logger := &Logger{schema: SchemaECS}
+	data, err := logger.marshalEntry(LogEntry{Level: LevelError, Message: "boom"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var doc map[string]any
+	_ = json.Unmarshal(data, &doc)
+	if _, ok := doc["level"]; ok {
+		t.Errorf("expected no flat level field in ECS mode, got %s", data)
+	}
+	if log, _ := doc["log"].(map[string]any); log["level"] != "ERROR" {
+		t.Errorf("expected log.level ERROR, got %s", data)
+	}
+}
+
+func TestValidateSchema(t *testing.T) {
+	for _, schema := range []string{SchemaDefault, SchemaECS} {
+		if err := ValidateSchema(schema); err != nil {
+			t.Errorf("unexpected error for %s: %v", schema, err)
+		}
+	}
+	if err := ValidateSchema("otel"); err == nil {
+		t.Error("expected error for unknown schema")
+	}
+}
@@ -0,0 +1,57 @@
+package logger
+
+import (
+	"log"
+
+	"github.com/signalmice/signalmice/internal/config"
+)
+
+// Sink receives every log entry in addition to stdout and Opensearch
+type Sink interface {
+	Write(entry LogEntry) error
+	Close() error
+}
+
+// AddSink registers s to receive all subsequent log entries. Sinks are closed by Close.
+func (l *Logger) AddSink(s Sink) {
+	l.sinksMu.Lock()
+	defer l.sinksMu.Unlock()
+	l.sinks = append(l.sinks, s)
+}
+
+// writeSinks passes entry to every registered sink; a failing sink is reported on stdout only
+func (l *Logger) writeSinks(entry LogEntry) {
+	l.sinksMu.RLock()
+	defer l.sinksMu.RUnlock()
+	for _, s := range l.sinks {
+		if err := s.Write(entry); err != nil {
+			log.Printf("[WARN] Failed to write log entry to sink: %v", err)
+		}
+	}
+}
+
+// closeSinks closes and forgets every registered sink
+func (l *Logger) closeSinks() {
+	l.sinksMu.Lock()
+	defer l.sinksMu.Unlock()
+	for _, s := range l.sinks {
+		if err := s.Close(); err != nil {
+			log.Printf("[WARN] Failed to close log sink: %v", err)
+		}
+	}
+	l.sinks = nil
+}
+
+// addConfiguredSinks attaches the sinks enabled in cfg. A sink that cannot be set up is
+// reported and skipped so logging to stdout and Opensearch still works.
+func (l *Logger) addConfiguredSinks(cfg *config.Config) {
+	if cfg.Syslog {
+		sink, err := NewSyslogSink(cfg.SyslogNetwork, cfg.SyslogAddress, cfg.Hostname)
+		if err != nil {
+			log.Printf("[WARN] Could not set up syslog logging: %v", err)
+		} else {
+			sink.lineFormat = cfg.LogFormat
+			l.AddSink(sink)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// recordingSink keeps every entry it receives
+type recordingSink struct {
+	entries []LogEntry
+	err     error
+	closed  bool
+}
+
+func (s *recordingSink) Write(entry LogEntry) error {
+	s.entries = append(s.entries, entry)
+	return s.err
+}
+
+func (s *recordingSink) Close() error {
+	s.closed = true
+	return nil
+}
+
+func TestLogger_AddSink_ReceivesEntries(t *testing.T) {
+	logger := &Logger{hostname: "test-host"}
+	sink := &recordingSink{}
+	failing := &recordingSink{err: errors.New("sink down")}
+	logger.AddSink(failing)
+	logger.AddSink(sink)
+
+	logger.Warn(context.Background(), "disk almost full")
+
+	if len(sink.entries) != 1 {
+		t.Fatalf("expected 1 entry despite the failing sink, got %d", len(sink.entries))
+	}
+	if sink.entries[0].Level != LevelWarn || sink.entries[0].Message != "disk almost full" {
+		t.Errorf("unexpected entry: %+v", sink.entries[0])
+	}
+
+	logger.Close(context.Background())
+	if !sink.closed || !failing.closed {
+		t.Error("expected Close to close all sinks")
+	}
+}
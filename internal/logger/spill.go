@@ -0,0 +1,39 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+)
+
+// spillFile appends log entries that could not be queued for Opensearch as JSON lines,
+// so they can be backfilled once Opensearch is reachable again
+type spillFile struct {
+	path  string
+	mu    sync.Mutex
+	count atomic.Int64
+}
+
+// write appends entry to the spill file and returns the total number of spilled entries
+func (s *spillFile) write(entry LogEntry) (int64, error) {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return s.count.Load(), fmt.Errorf("failed to marshal spilled entry: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, err := os.OpenFile(s.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return s.count.Load(), fmt.Errorf("failed to open spill file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return s.count.Load(), fmt.Errorf("failed to write spill file: %w", err)
+	}
+	return s.count.Add(1), nil
+}
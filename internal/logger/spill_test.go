@@ -0,0 +1,53 @@
+package logger
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLogger_Enqueue_SpillsOnFullQueue(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "spill.jsonl")
+
+	// A worker that never drains, with room for a single entry
+	l := &Logger{hostname: "test-host"}
+	l.worker = &logWorker{queue: make(chan LogEntry, 1), spill: &spillFile{path: path}}
+
+	ctx := context.Background()
+	for i := 0; i < 4; i++ {
+		l.enqueue(l.newEntry(ctx, time.Now(), LevelInfo, "saturating", nil))
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("expected spill file to be written: %v", err)
+	}
+	defer f.Close()
+
+	var lines int
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry LogEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			t.Fatalf("expected JSON lines in spill file, got %q: %v", scanner.Text(), err)
+		}
+		if entry.Message != "saturating" {
+			t.Errorf("expected spilled message 'saturating', got %q", entry.Message)
+		}
+		lines++
+	}
+
+	if lines != 3 {
+		t.Errorf("expected 3 overflow entries in the spill file, got %d", lines)
+	}
+	if got := l.worker.spill.count.Load(); got != 3 {
+		t.Errorf("expected spill count 3, got %d", got)
+	}
+	if got := len(l.worker.queue); got != 1 {
+		t.Errorf("expected the queued entry to remain queued, got %d", got)
+	}
+}
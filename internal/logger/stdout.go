@@ -0,0 +1,179 @@
+package logger
+
+import (
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/signalmice/signalmice/internal/config"
+)
+
+// bufferedWriter batches stdout lines so concurrent loggers only contend on a short append
+// instead of a write to the output each. Lines are written out in the order they were appended.
+type bufferedWriter struct {
+	out  io.Writer
+	size int // Buffered bytes that trigger an immediate flush
+
+	mu      sync.Mutex
+	buf     []byte
+	spare   []byte // Second buffer swapped in on flush, so appends never wait for the output
+	stopped bool
+
+	flushMu sync.Mutex // Serializes flushes, so swapped-out buffers reach out in order
+
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// newBufferedWriter returns a writer buffering up to size bytes for out, flushed every interval
+func newBufferedWriter(out io.Writer, size int, interval time.Duration) *bufferedWriter {
+	w := &bufferedWriter{
+		out:   out,
+		size:  size,
+		buf:   make([]byte, 0, size),
+		spare: make([]byte, 0, size),
+		done:  make(chan struct{}),
+	}
+	if interval > 0 {
+		w.wg.Add(1)
+		go w.run(interval)
+	}
+	return w
+}
+
+// run flushes the buffer every interval until the writer is closed
+func (w *bufferedWriter) run(interval time.Duration) {
+	defer w.wg.Done()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.done:
+			return
+		case <-ticker.C:
+			w.Flush()
+		}
+	}
+}
+
+// Write appends p to the buffer, flushing once it holds size bytes. After Close, p is
+// written straight to the output.
+func (w *bufferedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	if w.stopped {
+		w.mu.Unlock()
+		w.flushMu.Lock()
+		defer w.flushMu.Unlock()
+		return w.out.Write(p)
+	}
+	w.buf = append(w.buf, p...)
+	full := len(w.buf) >= w.size
+	w.mu.Unlock()
+
+	if full {
+		w.Flush()
+	}
+	return len(p), nil
+}
+
+// WriteThrough writes everything buffered so far and then p to the output before returning,
+// for lines that must not wait in the buffer
+func (w *bufferedWriter) WriteThrough(p []byte) (int, error) {
+	w.flushMu.Lock()
+	defer w.flushMu.Unlock()
+
+	w.mu.Lock()
+	if w.stopped {
+		w.mu.Unlock()
+		return w.out.Write(p)
+	}
+	pending := append(w.buf, p...)
+	w.buf = w.spare[:0]
+	w.mu.Unlock()
+
+	_, err := w.out.Write(pending)
+	w.spare = pending[:0]
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Flush writes everything buffered so far to the output
+func (w *bufferedWriter) Flush() error {
+	w.flushMu.Lock()
+	defer w.flushMu.Unlock()
+
+	w.mu.Lock()
+	pending := w.buf
+	w.buf = w.spare[:0]
+	w.mu.Unlock()
+
+	if len(pending) == 0 {
+		w.spare = pending
+		return nil
+	}
+	_, err := w.out.Write(pending)
+	w.spare = pending[:0]
+	return err
+}
+
+// Close stops the periodic flush and writes out the remaining buffer. Lines written after
+// Close go straight to the output, after the remaining buffer.
+func (w *bufferedWriter) Close() error {
+	w.closeOnce.Do(func() { close(w.done) })
+	w.wg.Wait()
+
+	w.flushMu.Lock()
+	defer w.flushMu.Unlock()
+
+	w.mu.Lock()
+	w.stopped = true
+	pending := w.buf
+	w.buf = nil
+	w.mu.Unlock()
+
+	if len(pending) == 0 {
+		return nil
+	}
+	_, err := w.out.Write(pending)
+	return err
+}
+
+// startStdout buffers stdout lines when cfg enables it; otherwise each line is written
+// to the standard logger's output as it is logged
+func (l *Logger) startStdout(cfg *config.Config) {
+	if cfg.StdoutBufferSize > 0 {
+		l.stdout = newBufferedWriter(log.Writer(), cfg.StdoutBufferSize, cfg.StdoutFlushInterval)
+	}
+}
+
+// writeStdout writes a stdout line. ERROR lines bypass the buffer, since the process may
+// exit right after logging one (os.Exit skips Close).
+func (l *Logger) writeStdout(level Level, line []byte) {
+	if l.stdout == nil {
+		log.Writer().Write(line)
+		return
+	}
+	if level == LevelError {
+		l.stdout.WriteThrough(line)
+		return
+	}
+	l.stdout.Write(line)
+}
+
+// flushStdout writes out buffered stdout lines
+func (l *Logger) flushStdout() {
+	if l.stdout != nil {
+		l.stdout.Flush()
+	}
+}
+
+// closeStdout flushes buffered stdout lines and stops buffering new ones
+func (l *Logger) closeStdout() {
+	if l.stdout != nil {
+		l.stdout.Close()
+	}
+}
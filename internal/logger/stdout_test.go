@@ -0,0 +1,239 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// lockedWriter is a mutex-guarded output counting the writes it receives, standing in for
+// stdout whose writes serialize on a file descriptor lock
+type lockedWriter struct {
+	mu     sync.Mutex
+	buf    bytes.Buffer
+	writes int
+}
+
+func (w *lockedWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.writes++
+	return w.buf.Write(p)
+}
+
+func (w *lockedWriter) snapshot() (string, int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String(), w.writes
+}
+
+func TestBufferedWriter_PreservesOrder(t *testing.T) {
+	out := &lockedWriter{}
+	w := newBufferedWriter(out, 64, 0)
+
+	for i := 0; i < 100; i++ {
+		fmt.Fprintf(w, "line %d\n", i)
+	}
+	w.Close()
+
+	lines := strings.Split(strings.TrimSuffix(out.buf.String(), "\n"), "\n")
+	if len(lines) != 100 {
+		t.Fatalf("expected 100 lines, got %d", len(lines))
+	}
+	for i, line := range lines {
+		if line != fmt.Sprintf("line %d", i) {
+			t.Fatalf("expected line %d in order, got %q", i, line)
+		}
+	}
+}
+
+func TestBufferedWriter_ConcurrentWritersKeepPerWriterOrder(t *testing.T) {
+	out := &lockedWriter{}
+	w := newBufferedWriter(out, 4096, time.Millisecond)
+
+	const writers, perWriter = 8, 500
+	var wg sync.WaitGroup
+	for g := 0; g < writers; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < perWriter; i++ {
+				fmt.Fprintf(w, "%d %d\n", g, i)
+			}
+		}()
+	}
+	wg.Wait()
+	w.Close()
+
+	output, writes := out.snapshot()
+	next := make([]int, writers)
+	for _, line := range strings.Split(strings.TrimSuffix(output, "\n"), "\n") {
+		var g, i int
+		if _, err := fmt.Sscanf(line, "%d %d", &g, &i); err != nil {
+			t.Fatalf("garbled line %q: %v", line, err)
+		}
+		if i != next[g] {
+			t.Fatalf("writer %d: expected line %d, got %d", g, next[g], i)
+		}
+		next[g]++
+	}
+	for g, n := range next {
+		if n != perWriter {
+			t.Errorf("writer %d: expected %d lines, got %d", g, perWriter, n)
+		}
+	}
+
+	// Batching is the point: the output is written far less often than lines are logged
+	if writes >= writers*perWriter/10 {
+		t.Errorf("expected batched output writes, got %d for %d lines", writes, writers*perWriter)
+	}
+}
+
+func TestBufferedWriter_FlushesPeriodically(t *testing.T) {
+	out := &lockedWriter{}
+	w := newBufferedWriter(out, 4096, 10*time.Millisecond)
+	defer w.Close()
+
+	fmt.Fprint(w, "queued\n")
+	if output, _ := out.snapshot(); output != "" {
+		t.Fatalf("expected the line to be buffered, got %q", output)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if output, _ := out.snapshot(); output == "queued\n" {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("expected the periodic flush to write the buffered line")
+}
+
+func TestBufferedWriter_WriteAfterClose(t *testing.T) {
+	out := &lockedWriter{}
+	w := newBufferedWriter(out, 4096, time.Hour)
+
+	fmt.Fprint(w, "before\n")
+	if err := w.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	fmt.Fprint(w, "after\n")
+	w.Close()
+
+	if output, _ := out.snapshot(); output != "before\nafter\n" {
+		t.Errorf("expected lines written after Close to follow the buffer, got %q", output)
+	}
+}
+
+func TestBufferedWriter_WriteThrough(t *testing.T) {
+	out := &lockedWriter{}
+	w := newBufferedWriter(out, 4096, time.Hour)
+	defer w.Close()
+
+	fmt.Fprint(w, "buffered\n")
+	if _, err := w.WriteThrough([]byte("urgent\n")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if output, writes := out.snapshot(); output != "buffered\nurgent\n" || writes != 1 {
+		t.Errorf("expected the buffer and the line in one write, got %q in %d writes", output, writes)
+	}
+}
+
+func TestLogger_BufferedStdout(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	cfg := createTestConfig()
+	cfg.StdoutBufferSize = 4096
+	cfg.StdoutFlushInterval = time.Hour
+	l, err := NewLogger(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	buf.Reset()
+
+	l.Info(context.Background(), "first")
+	l.Info(context.Background(), "second")
+	if buf.Len() != 0 {
+		t.Fatalf("expected stdout lines to be buffered, got %q", buf.String())
+	}
+
+	l.Flush(context.Background())
+	if !strings.Contains(buf.String(), "[INFO] first") {
+		t.Fatalf("expected Flush to write buffered lines, got %q", buf.String())
+	}
+
+	l.Info(context.Background(), "third")
+	l.Close(context.Background())
+	output := buf.String()
+	if strings.Index(output, "second") > strings.Index(output, "third") || !strings.Contains(output, "third") {
+		t.Errorf("expected Close to write the remaining lines in order, got %q", output)
+	}
+}
+
+func TestLogger_BufferedStdoutWritesErrorsImmediately(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	cfg := createTestConfig()
+	cfg.StdoutBufferSize = 4096
+	cfg.StdoutFlushInterval = time.Hour
+	l, err := NewLogger(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer l.Close(context.Background())
+	buf.Reset()
+
+	// A fatal line is followed by os.Exit, which never closes the logger
+	l.Info(context.Background(), "connecting")
+	l.Error(context.Background(), "failed to connect")
+
+	output := buf.String()
+	if !strings.Contains(output, "[ERROR] failed to connect") {
+		t.Fatalf("expected the ERROR line to bypass the buffer, got %q", output)
+	}
+	if strings.Index(output, "connecting") > strings.Index(output, "failed to connect") {
+		t.Errorf("expected the buffered lines to be written first, got %q", output)
+	}
+}
+
+// benchmarkStdout logs lines from parallel goroutines to a contended output, directly or buffered
+func benchmarkStdout(b *testing.B, buffered bool) {
+	out := &lockedWriter{}
+	var w io.Writer = out
+	if buffered {
+		bw := newBufferedWriter(out, 64*1024, 100*time.Millisecond)
+		defer bw.Close()
+		w = bw
+	}
+	line := []byte("2024-01-01T00:00:00Z [INFO] Signal key not found, continuing to monitor\n")
+
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			w.Write(line)
+		}
+	})
+	b.StopTimer()
+	_, writes := out.snapshot()
+	b.ReportMetric(float64(writes)/float64(b.N), "outputs/op")
+}
+
+func BenchmarkStdout_Unbuffered(b *testing.B) {
+	benchmarkStdout(b, false)
+}
+
+func BenchmarkStdout_Buffered(b *testing.B) {
+	benchmarkStdout(b, true)
+}
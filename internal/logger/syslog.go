@@ -0,0 +1,163 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// syslogFacilityDaemon is the facility used for all entries (RFC 5424 facility 3)
+const syslogFacilityDaemon = 3
+
+// syslogTimeout bounds connecting to syslog and writing a single message, since entries are
+// written synchronously and a stalled daemon must not hang the logger
+var syslogTimeout = 2 * time.Second
+
+// localSyslogPaths are the sockets tried, in order, for a local syslog daemon
+var localSyslogPaths = []string{"/dev/log", "/var/run/syslog", "/var/run/log"}
+
+// SyslogSink writes log entries as RFC 5424 messages to a local or remote syslog daemon
+type SyslogSink struct {
+	network  string
+	address  string
+	hostname string
+	// Message body format: FormatCEF sends CEF lines, anything else the plain message
+	lineFormat string
+
+	mu     sync.Mutex
+	conn   net.Conn
+	stream bool // Connection is a stream and needs message framing
+}
+
+// NewSyslogSink connects to syslog, sending messages as hostname. An empty network targets the
+// local daemon's socket; otherwise network is "udp", "tcp" or "unix"/"unixgram" and address the
+// remote endpoint.
+func NewSyslogSink(network, address, hostname string) (*SyslogSink, error) {
+	s := &SyslogSink{
+		network:  network,
+		address:  address,
+		hostname: hostname,
+	}
+	if err := s.connect(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// connect dials the configured endpoint, or the first reachable local socket
+func (s *SyslogSink) connect() error {
+	if s.network != "" {
+		conn, err := net.DialTimeout(s.network, s.address, syslogTimeout)
+		if err != nil {
+			return fmt.Errorf("failed to connect to syslog at %s://%s: %w", s.network, s.address, err)
+		}
+		s.conn = conn
+		s.stream = s.network == "tcp" || s.network == "unix"
+		return nil
+	}
+
+	paths := localSyslogPaths
+	if s.address != "" {
+		paths = []string{s.address}
+	}
+	for _, path := range paths {
+		for _, network := range []string{"unixgram", "unix"} {
+			if conn, err := net.DialTimeout(network, path, syslogTimeout); err == nil {
+				s.conn = conn
+				s.stream = network == "unix"
+				return nil
+			}
+		}
+	}
+	return errors.New("failed to connect to local syslog: no syslog socket found")
+}
+
+// syslogSeverity maps a log level to its RFC 5424 severity
+func syslogSeverity(level Level) int {
+	switch level {
+	case LevelError:
+		return 3
+	case LevelWarn:
+		return 4
+	case LevelDebug:
+		return 7
+	default:
+		return 6
+	}
+}
+
+// format renders entry as an RFC 5424 message
+func (s *SyslogSink) format(entry LogEntry) string {
+	priority := syslogFacilityDaemon*8 + syslogSeverity(entry.Level)
+
+	msgID := "-"
+	if entry.CorrelationID != "" {
+		msgID = entry.CorrelationID
+	}
+
+	// Entries carry the logger's current hostname, which may have changed since the sink was set up
+	hostname := entry.Hostname
+	if hostname == "" {
+		hostname = s.hostname
+	}
+	if hostname == "" {
+		hostname = "-"
+	}
+
+	message := strings.ReplaceAll(entry.Message, "\n", " ")
+	if s.lineFormat == FormatCEF {
+		message = formatCEF(entry)
+	}
+	return fmt.Sprintf("<%d>1 %s %s signalmice %d %s - %s",
+		priority, time.Now().UTC().Format(time.RFC3339Nano), hostname, os.Getpid(), msgID, message)
+}
+
+// Write sends entry to syslog, reconnecting once if the connection was lost
+func (s *SyslogSink) Write(entry LogEntry) error {
+	msg := s.format(entry)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn != nil {
+		if err := s.send(msg); err == nil {
+			return nil
+		}
+		s.conn.Close()
+		s.conn = nil
+	}
+
+	if err := s.connect(); err != nil {
+		return err
+	}
+	return s.send(msg)
+}
+
+// send writes msg on the current connection within syslogTimeout; stream transports use
+// octet-counting framing (RFC 6587)
+func (s *SyslogSink) send(msg string) error {
+	if s.stream {
+		msg = fmt.Sprintf("%d %s", len(msg), msg)
+	}
+	if err := s.conn.SetWriteDeadline(time.Now().Add(syslogTimeout)); err != nil {
+		return err
+	}
+	_, err := s.conn.Write([]byte(msg))
+	return err
+}
+
+// Close closes the syslog connection
+func (s *SyslogSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
@@ -0,0 +1,143 @@
+package logger
+
+import (
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSyslogSeverity(t *testing.T) {
+	tests := []struct {
+		level Level
+		want  int
+	}{
+		{LevelError, 3},
+		{LevelWarn, 4},
+		{LevelInfo, 6},
+		{LevelDebug, 7},
+	}
+
+	for _, tt := range tests {
+		if got := syslogSeverity(tt.level); got != tt.want {
+			t.Errorf("syslogSeverity(%s) = %d, want %d", tt.level, got, tt.want)
+		}
+	}
+}
+
+func TestSyslogSink_RemoteUDP(t *testing.T) {
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	sink, err := NewSyslogSink("udp", listener.LocalAddr().String(), "node-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer sink.Close()
+
+	if err := sink.Write(LogEntry{Level: LevelError, Message: "shutdown failed", CorrelationID: "abc123"}); err != nil {
+		t.Fatalf("unexpected write error: %v", err)
+	}
+
+	msg := readPacket(t, listener)
+	// daemon facility (3) * 8 + error severity (3)
+	if !strings.HasPrefix(msg, "<27>1 ") {
+		t.Errorf("expected RFC 5424 header with priority 27, got %q", msg)
+	}
+	if !strings.Contains(msg, " signalmice ") || !strings.Contains(msg, " abc123 - shutdown failed") {
+		t.Errorf("expected app name, message ID and message, got %q", msg)
+	}
+}
+
+func TestSyslogSink_LocalSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "log.sock")
+	listener, err := net.ListenPacket("unixgram", path)
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer listener.Close()
+
+	sink, err := NewSyslogSink("", path, "node-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer sink.Close()
+
+	if err := sink.Write(LogEntry{Level: LevelInfo, Message: "still alive"}); err != nil {
+		t.Fatalf("unexpected write error: %v", err)
+	}
+
+	msg := readPacket(t, listener)
+	// daemon facility (3) * 8 + informational severity (6)
+	if !strings.HasPrefix(msg, "<30>1 ") || !strings.HasSuffix(msg, "- - still alive") {
+		t.Errorf("expected info message with priority 30, got %q", msg)
+	}
+}
+
+func TestNewSyslogSink_Unreachable(t *testing.T) {
+	if _, err := NewSyslogSink("", filepath.Join(t.TempDir(), "missing.sock"), "node-1"); err == nil {
+		t.Error("expected error for missing local socket")
+	}
+}
+
+func TestSyslogSink_StalledDaemonDoesNotBlock(t *testing.T) {
+	original := syslogTimeout
+	syslogTimeout = 50 * time.Millisecond
+	defer func() { syslogTimeout = original }()
+
+	// The daemon accepts connections but never reads, so the socket buffers fill up
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		var conns []net.Conn
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				for _, conn := range conns {
+					conn.Close()
+				}
+				return
+			}
+			conns = append(conns, conn)
+		}
+	}()
+
+	sink, err := NewSyslogSink("tcp", listener.Addr().String(), "node-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer sink.Close()
+
+	// More than the socket buffers hold, so a write without a deadline would hang forever
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		entry := LogEntry{Level: LevelInfo, Message: strings.Repeat("x", 1<<20)}
+		for i := 0; i < 32; i++ {
+			_ = sink.Write(entry)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("expected the write timeout to bound writes to a stalled daemon")
+	}
+}
+
+func readPacket(t *testing.T, conn net.PacketConn) string {
+	t.Helper()
+	buf := make([]byte, 2048)
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("failed to read syslog message: %v", err)
+	}
+	return string(buf[:n])
+}
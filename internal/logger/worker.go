@@ -0,0 +1,155 @@
+package logger
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// logQueueSize is the number of entries buffered for Opensearch before new ones are dropped
+const logQueueSize = 1000
+
+// logWorker ships queued log entries to Opensearch in the background
+type logWorker struct {
+	queue   chan LogEntry
+	done    chan struct{}
+	ctx     context.Context
+	cancel  context.CancelFunc
+	pending atomic.Int64
+	spill   *spillFile // Receives overflow entries instead of dropping them; nil drops
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// startWorker starts the background worker that sends queued entries to Opensearch
+func (l *Logger) startWorker() {
+	ctx, cancel := context.WithCancel(context.Background())
+	l.worker = &logWorker{
+		queue:  make(chan LogEntry, logQueueSize),
+		done:   make(chan struct{}),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	if l.spillPath != "" {
+		l.worker.spill = &spillFile{path: l.spillPath}
+	}
+	go l.runWorker()
+}
+
+// runWorker drains the queue until it is closed, skipping entries once the worker is cancelled
+func (l *Logger) runWorker() {
+	w := l.worker
+	defer close(w.done)
+
+	for entry := range w.queue {
+		if w.ctx.Err() == nil {
+			l.sendToOpensearch(w.ctx, entry)
+		}
+		w.pending.Add(-1)
+	}
+}
+
+// enqueue hands an entry to the worker without blocking. If the queue is full, the entry is
+// appended to the spill file when one is configured, otherwise dropped.
+func (l *Logger) enqueue(entry LogEntry) {
+	w := l.worker
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		return
+	}
+
+	w.pending.Add(1)
+	select {
+	case w.queue <- entry:
+	default:
+		w.pending.Add(-1)
+		if w.spill == nil {
+			log.Printf("[WARN] Opensearch log queue full, dropping log entry")
+			return
+		}
+		spilled, err := w.spill.write(entry)
+		if err != nil {
+			log.Printf("[WARN] Opensearch log queue full and spill failed, dropping log entry: %v", err)
+			return
+		}
+		log.Printf("[WARN] Opensearch log queue full, spilled log entry to %s (%d spilled)", w.spill.path, spilled)
+	}
+}
+
+// QueueDepth returns the number of entries enqueued for Opensearch but not yet shipped
+func (l *Logger) QueueDepth() int {
+	if l.worker == nil {
+		return 0
+	}
+	return int(l.worker.pending.Load())
+}
+
+// QueueCapacity returns the number of entries the Opensearch queue holds before new ones are
+// dropped or spilled, or 0 when logging to stdout only
+func (l *Logger) QueueCapacity() int {
+	if l.worker == nil {
+		return 0
+	}
+	return cap(l.worker.queue)
+}
+
+// flushPollInterval is how often Flush checks whether the queue has drained
+const flushPollInterval = 10 * time.Millisecond
+
+// Flush writes out buffered stdout lines and waits until every entry queued so far has been
+// sent to Opensearch, without closing the logger. It reports whether the queue drained before
+// ctx was done.
+func (l *Logger) Flush(ctx context.Context) bool {
+	l.flushStdout()
+
+	w := l.worker
+	if w == nil {
+		return true
+	}
+
+	ticker := time.NewTicker(flushPollInterval)
+	defer ticker.Stop()
+	for w.pending.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-ticker.C:
+		}
+	}
+	return true
+}
+
+// Close stops accepting new entries and flushes the queued ones to Opensearch.
+// Flushing stops when ctx is done; the number of entries that could not be sent is returned.
+func (l *Logger) Close(ctx context.Context) int {
+	defer l.closeSinks()
+	defer l.closeStdout()
+
+	w := l.worker
+	if w == nil {
+		return 0
+	}
+
+	w.mu.Lock()
+	if w.closed {
+		w.mu.Unlock()
+		return 0
+	}
+	w.closed = true
+	close(w.queue)
+	w.mu.Unlock()
+
+	select {
+	case <-w.done:
+		return 0
+	case <-ctx.Done():
+		dropped := int(w.pending.Load())
+		w.cancel()
+		log.Printf("[WARN] Log flush deadline exceeded, dropped %d log entries", dropped)
+		return dropped
+	}
+}
@@ -0,0 +1,198 @@
+package logger
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLogger_Close_FlushesQueuedEntries(t *testing.T) {
+	var indexed int32
+	server := newTestOpensearchServer(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&indexed, 1)
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	cfg := createTestConfig()
+	cfg.OpensearchURL = server.URL
+	logger, err := NewLogger(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		logger.Info(ctx, "queued entry")
+	}
+
+	closeCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if dropped := logger.Close(closeCtx); dropped != 0 {
+		t.Errorf("expected no dropped entries, got %d", dropped)
+	}
+	if got := atomic.LoadInt32(&indexed); got != 5 {
+		t.Errorf("expected 5 entries to be flushed, got %d", got)
+	}
+}
+
+func TestLogger_Flush_DrainsQueueWithoutClosing(t *testing.T) {
+	var indexed int32
+	server := newTestOpensearchServer(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&indexed, 1)
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	cfg := createTestConfig()
+	cfg.OpensearchURL = server.URL
+	logger, err := NewLogger(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer logger.Close(context.Background())
+
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		logger.Info(ctx, "queued entry")
+	}
+
+	flushCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	if !logger.Flush(flushCtx) {
+		t.Fatal("expected queue to drain before the deadline")
+	}
+	if got := atomic.LoadInt32(&indexed); got != 3 {
+		t.Errorf("expected 3 entries to be flushed, got %d", got)
+	}
+
+	// The logger keeps accepting entries after a flush
+	logger.Info(ctx, "after flush")
+	if !logger.Flush(flushCtx) || atomic.LoadInt32(&indexed) != 4 {
+		t.Errorf("expected entry logged after flush to be sent, got %d indexed", atomic.LoadInt32(&indexed))
+	}
+}
+
+func TestLogger_Close_StopsAtDeadline(t *testing.T) {
+	server := newTestOpensearchServer(t, func(w http.ResponseWriter, r *http.Request) {
+		// Slow sink: each request takes far longer than the flush deadline
+		select {
+		case <-time.After(500 * time.Millisecond):
+		case <-r.Context().Done():
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	cfg := createTestConfig()
+	cfg.OpensearchURL = server.URL
+	logger, err := NewLogger(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx := context.Background()
+	for i := 0; i < 5; i++ {
+		logger.Info(ctx, "slow entry")
+	}
+
+	closeCtx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	dropped := logger.Close(closeCtx)
+	elapsed := time.Since(start)
+
+	if elapsed > time.Second {
+		t.Errorf("expected Close to return by its deadline, took %v", elapsed)
+	}
+	if dropped != 5 {
+		t.Errorf("expected 5 dropped entries, got %d", dropped)
+	}
+}
+
+func TestLogger_Close_WithoutOpensearch(t *testing.T) {
+	logger := &Logger{hostname: "test-host"}
+
+	if dropped := logger.Close(context.Background()); dropped != 0 {
+		t.Errorf("expected 0 dropped entries without Opensearch, got %d", dropped)
+	}
+
+	// Logging after Close must not panic
+	logger.Info(context.Background(), "after close")
+}
+
+func TestLogger_LogAfterClose(t *testing.T) {
+	server := newTestOpensearchServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	cfg := createTestConfig()
+	cfg.OpensearchURL = server.URL
+	logger, err := NewLogger(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	logger.Close(context.Background())
+
+	// Entries logged after Close go to stdout only and must not panic
+	logger.Info(context.Background(), "after close")
+	if dropped := logger.Close(context.Background()); dropped != 0 {
+		t.Errorf("expected second Close to be a no-op, got %d", dropped)
+	}
+}
+
+func TestLogger_QueueDepth_TracksUnshippedEntries(t *testing.T) {
+	release := make(chan struct{})
+	server := newTestOpensearchServer(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+		w.WriteHeader(http.StatusCreated)
+	})
+
+	cfg := createTestConfig()
+	cfg.OpensearchURL = server.URL
+	logger, err := NewLogger(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer logger.Close(context.Background())
+
+	if got := logger.QueueCapacity(); got != logQueueSize {
+		t.Errorf("expected capacity %d, got %d", logQueueSize, got)
+	}
+
+	ctx := context.Background()
+	for i := 0; i < 4; i++ {
+		logger.Info(ctx, "stuck entry")
+	}
+	// One entry is being sent, the other three wait in the queue; all are unshipped
+	if got := logger.QueueDepth(); got != 4 {
+		t.Errorf("expected depth 4 while Opensearch is stuck, got %d", got)
+	}
+
+	close(release)
+	flushCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if !logger.Flush(flushCtx) {
+		t.Fatal("expected queue to drain")
+	}
+	if got := logger.QueueDepth(); got != 0 {
+		t.Errorf("expected depth 0 once flushed, got %d", got)
+	}
+}
+
+func TestLogger_QueueDepth_StdoutOnly(t *testing.T) {
+	logger, err := NewLogger(createTestConfig())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	logger.Info(context.Background(), "stdout only")
+	if logger.QueueDepth() != 0 || logger.QueueCapacity() != 0 {
+		t.Errorf("expected an empty queue without Opensearch, got %d/%d", logger.QueueDepth(), logger.QueueCapacity())
+	}
+}
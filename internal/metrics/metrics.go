@@ -0,0 +1,390 @@
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Registry holds the gauges exposed on the metrics endpoint.
+// All methods are safe to call on a nil Registry, which records nothing.
+type Registry struct {
+	mu                  sync.RWMutex
+	lastSignal          time.Time
+	lastCheck           time.Time
+	consecutiveFailures int // Signal lookups failed in a row since the last success
+	redisAddr           string
+	redisDB             int
+
+	checksInFlight    atomic.Int64
+	shutdownInFlight  atomic.Int64
+	redisState        atomic.Int32 // One of the probe states
+	subscriptionState atomic.Int32 // One of the probe states
+	logQueue          LogQueue     // Read at scrape time; nil reports an empty queue
+	logHistory        LogHistory   // Serves /status?logs=N; nil reports no entries
+
+	redisCommands map[string]*commandStats // Latency and errors per Redis command, guarded by mu
+
+	now func() time.Time
+}
+
+// LogQueue reports the log shipping queue, so a backlog is visible before entries are dropped
+type LogQueue interface {
+	QueueDepth() int    // Entries enqueued but not yet shipped
+	QueueCapacity() int // Entries the queue holds before new ones are dropped or spilled
+}
+
+// LogHistory keeps the latest log entries, so recent history is served when Opensearch is down
+type LogHistory interface {
+	RecentLogs(n int) any // Up to n of the latest entries, oldest first, as a JSON list
+}
+
+// Probe states reported by RedisUp and KeyspaceSubscribed
+const (
+	stateUnknown int32 = iota
+	stateUp
+	stateDown
+)
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{now: time.Now}
+}
+
+// SignalObserved records that a signal was observed now
+func (r *Registry) SignalObserved() {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.lastSignal = r.now()
+	r.mu.Unlock()
+}
+
+// SecondsSinceLastSignal returns the time since the last observed signal, or -1 if none was seen
+func (r *Registry) SecondsSinceLastSignal() float64 {
+	if r == nil {
+		return -1
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.lastSignal.IsZero() {
+		return -1
+	}
+	return r.now().Sub(r.lastSignal).Seconds()
+}
+
+// CheckCompleted records the outcome of a signal lookup made now: a nil err resets the
+// consecutive failures, any other error adds one
+func (r *Registry) CheckCompleted(err error) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lastCheck = r.now()
+	if err != nil {
+		r.consecutiveFailures++
+	} else {
+		r.consecutiveFailures = 0
+	}
+}
+
+// SecondsSinceLastCheck returns the time since the last signal lookup, or -1 if none was made
+func (r *Registry) SecondsSinceLastCheck() float64 {
+	seconds, _ := r.checkState()
+	return seconds
+}
+
+// ConsecutiveFailures returns the number of signal lookups failed in a row
+func (r *Registry) ConsecutiveFailures() int {
+	_, failures := r.checkState()
+	return failures
+}
+
+// Healthy reports whether the latest signal lookup succeeded, or none was made yet
+func (r *Registry) Healthy() bool {
+	return r.ConsecutiveFailures() == 0
+}
+
+// checkState returns the time since the last lookup and the consecutive failures, read together
+func (r *Registry) checkState() (float64, int) {
+	if r == nil {
+		return -1, 0
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.lastCheck.IsZero() {
+		return -1, r.consecutiveFailures
+	}
+	return r.now().Sub(r.lastCheck).Seconds(), r.consecutiveFailures
+}
+
+// BeginCheck marks a check as in flight; the returned function marks it done
+func (r *Registry) BeginCheck() func() {
+	return r.begin(func(r *Registry) *atomic.Int64 { return &r.checksInFlight })
+}
+
+// BeginShutdown marks a shutdown sequence as in flight; the returned function marks it done
+func (r *Registry) BeginShutdown() func() {
+	return r.begin(func(r *Registry) *atomic.Int64 { return &r.shutdownInFlight })
+}
+
+// ChecksInFlight returns the number of checks currently running
+func (r *Registry) ChecksInFlight() int64 {
+	if r == nil {
+		return 0
+	}
+	return r.checksInFlight.Load()
+}
+
+// ShutdownInFlight returns the number of shutdown sequences currently running
+func (r *Registry) ShutdownInFlight() int64 {
+	if r == nil {
+		return 0
+	}
+	return r.shutdownInFlight.Load()
+}
+
+// SetRedisUp records whether Redis was reachable on the latest probe
+func (r *Registry) SetRedisUp(up bool) {
+	if r == nil {
+		return
+	}
+	storeState(&r.redisState, up)
+}
+
+// RedisUp returns 1 if Redis was reachable on the latest probe, 0 if not, or -1 if it was never probed
+func (r *Registry) RedisUp() float64 {
+	if r == nil {
+		return -1
+	}
+	return loadState(&r.redisState)
+}
+
+// SetKeyspaceSubscribed records whether the keyspace notification subscription is established
+func (r *Registry) SetKeyspaceSubscribed(up bool) {
+	if r == nil {
+		return
+	}
+	storeState(&r.subscriptionState, up)
+}
+
+// KeyspaceSubscribed returns 1 if the keyspace notification subscription is established, 0 if
+// it dropped, or -1 if subscribing was never attempted
+func (r *Registry) KeyspaceSubscribed() float64 {
+	if r == nil {
+		return -1
+	}
+	return loadState(&r.subscriptionState)
+}
+
+func storeState(state *atomic.Int32, up bool) {
+	if up {
+		state.Store(stateUp)
+	} else {
+		state.Store(stateDown)
+	}
+}
+
+func loadState(state *atomic.Int32) float64 {
+	switch state.Load() {
+	case stateUp:
+		return 1
+	case stateDown:
+		return 0
+	default:
+		return -1
+	}
+}
+
+func (r *Registry) begin(gauge func(*Registry) *atomic.Int64) func() {
+	if r == nil {
+		return func() {}
+	}
+	g := gauge(r)
+	g.Add(1)
+	var once sync.Once
+	return func() { once.Do(func() { g.Add(-1) }) }
+}
+
+// SetRedisTarget records the Redis address and DB the instance watches, reported on the status
+// endpoint to tell instances sharing a Redis server apart
+func (r *Registry) SetRedisTarget(addr string, db int) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.redisAddr = addr
+	r.redisDB = db
+	r.mu.Unlock()
+}
+
+// RedisTarget returns the Redis address and DB the instance watches
+func (r *Registry) RedisTarget() (addr string, db int) {
+	if r == nil {
+		return "", 0
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.redisAddr, r.redisDB
+}
+
+// SetLogQueue sets the log queue reported by the log queue gauges
+func (r *Registry) SetLogQueue(q LogQueue) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.logQueue = q
+	r.mu.Unlock()
+}
+
+// SetLogHistory sets the log entries served by the status document on request
+func (r *Registry) SetLogHistory(h LogHistory) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.logHistory = h
+	r.mu.Unlock()
+}
+
+// recentLogs returns up to n of the latest log entries, an empty list when no history is set
+func (r *Registry) recentLogs(n int) any {
+	if r != nil {
+		r.mu.RLock()
+		h := r.logHistory
+		r.mu.RUnlock()
+		if h != nil {
+			return h.RecentLogs(n)
+		}
+	}
+	return []any{}
+}
+
+// LogQueueDepth returns the number of log entries enqueued but not yet shipped
+func (r *Registry) LogQueueDepth() int {
+	if q := r.queue(); q != nil {
+		return q.QueueDepth()
+	}
+	return 0
+}
+
+// LogQueueCapacity returns the capacity of the log queue, 0 when none is set
+func (r *Registry) LogQueueCapacity() int {
+	if q := r.queue(); q != nil {
+		return q.QueueCapacity()
+	}
+	return 0
+}
+
+// queue returns the registered log queue, or nil
+func (r *Registry) queue() LogQueue {
+	if r == nil {
+		return nil
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.logQueue
+}
+
+// Status is the JSON document served on the status endpoint
+type Status struct {
+	SecondsSinceLastSignal float64        `json:"seconds_since_last_signal"`
+	SecondsSinceLastCheck  float64        `json:"seconds_since_last_check"`
+	ConsecutiveFailures    int            `json:"consecutive_failures"`
+	Healthy                bool           `json:"healthy"`
+	ChecksInFlight         int64          `json:"checks_in_flight"`
+	ShutdownInFlight       int64          `json:"shutdown_in_flight"`
+	RedisUp                float64        `json:"redis_up"`
+	RedisAddr              string         `json:"redis_addr"`
+	RedisDB                int            `json:"redis_db"`
+	KeyspaceSubscribed     float64        `json:"keyspace_subscribed"`
+	LogQueue               LogQueueStatus `json:"log_queue"`
+	Logs                   any            `json:"logs,omitempty"` // Latest log entries, only with ?logs=N
+}
+
+// LogQueueStatus is the log queue section of Status
+type LogQueueStatus struct {
+	Depth    int `json:"depth"`
+	Capacity int `json:"capacity"`
+}
+
+// Status returns a snapshot of the gauges
+func (r *Registry) Status() Status {
+	sinceCheck, failures := r.checkState()
+	redisAddr, redisDB := r.RedisTarget()
+	return Status{
+		SecondsSinceLastSignal: r.SecondsSinceLastSignal(),
+		SecondsSinceLastCheck:  sinceCheck,
+		ConsecutiveFailures:    failures,
+		Healthy:                failures == 0,
+		ChecksInFlight:         r.ChecksInFlight(),
+		ShutdownInFlight:       r.ShutdownInFlight(),
+		RedisUp:                r.RedisUp(),
+		RedisAddr:              redisAddr,
+		RedisDB:                redisDB,
+		KeyspaceSubscribed:     r.KeyspaceSubscribed(),
+		LogQueue: LogQueueStatus{
+			Depth:    r.LogQueueDepth(),
+			Capacity: r.LogQueueCapacity(),
+		},
+	}
+}
+
+// StatusHandler serves the gauges as a JSON Status document. With ?logs=N, it also includes
+// up to N of the latest log entries.
+func (r *Registry) StatusHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		status := r.Status()
+		if raw := req.URL.Query().Get("logs"); raw != "" {
+			n, err := strconv.Atoi(raw)
+			if err != nil || n < 1 {
+				http.Error(w, "logs must be a positive number of entries", http.StatusBadRequest)
+				return
+			}
+			status.Logs = r.recentLogs(n)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(status)
+	})
+}
+
+// ReadyHandler answers 200 while signals can be noticed as configured, and 503 while the
+// keyspace notification subscription is down
+func (r *Registry) ReadyHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if r.KeyspaceSubscribed() == 0 {
+			http.Error(w, "keyspace notification subscription down", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	})
+}
+
+// Handler serves the gauges in the Prometheus text exposition format
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		writeGauge(w, "signalmice_seconds_since_last_signal", "Seconds since the last signal was observed (-1 if none).", r.SecondsSinceLastSignal())
+		sinceCheck, failures := r.checkState()
+		writeGauge(w, "signalmice_seconds_since_last_check", "Seconds since the last signal lookup (-1 if none).", sinceCheck)
+		writeGauge(w, "signalmice_check_consecutive_failures", "Signal lookups failed in a row since the last success.", float64(failures))
+		writeGauge(w, "signalmice_checks_in_flight", "Number of signal checks currently running.", float64(r.ChecksInFlight()))
+		writeGauge(w, "signalmice_shutdown_in_flight", "Number of shutdown sequences currently running.", float64(r.ShutdownInFlight()))
+		writeGauge(w, "signalmice_redis_up", "Whether Redis answered the latest keepalive ping (-1 if never probed).", r.RedisUp())
+		writeGauge(w, "signalmice_keyspace_subscribed", "Whether the keyspace notification subscription is established (-1 if not subscribing).", r.KeyspaceSubscribed())
+		writeGauge(w, "signalmice_log_queue_depth", "Log entries enqueued for Opensearch but not yet shipped.", float64(r.LogQueueDepth()))
+		writeGauge(w, "signalmice_log_queue_capacity", "Log entries the Opensearch queue holds before dropping or spilling new ones.", float64(r.LogQueueCapacity()))
+		r.writeRedisCommands(w)
+	})
+}
+
+func writeGauge(w http.ResponseWriter, name, help string, value float64) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n%s %g\n", name, help, name, name, value)
+}
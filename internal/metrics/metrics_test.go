@@ -0,0 +1,319 @@
+package metrics
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRegistry_CheckInFlight(t *testing.T) {
+	r := NewRegistry()
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		end := r.BeginCheck()
+		close(started)
+		<-release
+		end()
+		close(done)
+	}()
+
+	<-started
+	if got := r.ChecksInFlight(); got != 1 {
+		t.Errorf("expected 1 check in flight during a long check, got %d", got)
+	}
+
+	close(release)
+	<-done
+	if got := r.ChecksInFlight(); got != 0 {
+		t.Errorf("expected 0 checks in flight afterward, got %d", got)
+	}
+}
+
+func TestRegistry_EndIsIdempotent(t *testing.T) {
+	r := NewRegistry()
+	end := r.BeginShutdown()
+	end()
+	end()
+	if got := r.ShutdownInFlight(); got != 0 {
+		t.Errorf("expected 0 shutdowns in flight, got %d", got)
+	}
+}
+
+func TestRegistry_SecondsSinceLastSignal(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	r := NewRegistry()
+	r.now = func() time.Time { return now }
+
+	if got := r.SecondsSinceLastSignal(); got != -1 {
+		t.Errorf("expected -1 before any signal, got %v", got)
+	}
+
+	r.SignalObserved()
+	now = now.Add(90 * time.Second)
+	if got := r.SecondsSinceLastSignal(); got != 90 {
+		t.Errorf("expected 90 seconds since last signal, got %v", got)
+	}
+}
+
+func TestRegistry_CheckCompleted(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	r := NewRegistry()
+	r.now = func() time.Time { return now }
+
+	if got := r.SecondsSinceLastCheck(); got != -1 {
+		t.Errorf("expected -1 before any check, got %v", got)
+	}
+	if !r.Healthy() {
+		t.Error("expected healthy before any check")
+	}
+
+	r.CheckCompleted(errors.New("connection refused"))
+	r.CheckCompleted(errors.New("connection refused"))
+	now = now.Add(30 * time.Second)
+	status := r.Status()
+	if status.ConsecutiveFailures != 2 || status.Healthy || status.SecondsSinceLastCheck != 30 {
+		t.Errorf("expected 2 failures, unhealthy, 30s since the last check, got %+v", status)
+	}
+
+	r.CheckCompleted(nil)
+	if got := r.ConsecutiveFailures(); got != 0 || !r.Healthy() {
+		t.Errorf("expected a success to reset the failures, got %d", got)
+	}
+}
+
+func TestRegistry_ConcurrentAccess(t *testing.T) {
+	// Run with -race: the loop updates state while the handlers read it
+	r := NewRegistry()
+	r.SetLogQueue(&fakeLogQueue{capacity: 1000})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				end := r.BeginCheck()
+				if j%3 == 0 {
+					r.CheckCompleted(errors.New("connection refused"))
+				} else {
+					r.CheckCompleted(nil)
+				}
+				r.SignalObserved()
+				r.SetRedisUp(j%2 == 0)
+				r.ObserveRedisCommand("get", time.Millisecond, nil)
+				end()
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				r.Status()
+				r.Handler().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/metrics", nil))
+				r.StatusHandler().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/status", nil))
+			}
+		}()
+	}
+	wg.Wait()
+
+	if got := r.ChecksInFlight(); got != 0 {
+		t.Errorf("expected 0 checks in flight afterward, got %d", got)
+	}
+	if count, _ := r.RedisCommandCount("get"); count != 800 {
+		t.Errorf("expected 800 observed commands, got %d", count)
+	}
+}
+
+func TestRegistry_NilIsNoop(t *testing.T) {
+	var r *Registry
+	r.SignalObserved()
+	r.BeginCheck()()
+	r.CheckCompleted(errors.New("connection refused"))
+	if r.ChecksInFlight() != 0 || r.SecondsSinceLastSignal() != -1 || r.SecondsSinceLastCheck() != -1 || !r.Healthy() {
+		t.Error("expected nil registry to report empty gauges")
+	}
+}
+
+func TestRegistry_RedisUp(t *testing.T) {
+	r := NewRegistry()
+	if got := r.RedisUp(); got != -1 {
+		t.Errorf("expected -1 before any probe, got %v", got)
+	}
+
+	r.SetRedisUp(false)
+	if got := r.RedisUp(); got != 0 {
+		t.Errorf("expected 0 after a failed probe, got %v", got)
+	}
+
+	r.SetRedisUp(true)
+	if got := r.RedisUp(); got != 1 {
+		t.Errorf("expected 1 after a successful probe, got %v", got)
+	}
+}
+
+func TestRegistry_Handler(t *testing.T) {
+	r := NewRegistry()
+	end := r.BeginCheck()
+	defer end()
+
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(rec.Body)
+
+	for _, want := range []string{
+		"# TYPE signalmice_checks_in_flight gauge",
+		"signalmice_checks_in_flight 1",
+		"signalmice_shutdown_in_flight 0",
+		"signalmice_seconds_since_last_signal -1",
+		"signalmice_seconds_since_last_check -1",
+		"signalmice_check_consecutive_failures 0",
+		"signalmice_redis_up -1",
+		"signalmice_log_queue_depth 0",
+		"signalmice_log_queue_capacity 0",
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("expected %q in metrics output, got:\n%s", want, body)
+		}
+	}
+}
+
+// fakeLogQueue is a LogQueue with fixed values
+type fakeLogQueue struct {
+	depth, capacity int
+}
+
+func (q *fakeLogQueue) QueueDepth() int    { return q.depth }
+func (q *fakeLogQueue) QueueCapacity() int { return q.capacity }
+
+func TestRegistry_LogQueue(t *testing.T) {
+	r := NewRegistry()
+	if r.LogQueueDepth() != 0 || r.LogQueueCapacity() != 0 {
+		t.Error("expected an empty log queue before one is set")
+	}
+
+	q := &fakeLogQueue{depth: 3, capacity: 1000}
+	r.SetLogQueue(q)
+	if r.LogQueueDepth() != 3 || r.LogQueueCapacity() != 1000 {
+		t.Errorf("expected depth 3 of 1000, got %d of %d", r.LogQueueDepth(), r.LogQueueCapacity())
+	}
+
+	// The depth is read live, so it follows the queue without further updates
+	q.depth = 7
+	if r.LogQueueDepth() != 7 {
+		t.Errorf("expected depth 7, got %d", r.LogQueueDepth())
+	}
+
+	var nilRegistry *Registry
+	nilRegistry.SetLogQueue(q)
+	if nilRegistry.LogQueueDepth() != 0 {
+		t.Error("expected nil registry to report an empty log queue")
+	}
+}
+
+func TestRegistry_StatusHandler(t *testing.T) {
+	r := NewRegistry()
+	r.SetLogQueue(&fakeLogQueue{depth: 12, capacity: 1000})
+	r.SetRedisUp(true)
+	r.SetRedisTarget("redis:6379", 3)
+
+	rec := httptest.NewRecorder()
+	r.StatusHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/status", nil))
+
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("expected JSON content type, got %q", ct)
+	}
+	var status Status
+	if err := json.NewDecoder(rec.Body).Decode(&status); err != nil {
+		t.Fatalf("failed to decode status: %v", err)
+	}
+	if status.LogQueue.Depth != 12 || status.LogQueue.Capacity != 1000 {
+		t.Errorf("expected log queue 12 of 1000, got %+v", status.LogQueue)
+	}
+	if status.RedisAddr != "redis:6379" || status.RedisDB != 3 {
+		t.Errorf("expected the Redis target redis:6379 DB 3, got %s DB %d", status.RedisAddr, status.RedisDB)
+	}
+	if status.RedisUp != 1 || status.SecondsSinceLastSignal != -1 {
+		t.Errorf("expected the other gauges in the status, got %+v", status)
+	}
+}
+
+// fakeLogHistory is a LogHistory of numbered messages, recording the count requested
+type fakeLogHistory struct {
+	requested int
+}
+
+func (h *fakeLogHistory) RecentLogs(n int) any {
+	h.requested = n
+	return []string{"first", "second"}
+}
+
+func TestRegistry_StatusHandler_Logs(t *testing.T) {
+	r := NewRegistry()
+	history := &fakeLogHistory{}
+	r.SetLogHistory(history)
+
+	get := func(target string) (*httptest.ResponseRecorder, map[string]json.RawMessage) {
+		rec := httptest.NewRecorder()
+		r.StatusHandler().ServeHTTP(rec, httptest.NewRequest("GET", target, nil))
+		var fields map[string]json.RawMessage
+		json.Unmarshal(rec.Body.Bytes(), &fields)
+		return rec, fields
+	}
+
+	if _, fields := get("/status"); fields["logs"] != nil {
+		t.Errorf("expected no logs unless requested, got %s", fields["logs"])
+	}
+
+	_, fields := get("/status?logs=50")
+	if history.requested != 50 {
+		t.Errorf("expected 50 entries requested, got %d", history.requested)
+	}
+	if string(fields["logs"]) != `["first","second"]` {
+		t.Errorf("expected the latest entries, got %s", fields["logs"])
+	}
+
+	if rec, _ := get("/status?logs=-1"); rec.Code != 400 {
+		t.Errorf("expected 400 for an invalid count, got %d", rec.Code)
+	}
+
+	r.SetLogHistory(nil)
+	if _, fields := get("/status?logs=5"); string(fields["logs"]) != "[]" {
+		t.Errorf("expected an empty list without a history, got %s", fields["logs"])
+	}
+}
+
+func TestRegistry_ReadyHandler(t *testing.T) {
+	r := NewRegistry()
+	ready := func() int {
+		rec := httptest.NewRecorder()
+		r.ReadyHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/readyz", nil))
+		return rec.Code
+	}
+
+	if got := r.KeyspaceSubscribed(); got != -1 {
+		t.Errorf("expected -1 before subscribing, got %v", got)
+	}
+	if code := ready(); code != 200 {
+		t.Errorf("expected ready without a subscription, got %d", code)
+	}
+
+	r.SetKeyspaceSubscribed(false)
+	if code := ready(); code != 503 {
+		t.Errorf("expected not ready while the subscription is down, got %d", code)
+	}
+	if got := r.Status().KeyspaceSubscribed; got != 0 {
+		t.Errorf("expected the status to report the subscription down, got %v", got)
+	}
+
+	r.SetKeyspaceSubscribed(true)
+	if code := ready(); code != 200 {
+		t.Errorf("expected ready once re-subscribed, got %d", code)
+	}
+}
@@ -0,0 +1,98 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// redisCommandBuckets are the upper bounds, in seconds, of the Redis command latency histogram
+var redisCommandBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5}
+
+// commandStats accumulates the latency histogram and error count of one Redis command
+type commandStats struct {
+	buckets []uint64 // Observations per bucket, not cumulative; the last one is +Inf
+	count   uint64
+	sum     float64
+	errors  uint64
+}
+
+// ObserveRedisCommand records the duration of a Redis command and whether it failed
+func (r *Registry) ObserveRedisCommand(command string, duration time.Duration, err error) {
+	if r == nil {
+		return
+	}
+	seconds := duration.Seconds()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.redisCommands == nil {
+		r.redisCommands = make(map[string]*commandStats)
+	}
+	stats, ok := r.redisCommands[command]
+	if !ok {
+		stats = &commandStats{buckets: make([]uint64, len(redisCommandBuckets)+1)}
+		r.redisCommands[command] = stats
+	}
+	stats.buckets[sort.SearchFloat64s(redisCommandBuckets, seconds)]++
+	stats.count++
+	stats.sum += seconds
+	if err != nil {
+		stats.errors++
+	}
+}
+
+// RedisCommandCount returns how many times command ran and how many of those runs failed
+func (r *Registry) RedisCommandCount(command string) (count, errors uint64) {
+	if r == nil {
+		return 0, 0
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if stats, ok := r.redisCommands[command]; ok {
+		return stats.count, stats.errors
+	}
+	return 0, 0
+}
+
+// writeRedisCommands writes the Redis command latency histogram and error counter, one
+// series per command observed so far
+func (r *Registry) writeRedisCommands(w io.Writer) {
+	if r == nil {
+		return
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.redisCommands) == 0 {
+		return
+	}
+	commands := make([]string, 0, len(r.redisCommands))
+	for command := range r.redisCommands {
+		commands = append(commands, command)
+	}
+	sort.Strings(commands)
+
+	const duration = "signalmice_redis_command_duration_seconds"
+	fmt.Fprintf(w, "# HELP %s Latency of Redis commands.\n# TYPE %s histogram\n", duration, duration)
+	for _, command := range commands {
+		stats := r.redisCommands[command]
+		var cumulative uint64
+		for i, n := range stats.buckets {
+			cumulative += n
+			le := "+Inf"
+			if i < len(redisCommandBuckets) {
+				le = strconv.FormatFloat(redisCommandBuckets[i], 'g', -1, 64)
+			}
+			fmt.Fprintf(w, "%s_bucket{command=%q,le=%q} %d\n", duration, command, le, cumulative)
+		}
+		fmt.Fprintf(w, "%s_sum{command=%q} %g\n%s_count{command=%q} %d\n", duration, command, stats.sum, duration, command, stats.count)
+	}
+
+	const errors = "signalmice_redis_command_errors_total"
+	fmt.Fprintf(w, "# HELP %s Redis commands that failed, a missing key excluded.\n# TYPE %s counter\n", errors, errors)
+	for _, command := range commands {
+		fmt.Fprintf(w, "%s{command=%q} %d\n", errors, command, r.redisCommands[command].errors)
+	}
+}
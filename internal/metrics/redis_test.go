@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRegistry_ObserveRedisCommand(t *testing.T) {
+	r := NewRegistry()
+	r.ObserveRedisCommand("get", 2*time.Millisecond, nil)
+	r.ObserveRedisCommand("get", 30*time.Millisecond, errors.New("i/o timeout"))
+	r.ObserveRedisCommand("del", time.Millisecond, nil)
+
+	if count, errs := r.RedisCommandCount("get"); count != 2 || errs != 1 {
+		t.Errorf("expected 2 GETs with 1 error, got %d with %d", count, errs)
+	}
+	if count, errs := r.RedisCommandCount("ping"); count != 0 || errs != 0 {
+		t.Errorf("expected no PINGs, got %d with %d errors", count, errs)
+	}
+
+	rec := httptest.NewRecorder()
+	r.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(rec.Body)
+
+	for _, want := range []string{
+		"# TYPE signalmice_redis_command_duration_seconds histogram",
+		`signalmice_redis_command_duration_seconds_bucket{command="get",le="0.001"} 0`,
+		`signalmice_redis_command_duration_seconds_bucket{command="get",le="0.0025"} 1`,
+		`signalmice_redis_command_duration_seconds_bucket{command="get",le="0.05"} 2`,
+		`signalmice_redis_command_duration_seconds_bucket{command="get",le="+Inf"} 2`,
+		`signalmice_redis_command_duration_seconds_count{command="get"} 2`,
+		`signalmice_redis_command_duration_seconds_count{command="del"} 1`,
+		`signalmice_redis_command_errors_total{command="get"} 1`,
+		`signalmice_redis_command_errors_total{command="del"} 0`,
+	} {
+		if !strings.Contains(string(body), want) {
+			t.Errorf("expected %q in output:\n%s", want, body)
+		}
+	}
+}
+
+func TestRegistry_RedisCommandsNil(t *testing.T) {
+	var r *Registry
+	r.ObserveRedisCommand("get", time.Millisecond, nil)
+	if count, _ := r.RedisCommandCount("get"); count != 0 {
+		t.Errorf("expected nil registry to record nothing, got %d", count)
+	}
+}
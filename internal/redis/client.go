@@ -2,68 +2,680 @@ package redis
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/go-redis/redis/v8"
 	"github.com/signalmice/signalmice/internal/config"
 )
 
+// Sentinel errors classifying Redis failures. A missing key is never an error.
+var (
+	// ErrConnection indicates Redis could not be reached (network, timeout, closed connection)
+	ErrConnection = errors.New("redis connection error")
+	// ErrServer indicates Redis was reached but replied with an error
+	ErrServer = errors.New("redis server error")
+	// ErrAuth indicates Redis rejected the credentials or requires them; it wraps ErrServer
+	ErrAuth = fmt.Errorf("%w: authentication failed", ErrServer)
+)
+
+// Error type labels returned by ErrorType
+const (
+	ErrorTypeConnection = "connection"
+	ErrorTypeServer     = "server"
+	ErrorTypeAuth       = "auth"
+	ErrorTypeUnknown    = "unknown"
+)
+
 // Client wraps the Redis client with application-specific methods
 type Client struct {
 	client *redis.Client
-	key    string
+	db     int
+
+	checkCommand   string      // How signal keys are read and consumed, one of the CheckCommand values
+	keyConcurrency int         // Keys checked in parallel by CheckAndDeleteSignal and PeekSignal
+	unlink         bool        // Delete collected keys with UNLINK instead of DEL
+	scanner        *keyScanner // Keys matching SIGNALMICE_KEY_PATTERN; nil outside pattern mode
+
+	mu   sync.RWMutex
+	key  string
+	keys []string
+}
+
+// Check commands selectable with SIGNALMICE_CHECK_COMMAND, to match the ACL grants of the Redis user
+const (
+	// CheckCommandGet reads the key with GET, then deletes it with DEL
+	CheckCommandGet = "get"
+	// CheckCommandExists tests the key with EXISTS, then deletes it with DEL; the value is never read
+	CheckCommandExists = "exists"
+	// CheckCommandGetDel reads and deletes the key atomically with GETDEL (Redis 6.2+)
+	CheckCommandGetDel = "getdel"
+)
+
+// ValidateCheckCommand returns an error unless command is a known check command
+func ValidateCheckCommand(command string) error {
+	switch command {
+	case CheckCommandGet, CheckCommandExists, CheckCommandGetDel:
+		return nil
+	default:
+		return fmt.Errorf("unknown check command %q (expected %s, %s or %s)", command, CheckCommandGet, CheckCommandExists, CheckCommandGetDel)
+	}
+}
+
+// Signal describes a signal key that was found (and deleted) in Redis
+type Signal struct {
+	Key   string
+	Value string
 }
 
 // NewClient creates a new Redis client
 func NewClient(cfg *config.Config) (*Client, error) {
+	if _, err := buildTLSConfig(cfg); err != nil {
+		return nil, err
+	}
+
+	c := NewUnverifiedClient(cfg)
+
+	// Test connection
+	if err := startupPing(context.Background(), cfg.RedisAddr(), c.Ping); err != nil {
+		c.client.Close()
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// startupPing pings Redis at addr and turns a failure into an actionable error, telling
+// rejected credentials apart from an unreachable server
+func startupPing(ctx context.Context, addr string, ping func(context.Context) error) error {
+	err := ping(ctx)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, ErrAuth):
+		return fmt.Errorf("Redis at %s rejected the credentials, check REDIS_PASSWORD: %w", addr, err)
+	case errors.Is(err, ErrConnection):
+		return fmt.Errorf("Redis at %s is unreachable, check REDIS_HOST, REDIS_PORT and the network: %w", addr, err)
+	default:
+		return fmt.Errorf("failed to connect to Redis at %s: %w", addr, err)
+	}
+}
+
+// NewUnverifiedClient creates a Redis client without testing the connection. Commands fail
+// with ErrConnection until Redis becomes reachable; the client reconnects on its own.
+func NewUnverifiedClient(cfg *config.Config) *Client {
+	tlsConfig, err := buildTLSConfig(cfg)
+	if err != nil {
+		// NewClient rejects an invalid REDIS_TLS_MIN_VERSION; never fall back to plaintext here
+		tlsConfig = &tls.Config{ServerName: cfg.RedisHost, MinVersion: tls.VersionTLS12}
+	}
+
 	client := redis.NewClient(&redis.Options{
-		Addr:     cfg.RedisAddr(),
-		Password: cfg.RedisPassword,
-		DB:       cfg.RedisDB,
+		Addr:      cfg.RedisAddr(),
+		Password:  cfg.RedisPassword,
+		DB:        cfg.RedisDB,
+		TLSConfig: tlsConfig,
 	})
 
-	// Test connection
-	ctx := context.Background()
-	if err := client.Ping(ctx).Err(); err != nil {
-		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	checkCommand := cfg.CheckCommand
+	if checkCommand == "" {
+		checkCommand = CheckCommandGet
+	}
+
+	c := &Client{
+		client:         client,
+		db:             cfg.RedisDB,
+		checkCommand:   checkCommand,
+		keyConcurrency: cfg.KeyConcurrency,
+		unlink:         cfg.UseUnlink,
+		key:            cfg.SignalKey(),
+		keys:           cfg.SignalKeys(),
+	}
+	c.scanner = newKeyScanner(cfg.SignalKeyPattern(), cfg.ScanCount, cfg.MaxKeysPerCycle, c.scanPage)
+	return c
+}
+
+// buildTLSConfig builds the TLS configuration for Redis, or nil when REDIS_TLS is off
+func buildTLSConfig(cfg *config.Config) (*tls.Config, error) {
+	if !cfg.RedisTLS {
+		return nil, nil
+	}
+
+	minVersion, err := config.ParseTLSVersion(cfg.RedisTLSMinVersion)
+	if err != nil {
+		return nil, fmt.Errorf("invalid REDIS_TLS_MIN_VERSION: %w", err)
 	}
 
-	return &Client{
-		client: client,
-		key:    cfg.RedisKey,
+	return &tls.Config{
+		ServerName: cfg.RedisHost,
+		MinVersion: minVersion,
 	}, nil
 }
 
+// Ping checks that Redis is reachable, which also keeps a pooled connection in use
+func (c *Client) Ping(ctx context.Context) error {
+	if err := c.client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("failed to ping Redis: %w", classifyError(err))
+	}
+	return nil
+}
+
 // CheckAndDeleteKey checks if the signal key exists and deletes it if found
 // Returns true if the key existed and was deleted, false otherwise
 func (c *Client) CheckAndDeleteKey(ctx context.Context) (bool, error) {
-	// Use GET to check if key exists
-	result, err := c.client.Get(ctx, c.key).Result()
-	if err == redis.Nil {
-		// Key does not exist
-		return false, nil
+	_, found, err := c.checkAndDelete(ctx, c.GetKey())
+	return found, err
+}
+
+// CheckAndDeleteSignal checks every monitored key and deletes all keys found in a single DEL
+// (or UNLINK), returning the first of them in key order. Returns false if none of the keys
+// exist. Up to the key concurrency keys are read at once. With GETDEL, or a single key, each
+// key is still read and deleted atomically, in order, stopping at the first one found.
+// In pattern mode, the keys matched by SCAN this cycle are checked after the signal keys.
+func (c *Client) CheckAndDeleteSignal(ctx context.Context) (Signal, bool, error) {
+	keys, err := c.cycleKeys(ctx)
+	if err != nil {
+		return Signal{}, false, err
+	}
+	if len(keys) <= 1 || c.checkCommand == CheckCommandGetDel {
+		return checkKeys(ctx, keys, c.keyConcurrency, c.checkAndDelete)
+	}
+
+	found, err := checkAll(ctx, keys, max(c.keyConcurrency, 1), c.peek)
+	if len(found) == 0 {
+		return Signal{}, false, err
+	}
+	if err := c.deleteKeys(ctx, signalKeys(found)); err != nil {
+		return Signal{}, false, err
+	}
+	return found[0], true, nil
+}
+
+// checkKeys runs check over keys and returns the first key, in key order, it found. With a
+// concurrency of 1 or less, keys are checked in order and the first hit or error stops the
+// scan. Otherwise at most concurrency checks run at once over all keys; a hit takes
+// precedence over errors from other keys, since its key may already be consumed.
+func checkKeys(ctx context.Context, keys []string, concurrency int, check func(context.Context, string) (string, bool, error)) (Signal, bool, error) {
+	if concurrency <= 1 {
+		for _, key := range keys {
+			value, found, err := check(ctx, key)
+			if err != nil {
+				return Signal{}, false, err
+			}
+			if found {
+				return Signal{Key: key, Value: value}, true, nil
+			}
+		}
+		return Signal{}, false, nil
+	}
+
+	found, err := checkAll(ctx, keys, concurrency, check)
+	if len(found) > 0 {
+		return found[0], true, nil
+	}
+	return Signal{}, false, err
+}
+
+// checkAll runs check over every key, at most concurrency at once, and returns the keys found
+// in key order, along with the first error in key order of the others
+func checkAll(ctx context.Context, keys []string, concurrency int, check func(context.Context, string) (string, bool, error)) ([]Signal, error) {
+	type result struct {
+		value string
+		found bool
+		err   error
+	}
+	results := make([]result, len(keys))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, key := range keys {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, key string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			value, found, err := check(ctx, key)
+			results[i] = result{value: value, found: found, err: err}
+		}(i, key)
+	}
+	wg.Wait()
+
+	var found []Signal
+	var firstErr error
+	for i, r := range results {
+		if r.found {
+			found = append(found, Signal{Key: keys[i], Value: r.value})
+		}
+		if r.err != nil && firstErr == nil {
+			firstErr = r.err
+		}
+	}
+	return found, firstErr
+}
+
+// signalKeys returns the keys of signals
+func signalKeys(signals []Signal) []string {
+	keys := make([]string, len(signals))
+	for i, sig := range signals {
+		keys[i] = sig.Key
+	}
+	return keys
+}
+
+// deleteKeys removes keys in one round trip, with UNLINK when configured so large values are
+// freed without blocking Redis
+func (c *Client) deleteKeys(ctx context.Context, keys []string) error {
+	var err error
+	if c.unlink {
+		err = c.client.Unlink(ctx, keys...).Err()
+	} else {
+		err = c.client.Del(ctx, keys...).Err()
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete keys: %w", classifyError(err))
+	}
+	return nil
+}
+
+// keyEventBuffer bounds the keyspace events queued for the consumer; extra events are dropped
+const keyEventBuffer = 16
+
+// KeyEvents subscribes to keyspace notifications for the signal keys and emits one value per
+// event until ctx is cancelled or the connection drops, closing the channel either way, so the
+// caller knows notifications may have been missed. The server must have notify-keyspace-events
+// enabled (e.g. "K$g").
+func (c *Client) KeyEvents(ctx context.Context) (<-chan struct{}, error) {
+	channels := make([]string, 0, len(c.keys))
+	for _, key := range c.GetKeys() {
+		channels = append(channels, fmt.Sprintf("__keyspace@%d__:%s", c.db, key))
+	}
+
+	pubsub := c.client.Subscribe(ctx, channels...)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, fmt.Errorf("failed to subscribe to keyspace events: %w", classifyError(err))
+	}
+
+	events := make(chan struct{}, keyEventBuffer)
+	go func() {
+		defer close(events)
+		defer pubsub.Close()
+		// Receive blocks regardless of ctx, so cancellation closes the subscription under it
+		stop := context.AfterFunc(ctx, func() { pubsub.Close() })
+		defer stop()
+		// Receive, unlike Channel, does not silently re-subscribe after a dropped connection
+		for {
+			msg, err := pubsub.Receive(ctx)
+			if err != nil {
+				return
+			}
+			if _, ok := msg.(*redis.Message); !ok {
+				continue
+			}
+			select {
+			case events <- struct{}{}:
+			default:
+			}
+		}
+	}()
+
+	return events, nil
+}
+
+// ChannelMessages subscribes to a pub/sub channel and emits the payload of every published
+// message. The returned channel is closed when ctx is cancelled or the subscription drops.
+func (c *Client) ChannelMessages(ctx context.Context, channel string) (<-chan string, error) {
+	pubsub := c.client.Subscribe(ctx, channel)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return nil, fmt.Errorf("failed to subscribe to channel %s: %w", channel, classifyError(err))
+	}
+
+	payloads := make(chan string, keyEventBuffer)
+	go func() {
+		defer close(payloads)
+		defer pubsub.Close()
+		messages := pubsub.Channel()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case msg, ok := <-messages:
+				if !ok {
+					return
+				}
+				select {
+				case payloads <- msg.Payload:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return payloads, nil
+}
+
+// PeekSignal checks each monitored key in order and returns the first one found, leaving it in place
+func (c *Client) PeekSignal(ctx context.Context) (Signal, bool, error) {
+	keys, err := c.cycleKeys(ctx)
+	if err != nil {
+		return Signal{}, false, err
+	}
+	return checkKeys(ctx, keys, c.keyConcurrency, c.peek)
+}
+
+// DeleteSignal deletes a signal key previously returned by PeekSignal
+func (c *Client) DeleteSignal(ctx context.Context, key string) error {
+	if err := c.client.Del(ctx, key).Err(); err != nil {
+		return fmt.Errorf("failed to delete key: %w", classifyError(err))
+	}
+	return nil
+}
+
+// ConfirmDeleted deletes key again and reports whether it had reappeared since the
+// signal was consumed, which means a setter is racing the delete
+func (c *Client) ConfirmDeleted(ctx context.Context, key string) (bool, error) {
+	n, err := c.client.Del(ctx, key).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to verify key deletion: %w", classifyError(err))
+	}
+	return n > 0, nil
+}
+
+// KeyExists reports whether the signal key is currently set, without deleting it
+func (c *Client) KeyExists(ctx context.Context) (bool, error) {
+	n, err := c.client.Exists(ctx, c.GetKey()).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to check key: %w", classifyError(err))
 	}
+	return n > 0, nil
+}
+
+// Exists reports whether key is currently set
+func (c *Client) Exists(ctx context.Context, key string) (bool, error) {
+	n, err := c.client.Exists(ctx, key).Result()
 	if err != nil {
-		return false, fmt.Errorf("failed to get key: %w", err)
+		return false, fmt.Errorf("failed to check key: %w", classifyError(err))
+	}
+	return n > 0, nil
+}
+
+// Lua scripts that only touch a lock still holding the caller's token
+var (
+	renewLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+	releaseLockScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+)
+
+// AcquireLock sets key to token with the given TTL if the key is not already set
+func (c *Client) AcquireLock(ctx context.Context, key, token string, ttl time.Duration) (bool, error) {
+	ok, err := c.client.SetNX(ctx, key, token, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire lock: %w", classifyError(err))
+	}
+	return ok, nil
+}
+
+// RenewLock extends the TTL of key only if it still holds token
+func (c *Client) RenewLock(ctx context.Context, key, token string, ttl time.Duration) (bool, error) {
+	n, err := renewLockScript.Run(ctx, c.client, []string{key}, token, ttl.Milliseconds()).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to renew lock: %w", classifyError(err))
+	}
+	return n == 1, nil
+}
+
+// ReleaseLock deletes key only if it still holds token
+func (c *Client) ReleaseLock(ctx context.Context, key, token string) error {
+	if err := releaseLockScript.Run(ctx, c.client, []string{key}, token).Err(); err != nil {
+		return fmt.Errorf("failed to release lock: %w", classifyError(err))
+	}
+	return nil
+}
+
+// probeStore is the set of primitive operations exercised by the self-test
+type probeStore interface {
+	set(ctx context.Context, key, value string, ttl time.Duration) error
+	get(ctx context.Context, key string) (string, error)
+	del(ctx context.Context, key string) error
+}
+
+// selfTestTTL bounds how long a probe key can linger if its deletion fails
+const selfTestTTL = time.Minute
+
+// SelfTest writes a throwaway probe key, reads it back, and deletes it, verifying that
+// the permissions needed at shutdown time (SET, GET, DEL) are granted
+func (c *Client) SelfTest(ctx context.Context, key string) error {
+	return runSelfTest(ctx, c, key)
+}
+
+// runSelfTest performs the probe-key round trip against the given store
+func runSelfTest(ctx context.Context, store probeStore, key string) error {
+	value := fmt.Sprintf("selftest-%d", time.Now().UnixNano())
+
+	if err := store.set(ctx, key, value, selfTestTTL); err != nil {
+		return fmt.Errorf("self-test SET failed: %w", classifyError(err))
+	}
+
+	got, err := store.get(ctx, key)
+	if err != nil {
+		return fmt.Errorf("self-test GET failed: %w", classifyError(err))
+	}
+	if got != value {
+		return fmt.Errorf("self-test GET returned unexpected value %q", got)
+	}
+
+	if err := store.del(ctx, key); err != nil {
+		return fmt.Errorf("self-test DEL failed: %w", classifyError(err))
+	}
+
+	return nil
+}
+
+func (c *Client) set(ctx context.Context, key, value string, ttl time.Duration) error {
+	return c.client.Set(ctx, key, value, ttl).Err()
+}
+
+func (c *Client) get(ctx context.Context, key string) (string, error) {
+	return c.client.Get(ctx, key).Result()
+}
+
+func (c *Client) del(ctx context.Context, key string) error {
+	return c.client.Del(ctx, key).Err()
+}
+
+func (c *Client) exists(ctx context.Context, key string) (bool, error) {
+	n, err := c.client.Exists(ctx, key).Result()
+	return n > 0, err
+}
+
+func (c *Client) getDel(ctx context.Context, key string) (string, error) {
+	return c.client.GetDel(ctx, key).Result()
+}
+
+// checkStore is the set of primitive operations used to read and consume signal keys
+type checkStore interface {
+	get(ctx context.Context, key string) (string, error)
+	exists(ctx context.Context, key string) (bool, error)
+	getDel(ctx context.Context, key string) (string, error)
+	del(ctx context.Context, key string) error
+}
+
+// ClearSignals deletes any monitored keys that are currently set without acting on them,
+// collecting them first and deleting them in a single DEL (or UNLINK). Returns the keys that
+// were present.
+func (c *Client) ClearSignals(ctx context.Context) ([]string, error) {
+	var present []string
+	for _, key := range c.GetKeys() {
+		_, found, err := c.peek(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			present = append(present, key)
+		}
+	}
+	if len(present) == 0 {
+		return nil, nil
+	}
+	if err := c.deleteKeys(ctx, present); err != nil {
+		return nil, err
+	}
+	return present, nil
+}
+
+// scanPage runs a single SCAN call for the key scanner
+func (c *Client) scanPage(ctx context.Context, cursor uint64, match string, count int64) ([]string, uint64, error) {
+	return c.client.Scan(ctx, cursor, match, count).Result()
+}
+
+// checkAndDelete reads a key and deletes it if present, returning its value
+func (c *Client) checkAndDelete(ctx context.Context, key string) (string, bool, error) {
+	return consume(ctx, c, c.checkCommand, key)
+}
+
+// peek reads a key without deleting it
+func (c *Client) peek(ctx context.Context, key string) (string, bool, error) {
+	return peek(ctx, c, c.checkCommand, key)
+}
+
+// consume reads key with the given check command and deletes it if present, returning its value.
+// The value is empty with CheckCommandExists.
+func consume(ctx context.Context, store checkStore, command, key string) (string, bool, error) {
+	if command == CheckCommandGetDel {
+		value, err := store.getDel(ctx, key)
+		if errors.Is(err, redis.Nil) {
+			return "", false, nil
+		}
+		if err != nil {
+			return "", false, fmt.Errorf("failed to get and delete key: %w", classifyError(err))
+		}
+		return value, true, nil
+	}
+
+	value, found, err := peek(ctx, store, command, key)
+	if err != nil || !found {
+		return "", false, err
 	}
 
 	// Key exists, delete it
-	if err := c.client.Del(ctx, c.key).Err(); err != nil {
-		return false, fmt.Errorf("failed to delete key: %w", err)
+	if err := store.del(ctx, key); err != nil {
+		return "", false, fmt.Errorf("failed to delete key: %w", classifyError(err))
 	}
 
-	// Log the value that was found (for debugging purposes)
-	_ = result
+	return value, true, nil
+}
+
+// peek checks key with the given check command without deleting it. CheckCommandExists
+// skips reading the value; every other command reads it with GET.
+func peek(ctx context.Context, store checkStore, command, key string) (string, bool, error) {
+	if command == CheckCommandExists {
+		found, err := store.exists(ctx, key)
+		if err != nil {
+			return "", false, fmt.Errorf("failed to check key: %w", classifyError(err))
+		}
+		return "", found, nil
+	}
 
-	return true, nil
+	// Use GET to check if key exists
+	value, err := store.get(ctx, key)
+	if errors.Is(err, redis.Nil) {
+		// Key does not exist
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to get key: %w", classifyError(err))
+	}
+	return value, true, nil
 }
 
 // GetKey returns the key being monitored
 func (c *Client) GetKey() string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
 	return c.key
 }
 
+// GetKeys returns all monitored signal keys in check order
+func (c *Client) GetKeys() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if len(c.keys) == 0 {
+		return []string{c.key}
+	}
+	return c.keys
+}
+
+// SetSignalKeys switches the monitored keys at runtime. key is the main signal key and
+// keys all keys in check order; an empty keys monitors the main key only.
+func (c *Client) SetSignalKeys(key string, keys []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.key = key
+	c.keys = keys
+}
+
 // Close closes the Redis client connection
 func (c *Client) Close() error {
 	return c.client.Close()
 }
+
+// authErrorPrefixes start the replies of a Redis server refusing a client's credentials
+var authErrorPrefixes = []string{
+	"NOAUTH",               // A password is required
+	"WRONGPASS",            // Invalid username or password (Redis 6+)
+	"ERR invalid password", // Invalid password (before Redis 6)
+	"ERR AUTH <password>",  // A password was sent, but none is configured
+	"ERR Client sent AUTH", // A password was sent, but none is set (before Redis 6)
+}
+
+// classifyError wraps err with ErrConnection, ErrAuth or ErrServer depending on its cause
+func classifyError(err error) error {
+	var redisErr redis.Error
+	if errors.As(err, &redisErr) {
+		for _, prefix := range authErrorPrefixes {
+			if strings.HasPrefix(redisErr.Error(), prefix) {
+				return fmt.Errorf("%w: %w", ErrAuth, err)
+			}
+		}
+		return fmt.Errorf("%w: %w", ErrServer, err)
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, redis.ErrClosed) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, context.Canceled) {
+		return fmt.Errorf("%w: %w", ErrConnection, err)
+	}
+
+	return err
+}
+
+// ErrorType returns a label describing the class of a Redis error for logs and alerting
+func ErrorType(err error) string {
+	switch {
+	case errors.Is(err, ErrConnection):
+		return ErrorTypeConnection
+	case errors.Is(err, ErrAuth):
+		return ErrorTypeAuth
+	case errors.Is(err, ErrServer):
+		return ErrorTypeServer
+	default:
+		return ErrorTypeUnknown
+	}
+}
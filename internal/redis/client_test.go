@@ -2,6 +2,14 @@ package redis
 
 import (
 	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -48,6 +56,48 @@ func TestNewClient_ConnectionError(t *testing.T) {
 	}
 }
 
+func TestBuildTLSConfig(t *testing.T) {
+	cfg := createTestConfig()
+	if tlsConfig, err := buildTLSConfig(cfg); err != nil || tlsConfig != nil {
+		t.Fatalf("expected no TLS config without REDIS_TLS, got %v (err %v)", tlsConfig, err)
+	}
+
+	cfg.RedisTLS = true
+	tlsConfig, err := buildTLSConfig(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tlsConfig.MinVersion != tls.VersionTLS12 {
+		t.Errorf("expected TLS 1.2 minimum by default, got %#x", tlsConfig.MinVersion)
+	}
+	if tlsConfig.ServerName != "localhost" {
+		t.Errorf("expected ServerName to be the Redis host, got %q", tlsConfig.ServerName)
+	}
+
+	cfg.RedisTLSMinVersion = "1.3"
+	if tlsConfig, _ = buildTLSConfig(cfg); tlsConfig.MinVersion != tls.VersionTLS13 {
+		t.Errorf("expected TLS 1.3 minimum, got %#x", tlsConfig.MinVersion)
+	}
+}
+
+func TestNewClient_InvalidTLSMinVersion(t *testing.T) {
+	cfg := createTestConfig()
+	cfg.RedisTLS = true
+	cfg.RedisTLSMinVersion = "1.4"
+
+	_, err := NewClient(cfg)
+	if err == nil || !strings.Contains(err.Error(), "REDIS_TLS_MIN_VERSION") {
+		t.Fatalf("expected an invalid REDIS_TLS_MIN_VERSION error, got %v", err)
+	}
+
+	// The unverified client still enforces TLS rather than falling back to plaintext
+	client := NewUnverifiedClient(cfg)
+	defer client.Close()
+	if tlsConfig := client.client.Options().TLSConfig; tlsConfig == nil || tlsConfig.MinVersion != tls.VersionTLS12 {
+		t.Errorf("expected the default TLS minimum, got %v", tlsConfig)
+	}
+}
+
 // TestClient_CheckAndDeleteKey_Integration tests with a real Redis if available
 // Skip this test if Redis is not available
 func TestClient_CheckAndDeleteKey_Integration(t *testing.T) {
@@ -116,3 +166,471 @@ func TestClient_Close(t *testing.T) {
 		t.Errorf("unexpected error closing client: %v", err)
 	}
 }
+
+// fakeServerError mimics a Redis server reply error (e.g. WRONGTYPE)
+type fakeServerError string
+
+func (e fakeServerError) Error() string { return string(e) }
+func (e fakeServerError) RedisError()   {}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		expected string
+	}{
+		{"server reply", fakeServerError("WRONGTYPE Operation against a key holding the wrong kind of value"), ErrorTypeServer},
+		{"missing password", fakeServerError("NOAUTH Authentication required."), ErrorTypeAuth},
+		{"wrong password", fakeServerError("WRONGPASS invalid username-password pair or user is disabled."), ErrorTypeAuth},
+		{"network error", &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, ErrorTypeConnection},
+		{"connection dropped", io.EOF, ErrorTypeConnection},
+		{"client closed", redis.ErrClosed, ErrorTypeConnection},
+		{"deadline exceeded", context.DeadlineExceeded, ErrorTypeConnection},
+		{"wrapped network error", fmt.Errorf("pool: %w", &net.OpError{Op: "read", Net: "tcp", Err: errors.New("reset")}), ErrorTypeConnection},
+		{"other", errors.New("something else"), ErrorTypeUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			classified := classifyError(tt.err)
+			if got := ErrorType(classified); got != tt.expected {
+				t.Errorf("ErrorType(classifyError(%v)) = %q, expected %q", tt.err, got, tt.expected)
+			}
+			if !errors.Is(classified, tt.err) {
+				t.Errorf("expected classified error to wrap the original error")
+			}
+		})
+	}
+}
+
+func TestStartupPing(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		sentinel error
+		contains string
+	}{
+		{"wrong password", fakeServerError("WRONGPASS invalid username-password pair or user is disabled."), ErrAuth, "check REDIS_PASSWORD"},
+		{"missing password", fakeServerError("NOAUTH Authentication required."), ErrAuth, "check REDIS_PASSWORD"},
+		{"dial error", &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, ErrConnection, "check REDIS_HOST, REDIS_PORT"},
+		{"timeout", context.DeadlineExceeded, ErrConnection, "unreachable"},
+		{"other server error", fakeServerError("LOADING Redis is loading the dataset in memory"), ErrServer, "failed to connect to Redis at redis:6379"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ping := func(context.Context) error { return classifyError(tt.err) }
+			err := startupPing(context.Background(), "redis:6379", ping)
+			if !errors.Is(err, tt.sentinel) {
+				t.Errorf("expected %v, got %v", tt.sentinel, err)
+			}
+			if !strings.Contains(err.Error(), tt.contains) || !strings.Contains(err.Error(), "redis:6379") {
+				t.Errorf("expected an actionable error naming the address and %q, got %v", tt.contains, err)
+			}
+		})
+	}
+
+	// Credentials and reachability are never confused
+	authErr := startupPing(context.Background(), "redis:6379", func(context.Context) error {
+		return classifyError(fakeServerError("WRONGPASS invalid username-password pair"))
+	})
+	if errors.Is(authErr, ErrConnection) || ErrorType(authErr) != ErrorTypeAuth {
+		t.Errorf("expected an auth error only, got %v", authErr)
+	}
+
+	if err := startupPing(context.Background(), "redis:6379", func(context.Context) error { return nil }); err != nil {
+		t.Errorf("expected no error on a successful ping, got %v", err)
+	}
+}
+
+func TestClient_CheckAndDeleteKey_ConnectionError(t *testing.T) {
+	client := &Client{
+		client: redis.NewClient(&redis.Options{
+			Addr:       "127.0.0.1:1", // Nothing listens here
+			MaxRetries: -1,
+		}),
+		key: "signalmice:test-key",
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	found, err := client.CheckAndDeleteKey(ctx)
+	if found {
+		t.Error("expected key not found on connection error")
+	}
+	if !errors.Is(err, ErrConnection) {
+		t.Errorf("expected ErrConnection, got: %v", err)
+	}
+	if errors.Is(err, ErrServer) {
+		t.Error("connection error must not be classified as a server error")
+	}
+}
+
+func TestClient_GetKeys(t *testing.T) {
+	client := &Client{key: "signalmice:main"}
+	if keys := client.GetKeys(); len(keys) != 1 || keys[0] != "signalmice:main" {
+		t.Errorf("expected only the main key, got %v", keys)
+	}
+
+	client.keys = []string{"signalmice:main", "signalmice:reboot"}
+	if keys := client.GetKeys(); len(keys) != 2 || keys[1] != "signalmice:reboot" {
+		t.Errorf("expected all configured keys, got %v", keys)
+	}
+}
+
+// TestClient_CheckAndDeleteSignal_Integration tests with a real Redis if available
+func TestClient_CheckAndDeleteSignal_Integration(t *testing.T) {
+	cfg := createTestConfig()
+	cfg.KeyActions = []config.KeyAction{{Key: "signalmice:test-reboot", Action: "reboot"}}
+
+	client, err := NewClient(cfg)
+	if err != nil {
+		t.Skipf("Skipping integration test - Redis not available: %v", err)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := client.client.Set(ctx, "signalmice:test-reboot", "now", 0).Err(); err != nil {
+		t.Fatalf("failed to set test key: %v", err)
+	}
+
+	sig, found, err := client.CheckAndDeleteSignal(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !found {
+		t.Fatal("expected signal to be found")
+	}
+	if sig.Key != "signalmice:test-reboot" || sig.Value != "now" {
+		t.Errorf("unexpected signal: %+v", sig)
+	}
+
+	if _, found, _ := client.CheckAndDeleteSignal(ctx); found {
+		t.Error("expected signal key to be deleted")
+	}
+}
+
+func TestClient_CheckAndDeleteSignal_DeletesFoundKeysInOneCommand(t *testing.T) {
+	for _, unlink := range []bool{false, true} {
+		values := map[string]string{
+			"signalmice:test-key": "1",
+			"signalmice:reboot":   "now",
+			"signalmice:halt":     "now",
+		}
+		cfg := startFakeRedis(t, values)
+		cfg.KeyActions = []config.KeyAction{
+			{Key: "signalmice:reboot", Action: "reboot"},
+			{Key: "signalmice:halt", Action: "halt"},
+			{Key: "signalmice:unset", Action: "poweroff"},
+		}
+		cfg.KeyConcurrency = 1
+		cfg.UseUnlink = unlink
+
+		client, err := NewClient(cfg)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		observer := &recordingObserver{}
+		client.Instrument(observer)
+		ctx := context.Background()
+
+		sig, found, err := client.CheckAndDeleteSignal(ctx)
+		if err != nil || !found || sig.Key != "signalmice:test-key" {
+			t.Fatalf("expected the first key to be returned, got %+v, %v, %v", sig, found, err)
+		}
+
+		deleteCommand := "del"
+		if unlink {
+			deleteCommand = "unlink"
+		}
+		var deletes int
+		for _, command := range observer.commands() {
+			if command == "del" || command == "unlink" {
+				if command != deleteCommand {
+					t.Errorf("expected %s, got %s", deleteCommand, command)
+				}
+				deletes++
+			}
+		}
+		if deletes != 1 {
+			t.Errorf("expected a single delete command, got %v", observer.commands())
+		}
+
+		// Every key found was removed by that one command
+		if _, found, err := client.CheckAndDeleteSignal(ctx); err != nil || found {
+			t.Errorf("expected all found keys to be deleted, got %v, %v", found, err)
+		}
+		client.Close()
+	}
+}
+
+func TestClient_ClearSignals_DeletesInOneCommand(t *testing.T) {
+	cfg := startFakeRedis(t, map[string]string{"signalmice:test-key": "1", "signalmice:reboot": "now"})
+	cfg.KeyActions = []config.KeyAction{{Key: "signalmice:reboot", Action: "reboot"}}
+
+	client, err := NewClient(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer client.Close()
+	observer := &recordingObserver{}
+	client.Instrument(observer)
+
+	cleared, err := client.ClearSignals(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(cleared) != 2 {
+		t.Errorf("expected both keys to be cleared, got %v", cleared)
+	}
+	if got := strings.Join(observer.commands(), " "); got != "get get del" {
+		t.Errorf("expected two reads and one delete, got %q", got)
+	}
+}
+
+// fakeProbeStore is an in-memory probeStore whose DEL can be denied
+type fakeProbeStore struct {
+	values  map[string]string
+	denyDel bool
+}
+
+func (f *fakeProbeStore) set(_ context.Context, key, value string, _ time.Duration) error {
+	f.values[key] = value
+	return nil
+}
+
+func (f *fakeProbeStore) get(_ context.Context, key string) (string, error) {
+	value, ok := f.values[key]
+	if !ok {
+		return "", redis.Nil
+	}
+	return value, nil
+}
+
+func (f *fakeProbeStore) del(_ context.Context, key string) error {
+	if f.denyDel {
+		return fakeServerError("NOPERM this user has no permissions to run the 'del' command")
+	}
+	delete(f.values, key)
+	return nil
+}
+
+func TestRunSelfTest_Success(t *testing.T) {
+	store := &fakeProbeStore{values: map[string]string{}}
+
+	if err := runSelfTest(context.Background(), store, "signalmice:selftest:node-1"); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if len(store.values) != 0 {
+		t.Errorf("expected probe key to be deleted, got %v", store.values)
+	}
+}
+
+func TestRunSelfTest_DelDenied(t *testing.T) {
+	store := &fakeProbeStore{values: map[string]string{}, denyDel: true}
+
+	err := runSelfTest(context.Background(), store, "signalmice:selftest:node-1")
+	if err == nil {
+		t.Fatal("expected self-test to fail when DEL is denied")
+	}
+	if !strings.Contains(err.Error(), "DEL failed") {
+		t.Errorf("expected 'DEL failed' error, got: %v", err)
+	}
+	if !errors.Is(err, ErrServer) {
+		t.Errorf("expected denied DEL to be classified as a server error, got: %v", err)
+	}
+}
+
+func TestClient_SetSignalKeys(t *testing.T) {
+	client := &Client{key: "signalmice:old", keys: []string{"signalmice:old", "signalmice:reboot"}}
+
+	client.SetSignalKeys("signalmice:new", []string{"signalmice:new", "signalmice:reboot"})
+
+	if got := client.GetKey(); got != "signalmice:new" {
+		t.Errorf("expected key 'signalmice:new', got '%s'", got)
+	}
+	if got := client.GetKeys(); len(got) != 2 || got[0] != "signalmice:new" {
+		t.Errorf("expected keys to start with 'signalmice:new', got %v", got)
+	}
+}
+
+// fakeCheckStore is an in-memory checkStore recording the commands it receives
+type fakeCheckStore struct {
+	values   map[string]string
+	commands []string
+}
+
+func (f *fakeCheckStore) get(_ context.Context, key string) (string, error) {
+	f.commands = append(f.commands, "GET")
+	value, ok := f.values[key]
+	if !ok {
+		return "", redis.Nil
+	}
+	return value, nil
+}
+
+func (f *fakeCheckStore) exists(_ context.Context, key string) (bool, error) {
+	f.commands = append(f.commands, "EXISTS")
+	_, ok := f.values[key]
+	return ok, nil
+}
+
+func (f *fakeCheckStore) getDel(_ context.Context, key string) (string, error) {
+	f.commands = append(f.commands, "GETDEL")
+	value, ok := f.values[key]
+	if !ok {
+		return "", redis.Nil
+	}
+	delete(f.values, key)
+	return value, nil
+}
+
+func (f *fakeCheckStore) del(_ context.Context, key string) error {
+	f.commands = append(f.commands, "DEL")
+	delete(f.values, key)
+	return nil
+}
+
+func TestConsume_CheckCommands(t *testing.T) {
+	tests := []struct {
+		command      string
+		wantValue    string
+		wantCommands string
+	}{
+		{command: CheckCommandGet, wantValue: "reboot", wantCommands: "GET,DEL"},
+		{command: CheckCommandExists, wantValue: "", wantCommands: "EXISTS,DEL"},
+		{command: CheckCommandGetDel, wantValue: "reboot", wantCommands: "GETDEL"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			store := &fakeCheckStore{values: map[string]string{"signalmice": "reboot"}}
+
+			value, found, err := consume(context.Background(), store, tt.command, "signalmice")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !found || value != tt.wantValue {
+				t.Errorf("expected found with value %q, got found=%v value=%q", tt.wantValue, found, value)
+			}
+			if _, ok := store.values["signalmice"]; ok {
+				t.Error("expected the key to be consumed")
+			}
+			if got := strings.Join(store.commands, ","); got != tt.wantCommands {
+				t.Errorf("expected commands %s, got %s", tt.wantCommands, got)
+			}
+
+			store.commands = nil
+			if _, found, err := consume(context.Background(), store, tt.command, "signalmice"); err != nil || found {
+				t.Errorf("expected missing key to be not found, got found=%v err=%v", found, err)
+			}
+			if len(store.commands) != 1 {
+				t.Errorf("expected a single lookup and no delete for a missing key, got %v", store.commands)
+			}
+		})
+	}
+}
+
+func TestPeek_ExistsSkipsValue(t *testing.T) {
+	store := &fakeCheckStore{values: map[string]string{"signalmice": "secret"}}
+
+	value, found, err := peek(context.Background(), store, CheckCommandExists, "signalmice")
+	if err != nil || !found || value != "" {
+		t.Errorf("expected found without value, got found=%v value=%q err=%v", found, value, err)
+	}
+	if len(store.values) != 1 || strings.Join(store.commands, ",") != "EXISTS" {
+		t.Errorf("expected a single EXISTS leaving the key in place, got %v", store.commands)
+	}
+}
+
+func TestValidateCheckCommand(t *testing.T) {
+	for _, command := range []string{CheckCommandGet, CheckCommandExists, CheckCommandGetDel} {
+		if err := ValidateCheckCommand(command); err != nil {
+			t.Errorf("unexpected error for %s: %v", command, err)
+		}
+	}
+	if err := ValidateCheckCommand("scan"); err == nil {
+		t.Error("expected error for unknown check command")
+	}
+}
+
+func TestCheckKeys_BoundedConcurrency(t *testing.T) {
+	keys := make([]string, 20)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("signalmice:%02d", i)
+	}
+
+	var inFlight, maxInFlight int32
+	var mu sync.Mutex
+	checked := map[string]bool{}
+	check := func(_ context.Context, key string) (string, bool, error) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+		for {
+			peak := atomic.LoadInt32(&maxInFlight)
+			if n <= peak || atomic.CompareAndSwapInt32(&maxInFlight, peak, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+
+		mu.Lock()
+		checked[key] = true
+		mu.Unlock()
+		return "shutdown", key == "signalmice:07" || key == "signalmice:15", nil
+	}
+
+	sig, found, err := checkKeys(context.Background(), keys, 4, check)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !found || sig.Key != "signalmice:07" {
+		t.Errorf("expected the first hit in key order, 'signalmice:07', got %+v (found %v)", sig, found)
+	}
+	if len(checked) != len(keys) {
+		t.Errorf("expected all %d keys to be checked, got %d", len(keys), len(checked))
+	}
+	if got := atomic.LoadInt32(&maxInFlight); got > 4 {
+		t.Errorf("expected at most 4 concurrent checks, got %d", got)
+	}
+}
+
+func TestCheckKeys_HitTakesPrecedenceOverErrors(t *testing.T) {
+	keys := []string{"signalmice:a", "signalmice:b"}
+	check := func(_ context.Context, key string) (string, bool, error) {
+		if key == "signalmice:a" {
+			return "", false, ErrConnection
+		}
+		return "reboot", true, nil
+	}
+
+	sig, found, err := checkKeys(context.Background(), keys, 2, check)
+	if err != nil || !found || sig.Key != "signalmice:b" {
+		t.Errorf("expected the hit on 'signalmice:b' despite the error, got %+v (found %v, err %v)", sig, found, err)
+	}
+
+	// Without a hit, the error is returned
+	_, found, err = checkKeys(context.Background(), keys[:1], 2, check)
+	if found || !errors.Is(err, ErrConnection) {
+		t.Errorf("expected ErrConnection without a hit, got found %v, err %v", found, err)
+	}
+}
+
+func TestCheckKeys_SerialStopsAtFirstHit(t *testing.T) {
+	var calls int
+	check := func(_ context.Context, key string) (string, bool, error) {
+		calls++
+		return "", key == "signalmice:a", nil
+	}
+
+	if _, found, _ := checkKeys(context.Background(), []string{"signalmice:a", "signalmice:b"}, 1, check); !found {
+		t.Fatal("expected a hit")
+	}
+	if calls != 1 {
+		t.Errorf("expected the serial scan to stop at the first hit, got %d checks", calls)
+	}
+}
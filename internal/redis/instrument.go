@@ -0,0 +1,60 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// CommandObserver records the outcome of Redis commands, e.g. into a metrics registry
+type CommandObserver interface {
+	ObserveRedisCommand(command string, duration time.Duration, err error)
+}
+
+// Instrument reports every command the client runs to observer, with its duration and error
+func (c *Client) Instrument(observer CommandObserver) {
+	c.client.AddHook(commandHook{observer: observer})
+}
+
+// commandStartKey carries the start time of a command or pipeline from before to after it runs
+type commandStartKey struct{}
+
+// commandHook is a go-redis hook timing commands for a CommandObserver
+type commandHook struct {
+	observer CommandObserver
+}
+
+func (h commandHook) BeforeProcess(ctx context.Context, _ redis.Cmder) (context.Context, error) {
+	return context.WithValue(ctx, commandStartKey{}, time.Now()), nil
+}
+
+func (h commandHook) AfterProcess(ctx context.Context, cmd redis.Cmder) error {
+	h.observe(ctx, cmd)
+	return nil
+}
+
+func (h commandHook) BeforeProcessPipeline(ctx context.Context, _ []redis.Cmder) (context.Context, error) {
+	return context.WithValue(ctx, commandStartKey{}, time.Now()), nil
+}
+
+func (h commandHook) AfterProcessPipeline(ctx context.Context, cmds []redis.Cmder) error {
+	for _, cmd := range cmds {
+		h.observe(ctx, cmd)
+	}
+	return nil
+}
+
+// observe reports cmd as started at the time stored in ctx. A missing key is not an error.
+func (h commandHook) observe(ctx context.Context, cmd redis.Cmder) {
+	start, ok := ctx.Value(commandStartKey{}).(time.Time)
+	if !ok {
+		return
+	}
+	err := cmd.Err()
+	if errors.Is(err, redis.Nil) {
+		err = nil
+	}
+	h.observer.ObserveRedisCommand(cmd.Name(), time.Since(start), err)
+}
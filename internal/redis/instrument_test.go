@@ -0,0 +1,157 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/signalmice/signalmice/internal/config"
+)
+
+// startFakeRedis serves PING, GET, DEL and UNLINK over RESP, rejecting other commands, from values and returns the config of a
+// client connecting to it
+func startFakeRedis(t *testing.T, values map[string]string) *config.Config {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	var mu sync.Mutex
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					args, err := readCommand(r)
+					if err != nil {
+						return
+					}
+					mu.Lock()
+					switch strings.ToUpper(args[0]) {
+					case "PING":
+						fmt.Fprint(conn, "+PONG\r\n")
+					case "GET":
+						if value, ok := values[args[1]]; ok {
+							fmt.Fprintf(conn, "$%d\r\n%s\r\n", len(value), value)
+						} else {
+							fmt.Fprint(conn, "$-1\r\n")
+						}
+					case "DEL", "UNLINK":
+						n := 0
+						for _, key := range args[1:] {
+							if _, ok := values[key]; ok {
+								delete(values, key)
+								n++
+							}
+						}
+						fmt.Fprintf(conn, ":%d\r\n", n)
+					default:
+						fmt.Fprintf(conn, "-ERR unknown command '%s'\r\n", args[0])
+					}
+					mu.Unlock()
+				}
+			}()
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	return &config.Config{RedisHost: host, RedisPort: port, RedisKey: "signalmice:test-key"}
+}
+
+// readCommand reads one RESP array of bulk strings
+func readCommand(r *bufio.Reader) ([]string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(strings.TrimPrefix(line, "*")))
+	if err != nil {
+		return nil, err
+	}
+	args := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		if _, err := r.ReadString('\n'); err != nil {
+			return nil, err
+		}
+		arg, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, strings.TrimSuffix(arg, "\r\n"))
+	}
+	return args, nil
+}
+
+type observation struct {
+	command string
+	err     error
+}
+
+type recordingObserver struct {
+	mu           sync.Mutex
+	observations []observation
+}
+
+func (o *recordingObserver) ObserveRedisCommand(command string, _ time.Duration, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.observations = append(o.observations, observation{command: command, err: err})
+}
+
+func (o *recordingObserver) commands() []string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	var commands []string
+	for _, obs := range o.observations {
+		commands = append(commands, obs.command)
+		if obs.err != nil {
+			commands = append(commands, "error")
+		}
+	}
+	return commands
+}
+
+func TestClient_Instrument(t *testing.T) {
+	cfg := startFakeRedis(t, map[string]string{"signalmice:test-key": "1"})
+	client, err := NewClient(cfg)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer client.Close()
+
+	observer := &recordingObserver{}
+	client.Instrument(observer)
+	ctx := context.Background()
+
+	if _, found, err := client.CheckAndDeleteSignal(ctx); err != nil || !found {
+		t.Fatalf("expected the signal to be found, got %v, %v", found, err)
+	}
+	if _, found, err := client.CheckAndDeleteSignal(ctx); err != nil || found {
+		t.Fatalf("expected no signal, got %v, %v", found, err)
+	}
+	if err := client.Ping(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.Exists(ctx, "signalmice:test-key"); err == nil {
+		t.Fatal("expected the fake server to reject EXISTS")
+	}
+
+	// The hit reads and deletes the key; the miss is a GET answered with nil, not an error
+	expected := "get del get ping exists error"
+	if got := strings.Join(observer.commands(), " "); got != expected {
+		t.Errorf("expected observations %q, got %q", expected, got)
+	}
+}
@@ -0,0 +1,96 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// scanFunc runs a single SCAN call, returning the page of matched keys and the next cursor
+type scanFunc func(ctx context.Context, cursor uint64, match string, count int64) ([]string, uint64, error)
+
+// keyScanner walks the keys matching a pattern with SCAN across check cycles. Each cycle
+// returns at most max keys and the next one resumes from the cursor, so a large keyspace
+// is covered over several cycles instead of delaying a single one.
+type keyScanner struct {
+	pattern string
+	count   int64 // SCAN COUNT hint; 0 uses the server default
+	max     int   // Keys returned per cycle; 0 returns a full iteration
+	scan    scanFunc
+
+	mu      sync.Mutex
+	cursor  uint64
+	pending []string // Keys of the last SCAN page beyond the previous cycle's cap
+}
+
+// newKeyScanner returns a scanner over the keys matching pattern, or nil when pattern is empty
+func newKeyScanner(pattern string, count, max int, scan scanFunc) *keyScanner {
+	if pattern == "" {
+		return nil
+	}
+	return &keyScanner{pattern: pattern, count: int64(count), max: max, scan: scan}
+}
+
+// next returns the matched keys of this cycle. A cycle stops at max keys, or once the SCAN
+// iteration completes; the next cycle then starts a new iteration.
+func (s *keyScanner) next(ctx context.Context) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var keys []string
+	for {
+		keys = s.take(keys)
+		if s.max > 0 && len(keys) >= s.max {
+			return keys, nil
+		}
+
+		page, cursor, err := s.scan(ctx, s.cursor, s.pattern, s.count)
+		if err != nil {
+			// Keep the keys taken so far for the next cycle, so none is skipped
+			s.pending = append(keys, s.pending...)
+			return nil, fmt.Errorf("failed to scan keys: %w", classifyError(err))
+		}
+		s.cursor = cursor
+		s.pending = append(s.pending, page...)
+		if cursor == 0 {
+			return s.take(keys), nil
+		}
+	}
+}
+
+// take moves pending keys into keys, up to the per-cycle cap
+func (s *keyScanner) take(keys []string) []string {
+	n := len(s.pending)
+	if s.max > 0 && n > s.max-len(keys) {
+		n = s.max - len(keys)
+	}
+	keys = append(keys, s.pending[:n]...)
+	s.pending = s.pending[n:]
+	return keys
+}
+
+// cycleKeys returns the keys to check this cycle: the signal keys, followed in pattern mode by
+// the next matched keys not among them
+func (c *Client) cycleKeys(ctx context.Context) ([]string, error) {
+	keys := c.GetKeys()
+	if c.scanner == nil {
+		return keys, nil
+	}
+
+	matched, err := c.scanner.next(ctx)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		seen[key] = true
+	}
+	all := append([]string(nil), keys...)
+	for _, key := range matched {
+		if !seen[key] {
+			seen[key] = true
+			all = append(all, key)
+		}
+	}
+	return all, nil
+}
@@ -0,0 +1,129 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// fakeKeyspace serves SCAN pages over a fixed list of matching keys, the cursor being the
+// offset of the next page
+type fakeKeyspace struct {
+	keys  []string
+	calls int
+	err   error
+}
+
+func (f *fakeKeyspace) scan(_ context.Context, cursor uint64, _ string, count int64) ([]string, uint64, error) {
+	f.calls++
+	if f.err != nil {
+		return nil, 0, f.err
+	}
+	end := int(cursor) + int(count)
+	if end >= len(f.keys) {
+		return f.keys[cursor:], 0, nil
+	}
+	return f.keys[cursor:end], uint64(end), nil
+}
+
+func newFakeKeyspace(n int) *fakeKeyspace {
+	f := &fakeKeyspace{}
+	for i := 0; i < n; i++ {
+		f.keys = append(f.keys, fmt.Sprintf("signalmice:host:%03d", i))
+	}
+	return f
+}
+
+func TestNewKeyScanner_Disabled(t *testing.T) {
+	if s := newKeyScanner("", 10, 10, newFakeKeyspace(1).scan); s != nil {
+		t.Error("expected no scanner without a pattern")
+	}
+}
+
+func TestKeyScanner_CapsKeysPerCycleAndContinues(t *testing.T) {
+	keyspace := newFakeKeyspace(250)
+	scanner := newKeyScanner("signalmice:host:*", 10, 25, keyspace.scan)
+
+	var seen []string
+	for cycle := 0; cycle < 10; cycle++ {
+		keyspace.calls = 0
+		keys, err := scanner.next(context.Background())
+		if err != nil {
+			t.Fatalf("cycle %d: unexpected error: %v", cycle, err)
+		}
+		if len(keys) != 25 {
+			t.Fatalf("cycle %d: expected 25 keys, got %d", cycle, len(keys))
+		}
+		if keyspace.calls > 3 {
+			t.Errorf("cycle %d: expected at most 3 SCAN calls, got %d", cycle, keyspace.calls)
+		}
+		seen = append(seen, keys...)
+	}
+	if !reflect.DeepEqual(seen, keyspace.keys) {
+		t.Error("expected the cycles to cover every key once, in cursor order")
+	}
+
+	// The iteration completed, so the next cycle starts over
+	keys, err := scanner.next(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if keys[0] != keyspace.keys[0] {
+		t.Errorf("expected a new iteration from the first key, got %s", keys[0])
+	}
+}
+
+func TestKeyScanner_StopsAtEndOfIteration(t *testing.T) {
+	keyspace := newFakeKeyspace(30)
+	scanner := newKeyScanner("signalmice:host:*", 10, 0, keyspace.scan)
+
+	keys, err := scanner.next(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(keys) != 30 || keyspace.calls != 3 {
+		t.Errorf("expected one full iteration of 30 keys in 3 calls, got %d keys in %d calls", len(keys), keyspace.calls)
+	}
+}
+
+func TestKeyScanner_ErrorKeepsPendingKeys(t *testing.T) {
+	keyspace := newFakeKeyspace(50)
+	scanner := newKeyScanner("signalmice:host:*", 15, 10, keyspace.scan)
+
+	// The first cycle leaves 5 keys of the page pending
+	if _, err := scanner.next(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	keyspace.err = errors.New("connection reset")
+	if _, err := scanner.next(context.Background()); err == nil {
+		t.Fatal("expected the SCAN error")
+	}
+
+	keyspace.err = nil
+	keys, err := scanner.next(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(keys, keyspace.keys[10:20]) {
+		t.Errorf("expected the failed cycle's keys to be retried, got %v", keys)
+	}
+}
+
+func TestClient_CycleKeys_PatternMode(t *testing.T) {
+	keyspace := &fakeKeyspace{keys: []string{"signalmice:main", "signalmice:host:a", "signalmice:host:b"}}
+	c := &Client{
+		key:     "signalmice:main",
+		scanner: newKeyScanner("signalmice:*", 10, 0, keyspace.scan),
+	}
+
+	keys, err := c.cycleKeys(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expected := []string{"signalmice:main", "signalmice:host:a", "signalmice:host:b"}
+	if !reflect.DeepEqual(keys, expected) {
+		t.Errorf("expected the signal key first and matched keys once, got %v", keys)
+	}
+}
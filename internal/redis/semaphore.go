@@ -0,0 +1,104 @@
+package redis
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// SlotClaim is the outcome of claiming a shutdown slot from the budget in the signal key
+type SlotClaim int
+
+const (
+	// SlotNoBudget means the signal key is not set
+	SlotNoBudget SlotClaim = iota
+	// SlotExhausted means the budget is used up, so no slot was claimed
+	SlotExhausted
+	// SlotClaimed means the budget was decremented for this instance
+	SlotClaimed
+	// SlotHeld means this instance claimed a slot of the current budget already
+	SlotHeld
+)
+
+// Replies of claimSlotScript other than the remaining budget
+const (
+	replyHeld      = -3
+	replyNoBudget  = -2
+	replyExhausted = -1
+)
+
+// claimsSuffix names the set of holders of the budget in the signal key, e.g. signalmice:fleet:claims
+const claimsSuffix = ":claims"
+
+// claimSlotScript decrements the integer budget in KEYS[1] only while it is above zero, so
+// concurrent instances never claim more slots than the budget holds. Holders are recorded in
+// the set KEYS[2], so a host still up after its claim (a delayed or slow poweroff, a reboot)
+// does not take another slot. Deleting the budget drops the holders with it.
+var claimSlotScript = redis.NewScript(`
+local value = redis.call("GET", KEYS[1])
+if not value then
+	redis.call("DEL", KEYS[2])
+	return -2
+end
+local budget = tonumber(value)
+if not budget or budget ~= math.floor(budget) then
+	return redis.error_reply("signal value is not an integer budget")
+end
+if redis.call("SISMEMBER", KEYS[2], ARGV[1]) == 1 then
+	return -3
+end
+if budget <= 0 then
+	return -1
+end
+redis.call("SADD", KEYS[2], ARGV[1])
+return redis.call("DECR", KEYS[1])`)
+
+// releaseSlotScript returns the slot of holder ARGV[1] to the budget in KEYS[1], unless it
+// holds none
+var releaseSlotScript = redis.NewScript(`
+if redis.call("SREM", KEYS[2], ARGV[1]) == 1 then
+	redis.call("INCR", KEYS[1])
+end
+return 0`)
+
+// ClaimSlot atomically takes one slot from the shutdown budget held in the signal key for
+// holder, typically the hostname. When a slot was claimed, remaining is the budget left for
+// other instances.
+func (c *Client) ClaimSlot(ctx context.Context, holder string) (claim SlotClaim, remaining int64, err error) {
+	n, err := claimSlotScript.Run(ctx, c.client, c.slotKeys(), holder).Int64()
+	if err != nil {
+		return SlotNoBudget, 0, fmt.Errorf("failed to claim shutdown slot: %w", classifyError(err))
+	}
+	claim, remaining = slotClaimFromReply(n)
+	return claim, remaining, nil
+}
+
+// ReleaseSlot returns the slot claimed by holder to the budget, e.g. when the shutdown could
+// not be initiated
+func (c *Client) ReleaseSlot(ctx context.Context, holder string) error {
+	if err := releaseSlotScript.Run(ctx, c.client, c.slotKeys(), holder).Err(); err != nil {
+		return fmt.Errorf("failed to release shutdown slot: %w", classifyError(err))
+	}
+	return nil
+}
+
+// slotKeys returns the budget key and the set of its holders
+func (c *Client) slotKeys() []string {
+	key := c.GetKey()
+	return []string{key, key + claimsSuffix}
+}
+
+// slotClaimFromReply interprets a claimSlotScript reply
+func slotClaimFromReply(n int64) (SlotClaim, int64) {
+	switch {
+	case n == replyHeld:
+		return SlotHeld, 0
+	case n == replyNoBudget:
+		return SlotNoBudget, 0
+	case n == replyExhausted:
+		return SlotExhausted, 0
+	default:
+		return SlotClaimed, n
+	}
+}
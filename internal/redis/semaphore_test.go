@@ -0,0 +1,24 @@
+package redis
+
+import "testing"
+
+func TestSlotClaimFromReply(t *testing.T) {
+	tests := []struct {
+		reply     int64
+		claim     SlotClaim
+		remaining int64
+	}{
+		{replyHeld, SlotHeld, 0},
+		{replyNoBudget, SlotNoBudget, 0},
+		{replyExhausted, SlotExhausted, 0},
+		{0, SlotClaimed, 0},
+		{4, SlotClaimed, 4},
+	}
+
+	for _, tt := range tests {
+		claim, remaining := slotClaimFromReply(tt.reply)
+		if claim != tt.claim || remaining != tt.remaining {
+			t.Errorf("slotClaimFromReply(%d) = %v, %d, expected %v, %d", tt.reply, claim, remaining, tt.claim, tt.remaining)
+		}
+	}
+}
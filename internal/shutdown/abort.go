@@ -0,0 +1,43 @@
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// abortCheckTimeout bounds the final abort check, so a slow store delays the shutdown by at
+// most this long
+var abortCheckTimeout = 500 * time.Millisecond
+
+// ErrAborted is returned when the abort check reported an abort before a shutdown method
+// would have run
+var ErrAborted = errors.New("shutdown aborted before the terminal command")
+
+// SetAbortCheck installs a check run before each shutdown method: once ahead of the
+// post-notification and log flush, so an aborted attempt never announces itself, and once more
+// right before the terminal command, so an abort set during the flush still stops it. When it
+// reports true, the shutdown is abandoned with ErrAborted. A check that fails or times out does
+// not stop the shutdown.
+func (m *Manager) SetAbortCheck(check func(ctx context.Context) (bool, error)) {
+	m.abortCheck = check
+}
+
+// aborted runs the abort check, if any, reporting whether the shutdown must be abandoned
+func (m *Manager) aborted(ctx context.Context) bool {
+	if m.abortCheck == nil {
+		return false
+	}
+	checkCtx, cancel := context.WithTimeout(ctx, abortCheckTimeout)
+	defer cancel()
+
+	abort, err := m.abortCheck(checkCtx)
+	if err != nil {
+		m.logger.WarnWithExtra(ctx, "Final abort check failed, shutting down anyway", map[string]string{"error": err.Error()})
+		return false
+	}
+	if abort {
+		m.logger.Warn(ctx, "Shutdown aborted at the point of no return: abort requested right before the terminal command")
+	}
+	return abort
+}
@@ -0,0 +1,146 @@
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/signalmice/signalmice/internal/config"
+)
+
+// abortKeyManager returns a manager with fake methods whose abort check reports the abort
+// key, which the pre-shutdown webhook sets when abortLate is true, as an operator reacting to
+// it would. The returned counter reports the post-notifications received.
+func abortKeyManager(t *testing.T, cfg *config.Config, abortLate bool) (*Manager, *[]string, *atomic.Int32) {
+	t.Helper()
+	var abortKey atomic.Bool
+	webhookServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		abortKey.Store(abortLate)
+	}))
+	t.Cleanup(webhookServer.Close)
+	var notified atomic.Int32
+	notifyServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		notified.Add(1)
+	}))
+	t.Cleanup(notifyServer.Close)
+
+	cfg.HostProcPath = "/non-existent"
+	cfg.WebhookURL = webhookServer.URL
+	cfg.WebhookTimeout = time.Second
+	cfg.WebhookMaxDuration = time.Second
+	cfg.PostNotifyURL = notifyServer.URL
+	cfg.PostNotifyTimeout = time.Second
+	manager := mustNewManager(t, cfg, createMockLogger())
+	calls := fakeMethods(manager, nil)
+	manager.SetAbortCheck(func(context.Context) (bool, error) {
+		return abortKey.Load(), nil
+	})
+	return manager, calls, &notified
+}
+
+func TestManager_AbortKeySetBeforeTerminalStep(t *testing.T) {
+	manager, calls, notified := abortKeyManager(t, &config.Config{}, true)
+
+	method, err := manager.NeutralizeStuartLittle(context.Background())
+	if !errors.Is(err, ErrAborted) {
+		t.Fatalf("expected ErrAborted, got %q, %v", method, err)
+	}
+	if len(*calls) != 0 {
+		t.Errorf("expected no shutdown method to run, got %v", *calls)
+	}
+	if got := notified.Load(); got != 0 {
+		t.Errorf("expected an aborted attempt not to be announced, got %d notifications", got)
+	}
+}
+
+func TestManager_AbortKeySetBeforeTerminalStep_Parallel(t *testing.T) {
+	manager, calls, notified := abortKeyManager(t, &config.Config{ParallelMethods: true}, true)
+
+	if _, err := manager.NeutralizeStuartLittle(context.Background()); !errors.Is(err, ErrAborted) {
+		t.Fatalf("expected ErrAborted, got %v", err)
+	}
+	if len(*calls) != 0 {
+		t.Errorf("expected no shutdown method to run, got %v", *calls)
+	}
+	if got := notified.Load(); got != 0 {
+		t.Errorf("expected an aborted attempt not to be announced, got %d notifications", got)
+	}
+}
+
+// abortDuringFlush makes the log flush set the abort key, as an operator reacting to the
+// shutdown log lines would
+func abortDuringFlush(manager *Manager) {
+	var abortKey atomic.Bool
+	manager.flush = func(context.Context) bool {
+		abortKey.Store(true)
+		return true
+	}
+	manager.SetAbortCheck(func(context.Context) (bool, error) {
+		return abortKey.Load(), nil
+	})
+}
+
+func TestManager_AbortKeySetDuringFlush(t *testing.T) {
+	for _, parallel := range []bool{false, true} {
+		manager := mustNewManager(t, &config.Config{HostProcPath: "/non-existent", ParallelMethods: parallel}, createMockLogger())
+		calls := fakeMethods(manager, nil)
+		abortDuringFlush(manager)
+
+		if _, err := manager.NeutralizeStuartLittle(context.Background()); !errors.Is(err, ErrAborted) {
+			t.Fatalf("parallel=%t: expected ErrAborted, got %v", parallel, err)
+		}
+		if len(*calls) != 0 {
+			t.Errorf("parallel=%t: expected no shutdown method to run, got %v", parallel, *calls)
+		}
+	}
+}
+
+func TestManager_AbortCheckClearProceeds(t *testing.T) {
+	manager, calls, notified := abortKeyManager(t, &config.Config{}, false)
+
+	if method, err := manager.NeutralizeStuartLittle(context.Background()); err != nil || method != "nsenter" {
+		t.Fatalf("expected the shutdown to proceed via nsenter, got %q, %v", method, err)
+	}
+	if got := strings.Join(*calls, ","); got != "nsenter" {
+		t.Errorf("expected nsenter to run, got %s", got)
+	}
+	if got := notified.Load(); got != 1 {
+		t.Errorf("expected the attempt to be announced once, got %d notifications", got)
+	}
+}
+
+func TestManager_AbortCheckFailureProceeds(t *testing.T) {
+	original := abortCheckTimeout
+	abortCheckTimeout = 10 * time.Millisecond
+	defer func() { abortCheckTimeout = original }()
+
+	manager := mustNewManager(t, &config.Config{HostProcPath: "/non-existent"}, createMockLogger())
+	calls := fakeMethods(manager, nil)
+	manager.SetAbortCheck(func(ctx context.Context) (bool, error) {
+		<-ctx.Done()
+		return false, ctx.Err()
+	})
+
+	if _, err := manager.NeutralizeStuartLittle(context.Background()); err != nil {
+		t.Fatalf("expected a failed abort check not to stop the shutdown, got %v", err)
+	}
+	if len(*calls) != 1 {
+		t.Errorf("expected the first method to run, got %v", *calls)
+	}
+}
+
+func TestManager_AbortDoesNotStartCooldown(t *testing.T) {
+	manager, _, _ := abortKeyManager(t, &config.Config{ShutdownRetryCooldown: time.Hour}, true)
+
+	if _, err := manager.NeutralizeStuartLittle(context.Background()); !errors.Is(err, ErrAborted) {
+		t.Fatalf("expected ErrAborted, got %v", err)
+	}
+	if _, ok := manager.cooldownElapsed(); !ok {
+		t.Error("expected an aborted attempt not to start the retry cooldown")
+	}
+}
@@ -0,0 +1,68 @@
+package shutdown
+
+import (
+	"context"
+	"fmt"
+)
+
+// Action is the kind of host shutdown to perform
+type Action string
+
+const (
+	ActionPoweroff Action = "poweroff"
+	ActionReboot   Action = "reboot"
+)
+
+// actionKey is the context key for the requested Action
+type actionKey struct{}
+
+// ParseAction validates and returns the Action for the given name
+func ParseAction(name string) (Action, error) {
+	switch Action(name) {
+	case ActionPoweroff, ActionReboot:
+		return Action(name), nil
+	default:
+		return "", fmt.Errorf("unknown shutdown action %q", name)
+	}
+}
+
+// WithAction returns a context carrying the action the shutdown methods should perform
+func WithAction(ctx context.Context, action Action) context.Context {
+	return context.WithValue(ctx, actionKey{}, action)
+}
+
+// ActionFromContext returns the action stored in ctx, defaulting to ActionPoweroff
+func ActionFromContext(ctx context.Context) Action {
+	if action, ok := ctx.Value(actionKey{}).(Action); ok && action != "" {
+		return action
+	}
+	return ActionPoweroff
+}
+
+// sysrqCommand returns the sysrq-trigger character for the action
+func (a Action) sysrqCommand() string {
+	if a == ActionReboot {
+		return "b"
+	}
+	return "o"
+}
+
+// directCommands returns the commands tried in order by the direct method for the action.
+// The shutdown invocation is scheduled at when and broadcasts message to logged-in users;
+// a delayed shutdown skips the immediate poweroff/reboot command so the warning window holds.
+func (a Action) directCommands(when, message string) [][]string {
+	immediate, flag := "poweroff", "-h"
+	if a == ActionReboot {
+		immediate, flag = "reboot", "-r"
+	}
+
+	scheduled := []string{"shutdown", flag, when}
+	if message != "" {
+		scheduled = append(scheduled, message)
+	}
+
+	if when != "now" {
+		return [][]string{scheduled}
+	}
+	return [][]string{{immediate}, scheduled}
+}
@@ -0,0 +1,105 @@
+package shutdown
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/signalmice/signalmice/internal/config"
+)
+
+func TestParseAction(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Action
+		wantErr bool
+	}{
+		{"poweroff", ActionPoweroff, false},
+		{"reboot", ActionReboot, false},
+		{"explode", "", true},
+		{"", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseAction(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseAction(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseAction(%q) = %q, expected %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestActionFromContext(t *testing.T) {
+	if got := ActionFromContext(context.Background()); got != ActionPoweroff {
+		t.Errorf("expected default action poweroff, got %q", got)
+	}
+
+	ctx := WithAction(context.Background(), ActionReboot)
+	if got := ActionFromContext(ctx); got != ActionReboot {
+		t.Errorf("expected action reboot, got %q", got)
+	}
+}
+
+func TestReasonFromContext(t *testing.T) {
+	if got := ReasonFromContext(context.Background()); got != config.DefaultShutdownReason {
+		t.Errorf("expected default reason %q, got %q", config.DefaultShutdownReason, got)
+	}
+
+	ctx := WithReason(context.Background(), "maintenance")
+	if got := ReasonFromContext(ctx); got != "maintenance" {
+		t.Errorf("expected reason 'maintenance', got %q", got)
+	}
+}
+
+func TestAction_SysrqCommand(t *testing.T) {
+	if ActionPoweroff.sysrqCommand() != "o" {
+		t.Errorf("expected 'o' for poweroff, got '%s'", ActionPoweroff.sysrqCommand())
+	}
+	if ActionReboot.sysrqCommand() != "b" {
+		t.Errorf("expected 'b' for reboot, got '%s'", ActionReboot.sysrqCommand())
+	}
+}
+
+func TestAction_DirectCommands(t *testing.T) {
+	tests := []struct {
+		name     string
+		action   Action
+		when     string
+		message  string
+		expected [][]string
+	}{
+		{
+			name:     "poweroff now",
+			action:   ActionPoweroff,
+			when:     "now",
+			message:  "maintenance",
+			expected: [][]string{{"poweroff"}, {"shutdown", "-h", "now", "maintenance"}},
+		},
+		{
+			name:     "poweroff delayed",
+			action:   ActionPoweroff,
+			when:     "+1",
+			message:  "maintenance",
+			expected: [][]string{{"shutdown", "-h", "+1", "maintenance"}},
+		},
+		{
+			name:     "reboot delayed without message",
+			action:   ActionReboot,
+			when:     "+5",
+			expected: [][]string{{"shutdown", "-r", "+5"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.action.directCommands(tt.when, tt.message)
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("directCommands(%q, %q) = %v, expected %v", tt.when, tt.message, got, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package shutdown
+
+import "context"
+
+// dryRunKey is the context key marking a single shutdown as a dry run
+type dryRunKey struct{}
+
+// WithDryRun returns a context whose shutdown runs as in safe mode, whatever the manager's
+// configuration: the pipeline runs but the host is not powered off, and the attempt does not
+// count towards rate limiting or the retry cooldown
+func WithDryRun(ctx context.Context) context.Context {
+	return context.WithValue(ctx, dryRunKey{}, true)
+}
+
+// DryRunFromContext reports whether ctx marks the shutdown as a dry run
+func DryRunFromContext(ctx context.Context) bool {
+	dryRun, _ := ctx.Value(dryRunKey{}).(bool)
+	return dryRun
+}
@@ -0,0 +1,42 @@
+package shutdown
+
+import (
+	"context"
+	"os"
+
+	"github.com/signalmice/signalmice/internal/logger"
+)
+
+// signalValueKey is the context key for the value of the signal that triggered the shutdown
+type signalValueKey struct{}
+
+// WithSignalValue returns a context carrying the value of the triggering signal
+func WithSignalValue(ctx context.Context, value string) context.Context {
+	return context.WithValue(ctx, signalValueKey{}, value)
+}
+
+// SignalValueFromContext returns the signal value stored in ctx, or "" if none was set
+func SignalValueFromContext(ctx context.Context) string {
+	value, _ := ctx.Value(signalValueKey{}).(string)
+	return value
+}
+
+// commandEnv returns the environment of the commands run by shutdown methods: variables
+// describing the shutdown, plus PATH so scripts can find their tools. The rest of the parent
+// environment, which may hold credentials, is only passed when inheritEnv is set.
+func (m *Manager) commandEnv(ctx context.Context) []string {
+	var env []string
+	if m.inheritEnv {
+		env = os.Environ()
+	} else if path, ok := os.LookupEnv("PATH"); ok {
+		env = append(env, "PATH="+path)
+	}
+
+	return append(env,
+		"SIGNALMICE_HOSTNAME="+m.Hostname(),
+		"SIGNALMICE_SIGNAL_VALUE="+SignalValueFromContext(ctx),
+		"SIGNALMICE_ACTION="+string(ActionFromContext(ctx)),
+		"SIGNALMICE_REASON="+ReasonFromContext(ctx),
+		"SIGNALMICE_CORRELATION_ID="+logger.CorrelationID(ctx),
+	)
+}
@@ -0,0 +1,100 @@
+package shutdown
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+)
+
+// Behaviors when neither nsenter nor sysrq can reach the host, selectable with
+// SIGNALMICE_NO_HOST_ACCESS
+const (
+	// NoHostAccessFail skips the methods that power off the host and runs only the others
+	NoHostAccessFail = "fail"
+	// NoHostAccessAttempt still tries the methods the probes cannot rule out after reporting
+	// what is missing
+	NoHostAccessAttempt = "attempt"
+)
+
+// ErrNoHostAccess is returned when the shutdown was given up because no configured method can
+// reach the host from this container
+var ErrNoHostAccess = errors.New("no shutdown method can reach the host")
+
+// ValidateNoHostAccess returns an error unless behavior is a known no-host-access behavior
+func ValidateNoHostAccess(behavior string) error {
+	switch behavior {
+	case NoHostAccessFail, NoHostAccessAttempt:
+		return nil
+	default:
+		return fmt.Errorf("unknown behavior %q (expected %s or %s)", behavior, NoHostAccessFail, NoHostAccessAttempt)
+	}
+}
+
+// hostMethod reports whether methods of kind power off the host, as opposed to acting within
+// the container like custom commands and signal-process
+func hostMethod(kind string) bool {
+	return kind == MethodTypeNsenter || kind == MethodTypeSysrq || kind == MethodTypeDirect
+}
+
+// assessHostAccess explains what the container lacks when both nsenter and sysrq methods are
+// configured and none of them can reach the host, the usual sign of a container started
+// without --privileged, --pid=host or the host's /proc. It returns nil otherwise, including
+// when either kind is not configured.
+func (m *Manager) assessHostAccess() []string {
+	var nsenterErr, sysrqErr error
+	var hasNsenter, hasSysrq bool
+	for _, method := range m.methods {
+		if method.probe == nil {
+			continue
+		}
+		switch method.kind {
+		case MethodTypeNsenter:
+			hasNsenter = true
+			if err := method.probe(); err == nil {
+				return nil
+			} else if nsenterErr == nil {
+				nsenterErr = err
+			}
+		case MethodTypeSysrq:
+			hasSysrq = true
+			if err := method.probe(); err == nil {
+				return nil
+			} else if sysrqErr == nil {
+				sysrqErr = err
+			}
+		}
+	}
+	if !hasNsenter || !hasSysrq {
+		return nil
+	}
+
+	guidance := []string{
+		fmt.Sprintf("nsenter cannot enter the host's namespaces (%v): run the container with --pid=host and --privileged", nsenterErr),
+	}
+	if m.checkHostProc() != nil {
+		guidance = append(guidance, fmt.Sprintf("the host's /proc is not mounted at HOST_PROC_PATH: mount it read-write, e.g. -v /proc:%s", m.hostProcPath))
+	} else {
+		guidance = append(guidance, fmt.Sprintf("sysrq cannot be triggered (%v): run the container with --privileged so %s is writable",
+			sysrqErr, filepath.Join(m.hostProcPath, "sysrq-trigger")))
+	}
+	return guidance
+}
+
+// reachableMethods returns the methods to run once assessHostAccess found the host
+// unreachable: only those that do not need the host when configured to fail, otherwise all but
+// the nsenter and sysrq methods whose probes already failed, since the probes can miss a host
+// the direct command reaches (a non-root install)
+func (m *Manager) reachableMethods() []method {
+	var methods []method
+	for _, method := range m.methods {
+		switch {
+		case !hostMethod(method.kind):
+		case m.noHostAccess == NoHostAccessFail:
+			continue
+		case method.probe != nil && (method.kind == MethodTypeNsenter || method.kind == MethodTypeSysrq):
+			continue
+		}
+		methods = append(methods, method)
+	}
+	return methods
+}
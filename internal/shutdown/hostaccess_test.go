@@ -0,0 +1,129 @@
+package shutdown
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/signalmice/signalmice/internal/config"
+)
+
+// noHostManager returns a manager whose nsenter and sysrq methods cannot reach the host, with
+// method functions recording their names in calls instead of running
+func noHostManager(t *testing.T, cfg *config.Config) (*Manager, *[]string) {
+	t.Helper()
+	cfg.HostProcPath = "/non-existent"
+	manager := mustNewManager(t, cfg, createMockLogger())
+	manager.procRoot = t.TempDir()
+
+	calls := &[]string{}
+	for i := range manager.methods {
+		name := manager.methods[i].name
+		manager.methods[i].fn = func(context.Context) error {
+			*calls = append(*calls, name)
+			return errors.New(name + " failed")
+		}
+	}
+	return manager, calls
+}
+
+func TestValidateNoHostAccess(t *testing.T) {
+	for _, behavior := range []string{NoHostAccessFail, NoHostAccessAttempt} {
+		if err := ValidateNoHostAccess(behavior); err != nil {
+			t.Errorf("expected %q to be valid, got %v", behavior, err)
+		}
+	}
+	if err := ValidateNoHostAccess("ignore"); err == nil {
+		t.Error("expected an unknown behavior to be rejected")
+	}
+}
+
+func TestManager_NoHostAccess_ConsolidatedError(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	manager, calls := noHostManager(t, &config.Config{NoHostAccess: NoHostAccessFail})
+
+	_, err := manager.NeutralizeStuartLittle(context.Background())
+	if !errors.Is(err, ErrNoHostAccess) {
+		t.Fatalf("expected ErrNoHostAccess, got %v", err)
+	}
+	if len(*calls) != 0 {
+		t.Errorf("expected no host method to run, got %v", *calls)
+	}
+
+	output := buf.String()
+	if n := strings.Count(output, "[ERROR]"); n != 1 {
+		t.Errorf("expected a single ERROR line, got %d:\n%s", n, output)
+	}
+	for _, guidance := range []string{"No shutdown method can reach the host", "--pid=host", "--privileged", "-v /proc:/non-existent"} {
+		if !strings.Contains(output, guidance) {
+			t.Errorf("expected the error to mention %q, got:\n%s", guidance, output)
+		}
+	}
+	if strings.Contains(output, "Shutdown via") {
+		t.Errorf("expected no per-method failures, got:\n%s", output)
+	}
+}
+
+func TestManager_NoHostAccess_RunsContainerMethods(t *testing.T) {
+	manager, calls := noHostManager(t, &config.Config{
+		NoHostAccess:        NoHostAccessFail,
+		ShutdownMethodsJSON: `[{"type":"nsenter"},{"type":"sysrq"},{"type":"custom","name":"app","command":"true"}]`,
+	})
+
+	if _, err := manager.NeutralizeStuartLittle(context.Background()); err == nil {
+		t.Fatal("expected the fake custom method to fail")
+	}
+	if got := strings.Join(*calls, ","); got != "app" {
+		t.Errorf("expected only the custom method to run, got %s", got)
+	}
+}
+
+func TestManager_NoHostAccess_AttemptsByDefault(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	manager, calls := noHostManager(t, &config.Config{})
+
+	_, err := manager.NeutralizeStuartLittle(context.Background())
+	if err == nil || errors.Is(err, ErrNoHostAccess) {
+		t.Fatalf("expected the methods to be attempted and fail, got %v", err)
+	}
+	// nsenter and sysrq were probed and cannot work; the direct command may still reach the host
+	if got := strings.Join(*calls, ","); got != "direct-command" {
+		t.Errorf("expected only the direct command to be attempted, got %s", got)
+	}
+
+	output := buf.String()
+	if n := strings.Count(output, "[ERROR]"); n != 1 {
+		t.Errorf("expected a single ERROR line, got %d:\n%s", n, output)
+	}
+	for _, skipped := range []string{"via nsenter", "via sysrq-trigger"} {
+		if strings.Contains(output, skipped) {
+			t.Errorf("expected no %s attempt, got:\n%s", skipped, output)
+		}
+	}
+}
+
+func TestManager_AssessHostAccess_ReachableNsenter(t *testing.T) {
+	manager, _ := noHostManager(t, &config.Config{})
+	ns := filepath.Join(manager.procRoot, "1", "ns")
+	if err := os.MkdirAll(ns, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("mnt:[4026531840]", filepath.Join(ns, "mnt")); err != nil {
+		t.Fatal(err)
+	}
+
+	if guidance := manager.assessHostAccess(); guidance != nil {
+		t.Errorf("expected no guidance while nsenter can reach the host, got %v", guidance)
+	}
+}
@@ -0,0 +1,201 @@
+package shutdown
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Method type identifiers accepted in SIGNALMICE_METHODS_JSON
+const (
+	MethodTypeNsenter = "nsenter"
+	MethodTypeSysrq   = "sysrq"
+	MethodTypeDirect  = "direct"
+	MethodTypeCustom  = "custom"
+	// MethodTypeSignalProcess signals a process instead of powering off, for graceful app shutdown
+	MethodTypeSignalProcess = "signal-process"
+)
+
+// MethodSpec describes a single shutdown method and its type-specific options
+type MethodSpec struct {
+	Type    string        `json:"type"`
+	Name    string        `json:"name,omitempty"`
+	Command string        `json:"command,omitempty"`  // nsenter/custom: command to run (nsenter defaults to the action)
+	Target  int           `json:"target,omitempty"`   // nsenter: PID whose namespaces are entered; signal-process: PID signalled
+	PIDFile string        `json:"pid_file,omitempty"` // signal-process: file holding the PID to signal
+	Process string        `json:"process,omitempty"`  // signal-process: name of the processes to signal
+	Signal  string        `json:"signal,omitempty"`   // signal-process: signal sent, e.g. TERM (the default)
+	Timeout time.Duration `json:"-"`
+}
+
+// method is a resolved shutdown method ready to be executed by the manager
+type method struct {
+	name     string
+	kind     string // One of the MethodType values
+	timeout  time.Duration
+	commands []string     // executables the method runs, resolved by the startup preflight
+	probe    func() error // Checks the method's privileges and paths without running it; nil when it needs none
+	fn       func(context.Context) error
+}
+
+// UnmarshalJSON decodes a MethodSpec, parsing the timeout as a Go duration string
+func (s *MethodSpec) UnmarshalJSON(data []byte) error {
+	type alias MethodSpec
+	raw := struct {
+		*alias
+		Timeout string `json:"timeout,omitempty"`
+	}{alias: (*alias)(s)}
+
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	if raw.Timeout != "" {
+		timeout, err := time.ParseDuration(raw.Timeout)
+		if err != nil {
+			return fmt.Errorf("invalid timeout %q: %w", raw.Timeout, err)
+		}
+		s.Timeout = timeout
+	}
+
+	return nil
+}
+
+// Validate checks that the spec has a known type and the options that type requires
+func (s MethodSpec) Validate() error {
+	switch s.Type {
+	case MethodTypeNsenter, MethodTypeSysrq, MethodTypeDirect:
+	case MethodTypeCustom:
+		if strings.TrimSpace(s.Command) == "" {
+			return fmt.Errorf("custom method requires a command")
+		}
+	case MethodTypeSignalProcess:
+		if err := s.validateSignalProcess(); err != nil {
+			return err
+		}
+	case "":
+		return fmt.Errorf("method type is required")
+	default:
+		return fmt.Errorf("unknown method type %q", s.Type)
+	}
+
+	if s.Target < 0 {
+		return fmt.Errorf("invalid nsenter target %d", s.Target)
+	}
+	if s.Timeout < 0 {
+		return fmt.Errorf("timeout must not be negative")
+	}
+
+	return nil
+}
+
+// ParseMethodSpecs parses and validates an ordered JSON array of method specs
+func ParseMethodSpecs(data string) ([]MethodSpec, error) {
+	var specs []MethodSpec
+	if err := json.Unmarshal([]byte(data), &specs); err != nil {
+		return nil, fmt.Errorf("failed to parse shutdown methods JSON: %w", err)
+	}
+
+	if len(specs) == 0 {
+		return nil, fmt.Errorf("shutdown methods JSON must contain at least one method")
+	}
+
+	for i, spec := range specs {
+		if err := spec.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid shutdown method at index %d: %w", i, err)
+		}
+	}
+
+	return specs, nil
+}
+
+// directCommandNames are the executables the direct method may run, depending on the action
+var directCommandNames = []string{"poweroff", "reboot", "shutdown"}
+
+// defaultMethods returns the built-in method order used when no JSON config is set
+func (m *Manager) defaultMethods() []method {
+	return []method{
+		{name: "nsenter", kind: MethodTypeNsenter, commands: []string{"nsenter"}, probe: m.nsenterProbe("1"), fn: m.shutdownViaNsenter},
+		{name: "sysrq-trigger", kind: MethodTypeSysrq, probe: m.probeSysrq, fn: m.shutdownViaSysrq},
+		{name: "direct-command", kind: MethodTypeDirect, commands: directCommandNames, fn: m.shutdownViaDirect},
+	}
+}
+
+// buildMethods resolves method specs into executable methods
+func (m *Manager) buildMethods(specs []MethodSpec) []method {
+	methods := make([]method, 0, len(specs))
+	for _, spec := range specs {
+		resolved := method{name: spec.Name, kind: spec.Type, timeout: spec.Timeout}
+
+		switch spec.Type {
+		case MethodTypeNsenter:
+			target := spec.Target
+			if target == 0 {
+				target = 1
+			}
+			command := strings.Fields(spec.Command)
+			resolved.commands = []string{"nsenter"}
+			resolved.probe = m.nsenterProbe(strconv.Itoa(target))
+			resolved.fn = func(ctx context.Context) error {
+				if len(command) == 0 {
+					return m.runNsenter(ctx, strconv.Itoa(target), []string{string(ActionFromContext(ctx))})
+				}
+				return m.runNsenter(ctx, strconv.Itoa(target), command)
+			}
+			if resolved.name == "" {
+				resolved.name = "nsenter"
+			}
+		case MethodTypeSysrq:
+			resolved.probe = m.probeSysrq
+			resolved.fn = m.shutdownViaSysrq
+			if resolved.name == "" {
+				resolved.name = "sysrq-trigger"
+			}
+		case MethodTypeDirect:
+			resolved.commands = directCommandNames
+			resolved.fn = m.shutdownViaDirect
+			if resolved.name == "" {
+				resolved.name = "direct-command"
+			}
+		case MethodTypeSignalProcess:
+			// Validate already parsed the signal
+			sig, _ := ParseSignal(spec.Signal)
+			target := processTarget{pid: spec.Target, pidFile: spec.PIDFile, name: spec.Process}
+			resolved.probe = func() error { return m.probeProcess(target) }
+			resolved.fn = func(ctx context.Context) error {
+				return m.signalProcess(ctx, target, sig)
+			}
+			if resolved.name == "" {
+				resolved.name = MethodTypeSignalProcess
+			}
+		case MethodTypeCustom:
+			args := strings.Fields(spec.Command)
+			resolved.commands = args[:1]
+			resolved.fn = func(ctx context.Context) error {
+				return runCommand(ctx, args, m.commandEnv(ctx))
+			}
+			if resolved.name == "" {
+				resolved.name = "custom"
+			}
+		}
+
+		methods = append(methods, resolved)
+	}
+
+	return methods
+}
+
+// runCommand executes a command with the given environment and includes its output in any error
+func runCommand(ctx context.Context, args, env []string) error {
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	cmd.Env = env
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s failed: %w, output: %s", args[0], err, string(output))
+	}
+	return nil
+}
@@ -0,0 +1,191 @@
+package shutdown
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/signalmice/signalmice/internal/config"
+	"github.com/signalmice/signalmice/internal/logger"
+)
+
+func TestParseMethodSpecs_MultiMethod(t *testing.T) {
+	data := `[
+		{"type":"custom","command":"systemctl poweroff","timeout":"10s"},
+		{"type":"nsenter","target":42,"command":"shutdown -h now","timeout":"5s"},
+		{"type":"sysrq"},
+		{"type":"direct","name":"fallback"}
+	]`
+
+	specs, err := ParseMethodSpecs(data)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(specs) != 4 {
+		t.Fatalf("expected 4 specs, got %d", len(specs))
+	}
+	if specs[0].Type != MethodTypeCustom || specs[0].Command != "systemctl poweroff" || specs[0].Timeout != 10*time.Second {
+		t.Errorf("unexpected custom spec: %+v", specs[0])
+	}
+	if specs[1].Type != MethodTypeNsenter || specs[1].Target != 42 || specs[1].Timeout != 5*time.Second {
+		t.Errorf("unexpected nsenter spec: %+v", specs[1])
+	}
+	if specs[2].Type != MethodTypeSysrq {
+		t.Errorf("expected sysrq spec, got %+v", specs[2])
+	}
+	if specs[3].Name != "fallback" {
+		t.Errorf("expected name 'fallback', got '%s'", specs[3].Name)
+	}
+}
+
+func TestParseMethodSpecs_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		contains string
+	}{
+		{"malformed JSON", `[{"type":`, "failed to parse"},
+		{"empty array", `[]`, "at least one method"},
+		{"missing type", `[{"command":"poweroff"}]`, "type is required"},
+		{"unknown type", `[{"type":"teleport"}]`, "unknown method type"},
+		{"custom without command", `[{"type":"custom"}]`, "requires a command"},
+		{"invalid timeout", `[{"type":"direct","timeout":"soon"}]`, "invalid timeout"},
+		{"negative target", `[{"type":"nsenter","target":-1}]`, "invalid nsenter target"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseMethodSpecs(tt.data)
+			if err == nil {
+				t.Fatal("expected error, got nil")
+			}
+			if !strings.Contains(err.Error(), tt.contains) {
+				t.Errorf("expected error containing %q, got: %v", tt.contains, err)
+			}
+		})
+	}
+}
+
+func TestNewManager_DefaultMethods(t *testing.T) {
+	cfg := &config.Config{HostProcPath: "/proc"}
+	manager := mustNewManager(t, cfg, createMockLogger())
+
+	expected := []string{"nsenter", "sysrq-trigger", "direct-command"}
+	if len(manager.methods) != len(expected) {
+		t.Fatalf("expected %d methods, got %d", len(expected), len(manager.methods))
+	}
+	for i, name := range expected {
+		if manager.methods[i].name != name {
+			t.Errorf("expected method %d to be '%s', got '%s'", i, name, manager.methods[i].name)
+		}
+	}
+}
+
+func TestNewManager_MethodsJSON(t *testing.T) {
+	cfg := &config.Config{
+		HostProcPath:        "/proc",
+		ShutdownMethodsJSON: `[{"type":"custom","command":"systemctl poweroff","timeout":"10s"},{"type":"sysrq"},{"type":"nsenter","name":"host-halt","command":"halt"}]`,
+	}
+	manager := mustNewManager(t, cfg, createMockLogger())
+
+	expected := []struct {
+		name    string
+		timeout time.Duration
+	}{
+		{"custom", 10 * time.Second},
+		{"sysrq-trigger", 0},
+		{"host-halt", 0},
+	}
+	if len(manager.methods) != len(expected) {
+		t.Fatalf("expected %d methods, got %d", len(expected), len(manager.methods))
+	}
+	for i, want := range expected {
+		got := manager.methods[i]
+		if got.name != want.name {
+			t.Errorf("expected method %d name '%s', got '%s'", i, want.name, got.name)
+		}
+		if got.timeout != want.timeout {
+			t.Errorf("expected method %d timeout %v, got %v", i, want.timeout, got.timeout)
+		}
+		if got.fn == nil {
+			t.Errorf("expected method %d to have a function", i)
+		}
+	}
+}
+
+func TestNewManager_InvalidMethodsJSON(t *testing.T) {
+	cfg := &config.Config{ShutdownMethodsJSON: `[{"type":"unknown"}]`}
+
+	_, err := NewManager(cfg, createMockLogger())
+	if err == nil {
+		t.Error("expected error for invalid methods JSON")
+	}
+}
+
+// runEnvScript runs a custom method whose script dumps its environment, and returns the dump
+func runEnvScript(t *testing.T, inheritEnv bool) string {
+	t.Helper()
+	dir := t.TempDir()
+	script := filepath.Join(dir, "hook.sh")
+	out := filepath.Join(dir, "env.txt")
+	if err := os.WriteFile(script, []byte("#!/bin/sh\nenv > \"$1\"\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{
+		HostProcPath:        "/non-existent",
+		Hostname:            "node-7",
+		ShutdownMethodsJSON: `[{"type":"custom","command":"sh ` + script + ` ` + out + `"}]`,
+		ExecInheritEnv:      inheritEnv,
+	}
+	manager := mustNewManager(t, cfg, createMockLogger())
+
+	ctx := WithSignalValue(WithReason(WithAction(context.Background(), ActionReboot), "kernel upgrade"), "reboot")
+	ctx = logger.WithCorrelationID(ctx, "corr-123")
+	if err := manager.methods[0].fn(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestCustomMethod_ReceivesShutdownEnv(t *testing.T) {
+	t.Setenv("SIGNALMICE_TEST_SECRET", "hunter2")
+	env := runEnvScript(t, false)
+
+	for _, want := range []string{
+		"SIGNALMICE_HOSTNAME=node-7",
+		"SIGNALMICE_SIGNAL_VALUE=reboot",
+		"SIGNALMICE_ACTION=reboot",
+		"SIGNALMICE_REASON=kernel upgrade",
+		"SIGNALMICE_CORRELATION_ID=corr-123",
+		"PATH=",
+	} {
+		if !strings.Contains(env, want) {
+			t.Errorf("expected %q in the command environment, got:\n%s", want, env)
+		}
+	}
+	if strings.Contains(env, "SIGNALMICE_TEST_SECRET") {
+		t.Errorf("expected the parent environment not to leak, got:\n%s", env)
+	}
+}
+
+func TestCustomMethod_InheritEnv(t *testing.T) {
+	t.Setenv("SIGNALMICE_TEST_SECRET", "hunter2")
+	env := runEnvScript(t, true)
+
+	if !strings.Contains(env, "SIGNALMICE_TEST_SECRET=hunter2") {
+		t.Errorf("expected the parent environment to be passed when opted in, got:\n%s", env)
+	}
+	if !strings.Contains(env, "SIGNALMICE_ACTION=reboot") {
+		t.Errorf("expected the shutdown variables alongside the parent environment, got:\n%s", env)
+	}
+}
@@ -0,0 +1,124 @@
+package shutdown
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// CommandCheck is the preflight result for one executable required by a shutdown method
+type CommandCheck struct {
+	Method  string
+	Command string
+	Path    string // Resolved path; empty when the command was not found
+}
+
+// Found reports whether the command resolved on PATH
+func (c CommandCheck) Found() bool {
+	return c.Path != ""
+}
+
+// Preflight resolves the executables of every configured method on PATH and logs
+// which are available, so operators learn before an incident whether a method is viable
+func (m *Manager) Preflight(ctx context.Context) []CommandCheck {
+	var checks []CommandCheck
+	for _, method := range m.methods {
+		for _, command := range method.commands {
+			check := CommandCheck{Method: method.name, Command: command}
+			if path, err := exec.LookPath(command); err == nil {
+				check.Path = path
+			}
+			checks = append(checks, check)
+
+			if check.Found() {
+				m.logger.InfoWithExtra(ctx, "Shutdown command resolvable", map[string]string{
+					"method":  check.Method,
+					"command": check.Command,
+					"path":    check.Path,
+				})
+			} else {
+				m.logger.WarnWithExtra(ctx, "Shutdown command not found on PATH", map[string]string{
+					"method":  check.Method,
+					"command": check.Command,
+				})
+			}
+		}
+	}
+	return checks
+}
+
+// MethodReadiness is the assessment of whether one shutdown method would likely succeed
+type MethodReadiness struct {
+	Method   string
+	Commands []CommandCheck
+	Problems []string // What would make the method fail; empty when it looks viable
+}
+
+// Ready reports whether nothing was found that would make the method fail
+func (r MethodReadiness) Ready() bool {
+	return len(r.Problems) == 0
+}
+
+// CheckMethods assesses every configured method in order without running it: its commands
+// must resolve on PATH (any one of the alternatives the method falls back between) and the
+// paths and privileges it relies on must be accessible
+func (m *Manager) CheckMethods() []MethodReadiness {
+	reports := make([]MethodReadiness, 0, len(m.methods))
+	for _, method := range m.methods {
+		report := MethodReadiness{Method: method.name}
+
+		found := false
+		for _, command := range method.commands {
+			check := CommandCheck{Method: method.name, Command: command}
+			if path, err := exec.LookPath(command); err == nil {
+				check.Path = path
+				found = true
+			}
+			report.Commands = append(report.Commands, check)
+		}
+		if len(method.commands) > 0 && !found {
+			report.Problems = append(report.Problems, fmt.Sprintf("%s not found on PATH", strings.Join(method.commands, ", ")))
+		}
+
+		if method.probe != nil {
+			if err := method.probe(); err != nil {
+				report.Problems = append(report.Problems, err.Error())
+			}
+		}
+
+		reports = append(reports, report)
+	}
+	return reports
+}
+
+// probeSysrq checks that kernel.sysrq permits poweroff and that the host's sysrq-trigger can
+// be opened for writing. Opening the trigger does nothing; only a write runs a command.
+func (m *Manager) probeSysrq() error {
+	if err := m.checkHostProc(); err != nil {
+		return err
+	}
+	if _, err := m.checkSysrqMask(); err != nil {
+		return err
+	}
+	path := filepath.Join(m.hostProcPath, "sysrq-trigger")
+	f, err := os.OpenFile(path, os.O_WRONLY, 0)
+	if err != nil {
+		return m.sysrqError(err)
+	}
+	return f.Close()
+}
+
+// nsenterProbe returns a probe checking that the mount namespace of the target PID is
+// visible, which needs the host PID namespace and the privileges nsenter requires
+func (m *Manager) nsenterProbe(target string) func() error {
+	return func() error {
+		path := filepath.Join(m.procRoot, target, "ns", "mnt")
+		if _, err := os.Readlink(path); err != nil {
+			return fmt.Errorf("namespaces of PID %s not accessible: %w", target, err)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,179 @@
+package shutdown
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/signalmice/signalmice/internal/config"
+)
+
+// writeExecutable creates an empty executable file named name in dir
+func writeExecutable(t *testing.T, dir, name string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte("#!/bin/sh\n"), 0755); err != nil {
+		t.Fatalf("failed to create %s: %v", name, err)
+	}
+}
+
+func TestManager_Preflight(t *testing.T) {
+	dir := t.TempDir()
+	writeExecutable(t, dir, "nsenter")
+	writeExecutable(t, dir, "shutdown")
+	t.Setenv("PATH", dir)
+
+	manager := mustNewManager(t, &config.Config{HostProcPath: "/non-existent"}, createMockLogger())
+	checks := manager.Preflight(context.Background())
+
+	found := map[string]bool{}
+	for _, check := range checks {
+		found[check.Command] = check.Found()
+	}
+
+	expected := map[string]bool{
+		"nsenter":  true,
+		"shutdown": true,
+		"poweroff": false,
+		"reboot":   false,
+	}
+	for command, want := range expected {
+		got, ok := found[command]
+		if !ok {
+			t.Errorf("expected preflight to check %s", command)
+			continue
+		}
+		if got != want {
+			t.Errorf("expected %s found=%v, got %v", command, want, got)
+		}
+	}
+}
+
+func TestManager_Preflight_EmptyPath(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	manager := mustNewManager(t, &config.Config{HostProcPath: "/non-existent"}, createMockLogger())
+	for _, check := range manager.Preflight(context.Background()) {
+		if check.Found() {
+			t.Errorf("expected %s not to be found, resolved to %s", check.Command, check.Path)
+		}
+	}
+}
+
+func TestManager_Preflight_CustomMethod(t *testing.T) {
+	dir := t.TempDir()
+	writeExecutable(t, dir, "halt-host")
+	t.Setenv("PATH", dir)
+
+	cfg := &config.Config{ShutdownMethodsJSON: `[{"type":"custom","command":"halt-host --now"},{"type":"sysrq"}]`}
+	manager := mustNewManager(t, cfg, createMockLogger())
+
+	checks := manager.Preflight(context.Background())
+	if len(checks) != 1 {
+		t.Fatalf("expected 1 command check (sysrq needs none), got %d", len(checks))
+	}
+	if checks[0].Command != "halt-host" || !checks[0].Found() {
+		t.Errorf("expected halt-host to be resolved, got %+v", checks[0])
+	}
+}
+
+// readinessByMethod indexes method readiness reports by method name
+func readinessByMethod(reports []MethodReadiness) map[string]MethodReadiness {
+	byMethod := map[string]MethodReadiness{}
+	for _, report := range reports {
+		byMethod[report.Method] = report
+	}
+	return byMethod
+}
+
+func TestManager_CheckMethods_Ready(t *testing.T) {
+	binDir := t.TempDir()
+	writeExecutable(t, binDir, "nsenter")
+	writeExecutable(t, binDir, "shutdown")
+	t.Setenv("PATH", binDir)
+
+	hostProc := t.TempDir()
+	if err := os.WriteFile(filepath.Join(hostProc, "sysrq-trigger"), nil, 0200); err != nil {
+		t.Fatalf("failed to create sysrq-trigger: %v", err)
+	}
+	procRoot := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(procRoot, "1", "ns"), 0755); err != nil {
+		t.Fatalf("failed to create ns dir: %v", err)
+	}
+	if err := os.Symlink("mnt:[4026531840]", filepath.Join(procRoot, "1", "ns", "mnt")); err != nil {
+		t.Fatalf("failed to create ns link: %v", err)
+	}
+
+	manager := mustNewManager(t, &config.Config{HostProcPath: hostProc}, createMockLogger())
+	manager.procRoot = procRoot
+
+	reports := manager.CheckMethods()
+	if len(reports) != 3 {
+		t.Fatalf("expected a report per default method, got %d", len(reports))
+	}
+	for _, report := range reports {
+		if !report.Ready() {
+			t.Errorf("expected %s to be ready, got problems %v", report.Method, report.Problems)
+		}
+	}
+
+	// The direct method needs only one of its alternatives
+	direct := readinessByMethod(reports)["direct-command"]
+	if len(direct.Commands) != 3 {
+		t.Errorf("expected every direct command to be checked, got %+v", direct.Commands)
+	}
+}
+
+func TestManager_CheckMethods_Problems(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	manager := mustNewManager(t, &config.Config{HostProcPath: filepath.Join(t.TempDir(), "missing")}, createMockLogger())
+	manager.procRoot = t.TempDir()
+
+	reports := readinessByMethod(manager.CheckMethods())
+
+	expected := map[string][]string{
+		"nsenter":        {"nsenter not found on PATH", "namespaces of PID 1 not accessible"},
+		"sysrq-trigger":  {"host proc path not mounted"},
+		"direct-command": {"poweroff, reboot, shutdown not found on PATH"},
+	}
+	for method, problems := range expected {
+		report, ok := reports[method]
+		if !ok {
+			t.Errorf("expected a report for %s", method)
+			continue
+		}
+		if report.Ready() {
+			t.Errorf("expected %s not to be ready", method)
+			continue
+		}
+		if len(report.Problems) != len(problems) {
+			t.Errorf("expected %d problems for %s, got %v", len(problems), method, report.Problems)
+			continue
+		}
+		for i, problem := range problems {
+			if !strings.Contains(report.Problems[i], problem) {
+				t.Errorf("expected %s problem %q, got %q", method, problem, report.Problems[i])
+			}
+		}
+	}
+}
+
+func TestManager_CheckMethods_CustomTarget(t *testing.T) {
+	binDir := t.TempDir()
+	writeExecutable(t, binDir, "nsenter")
+	t.Setenv("PATH", binDir)
+
+	cfg := &config.Config{ShutdownMethodsJSON: `[{"type":"nsenter","target":42}]`}
+	manager := mustNewManager(t, cfg, createMockLogger())
+	manager.procRoot = t.TempDir()
+
+	reports := manager.CheckMethods()
+	if len(reports) != 1 || reports[0].Ready() {
+		t.Fatalf("expected one nsenter method that is not ready, got %+v", reports)
+	}
+	if !strings.Contains(reports[0].Problems[0], "PID 42") {
+		t.Errorf("expected the configured target to be probed, got %v", reports[0].Problems)
+	}
+}
@@ -0,0 +1,23 @@
+package shutdown
+
+import (
+	"context"
+
+	"github.com/signalmice/signalmice/internal/config"
+)
+
+// reasonKey is the context key for the human-readable shutdown reason
+type reasonKey struct{}
+
+// WithReason returns a context carrying the reason recorded for the shutdown
+func WithReason(ctx context.Context, reason string) context.Context {
+	return context.WithValue(ctx, reasonKey{}, reason)
+}
+
+// ReasonFromContext returns the reason stored in ctx, defaulting to config.DefaultShutdownReason
+func ReasonFromContext(ctx context.Context) string {
+	if reason, ok := ctx.Value(reasonKey{}).(string); ok && reason != "" {
+		return reason
+	}
+	return config.DefaultShutdownReason
+}
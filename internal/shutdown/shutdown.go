@@ -2,126 +2,579 @@ package shutdown
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/signalmice/signalmice/internal/config"
 	"github.com/signalmice/signalmice/internal/logger"
+	"github.com/signalmice/signalmice/internal/metrics"
+	"github.com/signalmice/signalmice/internal/tracing"
+	"github.com/signalmice/signalmice/internal/webhook"
 )
 
+// shutdownWhenPattern matches the time formats accepted by shutdown(8)
+var shutdownWhenPattern = regexp.MustCompile(`^(now|\+\d+|\d{1,2}:\d{2})$`)
+
+// preShutdownFlushTimeout bounds the log flush that runs right before the shutdown commands
+const preShutdownFlushTimeout = 2 * time.Second
+
+// ErrRateLimited is returned when a shutdown attempt is suppressed by the minimum attempt interval
+var ErrRateLimited = errors.New("shutdown attempt suppressed by rate limit")
+
+// ErrCoolingDown is returned when a shutdown attempt is suppressed by the retry cooldown that
+// follows a failed attempt. It wraps ErrRateLimited.
+var ErrCoolingDown = fmt.Errorf("%w: cooling down after failed attempt", ErrRateLimited)
+
 // Manager handles host machine shutdown
 type Manager struct {
 	hostProcPath string
+	procRoot     string // Local /proc, whose target namespaces nsenter enters
+	hostname     string
 	logger       *logger.Logger
+	methods      []method // Tried in order; tests replace their functions with fakes
+	deadline     time.Duration
+	safeMode     bool
+	when         string // Time argument for the shutdown command, e.g. "now" or "+1"
+	parallel     bool   // Run methods concurrently, first success wins
+	inheritEnv   bool   // Pass the full parent environment to executed commands
+	noHostAccess string // What to do when no method can reach the host, one of the NoHostAccess values
+	metrics      *metrics.Registry
+	webhook      *webhook.Client
+	notifier     *webhook.Notifier                       // Announces the method right before the terminal command runs
+	flush        func(ctx context.Context) bool          // Drains queued logs before the power is cut
+	abortCheck   func(ctx context.Context) (bool, error) // Final check before each method; nil skips it
+
+	sysrqSyncCount int                                  // Sync ('s') writes before remount and poweroff
+	sysrqSyncDelay time.Duration                        // Pause after each sync write
+	writeSysrq     func(path string, data []byte) error // Writes a command to sysrq-trigger
+
+	minInterval   time.Duration
+	retryCooldown time.Duration
+	mu            sync.Mutex
+	lastAttempt   time.Time
+	lastFailure   time.Time
+
+	hostnameMu sync.RWMutex // Guards hostname, which SetHostname changes at runtime
 }
 
-// NewManager creates a new shutdown manager
-func NewManager(cfg *config.Config, log *logger.Logger) *Manager {
-	return &Manager{
-		hostProcPath: cfg.HostProcPath,
-		logger:       log,
+// NewManager creates a new shutdown manager.
+// If SIGNALMICE_METHODS_JSON is configured, its methods replace the built-in order.
+func NewManager(cfg *config.Config, log *logger.Logger) (*Manager, error) {
+	m := &Manager{
+		hostProcPath:  cfg.HostProcPath,
+		procRoot:      "/proc",
+		hostname:      cfg.Hostname,
+		logger:        log,
+		deadline:      cfg.ShutdownDeadline,
+		safeMode:      cfg.SafeMode,
+		minInterval:   cfg.MinShutdownInterval,
+		retryCooldown: cfg.ShutdownRetryCooldown,
+		when:          cfg.ShutdownWhen,
+		parallel:      cfg.ParallelMethods,
+		inheritEnv:    cfg.ExecInheritEnv,
+		noHostAccess:  cfg.NoHostAccess,
+
+		sysrqSyncCount: cfg.SysrqSyncCount,
+		sysrqSyncDelay: cfg.SysrqSyncDelay,
+		writeSysrq:     writeSysrqTrigger,
+	}
+	if log != nil {
+		m.flush = log.Flush
+	}
+
+	if m.when == "" {
+		m.when = "now"
+	}
+	if m.sysrqSyncCount < 1 {
+		m.sysrqSyncCount = 1
+	}
+	if err := ValidateWhen(m.when); err != nil {
+		return nil, fmt.Errorf("invalid SIGNALMICE_SHUTDOWN_WHEN: %w", err)
+	}
+
+	var webhooks []webhook.Spec
+	if cfg.WebhooksJSON != "" {
+		specs, err := webhook.ParseSpecs(cfg.WebhooksJSON)
+		if err != nil {
+			return nil, err
+		}
+		webhooks = specs
+	}
+	m.webhook = webhook.NewClient(cfg, webhooks...)
+	m.notifier = webhook.NewNotifier(cfg)
+
+	if cfg.ShutdownMethodsJSON == "" {
+		m.methods = m.defaultMethods()
+		return m, nil
+	}
+
+	specs, err := ParseMethodSpecs(cfg.ShutdownMethodsJSON)
+	if err != nil {
+		return nil, err
 	}
+	m.methods = m.buildMethods(specs)
+
+	return m, nil
 }
 
 // NeutralizeStuartLittle attempts to shutdown the host machine using multiple methods.
 // This function catches the shutdown signal and neutralizes the target machine.
+// It returns the name of the method that initiated the shutdown, which is empty in safe mode.
 // https://www.reddit.com/r/stuartlittlefacts/
-func (m *Manager) NeutralizeStuartLittle(ctx context.Context) error {
-	m.logger.Info(ctx, "Initiating host machine shutdown...")
+func (m *Manager) NeutralizeStuartLittle(ctx context.Context) (method string, err error) {
+	// A dry run never powers off, so it neither counts as an attempt nor waits for one
+	dryRun := DryRunFromContext(ctx)
+	if !dryRun {
+		if wait, ok := m.cooldownElapsed(); !ok {
+			m.logger.WarnWithExtra(ctx, "Shutdown attempt suppressed: cooling down after failed attempt", map[string]string{
+				"retry_cooldown": m.retryCooldown.String(),
+				"retry_after":    wait.String(),
+			})
+			return "", ErrCoolingDown
+		}
+		if wait, ok := m.allowAttempt(); !ok {
+			m.logger.WarnWithExtra(ctx, "Shutdown attempt suppressed: too soon after previous attempt", map[string]string{
+				"min_interval": m.minInterval.String(),
+				"retry_after":  wait.String(),
+			})
+			return "", ErrRateLimited
+		}
+	}
 
-	// Try multiple methods in order of preference
-	methods := []struct {
-		name string
-		fn   func(context.Context) error
-	}{
-		{"nsenter", m.shutdownViaNsenter},
-		{"sysrq-trigger", m.shutdownViaSysrq},
-		{"direct-command", m.shutdownViaDirect},
+	defer m.metrics.BeginShutdown()()
+	if !dryRun {
+		defer func() { m.recordOutcome(err) }()
+	}
+
+	reason := ReasonFromContext(ctx)
+	ctx, span := tracing.Start(ctx, "shutdown")
+	span.SetAttribute("action", string(ActionFromContext(ctx)))
+	span.SetAttribute("reason", reason)
+	span.SetAttribute("safe_mode", strconv.FormatBool(m.skipsShutdown(ctx)))
+	defer func() {
+		if method != "" {
+			span.SetAttribute("method", method)
+		}
+		span.SetAttribute("result", spanResult(err))
+		span.End(err)
+	}()
+	m.logger.InfoWithExtra(ctx, fmt.Sprintf("Initiating host machine shutdown (reason: %s)...", reason), map[string]string{
+		"action": string(ActionFromContext(ctx)),
+		"reason": reason,
+	})
+
+	// Bound the whole sequence, pre-shutdown webhook included, so a stuck step cannot hold it forever
+	seqCtx := ctx
+	if m.deadline > 0 {
+		var cancel context.CancelFunc
+		seqCtx, cancel = context.WithTimeout(ctx, m.deadline)
+		defer cancel()
+	}
+
+	m.notifyWebhook(seqCtx, reason)
+
+	// In safe mode everything up to the terminal shutdown runs, but nothing is powered off
+	if m.skipsShutdown(ctx) {
+		message := "Safe mode enabled: skipping host shutdown"
+		if dryRun {
+			message = "Dry run: skipping host shutdown"
+		}
+		m.logger.WarnWithExtra(ctx, message, map[string]any{
+			"methods": m.MethodNames(),
+		})
+		return "", nil
+	}
+
+	// One actionable error beats a failure per method when the container cannot reach the host
+	methods := m.methods
+	if guidance := m.assessHostAccess(); guidance != nil {
+		methods = m.reachableMethods()
+		m.logger.ErrorWithExtra(ctx, "No shutdown method can reach the host: "+strings.Join(guidance, "; "), map[string]any{
+			"guidance":       guidance,
+			"no_host_access": m.noHostAccess,
+			"methods":        methodNames(methods),
+		})
+		if len(methods) == 0 {
+			return "", ErrNoHostAccess
+		}
 	}
 
 	var lastErr error
+	if m.parallel {
+		if method, lastErr = m.runParallel(ctx, seqCtx, methods); lastErr == nil {
+			return method, nil
+		}
+		if errors.Is(lastErr, ErrAborted) {
+			return "", lastErr
+		}
+	} else {
+		// Try multiple methods in order of preference, announcing the attempt once for all of them
+		var waitNotified func()
+		for _, candidate := range methods {
+			if errors.Is(seqCtx.Err(), context.DeadlineExceeded) {
+				break
+			}
+			if m.aborted(seqCtx) {
+				return "", ErrAborted
+			}
+			m.logger.InfoWithExtra(ctx, fmt.Sprintf("Attempting shutdown via %s", candidate.name), nil)
+			if waitNotified == nil {
+				waitNotified = m.notifyInitiated(ctx, methodNames(methods))
+			}
+			m.flushLogs(seqCtx)
+			waitNotified()
+			// The flush and notification take time; an abort set meanwhile still wins
+			if m.aborted(seqCtx) {
+				return "", ErrAborted
+			}
+			if err := m.runMethod(seqCtx, candidate); err != nil {
+				m.logger.WarnWithExtra(ctx, fmt.Sprintf("Shutdown via %s failed", candidate.name), map[string]string{"error": err.Error()})
+				lastErr = err
+				continue
+			}
+			m.logger.Info(ctx, fmt.Sprintf("Shutdown initiated successfully via %s", candidate.name))
+			return candidate.name, nil
+		}
+	}
+
+	if errors.Is(seqCtx.Err(), context.DeadlineExceeded) {
+		m.logger.ErrorWithExtra(ctx, "Shutdown sequence abandoned: deadline exceeded", map[string]string{"deadline": m.deadline.String()})
+		return "", fmt.Errorf("shutdown sequence exceeded deadline of %s: %w", m.deadline, seqCtx.Err())
+	}
+
+	return "", fmt.Errorf("all shutdown methods failed, last error: %w", lastErr)
+}
+
+// Hostname returns the hostname reported in webhooks, notifications and command environments
+func (m *Manager) Hostname() string {
+	m.hostnameMu.RLock()
+	defer m.hostnameMu.RUnlock()
+	return m.hostname
+}
+
+// SetHostname changes the hostname reported by subsequent shutdowns
+func (m *Manager) SetHostname(hostname string) {
+	m.hostnameMu.Lock()
+	defer m.hostnameMu.Unlock()
+	m.hostname = hostname
+}
+
+// SetMetrics sets the registry whose shutdown-in-flight gauge tracks running sequences
+func (m *Manager) SetMetrics(reg *metrics.Registry) {
+	m.metrics = reg
+}
+
+// notifyWebhook gives the operator a heads-up before the host goes down.
+// A failed webhook is logged but never blocks the shutdown.
+func (m *Manager) notifyWebhook(ctx context.Context, reason string) {
+	if m.webhook == nil {
+		return
+	}
+
+	ctx, span := tracing.Start(ctx, "webhook")
+	err := m.webhook.Send(ctx, webhook.Payload{
+		Timestamp:     time.Now().UTC().Format(time.RFC3339),
+		Hostname:      m.Hostname(),
+		Action:        string(ActionFromContext(ctx)),
+		Reason:        reason,
+		CorrelationID: logger.CorrelationID(ctx),
+		SafeMode:      m.skipsShutdown(ctx),
+		Value:         SignalValueFromContext(ctx),
+	})
+	span.SetAttribute("result", spanResult(err))
+	span.End(err)
+	if err != nil {
+		m.logger.WarnWithExtra(ctx, "Pre-shutdown webhook failed, continuing with shutdown", map[string]string{"error": err.Error()})
+		return
+	}
+	m.logger.Info(ctx, "Pre-shutdown webhook delivered")
+}
+
+// notifyInitiated posts the post-notification announcing the attempt via methods in the
+// background and returns a function waiting for it, so the notification overlaps the log flush
+// yet leaves before the terminal command. Only the first call of wait blocks. A failed
+// notification is logged but never blocks the shutdown.
+func (m *Manager) notifyInitiated(ctx context.Context, methods []string) (wait func()) {
+	if m.notifier == nil {
+		return func() {}
+	}
+
+	notification := webhook.NewShutdownNotification(m.Hostname(), string(ActionFromContext(ctx)), methods, logger.CorrelationID(ctx))
+	done := m.notifier.Start(ctx, notification)
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			if err := <-done; err != nil {
+				m.logger.WarnWithExtra(ctx, "Post-shutdown notification failed, continuing with shutdown", map[string]string{"error": err.Error()})
+			}
+		})
+	}
+}
+
+// allowAttempt records a shutdown attempt unless one happened within the minimum interval.
+// When suppressed, it returns the remaining time until the next attempt is allowed.
+func (m *Manager) allowAttempt() (time.Duration, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if m.minInterval > 0 && !m.lastAttempt.IsZero() {
+		if elapsed := now.Sub(m.lastAttempt); elapsed < m.minInterval {
+			return m.minInterval - elapsed, false
+		}
+	}
+
+	m.lastAttempt = now
+	return 0, true
+}
+
+// cooldownElapsed reports whether the retry cooldown after the last failed attempt has passed.
+// When it has not, it returns the remaining time until the next attempt is allowed.
+func (m *Manager) cooldownElapsed() (time.Duration, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.retryCooldown <= 0 || m.lastFailure.IsZero() {
+		return 0, true
+	}
+	if elapsed := time.Since(m.lastFailure); elapsed < m.retryCooldown {
+		return m.retryCooldown - elapsed, false
+	}
+	return 0, true
+}
+
+// recordOutcome starts the retry cooldown after a failed attempt and clears it after a success.
+// An aborted attempt neither failed nor succeeded, so it leaves the cooldown as it was.
+func (m *Manager) recordOutcome(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if errors.Is(err, ErrAborted) {
+		return
+	}
+	if err != nil {
+		m.lastFailure = time.Now()
+		return
+	}
+	m.lastFailure = time.Time{}
+}
+
+// SafeMode reports whether the manager skips the actual host shutdown
+func (m *Manager) SafeMode() bool {
+	return m.safeMode
+}
+
+// skipsShutdown reports whether the shutdown in ctx stops short of powering off, either
+// because of safe mode or because it is a dry run
+func (m *Manager) skipsShutdown(ctx context.Context) bool {
+	return m.safeMode || DryRunFromContext(ctx)
+}
+
+// MethodNames returns the names of the configured shutdown methods in order
+func (m *Manager) MethodNames() []string {
+	return methodNames(m.methods)
+}
+
+// methodNames returns the names of methods, in order
+func methodNames(methods []method) []string {
+	names := make([]string, 0, len(methods))
 	for _, method := range methods {
-		m.logger.InfoWithExtra(ctx, fmt.Sprintf("Attempting shutdown via %s", method.name), nil)
-		if err := method.fn(ctx); err != nil {
-			m.logger.WarnWithExtra(ctx, fmt.Sprintf("Shutdown via %s failed", method.name), map[string]string{"error": err.Error()})
-			lastErr = err
-			continue
+		names = append(names, method.name)
+	}
+	return names
+}
+
+// runParallel launches methods concurrently and returns the name of the first to succeed,
+// cancelling the rest. Otherwise it returns the error of the last method to fail.
+func (m *Manager) runParallel(ctx, seqCtx context.Context, methods []method) (string, error) {
+	raceCtx, cancel := context.WithCancel(seqCtx)
+	defer cancel()
+
+	type result struct {
+		name string
+		err  error
+	}
+	results := make(chan result, len(methods))
+
+	if m.aborted(seqCtx) {
+		return "", ErrAborted
+	}
+	m.logger.InfoWithExtra(ctx, "Attempting shutdown via all methods in parallel", map[string]any{"methods": methodNames(methods)})
+	waitNotified := m.notifyInitiated(ctx, methodNames(methods))
+	m.flushLogs(seqCtx)
+	waitNotified()
+	if m.aborted(seqCtx) {
+		return "", ErrAborted
+	}
+	for _, method := range methods {
+		go func() {
+			results <- result{name: method.name, err: m.runMethod(raceCtx, method)}
+		}()
+	}
+
+	var lastErr error
+	for range methods {
+		res := <-results
+		if res.err == nil {
+			m.logger.Info(ctx, fmt.Sprintf("Shutdown initiated successfully via %s", res.name))
+			return res.name, nil
 		}
-		m.logger.Info(ctx, fmt.Sprintf("Shutdown initiated successfully via %s", method.name))
-		return nil
+		m.logger.WarnWithExtra(ctx, fmt.Sprintf("Shutdown via %s failed", res.name), map[string]string{"error": res.err.Error()})
+		lastErr = res.err
+	}
+	return "", lastErr
+}
+
+// flushLogs ships queued log entries before a shutdown command runs, so the last words reach
+// Opensearch before the power is cut. It waits at most preShutdownFlushTimeout. The trace so
+// far is exported alongside, since its root span never ends once the host is down.
+func (m *Manager) flushLogs(ctx context.Context) {
+	traced := make(chan struct{})
+	go func(ctx context.Context) {
+		defer close(traced)
+		tracing.Flush(ctx)
+	}(ctx)
+	defer func() { <-traced }()
+
+	if m.flush == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, preShutdownFlushTimeout)
+	defer cancel()
+	if !m.flush(ctx) {
+		m.logger.Warn(ctx, "Log flush before shutdown timed out, recent logs may be lost")
 	}
+}
+
+// runMethod executes a single method, bounded by its timeout when one is configured
+func (m *Manager) runMethod(ctx context.Context, method method) (err error) {
+	ctx, span := tracing.Start(ctx, "shutdown.method")
+	span.SetAttribute("method", method.name)
+	defer func() {
+		span.SetAttribute("result", spanResult(err))
+		span.End(err)
+	}()
 
-	return fmt.Errorf("all shutdown methods failed, last error: %w", lastErr)
+	if method.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, method.timeout)
+		defer cancel()
+	}
+	return method.fn(ctx)
+}
+
+// spanResult labels the outcome of a traced step
+func spanResult(err error) string {
+	if err != nil {
+		return "failure"
+	}
+	return "success"
 }
 
 // shutdownViaNsenter uses nsenter to enter the host namespace and run shutdown
 func (m *Manager) shutdownViaNsenter(ctx context.Context) error {
-	// Use nsenter to enter the host's namespace and run poweroff
+	// Use nsenter to enter the host's namespace and run poweroff (or reboot)
 	// This requires --privileged and --pid=host on the container
-	cmd := exec.CommandContext(ctx,
-		"nsenter",
-		"--target", "1",
+	return m.runNsenter(ctx, "1", []string{string(ActionFromContext(ctx))})
+}
+
+// runNsenter enters the namespaces of the target PID and runs the given command
+func (m *Manager) runNsenter(ctx context.Context, target string, command []string) error {
+	args := append([]string{
+		"--target", target,
 		"--mount",
 		"--uts",
 		"--ipc",
 		"--net",
 		"--pid",
 		"--",
-		"poweroff",
-	)
+	}, command...)
 
+	cmd := exec.CommandContext(ctx, "nsenter", args...)
+	cmd.Env = m.commandEnv(ctx)
 	output, err := cmd.CombinedOutput()
 	if err != nil {
-		return fmt.Errorf("nsenter poweroff failed: %w, output: %s", err, string(output))
+		return fmt.Errorf("nsenter %s failed: %w, output: %s", strings.Join(command, " "), err, string(output))
 	}
 
 	return nil
 }
 
-// shutdownViaSysrq uses the sysrq-trigger to power off the machine
+// shutdownViaSysrq uses the sysrq-trigger to power off (or reboot) the machine
 func (m *Manager) shutdownViaSysrq(ctx context.Context) error {
 	// First, sync all filesystems
 	syncPath := filepath.Join(m.hostProcPath, "sysrq-trigger")
 
 	// Check if we have access to host's proc
-	if _, err := os.Stat(m.hostProcPath); os.IsNotExist(err) {
-		return fmt.Errorf("host proc path not mounted: %s", m.hostProcPath)
+	if err := m.checkHostProc(); err != nil {
+		return err
 	}
 
-	// Sync filesystems first (sysrq 's')
-	if err := os.WriteFile(syncPath, []byte("s"), 0644); err != nil {
-		m.logger.Warn(ctx, "Failed to sync filesystems via sysrq")
+	// The kernel ignores commands kernel.sysrq does not permit, so check before writing
+	restricted, err := m.checkSysrqMask()
+	if err != nil {
+		return err
+	}
+	if len(restricted) > 0 {
+		m.logger.WarnWithExtra(ctx, "kernel.sysrq restricts sysrq functions, continuing since reboot and poweroff are permitted", map[string]any{
+			"restricted": restricted,
+		})
+	}
+
+	// Sync filesystems first (sysrq 's'), repeatedly on busy hosts, giving each sync time to flush
+	for i := 0; i < m.sysrqSyncCount; i++ {
+		if err := m.writeSysrq(syncPath, []byte("s")); err != nil {
+			m.logger.Warn(ctx, "Failed to sync filesystems via sysrq")
+		}
+		if m.sysrqSyncDelay > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(m.sysrqSyncDelay):
+			}
+		}
 	}
 
 	// Remount filesystems read-only (sysrq 'u')
-	if err := os.WriteFile(syncPath, []byte("u"), 0644); err != nil {
+	if err := m.writeSysrq(syncPath, []byte("u")); err != nil {
 		m.logger.Warn(ctx, "Failed to remount filesystems read-only via sysrq")
 	}
 
-	// Power off (sysrq 'o')
-	if err := os.WriteFile(syncPath, []byte("o"), 0644); err != nil {
-		return fmt.Errorf("failed to write to sysrq-trigger: %w", err)
+	// Power off (sysrq 'o') or reboot (sysrq 'b')
+	if err := m.writeSysrq(syncPath, []byte(ActionFromContext(ctx).sysrqCommand())); err != nil {
+		return m.sysrqError(err)
 	}
 
 	return nil
 }
 
+// writeSysrqTrigger writes a single sysrq command to the trigger file
+func writeSysrqTrigger(path string, data []byte) error {
+	return os.WriteFile(path, data, 0644)
+}
+
 // shutdownViaDirect uses the shutdown command directly
 // This only works if the container has access to host's init system
 func (m *Manager) shutdownViaDirect(ctx context.Context) error {
-	// Try poweroff command
-	cmd := exec.CommandContext(ctx, "poweroff")
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		// Try shutdown -h now as fallback
-		cmd = exec.CommandContext(ctx, "shutdown", "-h", "now")
-		output, err = cmd.CombinedOutput()
-		if err != nil {
-			return fmt.Errorf("shutdown commands failed: %w, output: %s", err, string(output))
+	// Try poweroff (or reboot), then shutdown -h now (or -r now) as fallback.
+	// A delayed SIGNALMICE_SHUTDOWN_WHEN only schedules shutdown, with the reason as wall message.
+	var output []byte
+	var err error
+	for _, args := range ActionFromContext(ctx).directCommands(m.whenFor(ctx), ReasonFromContext(ctx)) {
+		cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+		cmd.Env = m.commandEnv(ctx)
+		if output, err = cmd.CombinedOutput(); err == nil {
+			return nil
 		}
 	}
 
-	return nil
+	return fmt.Errorf("shutdown commands failed: %w, output: %s", err, string(output))
 }
@@ -3,14 +3,22 @@ package shutdown
 import (
 	"bytes"
 	"context"
+	"encoding/json"
+	"errors"
 	"log"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/signalmice/signalmice/internal/config"
 	"github.com/signalmice/signalmice/internal/logger"
+	"github.com/signalmice/signalmice/internal/tracing"
+	"github.com/signalmice/signalmice/internal/webhook"
 )
 
 // mockLogger creates a logger that doesn't connect to Opensearch
@@ -25,13 +33,23 @@ func createMockLogger() *logger.Logger {
 	return l
 }
 
+// mustNewManager creates a manager and fails the test if construction fails
+func mustNewManager(t *testing.T, cfg *config.Config, log *logger.Logger) *Manager {
+	t.Helper()
+	manager, err := NewManager(cfg, log)
+	if err != nil {
+		t.Fatalf("unexpected error creating manager: %v", err)
+	}
+	return manager
+}
+
 func TestNewManager(t *testing.T) {
 	cfg := &config.Config{
 		HostProcPath: "/test/proc",
 	}
 	mockLog := createMockLogger()
 
-	manager := NewManager(cfg, mockLog)
+	manager := mustNewManager(t, cfg, mockLog)
 
 	if manager == nil {
 		t.Error("expected manager to not be nil")
@@ -52,14 +70,15 @@ func TestManager_NeutralizeStuartLittle_NoValidMethod(t *testing.T) {
 
 	cfg := &config.Config{
 		HostProcPath: "/non-existent/path",
+		NoHostAccess: NoHostAccessAttempt,
 	}
 	mockLog := createMockLogger()
-	manager := NewManager(cfg, mockLog)
+	manager := mustNewManager(t, cfg, mockLog)
 
 	ctx := context.Background()
 
 	// This should fail because no shutdown method will work in a test environment
-	err := manager.NeutralizeStuartLittle(ctx)
+	_, err := manager.NeutralizeStuartLittle(ctx)
 	if err == nil {
 		t.Error("expected error when all shutdown methods fail")
 	}
@@ -74,7 +93,7 @@ func TestManager_shutdownViaSysrq_HostProcNotMounted(t *testing.T) {
 		HostProcPath: "/definitely-does-not-exist",
 	}
 	mockLog := createMockLogger()
-	manager := NewManager(cfg, mockLog)
+	manager := mustNewManager(t, cfg, mockLog)
 
 	ctx := context.Background()
 	err := manager.shutdownViaSysrq(ctx)
@@ -105,7 +124,7 @@ func TestManager_shutdownViaSysrq_SysrqTriggerPath(t *testing.T) {
 		HostProcPath: tmpDir,
 	}
 	mockLog := createMockLogger()
-	manager := NewManager(cfg, mockLog)
+	manager := mustNewManager(t, cfg, mockLog)
 
 	ctx := context.Background()
 
@@ -127,13 +146,61 @@ func TestManager_shutdownViaSysrq_SysrqTriggerPath(t *testing.T) {
 	}
 }
 
+func TestManager_shutdownViaSysrq_SyncRetries(t *testing.T) {
+	cfg := &config.Config{
+		HostProcPath:   t.TempDir(),
+		SysrqSyncCount: 3,
+		SysrqSyncDelay: 20 * time.Millisecond,
+	}
+	manager := mustNewManager(t, cfg, createMockLogger())
+
+	var writes []string
+	var times []time.Time
+	manager.writeSysrq = func(_ string, data []byte) error {
+		writes = append(writes, string(data))
+		times = append(times, time.Now())
+		return nil
+	}
+
+	if err := manager.shutdownViaSysrq(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := strings.Join(writes, ""); got != "sssuo" {
+		t.Fatalf("expected writes 'sssuo', got %q", got)
+	}
+	// Each sync is followed by the delay before the next write
+	for i := 1; i <= 3; i++ {
+		if gap := times[i].Sub(times[i-1]); gap < cfg.SysrqSyncDelay {
+			t.Errorf("expected at least %v after sync %d, got %v", cfg.SysrqSyncDelay, i, gap)
+		}
+	}
+}
+
+func TestManager_shutdownViaSysrq_DefaultsToSingleSync(t *testing.T) {
+	manager := mustNewManager(t, &config.Config{HostProcPath: t.TempDir()}, createMockLogger())
+
+	var writes []string
+	manager.writeSysrq = func(_ string, data []byte) error {
+		writes = append(writes, string(data))
+		return nil
+	}
+
+	if err := manager.shutdownViaSysrq(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := strings.Join(writes, ""); got != "suo" {
+		t.Errorf("expected writes 'suo', got %q", got)
+	}
+}
+
 func TestManager_shutdownViaNsenter_CommandNotFound(t *testing.T) {
 	// In most test environments, nsenter won't work or won't have access
 	cfg := &config.Config{
 		HostProcPath: "/proc",
 	}
 	mockLog := createMockLogger()
-	manager := NewManager(cfg, mockLog)
+	manager := mustNewManager(t, cfg, mockLog)
 
 	ctx := context.Background()
 
@@ -157,7 +224,7 @@ func TestManager_shutdownViaDirect_CommandNotFound(t *testing.T) {
 		HostProcPath: "/proc",
 	}
 	mockLog := createMockLogger()
-	manager := NewManager(cfg, mockLog)
+	manager := mustNewManager(t, cfg, mockLog)
 
 	ctx := context.Background()
 
@@ -173,19 +240,736 @@ func TestManager_shutdownViaDirect_CommandNotFound(t *testing.T) {
 	}
 }
 
+// fakeMethods replaces the function of each of the manager's methods with a fake recording
+// its name in calls, failing with the error given for it in failures, if any. Probes are
+// dropped, since the fakes need no access to the host.
+func fakeMethods(manager *Manager, failures map[string]error) *[]string {
+	calls := &[]string{}
+	for i := range manager.methods {
+		name := manager.methods[i].name
+		manager.methods[i].probe = nil
+		manager.methods[i].fn = func(context.Context) error {
+			*calls = append(*calls, name)
+			return failures[name]
+		}
+	}
+	return calls
+}
+
 // TestShutdownMethodOrder verifies that methods are tried in the correct order
 func TestShutdownMethodOrder(t *testing.T) {
-	// This is a behavioral test - we verify the method names in the order array
-	// by checking the function's structure
+	manager := mustNewManager(t, &config.Config{HostProcPath: "/non-existent"}, createMockLogger())
+	calls := fakeMethods(manager, map[string]error{
+		"nsenter":       errors.New("nsenter failed"),
+		"sysrq-trigger": errors.New("host proc path not mounted"),
+	})
+
+	method, err := manager.NeutralizeStuartLittle(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if method != "direct-command" {
+		t.Errorf("expected the last method to initiate the shutdown, got %q", method)
+	}
+	if got := strings.Join(*calls, ","); got != "nsenter,sysrq-trigger,direct-command" {
+		t.Errorf("expected methods tried in order nsenter, sysrq-trigger, direct-command, got %s", got)
+	}
+}
+
+func TestManager_NeutralizeStuartLittle_StopsAtFirstSuccess(t *testing.T) {
+	manager := mustNewManager(t, &config.Config{HostProcPath: "/non-existent"}, createMockLogger())
+	calls := fakeMethods(manager, map[string]error{"nsenter": errors.New("nsenter failed")})
+
+	method, err := manager.NeutralizeStuartLittle(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if method != "sysrq-trigger" {
+		t.Errorf("expected sysrq-trigger to initiate the shutdown, got %q", method)
+	}
+	if got := strings.Join(*calls, ","); got != "nsenter,sysrq-trigger" {
+		t.Errorf("expected no method tried after the first success, got %s", got)
+	}
+}
+
+func TestManager_NeutralizeStuartLittle_AllMethodsFail(t *testing.T) {
+	manager := mustNewManager(t, &config.Config{HostProcPath: "/non-existent"}, createMockLogger())
+	lastErr := errors.New("poweroff not found")
+	calls := fakeMethods(manager, map[string]error{
+		"nsenter":        errors.New("nsenter failed"),
+		"sysrq-trigger":  errors.New("host proc path not mounted"),
+		"direct-command": lastErr,
+	})
+
+	method, err := manager.NeutralizeStuartLittle(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "all shutdown methods failed") {
+		t.Fatalf("expected 'all shutdown methods failed' error, got: %v", err)
+	}
+	if !errors.Is(err, lastErr) {
+		t.Errorf("expected the error to wrap the last method's error, got: %v", err)
+	}
+	if method != "" {
+		t.Errorf("expected no method to be returned, got %q", method)
+	}
+	if len(*calls) != 3 {
+		t.Errorf("expected every method to be tried, got %v", *calls)
+	}
+}
+
+func TestManager_NeutralizeStuartLittle_DeadlineExceeded(t *testing.T) {
+	cfg := &config.Config{
+		HostProcPath:     "/non-existent",
+		ShutdownDeadline: 100 * time.Millisecond,
+	}
+	manager := mustNewManager(t, cfg, createMockLogger())
+
+	// Each slow method blocks until its context is cancelled
+	var attempts int
+	slow := func(ctx context.Context) error {
+		attempts++
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	manager.methods = []method{
+		{name: "slow-1", fn: slow},
+		{name: "slow-2", fn: slow},
+	}
+
+	start := time.Now()
+	_, err := manager.NeutralizeStuartLittle(context.Background())
+	elapsed := time.Since(start)
+
+	if err == nil {
+		t.Fatal("expected error when deadline is exceeded")
+	}
+	if !strings.Contains(err.Error(), "exceeded deadline") {
+		t.Errorf("expected 'exceeded deadline' error, got: %v", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected error to wrap context.DeadlineExceeded, got: %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("expected remaining methods to be skipped after deadline, got %d attempts", attempts)
+	}
+	if elapsed > time.Second {
+		t.Errorf("expected sequence to be abandoned near the deadline, took %v", elapsed)
+	}
+}
+
+func TestManager_NeutralizeStuartLittle_DeadlineCoversWebhook(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	cfg := &config.Config{
+		HostProcPath:       "/non-existent",
+		ShutdownDeadline:   100 * time.Millisecond,
+		WebhookURL:         server.URL,
+		WebhookTimeout:     10 * time.Second,
+		WebhookMaxDuration: 10 * time.Second,
+	}
+	manager := mustNewManager(t, cfg, createMockLogger())
+	calls := fakeMethods(manager, nil)
+
+	start := time.Now()
+	_, err := manager.NeutralizeStuartLittle(context.Background())
+	elapsed := time.Since(start)
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected the deadline to cover the webhook, got: %v", err)
+	}
+	if len(*calls) != 0 {
+		t.Errorf("expected no method to run past the deadline, got %v", *calls)
+	}
+	if elapsed > time.Second {
+		t.Errorf("expected a stalled webhook to be cut off at the deadline, took %v", elapsed)
+	}
+}
+
+func TestManager_NeutralizeStuartLittle_SafeMode(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
 
 	cfg := &config.Config{
 		HostProcPath: "/non-existent",
+		SafeMode:     true,
 	}
-	mockLog := createMockLogger()
-	manager := NewManager(cfg, mockLog)
+	manager := mustNewManager(t, cfg, createMockLogger())
+
+	executed := false
+	manager.methods = []method{
+		{name: "poweroff", fn: func(context.Context) error {
+			executed = true
+			return nil
+		}},
+	}
+
+	if _, err := manager.NeutralizeStuartLittle(context.Background()); err != nil {
+		t.Errorf("expected no error in safe mode, got: %v", err)
+	}
+	if executed {
+		t.Error("expected no shutdown method to execute in safe mode")
+	}
+	if !manager.SafeMode() {
+		t.Error("expected SafeMode to report true")
+	}
+	if !strings.Contains(buf.String(), "Safe mode enabled") {
+		t.Errorf("expected safe mode to be logged, got: %s", buf.String())
+	}
+}
+
+func TestManager_NeutralizeStuartLittle_RateLimited(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	cfg := &config.Config{
+		HostProcPath:        "/non-existent",
+		MinShutdownInterval: time.Hour,
+	}
+	manager := mustNewManager(t, cfg, createMockLogger())
+
+	var attempts int
+	manager.methods = []method{
+		{name: "counting", fn: func(context.Context) error {
+			attempts++
+			return nil
+		}},
+	}
+
+	ctx := context.Background()
+	if _, err := manager.NeutralizeStuartLittle(ctx); err != nil {
+		t.Fatalf("expected first attempt to succeed, got: %v", err)
+	}
+
+	_, err := manager.NeutralizeStuartLittle(ctx)
+	if !errors.Is(err, ErrRateLimited) {
+		t.Errorf("expected ErrRateLimited for second attempt, got: %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("expected exactly 1 shutdown attempt, got %d", attempts)
+	}
+	if !strings.Contains(buf.String(), "[WARN] Shutdown attempt suppressed") {
+		t.Errorf("expected suppression warning, got: %s", buf.String())
+	}
+}
+
+func TestManager_NeutralizeStuartLittle_DryRun(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	cfg := &config.Config{
+		HostProcPath:        "/non-existent",
+		MinShutdownInterval: time.Hour,
+	}
+	manager := mustNewManager(t, cfg, createMockLogger())
+
+	var attempts int
+	manager.methods = []method{
+		{name: "counting", fn: func(context.Context) error {
+			attempts++
+			return nil
+		}},
+	}
+
+	if _, err := manager.NeutralizeStuartLittle(WithDryRun(context.Background())); err != nil {
+		t.Fatalf("expected no error for a dry run, got: %v", err)
+	}
+	if attempts != 0 {
+		t.Errorf("expected no shutdown method to execute in a dry run, got %d", attempts)
+	}
+	if !strings.Contains(buf.String(), "[WARN] Dry run: skipping host shutdown") {
+		t.Errorf("expected a dry run warning, got: %s", buf.String())
+	}
+
+	// The dry run does not count towards the minimum interval
+	if _, err := manager.NeutralizeStuartLittle(context.Background()); err != nil {
+		t.Errorf("expected a real attempt after a dry run to be allowed, got: %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("expected 1 shutdown attempt after the dry run, got %d", attempts)
+	}
+}
+
+func TestManager_NeutralizeStuartLittle_RetryCooldown(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	cfg := &config.Config{
+		HostProcPath:          "/non-existent",
+		ShutdownRetryCooldown: 50 * time.Millisecond,
+	}
+	manager := mustNewManager(t, cfg, createMockLogger())
+
+	var attempts int
+	fail := true
+	manager.methods = []method{
+		{name: "flaky", fn: func(context.Context) error {
+			attempts++
+			if fail {
+				return errors.New("permission denied")
+			}
+			return nil
+		}},
+	}
+
+	ctx := context.Background()
+	if _, err := manager.NeutralizeStuartLittle(ctx); err == nil {
+		t.Fatal("expected first attempt to fail")
+	}
+
+	_, err := manager.NeutralizeStuartLittle(ctx)
+	if !errors.Is(err, ErrCoolingDown) || !errors.Is(err, ErrRateLimited) {
+		t.Errorf("expected ErrCoolingDown for retry within cooldown, got: %v", err)
+	}
+	if attempts != 1 {
+		t.Errorf("expected retry within cooldown to be skipped, got %d attempts", attempts)
+	}
+	if !strings.Contains(buf.String(), "[WARN] Shutdown attempt suppressed: cooling down") {
+		t.Errorf("expected cooldown warning, got: %s", buf.String())
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	fail = false
+	if _, err := manager.NeutralizeStuartLittle(ctx); err != nil {
+		t.Fatalf("expected retry after cooldown to succeed, got: %v", err)
+	}
+	if _, err := manager.NeutralizeStuartLittle(ctx); err != nil {
+		t.Errorf("expected no cooldown after a successful attempt, got: %v", err)
+	}
+	if attempts != 3 {
+		t.Errorf("expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestManager_NeutralizeStuartLittle_RateLimitWindowElapsed(t *testing.T) {
+	cfg := &config.Config{
+		HostProcPath:        "/non-existent",
+		MinShutdownInterval: 50 * time.Millisecond,
+	}
+	manager := mustNewManager(t, cfg, createMockLogger())
+
+	var attempts int
+	manager.methods = []method{
+		{name: "counting", fn: func(context.Context) error {
+			attempts++
+			return nil
+		}},
+	}
+
+	ctx := context.Background()
+	if _, err := manager.NeutralizeStuartLittle(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	time.Sleep(60 * time.Millisecond)
+
+	if _, err := manager.NeutralizeStuartLittle(ctx); err != nil {
+		t.Errorf("expected attempt after window to be allowed, got: %v", err)
+	}
+	if attempts != 2 {
+		t.Errorf("expected 2 shutdown attempts, got %d", attempts)
+	}
+}
+
+func TestManager_shutdownViaSysrq_Reboot(t *testing.T) {
+	tmpDir := t.TempDir()
+	sysrqPath := filepath.Join(tmpDir, "sysrq-trigger")
+	if err := os.WriteFile(sysrqPath, []byte(""), 0644); err != nil {
+		t.Fatalf("failed to create sysrq-trigger file: %v", err)
+	}
+
+	cfg := &config.Config{HostProcPath: tmpDir}
+	manager := mustNewManager(t, cfg, createMockLogger())
+
+	ctx := WithAction(context.Background(), ActionReboot)
+	if err := manager.shutdownViaSysrq(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	content, err := os.ReadFile(sysrqPath)
+	if err != nil {
+		t.Fatalf("failed to read sysrq-trigger: %v", err)
+	}
+	if string(content) != "b" {
+		t.Errorf("expected sysrq-trigger to contain 'b' for reboot, got '%s'", string(content))
+	}
+}
+
+func TestManager_NeutralizeStuartLittle_LogsReason(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	cfg := &config.Config{HostProcPath: "/non-existent", SafeMode: true}
+	manager := mustNewManager(t, cfg, createMockLogger())
+
+	ctx := WithReason(context.Background(), "rack 4 power work")
+	if _, err := manager.NeutralizeStuartLittle(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.Contains(buf.String(), "rack 4 power work") {
+		t.Errorf("expected reason in shutdown log, got: %s", buf.String())
+	}
+}
+
+func TestNewManager_ShutdownWhen(t *testing.T) {
+	for _, when := range []string{"", "now", "+1", "23:30"} {
+		if _, err := NewManager(&config.Config{ShutdownWhen: when}, createMockLogger()); err != nil {
+			t.Errorf("unexpected error for SIGNALMICE_SHUTDOWN_WHEN %q: %v", when, err)
+		}
+	}
+
+	if _, err := NewManager(&config.Config{ShutdownWhen: "later"}, createMockLogger()); err == nil {
+		t.Error("expected error for invalid SIGNALMICE_SHUTDOWN_WHEN")
+	}
+}
+
+func TestManager_NeutralizeStuartLittle_WebhookCarriesReason(t *testing.T) {
+	var received webhook.Payload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&received)
+	}))
+	defer server.Close()
+
+	cfg := &config.Config{
+		HostProcPath:   "/non-existent",
+		SafeMode:       true,
+		WebhookURL:     server.URL,
+		WebhookTimeout: time.Second,
+	}
+	manager := mustNewManager(t, cfg, createMockLogger())
+
+	ctx := WithAction(WithReason(context.Background(), "rack 4 power work"), ActionReboot)
+	if _, err := manager.NeutralizeStuartLittle(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if received.Reason != "rack 4 power work" {
+		t.Errorf("expected webhook reason 'rack 4 power work', got %q", received.Reason)
+	}
+	if received.Action != "reboot" {
+		t.Errorf("expected webhook action 'reboot', got %q", received.Action)
+	}
+	if !received.SafeMode {
+		t.Error("expected webhook payload to report safe mode")
+	}
+}
+
+func TestManager_NeutralizeStuartLittle_FlushesLogsBeforeExec(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+
+	cfg := &config.Config{HostProcPath: "/non-existent"}
+	manager := mustNewManager(t, cfg, createMockLogger())
+
+	var events []string
+	manager.flush = func(ctx context.Context) bool {
+		if _, ok := ctx.Deadline(); !ok {
+			t.Error("expected the flush to be bounded by a deadline")
+		}
+		if strings.Contains(buf.String(), "Attempting shutdown via poweroff") {
+			events = append(events, "logged")
+		}
+		events = append(events, "flush")
+		return true
+	}
+	manager.methods = []method{
+		{name: "poweroff", fn: func(context.Context) error {
+			events = append(events, "exec")
+			return nil
+		}},
+	}
+
+	if _, err := manager.NeutralizeStuartLittle(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if got := strings.Join(events, ","); got != "logged,flush,exec" {
+		t.Errorf("expected log, then flush, then exec, got %s", got)
+	}
+}
+
+func TestManager_NeutralizeStuartLittle_TracesSpanHierarchy(t *testing.T) {
+	cfg := &config.Config{HostProcPath: "/non-existent"}
+	manager := mustNewManager(t, cfg, createMockLogger())
+	exporter := &tracing.InMemoryExporter{}
+	var exportedBeforeExec []string
+	manager.methods = []method{
+		{name: "nsenter", fn: func(context.Context) error { return errors.New("not privileged") }},
+		{name: "direct", fn: func(context.Context) error {
+			for _, s := range exporter.Spans() {
+				exportedBeforeExec = append(exportedBeforeExec, s.Name)
+			}
+			return nil
+		}},
+	}
+
+	ctx := tracing.WithTracer(context.Background(), tracing.NewTracer(exporter))
+	ctx, root := tracing.Start(ctx, "signal")
+
+	if _, err := manager.NeutralizeStuartLittle(WithAction(ctx, ActionReboot)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	root.End(nil)
+
+	// The host may never come back, so the trace must leave before the terminal command runs
+	if got := strings.Join(exportedBeforeExec, ","); !strings.Contains(got, "signal") || !strings.Contains(got, "shutdown.method") {
+		t.Errorf("expected the trace so far to be exported before the terminal command, got %s", got)
+	}
+
+	// Spans flushed while open are exported again once they end; keep their final export
+	final := map[string]tracing.SpanData{}
+	var order []string
+	for _, s := range exporter.Spans() {
+		if _, ok := final[s.SpanID]; !ok {
+			order = append(order, s.SpanID)
+		}
+		final[s.SpanID] = s
+	}
+	if len(final) != 4 {
+		t.Fatalf("expected signal, shutdown and 2 method spans, got %d: %+v", len(final), final)
+	}
+
+	var rootSpan, shutdownSpan tracing.SpanData
+	var methodSpans []tracing.SpanData
+	for _, id := range order {
+		s := final[id]
+		switch s.Name {
+		case "signal":
+			rootSpan = s
+		case "shutdown":
+			shutdownSpan = s
+		case "shutdown.method":
+			methodSpans = append(methodSpans, s)
+		}
+	}
+
+	if shutdownSpan.ParentSpanID != rootSpan.SpanID {
+		t.Error("expected the shutdown span to be a child of the signal span")
+	}
+	if shutdownSpan.Attributes["action"] != "reboot" || shutdownSpan.Attributes["result"] != "success" {
+		t.Errorf("unexpected shutdown span attributes: %v", shutdownSpan.Attributes)
+	}
+	if len(methodSpans) != 2 {
+		t.Fatalf("expected 2 method spans, got %d", len(methodSpans))
+	}
+	results := map[string]string{}
+	for _, s := range methodSpans {
+		if s.ParentSpanID != shutdownSpan.SpanID {
+			t.Errorf("expected method span %s to be a child of the shutdown span", s.Attributes["method"])
+		}
+		results[s.Attributes["method"]] = s.Attributes["result"]
+	}
+	if results["nsenter"] != "failure" || results["direct"] != "success" {
+		t.Errorf("unexpected method results: %v", results)
+	}
+}
+
+func TestManager_NeutralizeStuartLittle_ReturnsSucceededMethod(t *testing.T) {
+	cfg := &config.Config{HostProcPath: "/non-existent"}
+	manager := mustNewManager(t, cfg, createMockLogger())
+	manager.methods = []method{
+		{name: "nsenter", fn: func(context.Context) error { return errors.New("nsenter not found") }},
+		{name: "direct", fn: func(context.Context) error { return nil }},
+		{name: "sysrq", fn: func(context.Context) error { return nil }},
+	}
+
+	method, err := manager.NeutralizeStuartLittle(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if method != "direct" {
+		t.Errorf("expected method 'direct', got %q", method)
+	}
+}
+
+func TestManager_NeutralizeStuartLittle_ParallelFirstSuccessWins(t *testing.T) {
+	cfg := &config.Config{HostProcPath: "/non-existent", ParallelMethods: true}
+	manager := mustNewManager(t, cfg, createMockLogger())
+
+	slowCancelled := make(chan struct{})
+	manager.methods = []method{
+		{name: "slow", fn: func(ctx context.Context) error {
+			select {
+			case <-ctx.Done():
+				close(slowCancelled)
+				return ctx.Err()
+			case <-time.After(5 * time.Second):
+				return nil
+			}
+		}},
+		{name: "fast", fn: func(context.Context) error {
+			return nil
+		}},
+	}
+
+	start := time.Now()
+	method, err := manager.NeutralizeStuartLittle(context.Background())
+	if err != nil {
+		t.Fatalf("expected the fast method to win, got: %v", err)
+	}
+	if method != "fast" {
+		t.Errorf("expected the winning method 'fast' to be returned, got %q", method)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the fast method to return promptly, took %v", elapsed)
+	}
+
+	select {
+	case <-slowCancelled:
+	case <-time.After(time.Second):
+		t.Error("expected the slow method to be cancelled")
+	}
+}
+
+func TestManager_NeutralizeStuartLittle_ParallelAllFail(t *testing.T) {
+	cfg := &config.Config{HostProcPath: "/non-existent", ParallelMethods: true}
+	manager := mustNewManager(t, cfg, createMockLogger())
+
+	failing := func(context.Context) error { return errors.New("boom") }
+	manager.methods = []method{{name: "a", fn: failing}, {name: "b", fn: failing}}
+
+	_, err := manager.NeutralizeStuartLittle(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "all shutdown methods failed") {
+		t.Errorf("expected 'all shutdown methods failed' error, got: %v", err)
+	}
+}
+
+func TestManager_WithWhen(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "args.txt")
+	script := "#!/bin/sh\necho \"$@\" > " + out + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "shutdown"), []byte(script), 0755); err != nil {
+		t.Fatalf("failed to create shutdown: %v", err)
+	}
+	t.Setenv("PATH", dir)
+
+	manager := mustNewManager(t, &config.Config{HostProcPath: "/non-existent"}, createMockLogger())
+	if got := manager.whenFor(context.Background()); got != "now" {
+		t.Errorf("expected the configured time without an override, got %q", got)
+	}
+
+	ctx := WithWhen(WithReason(WithAction(context.Background(), ActionReboot), "kernel upgrade"), "+5")
+	if err := manager.shutdownViaDirect(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("expected shutdown to run: %v", err)
+	}
+	if got := strings.TrimSpace(string(data)); got != "-r +5 kernel upgrade" {
+		t.Errorf("expected the overridden time, got args %q", got)
+	}
+}
+
+func TestValidateWhen(t *testing.T) {
+	for _, when := range []string{"now", "+5", "7:05"} {
+		if err := ValidateWhen(when); err != nil {
+			t.Errorf("unexpected error for %q: %v", when, err)
+		}
+	}
+	for _, when := range []string{"", "5", "+5m", "soon"} {
+		if err := ValidateWhen(when); err == nil {
+			t.Errorf("expected error for %q", when)
+		}
+	}
+}
+
+func TestNewManager_InvalidWebhooksJSON(t *testing.T) {
+	_, err := NewManager(&config.Config{WebhooksJSON: `[{"url":"not a url"}]`}, createMockLogger())
+	if err == nil || !strings.Contains(err.Error(), "invalid webhook at index 0") {
+		t.Errorf("expected invalid webhooks JSON to be rejected, got %v", err)
+	}
+}
+
+func TestManager_NeutralizeStuartLittle_PostNotifiesBeforeExec(t *testing.T) {
+	var mu sync.Mutex
+	var events []string
+	var received webhook.Notification
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		json.NewDecoder(r.Body).Decode(&received)
+		events = append(events, "notify")
+	}))
+	defer server.Close()
 
-	// Verify manager has the expected fields
-	if manager.hostProcPath != "/non-existent" {
-		t.Errorf("expected hostProcPath '/non-existent', got '%s'", manager.hostProcPath)
+	cfg := &config.Config{
+		HostProcPath:      "/non-existent",
+		PostNotifyURL:     server.URL,
+		PostNotifyTimeout: time.Second,
+	}
+	manager := mustNewManager(t, cfg, createMockLogger())
+	manager.methods = []method{
+		{name: "nsenter", fn: func(context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, "exec:nsenter")
+			return errors.New("not privileged")
+		}},
+		{name: "direct", fn: func(context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, "exec:direct")
+			return nil
+		}},
+	}
+
+	if _, err := manager.NeutralizeStuartLittle(WithAction(context.Background(), ActionReboot)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if got := strings.Join(events, ","); got != "notify,exec:nsenter,exec:direct" {
+		t.Errorf("expected a single notification before the first method runs, got %s", got)
+	}
+	if strings.Join(received.Methods, ",") != "nsenter,direct" || received.Action != "reboot" {
+		t.Errorf("unexpected notification: %+v", received)
+	}
+}
+
+func TestManager_NeutralizeStuartLittle_PostNotifyFailureDoesNotBlock(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	cfg := &config.Config{
+		HostProcPath:      "/non-existent",
+		PostNotifyURL:     server.URL,
+		PostNotifyTimeout: 50 * time.Millisecond,
+	}
+	manager := mustNewManager(t, cfg, createMockLogger())
+	ran := false
+	manager.methods = []method{
+		{name: "poweroff", fn: func(context.Context) error {
+			ran = true
+			return nil
+		}},
+	}
+
+	start := time.Now()
+	if _, err := manager.NeutralizeStuartLittle(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !ran {
+		t.Error("expected the shutdown to run despite the hung notification")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the notification timeout to bound the wait, took %v", elapsed)
 	}
 }
@@ -0,0 +1,156 @@
+package shutdown
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// defaultProcessSignal is sent by the signal-process method when its spec names no signal
+const defaultProcessSignal = syscall.SIGTERM
+
+// processSignals are the signal names accepted by the signal-process method, without "SIG"
+var processSignals = map[string]syscall.Signal{
+	"TERM": syscall.SIGTERM,
+	"INT":  syscall.SIGINT,
+	"HUP":  syscall.SIGHUP,
+	"QUIT": syscall.SIGQUIT,
+	"KILL": syscall.SIGKILL,
+	"USR1": syscall.SIGUSR1,
+	"USR2": syscall.SIGUSR2,
+}
+
+// ParseSignal resolves a signal name such as TERM or SIGTERM, or a signal number. An empty
+// name is SIGTERM.
+func ParseSignal(name string) (syscall.Signal, error) {
+	name = strings.ToUpper(strings.TrimSpace(name))
+	if name == "" {
+		return defaultProcessSignal, nil
+	}
+	if number, err := strconv.Atoi(name); err == nil {
+		if number < 1 || number > 64 {
+			return 0, fmt.Errorf("invalid signal number %d", number)
+		}
+		return syscall.Signal(number), nil
+	}
+	if sig, ok := processSignals[strings.TrimPrefix(name, "SIG")]; ok {
+		return sig, nil
+	}
+	return 0, fmt.Errorf("unknown signal %q", name)
+}
+
+// validateSignalProcess checks that a signal-process spec names exactly one target and a
+// known signal
+func (s MethodSpec) validateSignalProcess() error {
+	targets := 0
+	for _, set := range []bool{s.Target > 0, s.PIDFile != "", s.Process != ""} {
+		if set {
+			targets++
+		}
+	}
+	if targets != 1 {
+		return fmt.Errorf("signal-process method requires exactly one of target, pid_file or process")
+	}
+	if _, err := ParseSignal(s.Signal); err != nil {
+		return err
+	}
+	return nil
+}
+
+// processTarget identifies the processes signalled by the signal-process method
+type processTarget struct {
+	pid     int
+	pidFile string
+	name    string // Matched against /proc/<pid>/comm, so at most 15 characters are compared
+}
+
+// signalProcess sends sig to every process of target instead of powering off the host
+func (m *Manager) signalProcess(ctx context.Context, target processTarget, sig syscall.Signal) error {
+	pids, err := m.resolveProcesses(target)
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, pid := range pids {
+		if err := syscall.Kill(pid, sig); err != nil {
+			errs = append(errs, fmt.Errorf("failed to send %s to PID %d: %w", sig, pid, err))
+			continue
+		}
+		m.logger.InfoWithExtra(ctx, "Sent signal to process", map[string]any{
+			"pid":    pid,
+			"signal": sig.String(),
+		})
+	}
+	return errors.Join(errs...)
+}
+
+// probeProcess checks that the target processes exist and may be signalled, by sending the
+// null signal
+func (m *Manager) probeProcess(target processTarget) error {
+	pids, err := m.resolveProcesses(target)
+	if err != nil {
+		return err
+	}
+	for _, pid := range pids {
+		if err := syscall.Kill(pid, 0); err != nil {
+			return fmt.Errorf("PID %d cannot be signalled: %w", pid, err)
+		}
+	}
+	return nil
+}
+
+// resolveProcesses returns the PIDs of target: its PID, the PID read from its PID file, or
+// every process other than this one whose name matches
+func (m *Manager) resolveProcesses(target processTarget) ([]int, error) {
+	switch {
+	case target.pid > 0:
+		return []int{target.pid}, nil
+	case target.pidFile != "":
+		data, err := os.ReadFile(target.pidFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read PID file: %w", err)
+		}
+		pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+		if err != nil || pid < 1 {
+			return nil, fmt.Errorf("invalid PID in %s: %q", target.pidFile, strings.TrimSpace(string(data)))
+		}
+		return []int{pid}, nil
+	default:
+		return m.findProcesses(target.name)
+	}
+}
+
+// findProcesses returns the PIDs under procRoot whose comm is name, excluding this process
+func (m *Manager) findProcesses(name string) ([]int, error) {
+	entries, err := os.ReadDir(m.procRoot)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list processes: %w", err)
+	}
+
+	self := os.Getpid()
+	var pids []int
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || pid == self {
+			continue
+		}
+		// Processes may exit while listing; those simply do not match
+		comm, err := os.ReadFile(filepath.Join(m.procRoot, entry.Name(), "comm"))
+		if err != nil {
+			continue
+		}
+		if strings.TrimSpace(string(comm)) == name {
+			pids = append(pids, pid)
+		}
+	}
+	if len(pids) == 0 {
+		return nil, fmt.Errorf("no process named %q found", name)
+	}
+	return pids, nil
+}
@@ -0,0 +1,162 @@
+package shutdown
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/signalmice/signalmice/internal/config"
+)
+
+func TestParseSignal(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    syscall.Signal
+		wantErr bool
+	}{
+		{"", syscall.SIGTERM, false},
+		{"TERM", syscall.SIGTERM, false},
+		{"sigint", syscall.SIGINT, false},
+		{"SIGUSR1", syscall.SIGUSR1, false},
+		{"9", syscall.SIGKILL, false},
+		{"0", 0, true},
+		{"STOPALL", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseSignal(tt.name)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseSignal(%q): unexpected error result: %v", tt.name, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseSignal(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseMethodSpecs_SignalProcessInvalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		contains string
+	}{
+		{"no target", `[{"type":"signal-process"}]`, "exactly one of"},
+		{"two targets", `[{"type":"signal-process","target":42,"process":"app"}]`, "exactly one of"},
+		{"unknown signal", `[{"type":"signal-process","process":"app","signal":"NAP"}]`, "unknown signal"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseMethodSpecs(tt.data)
+			if err == nil || !strings.Contains(err.Error(), tt.contains) {
+				t.Errorf("expected error containing %q, got %v", tt.contains, err)
+			}
+		})
+	}
+}
+
+func TestSignalProcessMethod_SignalsTargetPID(t *testing.T) {
+	received := make(chan os.Signal, 1)
+	signal.Notify(received, syscall.SIGUSR1)
+	defer signal.Stop(received)
+
+	cfg := &config.Config{
+		HostProcPath:        "/non-existent",
+		ShutdownMethodsJSON: fmt.Sprintf(`[{"type":"signal-process","target":%d,"signal":"USR1"}]`, os.Getpid()),
+	}
+	manager := mustNewManager(t, cfg, createMockLogger())
+
+	method, err := manager.NeutralizeStuartLittle(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if method != MethodTypeSignalProcess {
+		t.Errorf("expected method %q, got %q", MethodTypeSignalProcess, method)
+	}
+
+	select {
+	case sig := <-received:
+		if sig != syscall.SIGUSR1 {
+			t.Errorf("expected SIGUSR1, got %v", sig)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the target process to receive the signal")
+	}
+}
+
+func TestSignalProcessMethod_SignalsPIDFile(t *testing.T) {
+	child := exec.Command("sleep", "60")
+	if err := child.Start(); err != nil {
+		t.Skipf("sleep not available: %v", err)
+	}
+	defer child.Process.Kill()
+
+	pidFile := filepath.Join(t.TempDir(), "app.pid")
+	if err := os.WriteFile(pidFile, []byte(fmt.Sprintf("%d\n", child.Process.Pid)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &config.Config{
+		HostProcPath:        "/non-existent",
+		ShutdownMethodsJSON: fmt.Sprintf(`[{"type":"signal-process","name":"app","pid_file":%q}]`, pidFile),
+	}
+	manager := mustNewManager(t, cfg, createMockLogger())
+
+	if reports := manager.CheckMethods(); !reports[0].Ready() {
+		t.Errorf("expected the running process to be ready to signal, got %v", reports[0].Problems)
+	}
+	if _, err := manager.NeutralizeStuartLittle(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	child.Wait()
+	status, ok := child.ProcessState.Sys().(syscall.WaitStatus)
+	if !ok || !status.Signaled() || status.Signal() != syscall.SIGTERM {
+		t.Errorf("expected the process to be terminated by SIGTERM, got %v", child.ProcessState)
+	}
+}
+
+func TestSignalProcessMethod_MissingPIDFile(t *testing.T) {
+	cfg := &config.Config{
+		HostProcPath:        "/non-existent",
+		ShutdownMethodsJSON: `[{"type":"signal-process","pid_file":"/non-existent/app.pid"}]`,
+	}
+	manager := mustNewManager(t, cfg, createMockLogger())
+
+	if reports := manager.CheckMethods(); reports[0].Ready() {
+		t.Error("expected a missing PID file to be reported as a problem")
+	}
+	if _, err := manager.NeutralizeStuartLittle(context.Background()); err == nil {
+		t.Error("expected the shutdown to fail without a PID to signal")
+	}
+}
+
+func TestManager_FindProcesses(t *testing.T) {
+	root := t.TempDir()
+	for pid, comm := range map[string]string{"10": "app\n", "11": "sidecar\n", "12": "app\n", "self": "app\n"} {
+		os.MkdirAll(filepath.Join(root, pid), 0755)
+		os.WriteFile(filepath.Join(root, pid, "comm"), []byte(comm), 0644)
+	}
+	manager := &Manager{procRoot: root}
+
+	pids, err := manager.findProcesses("app")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(pids, []int{10, 12}) {
+		t.Errorf("expected PIDs 10 and 12, got %v", pids)
+	}
+
+	if _, err := manager.findProcesses("db"); err == nil {
+		t.Error("expected an error when no process matches")
+	}
+}
@@ -0,0 +1,110 @@
+package shutdown
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+)
+
+// kernel.sysrq function bits used by the sysrq method (see the kernel's sysrq documentation)
+const (
+	sysrqEnableAll = 1   // Every function, whatever the other bits
+	sysrqSync      = 16  // Sync command ('s')
+	sysrqRemount   = 32  // Remount read-only ('u')
+	sysrqBoot      = 128 // Reboot and poweroff ('b', 'o')
+)
+
+// sysrqFunctions names the function bits the sysrq method relies on, in the order it uses them
+var sysrqFunctions = []struct {
+	bit  int
+	name string
+}{
+	{sysrqSync, "sync (s)"},
+	{sysrqRemount, "remount read-only (u)"},
+	{sysrqBoot, "reboot/poweroff (b, o)"},
+}
+
+// checkHostProc returns an error when the host's proc is not mounted at hostProcPath
+func (m *Manager) checkHostProc() error {
+	if _, err := os.Stat(m.hostProcPath); err != nil {
+		return fmt.Errorf("host proc path not mounted: %s", m.hostProcPath)
+	}
+	return nil
+}
+
+// sysrqError explains a failed open of or write to the host's sysrq-trigger, telling apart
+// a proc that is not mounted and one mounted without write access
+func (m *Manager) sysrqError(err error) error {
+	path := filepath.Join(m.hostProcPath, "sysrq-trigger")
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return fmt.Errorf("host proc path not mounted: %s has no sysrq-trigger", m.hostProcPath)
+	case errors.Is(err, fs.ErrPermission), errors.Is(err, syscall.EROFS):
+		return fmt.Errorf("host proc path mounted but not writable: %s (mount it read-write and grant CAP_SYS_ADMIN): %w", path, err)
+	}
+	return fmt.Errorf("failed to write to sysrq-trigger: %w", err)
+}
+
+// sysrqMaskPath returns the path of the host's kernel.sysrq
+func (m *Manager) sysrqMaskPath() string {
+	return filepath.Join(m.hostProcPath, "sys", "kernel", "sysrq")
+}
+
+// checkSysrqMask returns an error when the host's kernel.sysrq does not permit reboot and
+// poweroff, so a write the kernel would ignore is not mistaken for a shutdown. The sysrq
+// functions it restricts are returned either way. An unreadable or unparsable kernel.sysrq
+// is not held against the method.
+func (m *Manager) checkSysrqMask() (restricted []string, err error) {
+	data, err := os.ReadFile(m.sysrqMaskPath())
+	if err != nil {
+		return nil, nil
+	}
+	mask, err := parseSysrqMask(string(data))
+	if err != nil {
+		return nil, nil
+	}
+
+	restricted = sysrqRestrictions(mask)
+	switch {
+	case mask == 0:
+		return restricted, fmt.Errorf("sysrq disabled: %s is 0 (enable it with sysctl kernel.sysrq=1)", m.sysrqMaskPath())
+	case !sysrqPermits(mask, sysrqBoot):
+		return restricted, fmt.Errorf("sysrq restricted: %s is %d, which disables %s (enable them with sysctl kernel.sysrq=1)",
+			m.sysrqMaskPath(), mask, strings.Join(restricted, ", "))
+	}
+	return restricted, nil
+}
+
+// parseSysrqMask parses the contents of kernel.sysrq
+func parseSysrqMask(data string) (int, error) {
+	mask, err := strconv.Atoi(strings.TrimSpace(data))
+	if err != nil || mask < 0 {
+		return 0, fmt.Errorf("invalid kernel.sysrq value %q", strings.TrimSpace(data))
+	}
+	return mask, nil
+}
+
+// sysrqPermits reports whether mask enables the sysrq function bit: 0 disables every function,
+// 1 enables every function, and any other value enables only the functions whose bit is set
+func sysrqPermits(mask, bit int) bool {
+	if mask == sysrqEnableAll {
+		return true
+	}
+	return mask&bit != 0
+}
+
+// sysrqRestrictions returns the names of the functions used by the sysrq method that mask disables
+func sysrqRestrictions(mask int) []string {
+	var restricted []string
+	for _, function := range sysrqFunctions {
+		if !sysrqPermits(mask, function.bit) {
+			restricted = append(restricted, function.name)
+		}
+	}
+	return restricted
+}
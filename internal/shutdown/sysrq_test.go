@@ -0,0 +1,175 @@
+package shutdown
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"syscall"
+	"testing"
+
+	"github.com/signalmice/signalmice/internal/config"
+)
+
+// failingSysrq returns a sysrq writer failing every write with errno, as the kernel would
+func failingSysrq(errno syscall.Errno) func(string, []byte) error {
+	return func(path string, _ []byte) error {
+		return &fs.PathError{Op: "open", Path: path, Err: errno}
+	}
+}
+
+// writeSysrqMask writes value as the kernel.sysrq of the host proc at hostProc
+func writeSysrqMask(t *testing.T, hostProc, value string) {
+	t.Helper()
+	kernelDir := filepath.Join(hostProc, "sys", "kernel")
+	if err := os.MkdirAll(kernelDir, 0755); err != nil {
+		t.Fatalf("failed to create %s: %v", kernelDir, err)
+	}
+	if err := os.WriteFile(filepath.Join(kernelDir, "sysrq"), []byte(value+"\n"), 0644); err != nil {
+		t.Fatalf("failed to write sysrq: %v", err)
+	}
+}
+
+func TestManager_shutdownViaSysrq_Diagnosis(t *testing.T) {
+	tests := []struct {
+		name     string
+		errno    syscall.Errno
+		expected string
+	}{
+		{"read-only mount", syscall.EROFS, "mounted but not writable"},
+		{"permission denied", syscall.EACCES, "mounted but not writable"},
+		{"missing capability", syscall.EPERM, "mounted but not writable"},
+		{"other failure", syscall.EINVAL, "failed to write to sysrq-trigger"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := mustNewManager(t, &config.Config{HostProcPath: t.TempDir()}, createMockLogger())
+			manager.writeSysrq = failingSysrq(tt.errno)
+
+			err := manager.shutdownViaSysrq(context.Background())
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("expected error containing %q, got %v", tt.expected, err)
+			}
+		})
+	}
+}
+
+func TestManager_shutdownViaSysrq_Mask(t *testing.T) {
+	tests := []struct {
+		name     string
+		mask     string
+		expected string // Error expected before any write; empty when the writes go ahead
+	}{
+		{"disabled", "0", "sysrq disabled"},
+		{"sync only", "16", "is 16, which disables remount read-only (u), reboot/poweroff (b, o)"},
+		{"poweroff without sync", "128", ""},
+		{"distribution default", "176", ""},
+		{"everything", "1", ""},
+		{"unparsable", "yes", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hostProc := t.TempDir()
+			writeSysrqMask(t, hostProc, tt.mask)
+			manager := mustNewManager(t, &config.Config{HostProcPath: hostProc}, createMockLogger())
+			var writes []string
+			manager.writeSysrq = func(_ string, data []byte) error {
+				writes = append(writes, string(data))
+				return nil
+			}
+
+			err := manager.shutdownViaSysrq(context.Background())
+			if tt.expected == "" {
+				if err != nil || strings.Join(writes, "") != "suo" {
+					t.Errorf("expected the writes to go ahead, got %v with writes %q", err, writes)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.expected) {
+				t.Errorf("expected error containing %q, got %v", tt.expected, err)
+			}
+			if len(writes) != 0 {
+				t.Errorf("expected nothing written to a restricted trigger, got %q", writes)
+			}
+		})
+	}
+}
+
+func TestSysrqRestrictions(t *testing.T) {
+	tests := []struct {
+		mask       int
+		poweroff   bool
+		restricted []string
+	}{
+		{0, false, []string{"sync (s)", "remount read-only (u)", "reboot/poweroff (b, o)"}},
+		{1, true, nil},
+		{16, false, []string{"remount read-only (u)", "reboot/poweroff (b, o)"}},
+		{128, true, []string{"sync (s)", "remount read-only (u)"}},
+		{176, true, nil},
+		{438, true, nil},
+		{64, false, []string{"sync (s)", "remount read-only (u)", "reboot/poweroff (b, o)"}},
+	}
+
+	for _, tt := range tests {
+		if got := sysrqPermits(tt.mask, sysrqBoot); got != tt.poweroff {
+			t.Errorf("mask %d: expected poweroff permitted %v, got %v", tt.mask, tt.poweroff, got)
+		}
+		if got := sysrqRestrictions(tt.mask); !reflect.DeepEqual(got, tt.restricted) {
+			t.Errorf("mask %d: expected restrictions %v, got %v", tt.mask, tt.restricted, got)
+		}
+	}
+}
+
+func TestParseSysrqMask(t *testing.T) {
+	if mask, err := parseSysrqMask("176\n"); err != nil || mask != 176 {
+		t.Errorf("expected 176, got %d, %v", mask, err)
+	}
+	for _, invalid := range []string{"", "yes", "-1"} {
+		if _, err := parseSysrqMask(invalid); err == nil {
+			t.Errorf("expected %q to be rejected", invalid)
+		}
+	}
+}
+
+func TestManager_probeSysrq_NoTrigger(t *testing.T) {
+	manager := mustNewManager(t, &config.Config{HostProcPath: t.TempDir()}, createMockLogger())
+
+	err := manager.probeSysrq()
+	if err == nil || !strings.Contains(err.Error(), "host proc path not mounted") {
+		t.Errorf("expected a proc without sysrq-trigger to count as not mounted, got %v", err)
+	}
+}
+
+func TestManager_probeSysrq_Disabled(t *testing.T) {
+	hostProc := t.TempDir()
+	writeSysrqMask(t, hostProc, "0")
+	if err := os.WriteFile(filepath.Join(hostProc, "sysrq-trigger"), nil, 0200); err != nil {
+		t.Fatalf("failed to create sysrq-trigger: %v", err)
+	}
+	manager := mustNewManager(t, &config.Config{HostProcPath: hostProc}, createMockLogger())
+
+	err := manager.probeSysrq()
+	if err == nil || !strings.Contains(err.Error(), "sysrq disabled") {
+		t.Errorf("expected a sysrq disabled error, got %v", err)
+	}
+}
+
+func TestManager_probeSysrq_ReadOnlyTrigger(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("file permissions do not restrict root")
+	}
+	hostProc := t.TempDir()
+	if err := os.WriteFile(filepath.Join(hostProc, "sysrq-trigger"), nil, 0444); err != nil {
+		t.Fatalf("failed to create sysrq-trigger: %v", err)
+	}
+	manager := mustNewManager(t, &config.Config{HostProcPath: hostProc}, createMockLogger())
+
+	err := manager.probeSysrq()
+	if err == nil || !strings.Contains(err.Error(), "mounted but not writable") {
+		t.Errorf("expected a not writable error, got %v", err)
+	}
+}
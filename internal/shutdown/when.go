@@ -0,0 +1,37 @@
+package shutdown
+
+import (
+	"context"
+	"fmt"
+)
+
+// whenKey is the context key for a per-shutdown override of SIGNALMICE_SHUTDOWN_WHEN
+type whenKey struct{}
+
+// ValidateWhen returns an error unless when is a time accepted by shutdown(8): now, +minutes, or hh:mm
+func ValidateWhen(when string) error {
+	if !shutdownWhenPattern.MatchString(when) {
+		return fmt.Errorf("invalid shutdown time %q: expected now, +minutes, or hh:mm", when)
+	}
+	return nil
+}
+
+// WithWhen returns a context whose shutdown is scheduled at when instead of the manager's
+// configured time. It applies to the direct method, like SIGNALMICE_SHUTDOWN_WHEN.
+func WithWhen(ctx context.Context, when string) context.Context {
+	return context.WithValue(ctx, whenKey{}, when)
+}
+
+// WhenFromContext returns the shutdown time stored in ctx, or "" when none overrides the configured one
+func WhenFromContext(ctx context.Context) string {
+	when, _ := ctx.Value(whenKey{}).(string)
+	return when
+}
+
+// whenFor returns the shutdown time for ctx, defaulting to the configured one
+func (m *Manager) whenFor(ctx context.Context) string {
+	if when := WhenFromContext(ctx); when != "" {
+		return when
+	}
+	return m.when
+}
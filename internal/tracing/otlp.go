@@ -0,0 +1,163 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// OTLP/JSON enum values (opentelemetry-proto trace.proto)
+const (
+	otlpSpanKindInternal = 1
+	otlpStatusCodeOK     = 1
+	otlpStatusCodeError  = 2
+)
+
+// OTLPExporter posts spans to an OTLP/HTTP collector using the JSON encoding
+type OTLPExporter struct {
+	url        string
+	headers    map[string]string
+	hostname   string
+	httpClient *http.Client
+}
+
+// NewOTLPExporter creates an exporter for the collector at endpoint (e.g. http://collector:4318),
+// reporting spans from hostname. The /v1/traces path is appended unless endpoint already ends with it.
+func NewOTLPExporter(endpoint, hostname string, headers map[string]string) *OTLPExporter {
+	url := strings.TrimSuffix(endpoint, "/")
+	if !strings.HasSuffix(url, "/v1/traces") {
+		url += "/v1/traces"
+	}
+	return &OTLPExporter{
+		url:        url,
+		headers:    headers,
+		hostname:   hostname,
+		httpClient: &http.Client{Timeout: exportTimeout},
+	}
+}
+
+type otlpKeyValue struct {
+	Key   string `json:"key"`
+	Value struct {
+		StringValue string `json:"stringValue"`
+	} `json:"value"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpScopeSpans struct {
+	Scope struct {
+		Name string `json:"name"`
+	} `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpResourceSpans struct {
+	Resource struct {
+		Attributes []otlpKeyValue `json:"attributes"`
+	} `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+// attributes converts a map to OTLP key/values, sorted by key for stable output
+func attributes(m map[string]string) []otlpKeyValue {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	kvs := make([]otlpKeyValue, 0, len(keys))
+	for _, k := range keys {
+		kv := otlpKeyValue{Key: k}
+		kv.Value.StringValue = m[k]
+		kvs = append(kvs, kv)
+	}
+	return kvs
+}
+
+// encode builds the OTLP/JSON request body for spans
+func (e *OTLPExporter) encode(spans []SpanData) otlpRequest {
+	var scope otlpScopeSpans
+	scope.Scope.Name = "github.com/signalmice/signalmice"
+	for _, s := range spans {
+		status := otlpStatus{Code: otlpStatusCodeOK}
+		if s.Err != "" {
+			status = otlpStatus{Code: otlpStatusCodeError, Message: s.Err}
+		}
+		scope.Spans = append(scope.Spans, otlpSpan{
+			TraceID:           s.TraceID,
+			SpanID:            s.SpanID,
+			ParentSpanID:      s.ParentSpanID,
+			Name:              s.Name,
+			Kind:              otlpSpanKindInternal,
+			StartTimeUnixNano: strconv.FormatInt(s.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.End.UnixNano(), 10),
+			Attributes:        attributes(s.Attributes),
+			Status:            status,
+		})
+	}
+
+	var resource otlpResourceSpans
+	resource.Resource.Attributes = attributes(map[string]string{
+		"service.name": "signalmice",
+		"host.name":    e.hostname,
+	})
+	resource.ScopeSpans = []otlpScopeSpans{scope}
+
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{resource}}
+}
+
+// Export implements Exporter
+func (e *OTLPExporter) Export(ctx context.Context, spans []SpanData) error {
+	body, err := json.Marshal(e.encode(spans))
+	if err != nil {
+		return fmt.Errorf("failed to marshal spans: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create OTLP request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range e.headers {
+		req.Header.Set(name, value)
+	}
+
+	res, err := e.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send spans: %w", err)
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, res.Body)
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("OTLP collector returned status %d", res.StatusCode)
+	}
+	return nil
+}
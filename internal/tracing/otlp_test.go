@@ -0,0 +1,73 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestOTLPExporter_PostsJSONSpans(t *testing.T) {
+	requests := make(chan *http.Request, 1)
+	bodies := make(chan otlpRequest, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body otlpRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("failed to decode OTLP body: %v", err)
+		}
+		requests <- r
+		bodies <- body
+	}))
+	defer server.Close()
+
+	exporter := NewOTLPExporter(server.URL+"/", "node-1", map[string]string{"Authorization": "Bearer token"})
+	ctx := WithTracer(context.Background(), NewTracer(exporter))
+
+	rootCtx, root := Start(ctx, "signal")
+	_, child := Start(rootCtx, "shutdown")
+	child.SetAttribute("action", "reboot")
+	child.End(errors.New("all methods failed"))
+	root.End(nil)
+
+	r := <-requests
+	if r.URL.Path != "/v1/traces" {
+		t.Errorf("expected POST to /v1/traces, got %s", r.URL.Path)
+	}
+	if r.Header.Get("Content-Type") != "application/json" || r.Header.Get("Authorization") != "Bearer token" {
+		t.Errorf("unexpected headers: %v", r.Header)
+	}
+
+	body := <-bodies
+	if len(body.ResourceSpans) != 1 || len(body.ResourceSpans[0].ScopeSpans) != 1 {
+		t.Fatalf("unexpected OTLP structure: %+v", body)
+	}
+	spans := body.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 2 {
+		t.Fatalf("expected 2 spans, got %d", len(spans))
+	}
+
+	shutdownSpan := spans[0]
+	if shutdownSpan.Name != "shutdown" || shutdownSpan.Status.Code != otlpStatusCodeError || shutdownSpan.Status.Message != "all methods failed" {
+		t.Errorf("unexpected shutdown span: %+v", shutdownSpan)
+	}
+	if len(shutdownSpan.Attributes) != 1 || shutdownSpan.Attributes[0].Key != "action" || shutdownSpan.Attributes[0].Value.StringValue != "reboot" {
+		t.Errorf("unexpected attributes: %+v", shutdownSpan.Attributes)
+	}
+	if shutdownSpan.ParentSpanID != spans[1].SpanID || spans[1].Status.Code != otlpStatusCodeOK {
+		t.Errorf("expected shutdown span parented to a successful signal span, got %+v", spans)
+	}
+}
+
+func TestOTLPExporter_CollectorError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	exporter := NewOTLPExporter(server.URL+"/v1/traces", "node-1", nil)
+	if err := exporter.Export(context.Background(), []SpanData{{Name: "signal"}}); err == nil {
+		t.Error("expected error for a failing collector")
+	}
+}
@@ -0,0 +1,242 @@
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/signalmice/signalmice/internal/config"
+)
+
+// exportTimeout bounds a single export; the host may be about to go down
+const exportTimeout = 2 * time.Second
+
+// SpanData is a finished span as handed to an Exporter
+type SpanData struct {
+	Name         string
+	TraceID      string // 32 hex characters
+	SpanID       string // 16 hex characters
+	ParentSpanID string // Empty for a root span
+	Start        time.Time
+	End          time.Time
+	Attributes   map[string]string
+	Err          string // Empty when the span succeeded
+}
+
+// Exporter ships finished spans to a tracing backend
+type Exporter interface {
+	Export(ctx context.Context, spans []SpanData) error
+}
+
+// Tracer creates spans and exports each trace once its root span ends
+type Tracer struct {
+	exporter Exporter
+
+	mu      sync.Mutex
+	pending map[string][]SpanData // Finished spans by trace ID, awaiting their root
+}
+
+// NewTracer creates a tracer exporting to exporter
+func NewTracer(exporter Exporter) *Tracer {
+	return &Tracer{exporter: exporter, pending: make(map[string][]SpanData)}
+}
+
+// NewTracerFromConfig creates a tracer exporting over OTLP/HTTP, or returns nil when no
+// endpoint is configured. Spans started without a tracer are no-ops.
+func NewTracerFromConfig(cfg *config.Config) *Tracer {
+	if cfg.OTLPEndpoint == "" {
+		return nil
+	}
+	return NewTracer(NewOTLPExporter(cfg.OTLPEndpoint, cfg.Hostname, cfg.OTLPHeaders))
+}
+
+type tracerKey struct{}
+
+type spanKey struct{}
+
+// WithTracer returns a copy of ctx whose spans are created by t
+func WithTracer(ctx context.Context, t *Tracer) context.Context {
+	if t == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, tracerKey{}, t)
+}
+
+// Start begins a span named name, as a child of the span in ctx if there is one.
+// Without a tracer in ctx it returns ctx unchanged and a nil span, whose methods are no-ops.
+func Start(ctx context.Context, name string) (context.Context, *Span) {
+	t, _ := ctx.Value(tracerKey{}).(*Tracer)
+	if t == nil {
+		return ctx, nil
+	}
+
+	span := &Span{
+		tracer: t,
+		data: SpanData{
+			Name:       name,
+			SpanID:     randomHex(8),
+			Start:      time.Now(),
+			Attributes: map[string]string{},
+		},
+	}
+	if parent, ok := ctx.Value(spanKey{}).(*Span); ok {
+		span.parent = parent
+		span.data.TraceID = parent.data.TraceID
+		span.data.ParentSpanID = parent.data.SpanID
+	} else {
+		span.data.TraceID = randomHex(16)
+	}
+
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// TraceID returns the trace ID of the span in ctx, or "" if there is none
+func TraceID(ctx context.Context) string {
+	if span, ok := ctx.Value(spanKey{}).(*Span); ok {
+		return span.data.TraceID
+	}
+	return ""
+}
+
+// Span is an operation being timed. All methods are safe to call on a nil Span.
+type Span struct {
+	tracer *Tracer
+	parent *Span // Nil for a root span
+
+	mu    sync.Mutex
+	data  SpanData
+	ended bool
+}
+
+// SetAttribute records a key/value attribute on the span
+func (s *Span) SetAttribute(key, value string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Attributes[key] = value
+}
+
+// End finishes the span, marking it failed when err is non-nil. Ending a root span
+// exports its whole trace. Calls after the first are ignored.
+func (s *Span) End(err error) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	if err != nil {
+		s.data.Err = err.Error()
+	}
+	data := s.data
+	s.mu.Unlock()
+
+	s.tracer.finish(data)
+}
+
+// snapshot returns the span as if it ended now, and false once it has really ended
+func (s *Span) snapshot(now time.Time) (SpanData, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return SpanData{}, false
+	}
+	data := s.data
+	data.End = now
+	data.Attributes = make(map[string]string, len(s.data.Attributes))
+	for key, value := range s.data.Attributes {
+		data.Attributes[key] = value
+	}
+	return data, true
+}
+
+// Flush exports the trace of the span in ctx without waiting for its root to end: the spans
+// finished so far, and the spans still open in ctx as if they ended now. It is meant for right
+// before the host goes down, when the root may never end. Spans still open are exported again,
+// with their final outcome, if they do end.
+func Flush(ctx context.Context) {
+	span, ok := ctx.Value(spanKey{}).(*Span)
+	if !ok {
+		return
+	}
+	t := span.tracer
+	traceID := span.data.TraceID
+
+	t.mu.Lock()
+	spans := t.pending[traceID]
+	delete(t.pending, traceID)
+	t.mu.Unlock()
+
+	now := time.Now()
+	for open := span; open != nil; open = open.parent {
+		if data, ok := open.snapshot(now); ok {
+			spans = append(spans, data)
+		}
+	}
+	t.export(traceID, spans)
+}
+
+// finish queues a finished span and exports the trace once its root has finished
+func (t *Tracer) finish(data SpanData) {
+	t.mu.Lock()
+	t.pending[data.TraceID] = append(t.pending[data.TraceID], data)
+	if data.ParentSpanID != "" {
+		t.mu.Unlock()
+		return
+	}
+	spans := t.pending[data.TraceID]
+	delete(t.pending, data.TraceID)
+	t.mu.Unlock()
+
+	t.export(data.TraceID, spans)
+}
+
+// export ships spans of the trace traceID, bounded by exportTimeout
+func (t *Tracer) export(traceID string, spans []SpanData) {
+	if len(spans) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+	defer cancel()
+	if err := t.exporter.Export(ctx, spans); err != nil {
+		log.Printf("[WARN] Failed to export trace %s: %v", traceID, err)
+	}
+}
+
+// randomHex returns n random bytes, hex encoded
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// InMemoryExporter keeps exported spans in memory, for tests and debugging
+type InMemoryExporter struct {
+	mu    sync.Mutex
+	spans []SpanData
+}
+
+// Export implements Exporter
+func (e *InMemoryExporter) Export(_ context.Context, spans []SpanData) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+// Spans returns every span exported so far
+func (e *InMemoryExporter) Spans() []SpanData {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]SpanData(nil), e.spans...)
+}
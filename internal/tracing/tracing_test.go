@@ -0,0 +1,116 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestStart_WithoutTracerIsNoop(t *testing.T) {
+	ctx, span := Start(context.Background(), "noop")
+	if span != nil {
+		t.Fatal("expected a nil span without a tracer")
+	}
+	// Nil spans must be safe to use
+	span.SetAttribute("key", "value")
+	span.End(errors.New("ignored"))
+
+	if id := TraceID(ctx); id != "" {
+		t.Errorf("expected no trace ID, got %q", id)
+	}
+}
+
+func TestTracer_ExportsHierarchyWhenRootEnds(t *testing.T) {
+	exporter := &InMemoryExporter{}
+	ctx := WithTracer(context.Background(), NewTracer(exporter))
+
+	rootCtx, root := Start(ctx, "root")
+	childCtx, child := Start(rootCtx, "child")
+	_, grandchild := Start(childCtx, "grandchild")
+	grandchild.SetAttribute("method", "poweroff")
+
+	grandchild.End(errors.New("permission denied"))
+	child.End(nil)
+	if got := len(exporter.Spans()); got != 0 {
+		t.Fatalf("expected nothing exported before the root ends, got %d spans", got)
+	}
+	root.End(nil)
+	root.End(nil) // Ending twice is ignored
+
+	spans := exporter.Spans()
+	if len(spans) != 3 {
+		t.Fatalf("expected 3 spans, got %d", len(spans))
+	}
+
+	byName := map[string]SpanData{}
+	for _, s := range spans {
+		byName[s.Name] = s
+		if s.TraceID != TraceID(rootCtx) {
+			t.Errorf("span %s has trace ID %s, want %s", s.Name, s.TraceID, TraceID(rootCtx))
+		}
+	}
+	if byName["root"].ParentSpanID != "" {
+		t.Errorf("expected root to have no parent, got %q", byName["root"].ParentSpanID)
+	}
+	if byName["child"].ParentSpanID != byName["root"].SpanID {
+		t.Error("expected child to be parented to root")
+	}
+	if byName["grandchild"].ParentSpanID != byName["child"].SpanID {
+		t.Error("expected grandchild to be parented to child")
+	}
+	if byName["grandchild"].Err != "permission denied" || byName["grandchild"].Attributes["method"] != "poweroff" {
+		t.Errorf("unexpected grandchild span: %+v", byName["grandchild"])
+	}
+}
+
+func TestFlush_ExportsOpenTrace(t *testing.T) {
+	exporter := &InMemoryExporter{}
+	ctx := WithTracer(context.Background(), NewTracer(exporter))
+
+	rootCtx, root := Start(ctx, "root")
+	_, webhook := Start(rootCtx, "webhook")
+	webhook.End(nil)
+	childCtx, child := Start(rootCtx, "child")
+	child.SetAttribute("action", "poweroff")
+
+	Flush(childCtx)
+
+	spans := exporter.Spans()
+	if len(spans) != 3 {
+		t.Fatalf("expected the finished and open spans to be exported, got %d spans", len(spans))
+	}
+	for _, s := range spans {
+		if s.End.IsZero() || s.TraceID != TraceID(rootCtx) {
+			t.Errorf("unexpected flushed span: %+v", s)
+		}
+	}
+
+	// Once the flow ends after all, only the spans still open then are exported again
+	child.End(errors.New("poweroff failed"))
+	root.End(nil)
+	spans = exporter.Spans()
+	if len(spans) != 5 {
+		t.Fatalf("expected the open spans to be exported again with their outcome, got %d spans", len(spans))
+	}
+	if spans[3].Name != "child" || spans[3].Err != "poweroff failed" {
+		t.Errorf("expected the final child span, got %+v", spans[3])
+	}
+}
+
+func TestFlush_WithoutSpanIsNoop(t *testing.T) {
+	Flush(context.Background())
+}
+
+func TestTracer_SeparateRootsGetSeparateTraces(t *testing.T) {
+	ctx := WithTracer(context.Background(), NewTracer(&InMemoryExporter{}))
+
+	first, _ := Start(ctx, "first")
+	second, _ := Start(ctx, "second")
+
+	if TraceID(first) == TraceID(second) {
+		t.Error("expected independent root spans to start separate traces")
+	}
+	if len(TraceID(first)) != 32 {
+		t.Errorf("expected a 32 character trace ID, got %q", TraceID(first))
+	}
+}
@@ -0,0 +1,80 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/signalmice/signalmice/internal/config"
+)
+
+// Notification is the JSON body posted once per shutdown attempt, right before the first
+// terminal shutdown command runs
+type Notification struct {
+	Timestamp     string   `json:"@timestamp"`
+	Hostname      string   `json:"hostname"`
+	Action        string   `json:"action"`
+	Methods       []string `json:"methods"` // Methods that may be tried; none has run yet
+	Message       string   `json:"message"`
+	CorrelationID string   `json:"correlation_id,omitempty"`
+}
+
+// Notifier posts the final "attempting shutdown" notification. The host may be gone moments
+// later, so it makes a single attempt bounded by a tight timeout and never retries.
+type Notifier struct {
+	target target
+}
+
+// NewNotifier creates a notifier for SIGNALMICE_POST_NOTIFY_URL, or returns nil when it is not
+// configured. All methods are safe to call on a nil Notifier.
+func NewNotifier(cfg *config.Config) *Notifier {
+	if cfg.PostNotifyURL == "" {
+		return nil
+	}
+	return &Notifier{target: target{
+		url:        cfg.PostNotifyURL,
+		method:     http.MethodPost,
+		httpClient: &http.Client{Timeout: cfg.PostNotifyTimeout},
+	}}
+}
+
+// Start posts the notification in the background and returns a channel receiving its outcome
+// once the attempt completes or times out
+func (n *Notifier) Start(ctx context.Context, notification Notification) <-chan error {
+	done := make(chan error, 1)
+	if n == nil {
+		done <- nil
+		return done
+	}
+
+	body, err := json.Marshal(notification)
+	if err != nil {
+		done <- fmt.Errorf("failed to marshal post-notification: %w", err)
+		return done
+	}
+	go func() {
+		if err := n.target.post(ctx, body); err != nil {
+			done <- fmt.Errorf("post-notification %s failed: %w", n.target.url, err)
+			return
+		}
+		done <- nil
+	}()
+	return done
+}
+
+// NewShutdownNotification returns the notification announcing a shutdown attempt with the
+// candidate methods, tried in order (or at once in parallel mode). It is sent before any of
+// them runs, so it does not mean one of them succeeded.
+func NewShutdownNotification(hostname, action string, methods []string, correlationID string) Notification {
+	return Notification{
+		Timestamp:     time.Now().UTC().Format(time.RFC3339),
+		Hostname:      hostname,
+		Action:        action,
+		Methods:       methods,
+		Message:       fmt.Sprintf("attempting shutdown (candidate methods: %s)", strings.Join(methods, ", ")),
+		CorrelationID: correlationID,
+	}
+}
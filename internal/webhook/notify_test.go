@@ -0,0 +1,72 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/signalmice/signalmice/internal/config"
+)
+
+func TestNewNotifier_Disabled(t *testing.T) {
+	notifier := NewNotifier(&config.Config{})
+	if notifier != nil {
+		t.Fatal("expected nil notifier without a post-notify URL")
+	}
+	if err := <-notifier.Start(context.Background(), Notification{}); err != nil {
+		t.Errorf("expected nil notifier start to be a no-op, got: %v", err)
+	}
+}
+
+func TestNotifier_Start_PostsNotification(t *testing.T) {
+	var received Notification
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("expected POST, got %s", r.Method)
+		}
+		json.NewDecoder(r.Body).Decode(&received)
+	}))
+	defer server.Close()
+
+	notifier := NewNotifier(&config.Config{PostNotifyURL: server.URL, PostNotifyTimeout: time.Second})
+	notification := NewShutdownNotification("node-1", "poweroff", []string{"nsenter", "sysrq"}, "abc123")
+	if err := <-notifier.Start(context.Background(), notification); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if strings.Join(received.Methods, ",") != "nsenter,sysrq" || received.Message != "attempting shutdown (candidate methods: nsenter, sysrq)" {
+		t.Errorf("expected the candidate methods in the notification, got %+v", received)
+	}
+	if received.Hostname != "node-1" || received.Action != "poweroff" || received.CorrelationID != "abc123" {
+		t.Errorf("unexpected notification: %+v", received)
+	}
+}
+
+func TestNotifier_Start_SingleAttemptWithinTimeout(t *testing.T) {
+	var attempts int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	notifier := NewNotifier(&config.Config{PostNotifyURL: server.URL, PostNotifyTimeout: 50 * time.Millisecond})
+
+	start := time.Now()
+	if err := <-notifier.Start(context.Background(), Notification{Methods: []string{"direct"}}); err == nil {
+		t.Fatal("expected an error when the endpoint does not answer in time")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the attempt to give up at the timeout, took %v", elapsed)
+	}
+	if got := atomic.LoadInt32(&attempts); got != 1 {
+		t.Errorf("expected a single attempt, got %d", got)
+	}
+}
@@ -0,0 +1,115 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Spec describes one webhook of SIGNALMICE_WEBHOOKS_JSON
+type Spec struct {
+	URL     string            `json:"url"`
+	Method  string            `json:"method,omitempty"`  // HTTP method; defaults to POST
+	Headers map[string]string `json:"headers,omitempty"` // Request headers, e.g. for auth tokens or a Content-Type
+	Body    string            `json:"body,omitempty"`    // Go template rendered with the Payload; empty sends the JSON payload
+	Timeout time.Duration     `json:"-"`                 // Timeout of a single attempt; defaults to SIGNALMICE_WEBHOOK_TIMEOUT
+
+	body *template.Template
+}
+
+// templateFuncs are available to webhook body templates. json encodes a value as JSON,
+// so strings can be embedded in JSON bodies safely.
+var templateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// UnmarshalJSON decodes a Spec, parsing the timeout as a Go duration string
+func (s *Spec) UnmarshalJSON(data []byte) error {
+	type alias Spec
+	raw := struct {
+		*alias
+		Timeout string `json:"timeout,omitempty"`
+	}{alias: (*alias)(s)}
+
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	if raw.Timeout != "" {
+		timeout, err := time.ParseDuration(raw.Timeout)
+		if err != nil {
+			return fmt.Errorf("invalid timeout %q: %w", raw.Timeout, err)
+		}
+		s.Timeout = timeout
+	}
+
+	return nil
+}
+
+// validate checks the spec and compiles its body template
+func (s *Spec) validate() error {
+	u, err := url.Parse(s.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid url %q", s.URL)
+	}
+
+	s.Method = strings.ToUpper(s.Method)
+	switch s.Method {
+	case "":
+		s.Method = http.MethodPost
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+	default:
+		return fmt.Errorf("unsupported method %q (expected POST, PUT or PATCH)", s.Method)
+	}
+
+	if s.Timeout < 0 {
+		return fmt.Errorf("timeout must not be negative")
+	}
+
+	if s.Body != "" {
+		body, err := template.New(s.URL).Funcs(templateFuncs).Option("missingkey=error").Parse(s.Body)
+		if err != nil {
+			return fmt.Errorf("invalid body template: %w", err)
+		}
+		s.body = body
+	}
+	return nil
+}
+
+// target resolves the spec into a webhook target, using defaultTimeout when it sets none
+func (s Spec) target(defaultTimeout time.Duration) target {
+	timeout := s.Timeout
+	if timeout == 0 {
+		timeout = defaultTimeout
+	}
+	return target{
+		url:        s.URL,
+		method:     s.Method,
+		headers:    s.Headers,
+		body:       s.body,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+// ParseSpecs parses and validates a JSON array of webhook specs, compiling their body templates
+func ParseSpecs(data string) ([]Spec, error) {
+	var specs []Spec
+	if err := json.Unmarshal([]byte(data), &specs); err != nil {
+		return nil, fmt.Errorf("failed to parse webhooks JSON: %w", err)
+	}
+
+	for i := range specs {
+		if err := specs[i].validate(); err != nil {
+			return nil, fmt.Errorf("invalid webhook at index %d: %w", i, err)
+		}
+	}
+
+	return specs, nil
+}
@@ -0,0 +1,191 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/signalmice/signalmice/internal/config"
+)
+
+func TestParseSpecs(t *testing.T) {
+	specs, err := ParseSpecs(`[
+		{"url":"https://hooks.slack.com/services/T0/B0/x","body":"{\"text\":{{json .Reason}}}"},
+		{"url":"http://api.internal/hosts","method":"put","headers":{"X-Token":"t0ken"},"timeout":"750ms"}
+	]`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(specs) != 2 {
+		t.Fatalf("expected 2 specs, got %d", len(specs))
+	}
+	if specs[0].Method != http.MethodPost || specs[0].body == nil {
+		t.Errorf("expected POST with a compiled body, got %+v", specs[0])
+	}
+	if specs[1].Method != http.MethodPut || specs[1].Timeout != 750*time.Millisecond || specs[1].body != nil {
+		t.Errorf("expected PUT with a 750ms timeout and no template, got %+v", specs[1])
+	}
+}
+
+func TestParseSpecs_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		json     string
+		contains string
+	}{
+		{"malformed", `{"url":`, "failed to parse webhooks JSON"},
+		{"missing url", `[{"method":"POST"}]`, "invalid url"},
+		{"relative url", `[{"url":"/hook"}]`, "invalid url"},
+		{"unsupported method", `[{"url":"http://example.com","method":"DELETE"}]`, "unsupported method"},
+		{"bad timeout", `[{"url":"http://example.com","timeout":"soon"}]`, "invalid timeout"},
+		{"bad template", `[{"url":"http://example.com","body":"{{.Reason"}]`, "invalid body template"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseSpecs(tt.json)
+			if err == nil || !strings.Contains(err.Error(), tt.contains) {
+				t.Errorf("expected error containing %q, got %v", tt.contains, err)
+			}
+		})
+	}
+}
+
+// recordedRequest is a request captured by a recording webhook server
+type recordedRequest struct {
+	method      string
+	contentType string
+	token       string
+	body        string
+}
+
+// newRecordingServer returns a server recording the requests it receives
+func newRecordingServer(t *testing.T) (*httptest.Server, func() []recordedRequest) {
+	t.Helper()
+	var mu sync.Mutex
+	var requests []recordedRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		requests = append(requests, recordedRequest{
+			method:      r.Method,
+			contentType: r.Header.Get("Content-Type"),
+			token:       r.Header.Get("X-Token"),
+			body:        string(body),
+		})
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+	return server, func() []recordedRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]recordedRequest(nil), requests...)
+	}
+}
+
+func TestClient_Send_TemplatedWebhooks(t *testing.T) {
+	slack, slackRequests := newRecordingServer(t)
+	api, apiRequests := newRecordingServer(t)
+
+	specs, err := ParseSpecs(`[
+		{"url":"` + slack.URL + `","body":"{\"text\":{{json (printf \"%s is going down: %s\" .Hostname .Reason)}}}"},
+		{"url":"` + api.URL + `","method":"PUT","headers":{"X-Token":"t0ken","Content-Type":"text/plain"},
+		 "body":"host={{.Hostname}} action={{.Action}} value={{.Value}} at={{.Timestamp}}"}
+	]`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	client := NewClient(&config.Config{WebhookTimeout: time.Second}, specs...)
+
+	err = client.Send(context.Background(), Payload{
+		Timestamp: "2024-05-01T12:00:00Z",
+		Hostname:  "node-1",
+		Action:    "reboot",
+		Reason:    `kernel "6.8" upgrade`,
+		Value:     "reboot",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	got := slackRequests()
+	if len(got) != 1 {
+		t.Fatalf("expected one Slack request, got %d", len(got))
+	}
+	if got[0].body != `{"text":"node-1 is going down: kernel \"6.8\" upgrade"}` {
+		t.Errorf("unexpected Slack body: %s", got[0].body)
+	}
+	if got[0].method != http.MethodPost || got[0].contentType != "application/json" {
+		t.Errorf("expected a JSON POST to Slack, got %s %s", got[0].method, got[0].contentType)
+	}
+
+	got = apiRequests()
+	if len(got) != 1 {
+		t.Fatalf("expected one API request, got %d", len(got))
+	}
+	if got[0].body != "host=node-1 action=reboot value=reboot at=2024-05-01T12:00:00Z" {
+		t.Errorf("unexpected API body: %s", got[0].body)
+	}
+	if got[0].method != http.MethodPut || got[0].token != "t0ken" || got[0].contentType != "text/plain" {
+		t.Errorf("expected a PUT with the spec's headers, got %+v", got[0])
+	}
+}
+
+func TestClient_Send_FailingWebhookDoesNotBlockOthers(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	defer slow.Close()
+	fast, fastRequests := newRecordingServer(t)
+
+	specs, err := ParseSpecs(`[{"url":"` + slow.URL + `","timeout":"50ms"},{"url":"` + fast.URL + `"}]`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	client := NewClient(&config.Config{WebhookTimeout: time.Second}, specs...)
+
+	start := time.Now()
+	err = client.Send(context.Background(), Payload{Hostname: "node-1"})
+	if err == nil || !strings.Contains(err.Error(), slow.URL) {
+		t.Errorf("expected an error naming the slow webhook, got %v", err)
+	}
+	if strings.Contains(err.Error(), fast.URL) {
+		t.Errorf("expected the fast webhook to succeed, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("expected the slow webhook to be bounded by its own timeout, took %v", elapsed)
+	}
+	if len(fastRequests()) != 1 {
+		t.Error("expected the fast webhook to be notified")
+	}
+}
+
+func TestNewClient_CombinesURLAndSpecs(t *testing.T) {
+	legacy, legacyRequests := newRecordingServer(t)
+	extra, extraRequests := newRecordingServer(t)
+
+	specs, err := ParseSpecs(`[{"url":"` + extra.URL + `"}]`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	client := NewClient(&config.Config{WebhookURL: legacy.URL, WebhookTimeout: time.Second}, specs...)
+	if err := client.Send(context.Background(), Payload{Hostname: "node-1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Without a template, both receive the JSON payload
+	for name, requests := range map[string][]recordedRequest{"legacy": legacyRequests(), "extra": extraRequests()} {
+		if len(requests) != 1 || !strings.Contains(requests[0].body, `"hostname":"node-1"`) {
+			t.Errorf("expected the %s webhook to receive the JSON payload, got %+v", name, requests)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/signalmice/signalmice/internal/config"
+)
+
+// retryBackoff is the delay before the first retry; it doubles on each attempt
+var retryBackoff = 250 * time.Millisecond
+
+// Payload is the JSON body posted before the host shuts down
+type Payload struct {
+	Timestamp     string `json:"@timestamp"`
+	Hostname      string `json:"hostname"`
+	Action        string `json:"action"`
+	Reason        string `json:"reason"`
+	CorrelationID string `json:"correlation_id,omitempty"`
+	SafeMode      bool   `json:"safe_mode"`
+	Value         string `json:"-"` // Signal value; only available to templated bodies
+}
+
+// Client posts pre-shutdown notifications with retries, bounded by a total time budget
+type Client struct {
+	targets     []target
+	retries     int
+	maxDuration time.Duration
+}
+
+// target is a single webhook endpoint notified by the client
+type target struct {
+	url        string
+	method     string
+	headers    map[string]string
+	body       *template.Template // Renders the request body; nil sends the JSON payload
+	httpClient *http.Client
+}
+
+// NewClient creates a webhook client for SIGNALMICE_WEBHOOK_URL and the given specs, or
+// returns nil when there is no webhook to notify. All methods are safe to call on a nil Client.
+func NewClient(cfg *config.Config, specs ...Spec) *Client {
+	var targets []target
+	if cfg.WebhookURL != "" {
+		targets = append(targets, target{
+			url:        cfg.WebhookURL,
+			method:     http.MethodPost,
+			headers:    cfg.WebhookHeaders,
+			httpClient: &http.Client{Timeout: cfg.WebhookTimeout},
+		})
+	}
+	for _, spec := range specs {
+		targets = append(targets, spec.target(cfg.WebhookTimeout))
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+
+	return &Client{
+		targets:     targets,
+		retries:     cfg.WebhookRetries,
+		maxDuration: cfg.WebhookMaxDuration,
+	}
+}
+
+// Send notifies every webhook concurrently, retrying failed attempts with backoff until the
+// retries or the total time budget run out. The host is about to go down, so the budget is
+// strict. The failures of all webhooks are returned joined.
+func (c *Client) Send(ctx context.Context, payload Payload) error {
+	if c == nil {
+		return nil
+	}
+
+	if c.maxDuration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.maxDuration)
+		defer cancel()
+	}
+
+	errs := make([]error, len(c.targets))
+	var wg sync.WaitGroup
+	for i, t := range c.targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = c.send(ctx, t, payload)
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// send notifies a single webhook with retries
+func (c *Client) send(ctx context.Context, t target, payload Payload) error {
+	body, err := t.render(payload)
+	if err != nil {
+		return err
+	}
+
+	backoff := retryBackoff
+	for attempt := 0; ; attempt++ {
+		err = t.post(ctx, body)
+		if err == nil {
+			return nil
+		}
+		if attempt >= c.retries {
+			return fmt.Errorf("webhook %s failed after %d attempts: %w", t.url, attempt+1, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("webhook %s abandoned after %d attempts: %w", t.url, attempt+1, err)
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// render returns the request body for payload: the rendered template, or the JSON payload
+func (t target) render(payload Payload) ([]byte, error) {
+	if t.body == nil {
+		body, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal webhook payload: %w", err)
+		}
+		return body, nil
+	}
+
+	var buf bytes.Buffer
+	if err := t.body.Execute(&buf, payload); err != nil {
+		return nil, fmt.Errorf("failed to render webhook %s body: %w", t.url, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// post performs a single webhook attempt
+func (t target) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, t.method, t.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range t.headers {
+		req.Header.Set(name, value)
+	}
+
+	res, err := t.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	io.Copy(io.Discard, res.Body)
+
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", res.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/signalmice/signalmice/internal/config"
+)
+
+func init() {
+	retryBackoff = time.Millisecond
+}
+
+func TestNewClient_Disabled(t *testing.T) {
+	client := NewClient(&config.Config{})
+	if client != nil {
+		t.Fatal("expected nil client without a webhook URL")
+	}
+	if err := client.Send(context.Background(), Payload{}); err != nil {
+		t.Errorf("expected nil client send to be a no-op, got: %v", err)
+	}
+}
+
+func TestClient_Send_RetriesThenSucceeds(t *testing.T) {
+	var attempts int32
+	var received Payload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer t0ken" {
+			t.Errorf("expected Authorization header 'Bearer t0ken', got '%s'", got)
+		}
+		if got := r.Header.Get("Content-Type"); got != "application/json" {
+			t.Errorf("expected JSON content type, got '%s'", got)
+		}
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&received)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := NewClient(&config.Config{
+		WebhookURL:     server.URL,
+		WebhookTimeout: time.Second,
+		WebhookRetries: 2,
+		WebhookHeaders: map[string]string{"Authorization": "Bearer t0ken"},
+	})
+
+	err := client.Send(context.Background(), Payload{Action: "poweroff", Reason: "maintenance"})
+	if err != nil {
+		t.Fatalf("expected webhook to succeed after a retry, got: %v", err)
+	}
+	if got := atomic.LoadInt32(&attempts); got != 2 {
+		t.Errorf("expected 2 attempts, got %d", got)
+	}
+	if received.Reason != "maintenance" || received.Action != "poweroff" {
+		t.Errorf("expected payload to carry action and reason, got %+v", received)
+	}
+}
+
+func TestClient_Send_GivesUp(t *testing.T) {
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := NewClient(&config.Config{WebhookURL: server.URL, WebhookTimeout: time.Second, WebhookRetries: 1})
+
+	err := client.Send(context.Background(), Payload{})
+	if err == nil || !strings.Contains(err.Error(), "after 2 attempts") {
+		t.Errorf("expected failure after 2 attempts, got: %v", err)
+	}
+	if got := atomic.LoadInt32(&attempts); got != 2 {
+		t.Errorf("expected 2 attempts, got %d", got)
+	}
+}
+
+func TestClient_Send_MaxDuration(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(300 * time.Millisecond):
+		}
+	}))
+	defer server.Close()
+
+	client := NewClient(&config.Config{
+		WebhookURL:         server.URL,
+		WebhookTimeout:     time.Second,
+		WebhookRetries:     5,
+		WebhookMaxDuration: 100 * time.Millisecond,
+	})
+
+	start := time.Now()
+	if err := client.Send(context.Background(), Payload{}); err == nil {
+		t.Fatal("expected error when the total budget is exceeded")
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("expected webhook to give up near the total budget, took %v", elapsed)
+	}
+}
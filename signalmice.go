@@ -0,0 +1,253 @@
+// Package signalmice exposes signalmice's signal monitoring as a library, so other binaries
+// can watch for signal keys and react to them with their own handlers. Shutting the host
+// down is one such handler, available via NewShutdownHandlerFromEnv.
+package signalmice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/signalmice/signalmice/internal/config"
+	"github.com/signalmice/signalmice/internal/instruction"
+	"github.com/signalmice/signalmice/internal/logger"
+	"github.com/signalmice/signalmice/internal/redis"
+	"github.com/signalmice/signalmice/internal/shutdown"
+)
+
+// Signal describes a signal key that was found (and consumed)
+type Signal struct {
+	Key   string
+	Value string
+}
+
+// Source is checked by the Monitor for signals. CheckAndDeleteSignal consumes and returns
+// the first signal found, or false if none is set.
+type Source interface {
+	CheckAndDeleteSignal(ctx context.Context) (Signal, bool, error)
+}
+
+// Handler is called with each signal the Monitor observes
+type Handler func(ctx context.Context, sig Signal)
+
+// ErrorHandler is called when a check fails
+type ErrorHandler func(ctx context.Context, err error)
+
+// Monitor polls a Source and dispatches observed signals to its handlers
+type Monitor struct {
+	source   Source
+	interval time.Duration
+
+	mu            sync.RWMutex
+	handlers      []Handler
+	errorHandlers []ErrorHandler
+}
+
+// NewMonitor creates a Monitor that checks source every interval
+func NewMonitor(source Source, interval time.Duration) *Monitor {
+	return &Monitor{
+		source:   source,
+		interval: interval,
+	}
+}
+
+// OnSignal registers h to be called, in registration order, for every observed signal
+func (m *Monitor) OnSignal(h Handler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.handlers = append(m.handlers, h)
+}
+
+// OnError registers h to be called whenever a check fails
+func (m *Monitor) OnError(h ErrorHandler) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.errorHandlers = append(m.errorHandlers, h)
+}
+
+// Check runs a single check, dispatching a found signal to the handlers.
+// Reports whether a signal was found.
+func (m *Monitor) Check(ctx context.Context) (bool, error) {
+	sig, found, err := m.source.CheckAndDeleteSignal(ctx)
+	if err != nil {
+		m.mu.RLock()
+		errorHandlers := m.errorHandlers
+		m.mu.RUnlock()
+		for _, h := range errorHandlers {
+			h(ctx, err)
+		}
+		return false, err
+	}
+	if !found {
+		return false, nil
+	}
+
+	m.mu.RLock()
+	handlers := m.handlers
+	m.mu.RUnlock()
+	for _, h := range handlers {
+		h(ctx, sig)
+	}
+	return true, nil
+}
+
+// Run checks immediately and then on every interval until ctx is cancelled.
+// Failed checks are reported to the error handlers and do not stop the loop.
+func (m *Monitor) Run(ctx context.Context) error {
+	if m.interval <= 0 {
+		return fmt.Errorf("invalid check interval %s", m.interval)
+	}
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		_, _ = m.Check(ctx)
+
+		select {
+		case <-ctx.Done():
+			if errors.Is(ctx.Err(), context.Canceled) {
+				return nil
+			}
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// signalStore is the part of the Redis client read by redisSource
+type signalStore interface {
+	CheckAndDeleteSignal(ctx context.Context) (redis.Signal, bool, error)
+	PeekSignal(ctx context.Context) (redis.Signal, bool, error)
+	DeleteSignal(ctx context.Context, key string) error
+}
+
+// redisSource adapts the internal Redis client to Source
+type redisSource struct {
+	store signalStore
+	cfg   *config.Config
+}
+
+// CheckAndDeleteSignal implements Source. With JSON payloads, the key is read first and only
+// consumed when its payload applies to this host, like the binary does, so the other hosts
+// still find a signal meant for them.
+func (s *redisSource) CheckAndDeleteSignal(ctx context.Context) (Signal, bool, error) {
+	if !s.cfg.SignalJSON {
+		sig, found, err := s.store.CheckAndDeleteSignal(ctx)
+		return Signal{Key: sig.Key, Value: sig.Value}, found, err
+	}
+
+	sig, found, err := s.store.PeekSignal(ctx)
+	if err != nil || !found {
+		return Signal{}, false, err
+	}
+	if _, err := instruction.Parse(s.cfg, sig.Key, sig.Value, s.cfg.CurrentHostname(), time.Now()); errors.Is(err, instruction.ErrOtherHost) {
+		return Signal{}, false, nil
+	}
+	if err := s.store.DeleteSignal(ctx, sig.Key); err != nil {
+		return Signal{}, false, err
+	}
+	return Signal{Key: sig.Key, Value: sig.Value}, true, nil
+}
+
+// NewRedisSourceFromEnv connects to Redis using the same environment variables as the
+// signalmice binary (REDIS_HOST, SIGNALMICE_KEY, SIGNALMICE_KEYS, ...)
+func NewRedisSourceFromEnv() (Source, error) {
+	cfg := config.Load()
+	client, err := redis.NewClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &redisSource{store: client, cfg: cfg}, nil
+}
+
+// NewShutdownHandlerFromEnv returns a Handler that shuts the host down the way the signalmice
+// binary does, configured from the same environment variables (SIGNALMICE_SAFE_MODE,
+// SIGNALMICE_KEY_ACTIONS, SIGNALMICE_SIGNING_KEY, SIGNALMICE_SIGNAL_JSON, ...). Unless
+// SIGNALMICE_KILLSWITCH_KEY is empty, it connects to Redis to honor the kill switch. Refused
+// signals and shutdown failures are logged through signalmice's logger.
+func NewShutdownHandlerFromEnv() (Handler, error) {
+	cfg := config.Load()
+
+	appLogger, err := logger.NewLogger(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize logger: %w", err)
+	}
+
+	manager, err := shutdown.NewManager(cfg, appLogger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize shutdown manager: %w", err)
+	}
+
+	var store keyChecker
+	if key := cfg.KillSwitchKey(); key != "" {
+		client, err := redis.NewClient(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to Redis for the kill switch: %w", err)
+		}
+		store = client
+		manager.SetAbortCheck(func(ctx context.Context) (bool, error) {
+			return client.Exists(ctx, key)
+		})
+	}
+
+	return newShutdownHandler(cfg, manager, store, appLogger), nil
+}
+
+// shutdowner runs a shutdown, implemented by shutdown.Manager
+type shutdowner interface {
+	NeutralizeStuartLittle(ctx context.Context) (string, error)
+}
+
+// keyChecker reports whether a Redis key is set, implemented by redis.Client
+type keyChecker interface {
+	Exists(ctx context.Context, key string) (bool, error)
+}
+
+// newShutdownHandler returns the handler behind NewShutdownHandlerFromEnv. The signal is
+// interpreted exactly like the binary does, and refused while the kill switch key in store is
+// set or, unless the kill switch fails open, cannot be read. A nil store disables the kill switch.
+func newShutdownHandler(cfg *config.Config, manager shutdowner, store keyChecker, appLogger *logger.Logger) Handler {
+	return func(ctx context.Context, sig Signal) {
+		if store != nil && killSwitchActive(ctx, cfg, store, appLogger) {
+			return
+		}
+
+		inst, err := instruction.Parse(cfg, sig.Key, sig.Value, cfg.CurrentHostname(), time.Now())
+		if err != nil {
+			appLogger.WarnWithExtra(ctx, "Signal refused, not shutting down", map[string]string{"key": sig.Key, "error": err.Error()})
+			return
+		}
+
+		ctx = shutdown.WithReason(shutdown.WithAction(ctx, inst.Action), inst.Reason)
+		ctx = shutdown.WithSignalValue(ctx, inst.Value)
+		if inst.Delay != "" {
+			ctx = shutdown.WithWhen(ctx, inst.Delay)
+		}
+		if _, err := manager.NeutralizeStuartLittle(ctx); err != nil {
+			appLogger.ErrorWithExtra(ctx, "Shutdown failed", map[string]any{"key": sig.Key, "error": err.Error()})
+		}
+	}
+}
+
+// killSwitchActive reports whether the kill switch forbids acting on a signal, which it does
+// while its key is set or cannot be read, unless SIGNALMICE_KILLSWITCH_FAIL_OPEN is set
+func killSwitchActive(ctx context.Context, cfg *config.Config, store keyChecker, appLogger *logger.Logger) bool {
+	key := cfg.KillSwitchKey()
+	active, err := store.Exists(ctx, key)
+	if err != nil {
+		fields := map[string]string{"key": key, "error": err.Error()}
+		if cfg.KillSwitchFailOpen {
+			appLogger.WarnWithExtra(ctx, "Failed to read kill switch key, acting on signal anyway", fields)
+			return false
+		}
+		appLogger.WarnWithExtra(ctx, "Failed to read kill switch key, not acting on signal", fields)
+		return true
+	}
+	if active {
+		appLogger.WarnWithExtra(ctx, "Kill switch active, not acting on signal", map[string]string{"key": key})
+	}
+	return active
+}
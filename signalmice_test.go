@@ -0,0 +1,273 @@
+package signalmice
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/signalmice/signalmice/internal/config"
+	"github.com/signalmice/signalmice/internal/instruction"
+	"github.com/signalmice/signalmice/internal/logger"
+	"github.com/signalmice/signalmice/internal/redis"
+	"github.com/signalmice/signalmice/internal/shutdown"
+)
+
+// fakeSource returns queued signals and errors in order, then reports no signal
+type fakeSource struct {
+	mu      sync.Mutex
+	signals []Signal
+	errs    []error
+}
+
+func (f *fakeSource) CheckAndDeleteSignal(ctx context.Context) (Signal, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.errs) > 0 {
+		err := f.errs[0]
+		f.errs = f.errs[1:]
+		return Signal{}, false, err
+	}
+	if len(f.signals) == 0 {
+		return Signal{}, false, nil
+	}
+	sig := f.signals[0]
+	f.signals = f.signals[1:]
+	return sig, true, nil
+}
+
+func TestMonitor_Check_CallsHandlers(t *testing.T) {
+	source := &fakeSource{signals: []Signal{{Key: "signalmice:poweroff", Value: "maintenance"}}}
+	monitor := NewMonitor(source, time.Minute)
+
+	var got []Signal
+	monitor.OnSignal(func(ctx context.Context, sig Signal) { got = append(got, sig) })
+	monitor.OnSignal(func(ctx context.Context, sig Signal) { got = append(got, sig) })
+
+	found, err := monitor.Check(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !found {
+		t.Fatal("expected a signal to be found")
+	}
+	if len(got) != 2 || got[0].Key != "signalmice:poweroff" || got[0].Value != "maintenance" {
+		t.Errorf("expected both handlers to receive the signal, got %+v", got)
+	}
+
+	found, _ = monitor.Check(context.Background())
+	if found {
+		t.Error("expected no signal on the second check")
+	}
+	if len(got) != 2 {
+		t.Errorf("expected handlers not to be called without a signal, got %d calls", len(got))
+	}
+}
+
+func TestMonitor_Check_ReportsErrors(t *testing.T) {
+	checkErr := errors.New("connection refused")
+	monitor := NewMonitor(&fakeSource{errs: []error{checkErr}}, time.Minute)
+
+	var reported error
+	monitor.OnError(func(ctx context.Context, err error) { reported = err })
+	monitor.OnSignal(func(ctx context.Context, sig Signal) { t.Error("handler should not be called on error") })
+
+	if _, err := monitor.Check(context.Background()); !errors.Is(err, checkErr) {
+		t.Errorf("expected check error, got %v", err)
+	}
+	if !errors.Is(reported, checkErr) {
+		t.Errorf("expected error handler to receive %v, got %v", checkErr, reported)
+	}
+}
+
+func TestMonitor_Run_FiresCallbackOnSimulatedSignal(t *testing.T) {
+	source := &fakeSource{
+		errs:    []error{errors.New("transient")},
+		signals: []Signal{{Key: "signalmice", Value: "1"}},
+	}
+	monitor := NewMonitor(source, 10*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	fired := make(chan Signal, 1)
+	monitor.OnSignal(func(ctx context.Context, sig Signal) { fired <- sig })
+
+	done := make(chan error, 1)
+	go func() { done <- monitor.Run(ctx) }()
+
+	select {
+	case sig := <-fired:
+		if sig.Key != "signalmice" {
+			t.Errorf("expected key signalmice, got %q", sig.Key)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected callback to fire")
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("expected nil error on cancel, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected Run to return after cancel")
+	}
+}
+
+func TestMonitor_Run_InvalidInterval(t *testing.T) {
+	if err := NewMonitor(&fakeSource{}, 0).Run(context.Background()); err == nil {
+		t.Error("expected error for zero interval")
+	}
+}
+
+// fakeShutdowner records the action and reason of every shutdown it is asked to run
+type fakeShutdowner struct {
+	actions []shutdown.Action
+	reasons []string
+}
+
+func (f *fakeShutdowner) NeutralizeStuartLittle(ctx context.Context) (string, error) {
+	f.actions = append(f.actions, shutdown.ActionFromContext(ctx))
+	f.reasons = append(f.reasons, shutdown.ReasonFromContext(ctx))
+	return "fake", nil
+}
+
+// fakeKeyChecker reports the kill switch key as set, or fails with err
+type fakeKeyChecker struct {
+	set bool
+	err error
+}
+
+func (f fakeKeyChecker) Exists(ctx context.Context, key string) (bool, error) {
+	return f.set, f.err
+}
+
+func createMockLogger() *logger.Logger {
+	l, _ := logger.NewLogger(&config.Config{OpensearchURL: "http://localhost:9200", OpensearchIndex: "test-logs", RedisKey: "test-key"})
+	return l
+}
+
+func TestShutdownHandler_MatchesBinary(t *testing.T) {
+	tests := []struct {
+		name   string
+		cfg    config.Config
+		value  string
+		action shutdown.Action
+		reason string
+	}{
+		{name: "action value", value: "reboot", action: shutdown.ActionReboot, reason: config.DefaultShutdownReason},
+		{name: "free-text value", cfg: config.Config{ShutdownReason: "maintenance"}, value: "rack 4", action: shutdown.ActionPoweroff, reason: "rack 4"},
+		{name: "signed value", cfg: config.Config{SigningKey: "s3cret"}, value: "reboot." + instruction.Sign([]byte("s3cret"), "reboot"), action: shutdown.ActionReboot, reason: config.DefaultShutdownReason},
+		{name: "JSON payload", cfg: config.Config{SignalJSON: true}, value: `{"action":"reboot","reason":"kernel upgrade"}`, action: shutdown.ActionReboot, reason: "kernel upgrade"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := &fakeShutdowner{}
+			handler := newShutdownHandler(&tt.cfg, manager, nil, createMockLogger())
+
+			handler(context.Background(), Signal{Key: "signalmice:main", Value: tt.value})
+
+			if len(manager.actions) != 1 {
+				t.Fatalf("expected one shutdown, got %d", len(manager.actions))
+			}
+			if manager.actions[0] != tt.action || manager.reasons[0] != tt.reason {
+				t.Errorf("expected %s for %q, got %s for %q", tt.action, tt.reason, manager.actions[0], manager.reasons[0])
+			}
+		})
+	}
+}
+
+func TestShutdownHandler_RefusesSignals(t *testing.T) {
+	tests := []struct {
+		name  string
+		cfg   config.Config
+		value string
+		store keyChecker
+	}{
+		{name: "unsigned value", cfg: config.Config{SigningKey: "s3cret"}, value: "reboot"},
+		{name: "tampered value", cfg: config.Config{SigningKey: "s3cret"}, value: "poweroff." + instruction.Sign([]byte("s3cret"), "reboot")},
+		{name: "malformed JSON payload", cfg: config.Config{SignalJSON: true}, value: `{"action":`},
+		{name: "JSON payload for another host", cfg: config.Config{SignalJSON: true, Hostname: "node-1"}, value: `{"allowed_hosts":["node-2"]}`},
+		{name: "kill switch active", cfg: config.Config{KillSwitchKeyName: "signalmice:disabled"}, value: "reboot", store: fakeKeyChecker{set: true}},
+		{name: "kill switch unreadable", cfg: config.Config{KillSwitchKeyName: "signalmice:disabled"}, value: "reboot", store: fakeKeyChecker{err: errors.New("connection refused")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := &fakeShutdowner{}
+			handler := newShutdownHandler(&tt.cfg, manager, tt.store, createMockLogger())
+
+			handler(context.Background(), Signal{Key: "signalmice:main", Value: tt.value})
+
+			if len(manager.actions) != 0 {
+				t.Errorf("expected the signal to be refused, got %d shutdowns", len(manager.actions))
+			}
+		})
+	}
+}
+
+func TestShutdownHandler_KillSwitchFailOpen(t *testing.T) {
+	cfg := &config.Config{KillSwitchKeyName: "signalmice:disabled", KillSwitchFailOpen: true}
+	manager := &fakeShutdowner{}
+	handler := newShutdownHandler(cfg, manager, fakeKeyChecker{err: errors.New("connection refused")}, createMockLogger())
+
+	handler(context.Background(), Signal{Key: "signalmice:main", Value: "reboot"})
+
+	if len(manager.actions) != 1 {
+		t.Errorf("expected an unreadable kill switch to be ignored when failing open, got %d shutdowns", len(manager.actions))
+	}
+}
+
+// fakeSignalStore holds signal keys for redisSource, in key order
+type fakeSignalStore struct {
+	keys   []string
+	values map[string]string
+}
+
+func (f *fakeSignalStore) PeekSignal(ctx context.Context) (redis.Signal, bool, error) {
+	for _, key := range f.keys {
+		if value, ok := f.values[key]; ok {
+			return redis.Signal{Key: key, Value: value}, true, nil
+		}
+	}
+	return redis.Signal{}, false, nil
+}
+
+func (f *fakeSignalStore) DeleteSignal(ctx context.Context, key string) error {
+	delete(f.values, key)
+	return nil
+}
+
+func (f *fakeSignalStore) CheckAndDeleteSignal(ctx context.Context) (redis.Signal, bool, error) {
+	sig, found, err := f.PeekSignal(ctx)
+	if found {
+		delete(f.values, sig.Key)
+	}
+	return sig, found, err
+}
+
+func TestRedisSource_LeavesSignalsForOtherHosts(t *testing.T) {
+	cfg := &config.Config{SignalJSON: true, Hostname: "node-1"}
+	store := &fakeSignalStore{keys: []string{"signalmice:main"}, values: map[string]string{"signalmice:main": `{"allowed_hosts":["node-2"]}`}}
+	source := &redisSource{store: store, cfg: cfg}
+
+	if _, found, err := source.CheckAndDeleteSignal(context.Background()); err != nil || found {
+		t.Fatalf("expected a signal for another host not to be found, got %v, %v", found, err)
+	}
+	if _, ok := store.values["signalmice:main"]; !ok {
+		t.Error("expected the signal for another host to be left in place")
+	}
+
+	store.values["signalmice:main"] = `{"allowed_hosts":["node-1"],"action":"reboot"}`
+	sig, found, err := source.CheckAndDeleteSignal(context.Background())
+	if err != nil || !found || sig.Key != "signalmice:main" {
+		t.Fatalf("expected the signal for this host to be found, got %+v, %v, %v", sig, found, err)
+	}
+	if _, ok := store.values["signalmice:main"]; ok {
+		t.Error("expected the signal for this host to be consumed")
+	}
+}